@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
 	"time"
@@ -9,6 +10,8 @@ import (
 	"github.com/mochivi/go-real-time-leaderboards/config"
 	"github.com/mochivi/go-real-time-leaderboards/internal/api/handlers"
 	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
+	"github.com/mochivi/go-real-time-leaderboards/internal/jobs"
+	"github.com/mochivi/go-real-time-leaderboards/internal/rankings"
 	"github.com/mochivi/go-real-time-leaderboards/internal/server"
 	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
 	cache "github.com/mochivi/go-real-time-leaderboards/internal/storage/redis"
@@ -47,14 +50,47 @@ func main() {
 	// Initialize services
 	jwtService, redisService := initServices(cfg.RedisConfig)
 
-	// Initialize dependencies with concrete types
+	// Initialize repositories with concrete types
+	leaderboardRepo := storage.NewLeaderboardRepoPG(pgDB)
+	userRepo := storage.NewUserRepoPG(pgDB)
+	teamRepo := storage.NewTeamRepoPG(pgDB)
+
+	// Initialize dependencies
 	dependencies := initDependencies(
-		storage.NewLeaderboardRepoPG(pgDB),
-		storage.NewUserRepoPG(pgDB),
+		leaderboardRepo,
+		userRepo,
+		storage.NewAuditRepoPG(pgDB),
+		storage.NewReportRepoPG(pgDB),
+		storage.NewChangeSetRepoPG(pgDB),
+		storage.NewFriendRepoPG(pgDB),
+		teamRepo,
+		storage.NewTournamentRepoPG(pgDB),
+		storage.NewRatingRepoPG(pgDB),
+		storage.NewGameRepoPG(pgDB),
+		storage.NewTenantRepoPG(pgDB),
+		storage.NewMemberRepoPG(pgDB),
 		jwtService,
 		redisService,
+		utils.GetEnvInt("REPORT_ESCALATION_THRESHOLD", 3),
 	)
 
+	// Start background jobs, they stop when the process exits
+	banExpiryInterval := utils.GetEnvInt("BAN_EXPIRY_INTERVAL_SECONDS", 60)
+	go jobs.NewBanExpiry(
+		userRepo,
+		rankings.NewSyncer(leaderboardRepo, redisService),
+		rankings.NewTeams(teamRepo, redisService),
+		time.Duration(banExpiryInterval)*time.Second,
+	).Run(context.Background())
+
+	purgeInterval := utils.GetEnvInt("PURGE_INTERVAL_MINUTES", 60)
+	go jobs.NewPurge(
+		leaderboardRepo,
+		userRepo,
+		time.Duration(utils.GetEnvInt("SOFT_DELETE_RETENTION_DAYS", 30))*24*time.Hour,
+		time.Duration(purgeInterval)*time.Minute,
+	).Run(context.Background())
+
 	// Initialize server
 	server := server.NewServer(cfg.ServerConfig, dependencies)
 
@@ -70,26 +106,65 @@ func main() {
 func initDependencies(
 	leaderboardRepo storage.LeaderboardRepo,
 	userRepo storage.UserRepo,
+	auditRepo storage.AuditRepo,
+	reportRepo storage.ReportRepo,
+	changeSetRepo storage.ChangeSetRepo,
+	friendRepo storage.FriendRepo,
+	teamRepo storage.TeamRepo,
+	tournamentRepo storage.TournamentRepo,
+	ratingRepo storage.RatingRepo,
+	gameRepo storage.GameRepo,
+	tenantRepo storage.TenantRepo,
+	memberRepo storage.MemberRepo,
 	jwtService auth.JWTService,
 	redisService cache.RedisService,
+	reportThreshold int,
 ) server.DependencyContainer {
 
 	controllers := struct {
 		Leaderboards handlers.LeaderboardController
 		Auth         handlers.AuthController
 		Users        handlers.UserController
+		Moderation   handlers.ModerationController
+		Audit        handlers.AuditController
+		Bans         handlers.BanController
+		Reports      handlers.ReportController
+		ChangeSets   handlers.ChangeSetController
+		Friends      handlers.FriendController
+		Teams        handlers.TeamController
+		Tournaments  handlers.TournamentController
+		Ratings      handlers.RatingController
+		Games        handlers.GameController
+		Tenants      handlers.TenantController
+		Members      handlers.MemberController
 	}{
-		Leaderboards: handlers.NewLeaderboardController(leaderboardRepo, redisService),
+		Leaderboards: handlers.NewLeaderboardController(leaderboardRepo, friendRepo, teamRepo, userRepo, redisService),
 		Auth:         handlers.NewAuthController(userRepo, jwtService),
-		Users:        handlers.NewUserController(userRepo),
+		Users:        handlers.NewUserController(userRepo, leaderboardRepo, teamRepo, redisService),
+		Moderation:   handlers.NewModerationController(leaderboardRepo, teamRepo, redisService),
+		Audit:        handlers.NewAuditController(auditRepo),
+		Bans:         handlers.NewBanController(userRepo, leaderboardRepo, teamRepo, redisService),
+		Reports:      handlers.NewReportController(reportRepo, leaderboardRepo, teamRepo, redisService, reportThreshold),
+		ChangeSets:   handlers.NewChangeSetController(changeSetRepo, leaderboardRepo, teamRepo, redisService),
+		Friends:      handlers.NewFriendController(friendRepo, userRepo, redisService),
+		Teams:        handlers.NewTeamController(teamRepo, redisService),
+		Tournaments:  handlers.NewTournamentController(tournamentRepo, memberRepo),
+		Ratings:      handlers.NewRatingController(ratingRepo, leaderboardRepo, redisService),
+		Games:        handlers.NewGameController(gameRepo),
+		Tenants:      handlers.NewTenantController(tenantRepo),
+		Members:      handlers.NewMemberController(memberRepo),
 	}
 
 	services := struct {
 		JWTService   auth.JWTService
 		RedisService cache.RedisService
+		TenantRepo   storage.TenantRepo
+		MemberRepo   storage.MemberRepo
 	}{
 		JWTService:   jwtService,
 		RedisService: redisService,
+		TenantRepo:   tenantRepo,
+		MemberRepo:   memberRepo,
 	}
 
 	dependencies := server.DependencyContainer{
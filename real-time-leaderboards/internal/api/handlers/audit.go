@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/api/problems"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+)
+
+type AuditController struct {
+	repo storage.AuditRepo
+}
+
+func NewAuditController(repo storage.AuditRepo) AuditController {
+	return AuditController{
+		repo: repo,
+	}
+}
+
+// Returns the audit log newest first, filtered by actor, action, target and time range
+func (a AuditController) List(c *gin.Context) {
+	filter := models.AuditLogFilter{}
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid query parameters, times must be RFC 3339"))
+		return
+	}
+	if err := filter.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	filter.Normalize()
+
+	entries, err := a.repo.GetAuditLog(c.Request.Context(), &filter)
+	if err != nil {
+		problems.RenderError(c, err, "Audit log")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       entries,
+		"pagination": filter.Pagination,
+	})
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/mocks"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuditList(t *testing.T) {
+
+	setupAuditRepoMock := func(filter any, returns ...any) *mocks.MockAuditRepo {
+		mockRepo := mocks.MockAuditRepo{}
+		mockRepo.On("GetAuditLog", filter).Return(returns...)
+		return &mockRepo
+	}
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockAuditRepo
+		expectedStatus int
+		requestOpts    requestOpts
+	}{
+		{
+			name: "list audit log",
+			mockRepo: setupAuditRepoMock(
+				mock.MatchedBy(func(filter *models.AuditLogFilter) bool {
+					return filter.Action == models.AuditActionLeaderboardUpdate &&
+						filter.From.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) &&
+						filter.Limit == 10
+				}),
+				[]models.AuditEntry{{ID: "1", Action: models.AuditActionLeaderboardUpdate}}, nil,
+			),
+			expectedStatus: http.StatusOK,
+			requestOpts: requestOpts{query: map[string]string{
+				"action": models.AuditActionLeaderboardUpdate,
+				"from":   "2024-01-01T00:00:00Z",
+				"limit":  "10",
+			}},
+		},
+		{
+			name:           "list audit log invalid time",
+			mockRepo:       &mocks.MockAuditRepo{},
+			expectedStatus: http.StatusBadRequest,
+			requestOpts:    requestOpts{query: map[string]string{"from": "yesterday"}},
+		},
+		{
+			name:           "list audit log inverted time range",
+			mockRepo:       &mocks.MockAuditRepo{},
+			expectedStatus: http.StatusBadRequest,
+			requestOpts: requestOpts{query: map[string]string{
+				"from": "2024-02-01T00:00:00Z",
+				"to":   "2024-01-01T00:00:00Z",
+			}},
+		},
+		{
+			name: "list audit log db error",
+			mockRepo: setupAuditRepoMock(
+				mock.AnythingOfType("*models.AuditLogFilter"),
+				[]models.AuditEntry{}, ErrRepoOperation,
+			),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ac := NewAuditController(testCase.mockRepo)
+
+			w := executeRequest([]gin.HandlerFunc{ac.List}, testCase.requestOpts)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+		})
+	}
+}
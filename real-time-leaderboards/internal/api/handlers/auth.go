@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/api/problems"
 	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
 	"github.com/mochivi/go-real-time-leaderboards/internal/models"
 	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
@@ -33,36 +37,38 @@ func (a AuthController) Login(c *gin.Context) {
 	// Bind request body with AuthRequest model
 	authRequest := models.AuthRequest{}
 	if err := c.ShouldBindBodyWithJSON(&authRequest); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Unable to parse login request from request body",
-			"message": "Authentication failed",
-		})
+		problems.Render(c, problems.InvalidRequest("Unable to parse login request from request body"))
 		return
 	}
 
 	// Check username and password in the repo
+	// Unknown users are reported as invalid credentials to avoid leaking which usernames exist
 	user, err := a.repo.GetByUsername(c.Request.Context(), authRequest.Username)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"message": "User does not exist",
-		})
+		if errors.Is(err, storage.ErrNotFound) {
+			err = auth.ErrInvalidCredentials
+		}
+		problems.RenderError(c, err, "User")
 		return
 	}
 
 	// Validate provided password with the password hash
 	if !user.ValidatePasswordHash(authRequest.Password) {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"message": "Username or password incorrect",
-		})
+		problems.RenderError(c, auth.ErrInvalidCredentials, "User")
+		return
+	}
+
+	// Banned users are only told after proving they own the account, shadowbanned users log in as usual
+	if user.IsBanned(time.Now()) {
+		problems.RenderError(c, auth.ErrBanned, "User")
 		return
 	}
 
 	// Generate JWT token to send with response
 	tokens, err := a.jwtService.CreateAccessTokens(user.ID, user.Role)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to generate JWT",
-		})
+		log.Printf("Failed to generate JWT: %v", err)
+		problems.Render(c, problems.Internal())
 		return
 	}
 
@@ -107,29 +113,22 @@ func (a AuthController) RefreshToken(c *gin.Context) {
 
 	// Validate if token was received
 	if refreshToken == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Refresh token cookie not sent",
-			"message": "Failed to refresh access token",
-		})
+		problems.Render(c, problems.InvalidRequest("Refresh token cookie not sent"))
 		return
 	}
 
 	// Get userID & Role to generate the custom claims
 	user := models.User{}
 	if err := c.ShouldBindBodyWithJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Missing required user information",
-			"message": "Failed to refresh access token",
-		})
+		problems.Render(c, problems.InvalidRequest("Missing required user information"))
 		return
 	}
 
 	// Create new access and refresh tokens for user
 	tokens, err := a.jwtService.CreateAccessTokens(user.ID, user.Role)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to generate JWT",
-		})
+		log.Printf("Failed to generate JWT: %v", err)
+		problems.Render(c, problems.Internal())
 		return
 	}
 
@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/api/problems"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/rankings"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	redis "github.com/mochivi/go-real-time-leaderboards/internal/storage/redis"
+)
+
+// Bans are restricted to administrators
+type BanController struct {
+	repo     storage.UserRepo
+	rankings rankings.Syncer
+	teams    rankings.Teams
+}
+
+func NewBanController(
+	repo storage.UserRepo,
+	leaderboardRepo storage.LeaderboardRepo,
+	teamRepo storage.TeamRepo,
+	redisService redis.RedisService,
+) BanController {
+	return BanController{
+		repo:     repo,
+		rankings: rankings.NewSyncer(leaderboardRepo, redisService),
+		teams:    rankings.NewTeams(teamRepo, redisService),
+	}
+}
+
+// Returns the active bans, optionally of a single type
+func (b BanController) List(c *gin.Context) {
+	filter := models.BanFilter{}
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid query parameters"))
+		return
+	}
+	filter.Normalize()
+
+	bans, err := b.repo.GetBans(c.Request.Context(), &filter)
+	if err != nil {
+		problems.RenderError(c, err, "Ban")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       bans,
+		"pagination": filter.Pagination,
+	})
+}
+
+// Bans or shadowbans the user and removes their scores from every ranking
+func (b BanController) Ban(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing user id"))
+		return
+	}
+
+	request := models.BanRequest{}
+	if err := c.ShouldBindBodyWithJSON(&request); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid request body"))
+		return
+	}
+	if err := request.Validate(time.Now()); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+
+	actor, err := auditActor(c)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+	if actor.UserID == userID {
+		problems.Render(c, problems.InvalidRequest("Administrators cannot ban themselves"))
+		return
+	}
+
+	ban, err := b.repo.Ban(c.Request.Context(), userID, &request, actor)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	// Postgres queries already hide the user, a failed sync only leaves the Redis rankings stale
+	if err := b.rankings.SyncUser(c.Request.Context(), userID); err != nil {
+		log.Printf("Failed to remove banned user from rankings: %v", err)
+	}
+	if err := b.teams.SyncUser(c.Request.Context(), userID, ""); err != nil {
+		log.Printf("Failed to remove banned user from team rankings: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    ban,
+		"message": "User banned",
+	})
+}
+
+// Lifts the ban of the user and restores their scores in every ranking
+func (b BanController) Unban(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing user id"))
+		return
+	}
+
+	actor, err := auditActor(c)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	if err := b.repo.Unban(c.Request.Context(), userID, actor); err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	if err := b.rankings.SyncUser(c.Request.Context(), userID); err != nil {
+		log.Printf("Failed to restore rankings of unbanned user: %v", err)
+	}
+	if err := b.teams.SyncUser(c.Request.Context(), userID, ""); err != nil {
+		log.Printf("Failed to restore team rankings of unbanned user: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User unbanned",
+	})
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
+	"github.com/mochivi/go-real-time-leaderboards/internal/mocks"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBansBan(t *testing.T) {
+
+	shadowbanRequest := models.BanRequest{Type: models.BanTypeShadowban, Reason: "Speed hacks"}
+	expired := time.Now().Add(-time.Hour)
+
+	// Banned scores are removed from every ranking
+	rankedScores := []models.RankedScore{
+		{LeaderboardID: "1", Score: 10, Hidden: true},
+		{LeaderboardID: "2", Score: 20, Hidden: true},
+	}
+	removedFromRankings := func() *mocks.MockRedisService {
+		mockRedisService := setupRedisServiceMock("ZRem", []any{"leaderboard:1:ranking", "2"}, []any{nil})
+		mockRedisService.On("ZRem", "leaderboard:2:ranking", "2").Return(nil)
+		return mockRedisService
+	}
+
+	// The team is aggregated again without the banned member, leaving it unranked where they were its only member
+	teamScoresWithoutUser := []models.TeamScore{
+		{LeaderboardID: "1", TeamID: "7", Score: 40},
+		{LeaderboardID: "2", TeamID: "7", Hidden: true},
+	}
+
+	testCases := []struct {
+		name            string
+		mockRepo        *mocks.MockUserRepo
+		mockLeaderboard *mocks.MockLeaderboardsRepo
+		mockTeams       *mocks.MockTeamRepo
+		mockCache       *mocks.MockRedisService
+		expectedStatus  int
+		requestOpts     requestOpts
+	}{
+		{
+			name: "shadowban user",
+			mockRepo: setupUserRepoMock(
+				"Ban",
+				[]any{"2", &shadowbanRequest, mock.AnythingOfType("*models.AuditActor")},
+				[]any{&models.Ban{UserID: "2", Type: models.BanTypeShadowban}, nil},
+			),
+			mockLeaderboard: setupLeaderboardRepoMock("GetRankedScores", []any{"2"}, []any{rankedScores, nil}),
+			mockTeams:       setupNoTeamsMock(),
+			mockCache:       removedFromRankings(),
+			expectedStatus:  http.StatusOK,
+			requestOpts:     requestOpts{params: map[string]string{"id": "2"}, body: shadowbanRequest},
+		},
+		{
+			name: "ban team member",
+			mockRepo: setupUserRepoMock(
+				"Ban",
+				[]any{"2", &shadowbanRequest, mock.AnythingOfType("*models.AuditActor")},
+				[]any{&models.Ban{UserID: "2", Type: models.BanTypeShadowban}, nil},
+			),
+			mockLeaderboard: setupLeaderboardRepoMock("GetRankedScores", []any{"2"}, []any{rankedScores, nil}),
+			mockTeams: func() *mocks.MockTeamRepo {
+				mockRepo := setupTeamRepoMock("GetUserTeam", []any{"2"}, []any{&models.Team{ID: "7"}, nil})
+				mockRepo.On("GetTeamScores", "7", "").Return(teamScoresWithoutUser, nil)
+				return mockRepo
+			}(),
+			mockCache: func() *mocks.MockRedisService {
+				mockRedisService := removedFromRankings()
+				mockRedisService.On("ZAdd", "leaderboard:1:teams", "7", float64(40)).Return(nil)
+				mockRedisService.On("ZRem", "leaderboard:2:teams", "7").Return(nil)
+				return mockRedisService
+			}(),
+			expectedStatus: http.StatusOK,
+			requestOpts:    requestOpts{params: map[string]string{"id": "2"}, body: shadowbanRequest},
+		},
+		{
+			name:            "ban user invalid type",
+			mockRepo:        &mocks.MockUserRepo{},
+			mockLeaderboard: &mocks.MockLeaderboardsRepo{},
+			mockTeams:       setupNoTeamsMock(),
+			mockCache:       &mocks.MockRedisService{},
+			expectedStatus:  http.StatusBadRequest,
+			requestOpts: requestOpts{
+				params: map[string]string{"id": "2"},
+				body:   models.BanRequest{Type: "mute", Reason: "Spam"},
+			},
+		},
+		{
+			name:            "ban user expiry in the past",
+			mockRepo:        &mocks.MockUserRepo{},
+			mockLeaderboard: &mocks.MockLeaderboardsRepo{},
+			mockTeams:       setupNoTeamsMock(),
+			mockCache:       &mocks.MockRedisService{},
+			expectedStatus:  http.StatusBadRequest,
+			requestOpts: requestOpts{
+				params: map[string]string{"id": "2"},
+				body:   models.BanRequest{Type: models.BanTypeBan, Reason: "Spam", ExpiresAt: &expired},
+			},
+		},
+		{
+			name:            "ban self",
+			mockRepo:        &mocks.MockUserRepo{},
+			mockLeaderboard: &mocks.MockLeaderboardsRepo{},
+			mockTeams:       setupNoTeamsMock(),
+			mockCache:       &mocks.MockRedisService{},
+			expectedStatus:  http.StatusBadRequest,
+			requestOpts:     requestOpts{params: map[string]string{"id": "1"}, body: shadowbanRequest},
+		},
+		{
+			name: "ban user not found",
+			mockRepo: setupUserRepoMock(
+				"Ban",
+				[]any{"2", mock.Anything, mock.Anything},
+				[]any{&models.Ban{}, storage.ErrNotFound},
+			),
+			mockLeaderboard: &mocks.MockLeaderboardsRepo{},
+			mockTeams:       setupNoTeamsMock(),
+			mockCache:       &mocks.MockRedisService{},
+			expectedStatus:  http.StatusNotFound,
+			requestOpts:     requestOpts{params: map[string]string{"id": "2"}, body: shadowbanRequest},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			bc := NewBanController(testCase.mockRepo, testCase.mockLeaderboard, testCase.mockTeams, testCase.mockCache)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAdminMiddleware(&auth.CustomClaims{UserID: "1", Role: "administrator"}),
+					bc.Ban,
+				},
+				testCase.requestOpts,
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+			testCase.mockLeaderboard.AssertExpectations(t)
+			testCase.mockTeams.AssertExpectations(t)
+			testCase.mockCache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBansUnban(t *testing.T) {
+
+	testCases := []struct {
+		name            string
+		mockRepo        *mocks.MockUserRepo
+		mockLeaderboard *mocks.MockLeaderboardsRepo
+		mockTeams       *mocks.MockTeamRepo
+		mockCache       *mocks.MockRedisService
+		expectedStatus  int
+	}{
+		{
+			name:     "unban user",
+			mockRepo: setupUserRepoMock("Unban", []any{"2", mock.AnythingOfType("*models.AuditActor")}, []any{nil}),
+			mockLeaderboard: setupLeaderboardRepoMock(
+				"GetRankedScores",
+				[]any{"2"},
+				[]any{[]models.RankedScore{{LeaderboardID: "1", Score: 10}}, nil},
+			),
+			mockTeams:      setupNoTeamsMock(),
+			mockCache:      setupRedisServiceMock("ZAdd", []any{"leaderboard:1:ranking", "2", float64(10)}, []any{nil}),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:            "unban user not banned",
+			mockRepo:        setupUserRepoMock("Unban", []any{"2", mock.Anything}, []any{storage.ErrStateConflict}),
+			mockLeaderboard: &mocks.MockLeaderboardsRepo{},
+			mockTeams:       setupNoTeamsMock(),
+			mockCache:       &mocks.MockRedisService{},
+			expectedStatus:  http.StatusConflict,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			bc := NewBanController(testCase.mockRepo, testCase.mockLeaderboard, testCase.mockTeams, testCase.mockCache)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAdminMiddleware(&auth.CustomClaims{UserID: "1", Role: "administrator"}),
+					bc.Unban,
+				},
+				requestOpts{params: map[string]string{"id": "2"}},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+			testCase.mockLeaderboard.AssertExpectations(t)
+			testCase.mockTeams.AssertExpectations(t)
+			testCase.mockCache.AssertExpectations(t)
+		})
+	}
+}
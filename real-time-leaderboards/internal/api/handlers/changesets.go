@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/api/problems"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/rankings"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	redis "github.com/mochivi/go-real-time-leaderboards/internal/storage/redis"
+)
+
+// Bulk removal and rescoring of entries, restricted to administrators
+// Every operation is recorded as a change set that can be reverted
+type ChangeSetController struct {
+	repo     storage.ChangeSetRepo
+	rankings rankings.Syncer
+	teams    rankings.Teams
+}
+
+func NewChangeSetController(
+	repo storage.ChangeSetRepo,
+	leaderboardRepo storage.LeaderboardRepo,
+	teamRepo storage.TeamRepo,
+	redisService redis.RedisService,
+) ChangeSetController {
+	return ChangeSetController{
+		repo:     repo,
+		rankings: rankings.NewSyncer(leaderboardRepo, redisService),
+		teams:    rankings.NewTeams(teamRepo, redisService),
+	}
+}
+
+// Deletes or rescores the entries of the leaderboard matching the filter
+func (cs ChangeSetController) Apply(c *gin.Context) {
+	leaderboardID := c.Param("id")
+	if leaderboardID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing leaderboard id"))
+		return
+	}
+
+	request := models.BulkEntryRequest{}
+	if err := c.ShouldBindBodyWithJSON(&request); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid request body"))
+		return
+	}
+	if err := request.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	request.LeaderboardID = leaderboardID
+
+	actor, err := auditActor(c)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	changeSet, err := cs.repo.Apply(c.Request.Context(), &request, actor)
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard entry")
+		return
+	}
+
+	cs.syncRanking(c, changeSet)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    changeSet,
+		"message": "Leaderboard entries changed",
+	})
+}
+
+// Restores the entries changed by the change set
+func (cs ChangeSetController) Revert(c *gin.Context) {
+	changeSetID := c.Param("id")
+	if changeSetID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing change set id"))
+		return
+	}
+
+	actor, err := auditActor(c)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	changeSet, err := cs.repo.Revert(c.Request.Context(), changeSetID, actor)
+	if err != nil {
+		problems.RenderError(c, err, "Change set")
+		return
+	}
+
+	cs.syncRanking(c, changeSet)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    changeSet,
+		"message": "Change set reverted",
+	})
+}
+
+// Returns the change sets newest first, optionally for a single leaderboard
+func (cs ChangeSetController) List(c *gin.Context) {
+	filter := models.ChangeSetFilter{}
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid query parameters"))
+		return
+	}
+	filter.Normalize()
+
+	changeSets, err := cs.repo.GetChangeSets(c.Request.Context(), &filter)
+	if err != nil {
+		problems.RenderError(c, err, "Change set")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       changeSets,
+		"pagination": filter.Pagination,
+	})
+}
+
+// The change is already committed, a failed sync is logged and fixed by the next one
+func (cs ChangeSetController) syncRanking(c *gin.Context, changeSet *models.EntryChangeSet) {
+	if err := cs.rankings.SyncLeaderboard(c.Request.Context(), changeSet.LeaderboardID); err != nil {
+		log.Printf("Failed to update ranking: %v", err)
+	}
+	// Leaderboards without a team ranking have no team scores, which leaves their team ranking empty
+	if err := cs.teams.SyncLeaderboard(c.Request.Context(), changeSet.LeaderboardID); err != nil {
+		log.Printf("Failed to update team ranking: %v", err)
+	}
+}
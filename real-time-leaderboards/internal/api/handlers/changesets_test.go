@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
+	"github.com/mochivi/go-real-time-leaderboards/internal/mocks"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// The ranking is rebuilt from the remaining scores after every committed change set
+func setupRankingRebuildMock() (*mocks.MockLeaderboardsRepo, *mocks.MockRedisService) {
+	mockLeaderboard := setupLeaderboardRepoMock(
+		"GetLeaderboardScores",
+		[]any{"1"},
+		[]any{[]models.RankedScore{{LeaderboardID: "1", UserID: "3", Score: 50}}, nil},
+	)
+	mockCache := setupRedisServiceMock(
+		"ZReplace",
+		[]any{"leaderboard:1:ranking", map[string]float64{"3": 50}},
+		[]any{nil},
+	)
+	mockCache.On("ZReplace", "leaderboard:1:teams", map[string]float64{}).Return(nil)
+	return mockLeaderboard, mockCache
+}
+
+func TestChangeSetsApply(t *testing.T) {
+
+	set := 100
+	deleteRequest := models.BulkEntryRequest{
+		Operation: models.ChangeSetOperationDelete,
+		Filter:    models.BulkEntryFilter{UserID: "2"},
+	}
+	expectedDelete := deleteRequest
+	expectedDelete.LeaderboardID = "1"
+
+	rebuiltLeaderboard, rebuiltCache := setupRankingRebuildMock()
+
+	testCases := []struct {
+		name            string
+		mockRepo        *mocks.MockChangeSetRepo
+		mockLeaderboard *mocks.MockLeaderboardsRepo
+		mockCache       *mocks.MockRedisService
+		expectedStatus  int
+		body            any
+	}{
+		{
+			name: "delete entries of a user",
+			mockRepo: setupChangeSetRepoMock(
+				"Apply",
+				[]any{&expectedDelete, mock.AnythingOfType("*models.AuditActor")},
+				[]any{&models.EntryChangeSet{ID: "7", LeaderboardID: "1", Entries: 4}, nil},
+			),
+			mockLeaderboard: rebuiltLeaderboard,
+			mockCache:       rebuiltCache,
+			expectedStatus:  http.StatusCreated,
+			body:            deleteRequest,
+		},
+		{
+			name:            "delete entries without filter",
+			mockRepo:        &mocks.MockChangeSetRepo{},
+			mockLeaderboard: &mocks.MockLeaderboardsRepo{},
+			mockCache:       &mocks.MockRedisService{},
+			expectedStatus:  http.StatusBadRequest,
+			body:            models.BulkEntryRequest{Operation: models.ChangeSetOperationDelete},
+		},
+		{
+			name:            "rescore entries with set and add",
+			mockRepo:        &mocks.MockChangeSetRepo{},
+			mockLeaderboard: &mocks.MockLeaderboardsRepo{},
+			mockCache:       &mocks.MockRedisService{},
+			expectedStatus:  http.StatusBadRequest,
+			body: models.BulkEntryRequest{
+				Operation: models.ChangeSetOperationRescore,
+				Filter:    models.BulkEntryFilter{EntryIDs: []string{"5"}},
+				Set:       &set,
+				Add:       10,
+			},
+		},
+		{
+			name: "rescore entries matching nothing",
+			mockRepo: setupChangeSetRepoMock(
+				"Apply",
+				[]any{mock.Anything, mock.Anything},
+				[]any{&models.EntryChangeSet{}, storage.ErrNotFound},
+			),
+			mockLeaderboard: &mocks.MockLeaderboardsRepo{},
+			mockCache:       &mocks.MockRedisService{},
+			expectedStatus:  http.StatusNotFound,
+			body: models.BulkEntryRequest{
+				Operation: models.ChangeSetOperationRescore,
+				Filter:    models.BulkEntryFilter{EntryIDs: []string{"5"}},
+				Set:       &set,
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			cc := NewChangeSetController(testCase.mockRepo, testCase.mockLeaderboard, setupNoTeamsMock(), testCase.mockCache)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAdminMiddleware(&auth.CustomClaims{UserID: "1", Role: "administrator"}),
+					cc.Apply,
+				},
+				requestOpts{params: map[string]string{"id": "1"}, body: testCase.body},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+			testCase.mockLeaderboard.AssertExpectations(t)
+			testCase.mockCache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestChangeSetsRevert(t *testing.T) {
+
+	rebuiltLeaderboard, rebuiltCache := setupRankingRebuildMock()
+
+	testCases := []struct {
+		name            string
+		mockRepo        *mocks.MockChangeSetRepo
+		mockLeaderboard *mocks.MockLeaderboardsRepo
+		mockCache       *mocks.MockRedisService
+		expectedStatus  int
+	}{
+		{
+			name: "revert change set",
+			mockRepo: setupChangeSetRepoMock(
+				"Revert",
+				[]any{"7", mock.AnythingOfType("*models.AuditActor")},
+				[]any{&models.EntryChangeSet{ID: "7", LeaderboardID: "1"}, nil},
+			),
+			mockLeaderboard: rebuiltLeaderboard,
+			mockCache:       rebuiltCache,
+			expectedStatus:  http.StatusOK,
+		},
+		{
+			name: "revert change set twice",
+			mockRepo: setupChangeSetRepoMock(
+				"Revert",
+				[]any{"7", mock.Anything},
+				[]any{&models.EntryChangeSet{}, storage.ErrStateConflict},
+			),
+			mockLeaderboard: &mocks.MockLeaderboardsRepo{},
+			mockCache:       &mocks.MockRedisService{},
+			expectedStatus:  http.StatusConflict,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			cc := NewChangeSetController(testCase.mockRepo, testCase.mockLeaderboard, setupNoTeamsMock(), testCase.mockCache)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAdminMiddleware(&auth.CustomClaims{UserID: "1", Role: "administrator"}),
+					cc.Revert,
+				},
+				requestOpts{params: map[string]string{"id": "7"}},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+			testCase.mockLeaderboard.AssertExpectations(t)
+			testCase.mockCache.AssertExpectations(t)
+		})
+	}
+}
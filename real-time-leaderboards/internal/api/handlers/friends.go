@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/api/problems"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/rankings"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	redis "github.com/mochivi/go-real-time-leaderboards/internal/storage/redis"
+)
+
+// Players send friend requests to each other, accepted requests make both players friends
+type FriendController struct {
+	repo    storage.FriendRepo
+	circles rankings.Circles
+}
+
+func NewFriendController(
+	repo storage.FriendRepo,
+	userRepo storage.UserRepo,
+	redisService redis.RedisService,
+) FriendController {
+	return FriendController{
+		repo:    repo,
+		circles: rankings.NewCircles(repo, userRepo, redisService),
+	}
+}
+
+// Lists the friends of the signed in player
+func (f FriendController) List(c *gin.Context) {
+	f.list(c, f.repo.GetFriends)
+}
+
+// Lists the pending friend requests received by the signed in player
+func (f FriendController) ListRequests(c *gin.Context) {
+	f.list(c, f.repo.GetRequests)
+}
+
+func (f FriendController) list(
+	c *gin.Context,
+	query func(context.Context, string, *models.Pagination) ([]models.Friendship, error),
+) {
+	userClaims, err := parseUserClaims(c)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	page := models.Pagination{}
+	if err := c.ShouldBindQuery(&page); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid query parameters"))
+		return
+	}
+	page.Normalize()
+
+	friendships, err := query(c.Request.Context(), userClaims.UserID, &page)
+	if err != nil {
+		problems.RenderError(c, err, "Friendship")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       friendships,
+		"pagination": page,
+	})
+}
+
+// Sends a friend request, if the other player already sent one both become friends right away
+func (f FriendController) Request(c *gin.Context) {
+	userClaims, err := parseUserClaims(c)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	var request models.FriendRequest
+	if err := c.ShouldBindBodyWithJSON(&request); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid request body"))
+		return
+	}
+	if err := request.Validate(userClaims.UserID); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+
+	friendship, err := f.repo.Request(c.Request.Context(), userClaims.UserID, request.UserID)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	if friendship.Status == models.FriendshipStatusAccepted {
+		f.syncCircles(c, userClaims.UserID, request.UserID)
+		c.JSON(http.StatusOK, gin.H{
+			"data":    friendship,
+			"message": "Friend request accepted",
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    friendship,
+		"message": "Friend request sent",
+	})
+}
+
+// Accepts the friend request sent by the player in the path
+func (f FriendController) Accept(c *gin.Context) {
+	requesterID := c.Param("id")
+	if requesterID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing user id"))
+		return
+	}
+
+	userClaims, err := parseUserClaims(c)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	friendship, err := f.repo.Accept(c.Request.Context(), userClaims.UserID, requesterID)
+	if err != nil {
+		problems.RenderError(c, err, "Friend request")
+		return
+	}
+	f.syncCircles(c, userClaims.UserID, requesterID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    friendship,
+		"message": "Friend request accepted",
+	})
+}
+
+// Removes a friend, or declines or cancels a pending request with the player in the path
+func (f FriendController) Remove(c *gin.Context) {
+	otherID := c.Param("id")
+	if otherID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing user id"))
+		return
+	}
+
+	userClaims, err := parseUserClaims(c)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	if err := f.repo.Remove(c.Request.Context(), userClaims.UserID, otherID); err != nil {
+		problems.RenderError(c, err, "Friendship")
+		return
+	}
+	f.syncCircles(c, userClaims.UserID, otherID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Friendship removed",
+	})
+}
+
+// The friendship is already committed, a stale circle only affects the friends rankings until the next change
+func (f FriendController) syncCircles(c *gin.Context, userIDs ...string) {
+	for _, userID := range userIDs {
+		if err := f.circles.Sync(c.Request.Context(), userID); err != nil {
+			log.Printf("Failed to sync circle of friends: %v", err)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
+	"github.com/mochivi/go-real-time-leaderboards/internal/mocks"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// Both circles are rebuilt once two players become friends or stop being friends
+func setupCircleSyncMocks(repo *mocks.MockFriendRepo) *mocks.MockRedisService {
+	repo.On("GetCircle", "2").Return([]models.User{{ID: "2"}, {ID: "3"}}, nil)
+	repo.On("GetCircle", "3").Return([]models.User{{ID: "3"}, {ID: "2"}}, nil)
+
+	mockRedisService := setupRedisServiceMock("SReplace", []any{"user:2:circle", []string{"2", "3"}}, []any{nil})
+	mockRedisService.On("SReplace", "user:3:circle", []string{"3", "2"}).Return(nil)
+	return mockRedisService
+}
+
+func TestFriendsRequest(t *testing.T) {
+
+	acceptedAt := time.Now()
+	pendingRepo := setupFriendRepoMock(
+		"Request",
+		[]any{"2", "3"},
+		[]any{&models.Friendship{User: models.User{ID: "3"}, Status: models.FriendshipStatusPending}, nil},
+	)
+	acceptedRepo := setupFriendRepoMock(
+		"Request",
+		[]any{"2", "3"},
+		[]any{&models.Friendship{User: models.User{ID: "3"}, Status: models.FriendshipStatusAccepted, AcceptedAt: &acceptedAt}, nil},
+	)
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockFriendRepo
+		mockCache      *mocks.MockRedisService
+		body           any
+		expectedStatus int
+	}{
+		{
+			name:           "send friend request",
+			mockRepo:       pendingRepo,
+			mockCache:      &mocks.MockRedisService{},
+			body:           models.FriendRequest{UserID: "3"},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "accept request the other player already sent",
+			mockRepo:       acceptedRepo,
+			mockCache:      setupCircleSyncMocks(acceptedRepo),
+			body:           models.FriendRequest{UserID: "3"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "befriend themselves",
+			mockRepo:       &mocks.MockFriendRepo{},
+			mockCache:      &mocks.MockRedisService{},
+			body:           models.FriendRequest{UserID: "2"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "request already sent",
+			mockRepo: setupFriendRepoMock(
+				"Request",
+				[]any{"2", "3"},
+				[]any{&models.Friendship{}, storage.ErrConflict},
+			),
+			mockCache:      &mocks.MockRedisService{},
+			body:           models.FriendRequest{UserID: "3"},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "request unknown user",
+			mockRepo: setupFriendRepoMock(
+				"Request",
+				[]any{"2", "3"},
+				[]any{&models.Friendship{}, storage.ErrNotFound},
+			),
+			mockCache:      &mocks.MockRedisService{},
+			body:           models.FriendRequest{UserID: "3"},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			fc := NewFriendController(testCase.mockRepo, &mocks.MockUserRepo{}, testCase.mockCache)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAuthMiddleware(&auth.CustomClaims{UserID: "2", Role: "visitor"}),
+					fc.Request,
+				},
+				requestOpts{body: testCase.body},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+			testCase.mockCache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestFriendsAccept(t *testing.T) {
+
+	acceptedRepo := setupFriendRepoMock(
+		"Accept",
+		[]any{"2", "3"},
+		[]any{&models.Friendship{User: models.User{ID: "3"}, Status: models.FriendshipStatusAccepted}, nil},
+	)
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockFriendRepo
+		mockCache      *mocks.MockRedisService
+		expectedStatus int
+	}{
+		{
+			name:           "accept friend request",
+			mockRepo:       acceptedRepo,
+			mockCache:      setupCircleSyncMocks(acceptedRepo),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "accept missing request",
+			mockRepo:       setupFriendRepoMock("Accept", []any{"2", "3"}, []any{&models.Friendship{}, storage.ErrNotFound}),
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			fc := NewFriendController(testCase.mockRepo, &mocks.MockUserRepo{}, testCase.mockCache)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAuthMiddleware(&auth.CustomClaims{UserID: "2", Role: "visitor"}),
+					fc.Accept,
+				},
+				requestOpts{params: map[string]string{"id": "3"}},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+			testCase.mockCache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestFriendsRemove(t *testing.T) {
+
+	removedRepo := setupFriendRepoMock("Remove", []any{"2", "3"}, []any{nil})
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockFriendRepo
+		mockCache      *mocks.MockRedisService
+		expectedStatus int
+	}{
+		{
+			name:           "remove friend",
+			mockRepo:       removedRepo,
+			mockCache:      setupCircleSyncMocks(removedRepo),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "remove unknown friend",
+			mockRepo:       setupFriendRepoMock("Remove", []any{"2", "3"}, []any{storage.ErrNotFound}),
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			fc := NewFriendController(testCase.mockRepo, &mocks.MockUserRepo{}, testCase.mockCache)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAuthMiddleware(&auth.CustomClaims{UserID: "2", Role: "visitor"}),
+					fc.Remove,
+				},
+				requestOpts{params: map[string]string{"id": "3"}},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+			testCase.mockCache.AssertExpectations(t)
+		})
+	}
+}
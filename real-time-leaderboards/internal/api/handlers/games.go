@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/api/problems"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+)
+
+// Games, categories and variables are declared by administrators, the hierarchy is public
+type GameController struct {
+	repo storage.GameRepo
+}
+
+func NewGameController(repo storage.GameRepo) GameController {
+	return GameController{
+		repo: repo,
+	}
+}
+
+// Returns the game with its categories, their variables and the leaderboards ranking them
+func (g GameController) Get(c *gin.Context) {
+	gameID := c.Param("id")
+	if gameID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing game id"))
+		return
+	}
+
+	game, err := g.repo.Get(c.Request.Context(), gameID)
+	if err != nil {
+		problems.RenderError(c, err, "Game")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": game,
+	})
+}
+
+func (g GameController) Create(c *gin.Context) {
+	request := models.GameRequest{}
+	if err := c.ShouldBindBodyWithJSON(&request); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid request body"))
+		return
+	}
+	if err := request.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+
+	game, err := g.repo.Create(c.Request.Context(), &request)
+	if err != nil {
+		problems.RenderError(c, err, "Game")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    game,
+		"message": "Game created",
+	})
+}
+
+// Adds a category to the game, ranked once a leaderboard is created with its category_id
+func (g GameController) CreateCategory(c *gin.Context) {
+	gameID := c.Param("id")
+	if gameID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing game id"))
+		return
+	}
+
+	request := models.CategoryRequest{}
+	if err := c.ShouldBindBodyWithJSON(&request); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid request body"))
+		return
+	}
+	if err := request.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	request.GameID = gameID
+
+	category, err := g.repo.CreateCategory(c.Request.Context(), &request)
+	if err != nil {
+		problems.RenderError(c, err, "Game")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    category,
+		"message": "Category created",
+	})
+}
+
+// Adds a variable to the category, runs submitted afterwards pick one of its values
+func (g GameController) CreateVariable(c *gin.Context) {
+	categoryID := c.Param("id")
+	if categoryID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing category id"))
+		return
+	}
+
+	request := models.VariableRequest{}
+	if err := c.ShouldBindBodyWithJSON(&request); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid request body"))
+		return
+	}
+	if err := request.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	request.CategoryID = categoryID
+
+	variable, err := g.repo.CreateVariable(c.Request.Context(), &request)
+	if err != nil {
+		problems.RenderError(c, err, "Category")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    variable,
+		"message": "Variable created",
+	})
+}
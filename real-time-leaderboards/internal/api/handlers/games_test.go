@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
+	"github.com/mochivi/go-real-time-leaderboards/internal/mocks"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGamesCreateCategory(t *testing.T) {
+
+	request := &models.CategoryRequest{GameID: "3", Name: "Any%"}
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockGameRepo
+		body           any
+		expectedStatus int
+	}{
+		{
+			name: "create category",
+			mockRepo: setupGameRepoMock(
+				"CreateCategory",
+				[]any{request},
+				[]any{&models.Category{ID: "7", GameID: "3", Name: "Any%"}, nil},
+			),
+			body:           models.CategoryRequest{Name: " Any% "},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "missing name",
+			mockRepo:       &mocks.MockGameRepo{},
+			body:           models.CategoryRequest{},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "category already exists",
+			mockRepo: setupGameRepoMock(
+				"CreateCategory",
+				[]any{request},
+				[]any{&models.Category{}, storage.ErrConflict},
+			),
+			body:           request,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "unknown game",
+			mockRepo: setupGameRepoMock(
+				"CreateCategory",
+				[]any{request},
+				[]any{&models.Category{}, storage.ErrNotFound},
+			),
+			body:           request,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			gc := NewGameController(testCase.mockRepo)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAdminMiddleware(&auth.CustomClaims{UserID: "1", Role: "administrator"}),
+					gc.CreateCategory,
+				},
+				requestOpts{params: map[string]string{"id": "3"}, body: testCase.body},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGamesCreateVariable(t *testing.T) {
+
+	request := &models.VariableRequest{CategoryID: "7", Name: "platform", Values: []string{"PC", "Switch"}, Required: true}
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockGameRepo
+		body           any
+		expectedStatus int
+	}{
+		{
+			name: "create variable",
+			mockRepo: setupGameRepoMock(
+				"CreateVariable",
+				[]any{request},
+				[]any{&models.Variable{ID: "2", CategoryID: "7", Name: "platform", Values: request.Values}, nil},
+			),
+			body:           request,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "duplicated value",
+			mockRepo:       &mocks.MockGameRepo{},
+			body:           models.VariableRequest{Name: "platform", Values: []string{"PC", "PC"}},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "unknown category",
+			mockRepo: setupGameRepoMock(
+				"CreateVariable",
+				[]any{request},
+				[]any{&models.Variable{}, storage.ErrNotFound},
+			),
+			body:           request,
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			gc := NewGameController(testCase.mockRepo)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAdminMiddleware(&auth.CustomClaims{UserID: "1", Role: "administrator"}),
+					gc.CreateVariable,
+				},
+				requestOpts{params: map[string]string{"id": "7"}, body: testCase.body},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -2,26 +2,48 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"sync"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/api/problems"
+	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
 	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/rankings"
 	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
 	redis "github.com/mochivi/go-real-time-leaderboards/internal/storage/redis"
 )
 
 type LeaderboardController struct {
-	repo  storage.LeaderboardRepo
-	redis redis.RedisService
+	repo        storage.LeaderboardRepo
+	redis       redis.RedisService
+	rankings    rankings.Syncer
+	circles     rankings.Circles
+	teams       rankings.Teams
+	percentiles rankings.Percentiles
+	neighbours  rankings.Neighbours
 }
 
-func NewLeaderboardController(repo storage.LeaderboardRepo, redisService redis.RedisService) LeaderboardController {
+func NewLeaderboardController(
+	repo storage.LeaderboardRepo,
+	friendRepo storage.FriendRepo,
+	teamRepo storage.TeamRepo,
+	userRepo storage.UserRepo,
+	redisService redis.RedisService,
+) LeaderboardController {
 	return LeaderboardController{
-		repo:  repo,
-		redis: redisService,
+		repo:        repo,
+		redis:       redisService,
+		rankings:    rankings.NewSyncer(repo, redisService),
+		circles:     rankings.NewCircles(friendRepo, userRepo, redisService),
+		teams:       rankings.NewTeams(teamRepo, redisService),
+		percentiles: rankings.NewPercentiles(redisService),
+		neighbours:  rankings.NewNeighbours(userRepo, redisService),
 	}
 }
 
@@ -31,29 +53,13 @@ func NewLeaderboardController(repo storage.LeaderboardRepo, redisService redis.R
 func (l LeaderboardController) Get(c *gin.Context) {
 	leaderboardID := c.Param("id")
 	if leaderboardID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid request",
-			"message": "missing leaderboard id",
-		})
+		problems.Render(c, problems.InvalidRequest("Missing leaderboard id"))
 		return
 	}
 
 	leaderboard, err := l.repo.Get(c.Request.Context(), leaderboardID)
 	if err != nil {
-		var errorMessage string
-		var statusCode int
-		switch err {
-		case storage.ErrNotFound:
-			statusCode = http.StatusNotFound
-			errorMessage = "Leaderboard not found"
-		default:
-			statusCode = http.StatusInternalServerError
-			errorMessage = "Something went wrong"
-		}
-		c.JSON(statusCode, gin.H{
-			"error":   "Failed to get data",
-			"message": errorMessage,
-		})
+		problems.RenderError(c, err, "Leaderboard")
 		return
 	}
 
@@ -63,37 +69,336 @@ func (l LeaderboardController) Get(c *gin.Context) {
 	})
 }
 
+// Lists the leaderboards of the tenant, searching their name and description when q is set
+// Unlisted leaderboards are never listed, private ones only to their members
+func (l LeaderboardController) List(c *gin.Context) {
+	filter := models.LeaderboardListFilter{}
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid query parameters"))
+		return
+	}
+	if err := filter.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	filter.Normalize()
+	filter.ViewerID = viewerID(c)
+
+	leaderboards, err := l.repo.List(c.Request.Context(), &filter)
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       leaderboards,
+		"pagination": filter.Pagination,
+	})
+}
+
 func (l LeaderboardController) GetEntries(c *gin.Context) {
 	leaderboardID := c.Param("id")
 	if leaderboardID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid request",
-			"message": "missing leaderboard id",
-		})
+		problems.Render(c, problems.InvalidRequest("Missing leaderboard id"))
+		return
+	}
+
+	switch c.Query("scope") {
+	case "", models.EntryScopeGlobal:
+	case models.EntryScopeFriends:
+		l.getFriendsEntries(c, leaderboardID)
+		return
+	default:
+		problems.Render(c, problems.InvalidRequest("scope must be either 'global' or 'friends'"))
+		return
+	}
+
+	// Banned and shadowbanned users still see their own entries
+	leaderboardEntries, err := l.repo.GetEntries(c.Request.Context(), leaderboardID, viewerID(c))
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": leaderboardEntries,
+	})
+}
+
+// Ranks the leaderboard among the signed in player and their friends
+func (l LeaderboardController) getFriendsEntries(c *gin.Context, leaderboardID string) {
+	userID := viewerID(c)
+	if userID == "" {
+		problems.RenderError(c, auth.ErrUnauthorized, "User")
+		return
+	}
+
+	leaderboard, err := l.repo.Get(c.Request.Context(), leaderboardID)
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard")
+		return
+	}
+
+	entries, err := l.circles.Rank(c.Request.Context(), leaderboard, userID)
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": entries,
+	})
+}
+
+// Returns a page of the team ranking of a leaderboard configured with a team aggregate
+func (l LeaderboardController) GetTeamRanking(c *gin.Context) {
+	leaderboardID := c.Param("id")
+	if leaderboardID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing leaderboard id"))
+		return
+	}
+
+	page := models.Pagination{}
+	if err := c.ShouldBindQuery(&page); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid query parameters"))
+		return
+	}
+	page.Normalize()
+
+	leaderboard, err := l.repo.Get(c.Request.Context(), leaderboardID)
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard")
+		return
+	}
+	if leaderboard.TeamRanking == nil {
+		problems.Render(c, problems.NotFound("Leaderboard has no team ranking"))
+		return
+	}
+
+	teams, err := l.teams.Rank(c.Request.Context(), leaderboard, &page)
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       teams,
+		"pagination": page,
+	})
+}
+
+// Returns a page of the ranking filtered by variable values, such as variables[platform]=PC
+// Variables left out are aggregated, without any the best run of every player is ranked across all variants
+// Runs can also be filtered by their metadata, such as metadata[character]=mario
+func (l LeaderboardController) GetRanking(c *gin.Context) {
+	leaderboardID := c.Param("id")
+	if leaderboardID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing leaderboard id"))
+		return
+	}
+
+	page := models.Pagination{}
+	if err := c.ShouldBindQuery(&page); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid query parameters"))
+		return
+	}
+	page.Normalize()
+
+	leaderboard, err := l.repo.Get(c.Request.Context(), leaderboardID)
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard")
+		return
+	}
+	if leaderboard.Type == models.LeaderboardTypeRating {
+		problems.Render(c, problems.InvalidRequest("Rating leaderboards are ranked by their ratings"))
+		return
+	}
+
+	metadata := c.QueryMap("metadata")
+	if err := models.ValidateMetadataFilter(metadata); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+
+	entries, err := l.repo.GetRankedEntries(c.Request.Context(), &models.VariantFilter{
+		LeaderboardID: leaderboard.ID,
+		RankMode:      leaderboard.RankMode,
+		Values:        c.QueryMap("variables"),
+		Metadata:      metadata,
+		Page:          page,
+	})
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       entries,
+		"pagination": page,
+	})
+}
+
+// Returns the players ranked around the signed in user, up to radius players above and below them
+func (l LeaderboardController) GetRankingAroundMe(c *gin.Context) {
+	leaderboardID := c.Param("id")
+	if leaderboardID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing leaderboard id"))
+		return
+	}
+
+	userID := viewerID(c)
+	if userID == "" {
+		problems.RenderError(c, auth.ErrUnauthorized, "User")
+		return
+	}
+
+	filter := models.AroundFilter{}
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid query parameters"))
+		return
+	}
+	filter.Normalize()
+
+	leaderboard, err := l.repo.Get(c.Request.Context(), leaderboardID)
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard")
+		return
+	}
+	if leaderboard.Type == models.LeaderboardTypeRating {
+		problems.Render(c, problems.InvalidRequest("Rating leaderboards are ranked by their ratings"))
+		return
+	}
+
+	entries, err := l.neighbours.Around(c.Request.Context(), leaderboard, userID, &filter)
+	if errors.Is(err, redis.ErrNotFound) {
+		problems.Render(c, problems.NotFound("User is not ranked on this leaderboard"))
+		return
+	}
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   entries,
+		"radius": filter.Radius,
+	})
+}
+
+// Returns where a user stands in the ranking, as a percentile and the tier it reaches
+// Counting the players around the user scales to rankings too large for exact ranks to be meaningful
+func (l LeaderboardController) GetUserPercentile(c *gin.Context) {
+	leaderboardID, userID := c.Param("id"), c.Param("userId")
+	if leaderboardID == "" || userID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing leaderboard or user id"))
+		return
+	}
+
+	leaderboard, err := l.repo.Get(c.Request.Context(), leaderboardID)
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard")
+		return
+	}
+
+	standing, err := l.percentiles.OfUser(c.Request.Context(), leaderboard, userID)
+	if errors.Is(err, redis.ErrNotFound) {
+		problems.Render(c, problems.NotFound("User is not ranked on this leaderboard"))
+		return
+	}
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": standing,
+	})
+}
+
+// Returns where the score given in the query would stand in the ranking, whether or not anyone reached it
+func (l LeaderboardController) GetScorePercentile(c *gin.Context) {
+	leaderboardID := c.Param("id")
+	if leaderboardID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing leaderboard id"))
 		return
 	}
 
-	leaderboardEntries, err := l.repo.GetEntries(c.Request.Context(), leaderboardID)
+	score, err := strconv.ParseFloat(c.Query("score"), 64)
+	if err != nil || math.IsNaN(score) || math.IsInf(score, 0) {
+		problems.Render(c, problems.InvalidRequest("score must be a number"))
+		return
+	}
+
+	leaderboard, err := l.repo.Get(c.Request.Context(), leaderboardID)
 	if err != nil {
-		var errorMessage string
-		var statusCode int
-		switch err {
-		case storage.ErrNotFound:
-			statusCode = http.StatusNotFound
-			errorMessage = "Leaderboard not found"
-		default:
-			statusCode = http.StatusInternalServerError
-			errorMessage = "Something went wrong"
+		problems.RenderError(c, err, "Leaderboard")
+		return
+	}
+
+	standing, err := l.percentiles.OfScore(c.Request.Context(), leaderboard, score)
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": standing,
+	})
+}
+
+// Returns the distribution of the best scores on the leaderboard, with a histogram of bucket_width wide buckets
+// Distributions are cached for a short while, so designers polling the endpoint do not aggregate every score each time
+func (l LeaderboardController) GetStats(c *gin.Context) {
+	leaderboardID := c.Param("id")
+	if leaderboardID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing leaderboard id"))
+		return
+	}
+
+	// Without a bucket width the range of scores is split into the default number of buckets
+	var bucketWidth float64
+	if raw := c.Query("bucket_width"); raw != "" {
+		width, err := strconv.ParseFloat(raw, 64)
+		if err != nil || !(width > 0) || math.IsInf(width, 0) {
+			problems.Render(c, problems.InvalidRequest("bucket_width must be a positive number"))
+			return
 		}
-		c.JSON(statusCode, gin.H{
-			"error":   "Failed to get data",
-			"message": errorMessage,
+		bucketWidth = width
+	}
+
+	cacheKey := models.Leaderboard{
+		ID:       leaderboardID,
+		TenantID: models.TenantFromContext(c.Request.Context()),
+	}.DistributionKey(bucketWidth)
+	var cached models.ScoreDistribution
+	err := l.redis.Get(c.Request.Context(), cacheKey, &cached)
+	if err == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"data": cached,
 		})
 		return
 	}
+	if !errors.Is(err, redis.ErrNotFound) {
+		log.Printf("Failed to read cached score distribution: %v", err)
+	}
+
+	if _, err := l.repo.Get(c.Request.Context(), leaderboardID); err != nil {
+		problems.RenderError(c, err, "Leaderboard")
+		return
+	}
+
+	distribution, err := l.repo.GetScoreDistribution(c.Request.Context(), leaderboardID, bucketWidth)
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard")
+		return
+	}
+
+	if err := l.redis.Set(c.Request.Context(), cacheKey, distribution, models.DistributionCacheTTL); err != nil {
+		log.Printf("Failed to cache score distribution: %v", err)
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"data": leaderboardEntries,
+		"data": distribution,
 	})
 }
 
@@ -101,26 +406,55 @@ func (l LeaderboardController) Create(c *gin.Context) {
 
 	newLeaderboardRequest := models.LeaderboardRequest{}
 	if err := c.ShouldBindBodyWithJSON(&newLeaderboardRequest); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   http.StatusBadRequest,
-			"message": "Invalid request body",
-		})
+		problems.Render(c, problems.InvalidRequest("Invalid request body"))
+		return
+	}
+	if err := newLeaderboardRequest.ScoreRules.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	if err := newLeaderboardRequest.TeamRanking.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	if err := newLeaderboardRequest.TieBreakers.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	if err := newLeaderboardRequest.RankMode.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	if err := newLeaderboardRequest.Tiers.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	if err := newLeaderboardRequest.Metadata.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	if err := newLeaderboardRequest.MetadataSchema.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	if err := newLeaderboardRequest.Visibility.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	if err := newLeaderboardRequest.Tags.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	if err := newLeaderboardRequest.ValidateType(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
 		return
 	}
 
+	newLeaderboardRequest.CreatedBy = viewerID(c)
+
 	leaderboard, err := l.repo.Create(c.Request.Context(), &newLeaderboardRequest)
 	if err != nil {
-		var errorMessage string
-		switch err {
-		case storage.ErrConflict:
-			errorMessage = "Leaderboard already exists"
-		default:
-			errorMessage = "Something went wrong"
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   http.StatusInternalServerError,
-			"message": errorMessage,
-		})
+		problems.RenderError(c, err, "Leaderboard")
 		return
 	}
 
@@ -140,28 +474,39 @@ func (l LeaderboardController) CreateEntry(c *gin.Context) {
 	// Bind entry request with fields that can be provided upon creation
 	leaderboardEntryRequest := models.LeaderboardEntryRequest{}
 	if err := c.ShouldBindBodyWithJSON(&leaderboardEntryRequest); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   http.StatusBadRequest,
-			"message": "Invalid request body",
-		})
+		problems.Render(c, problems.InvalidRequest("Invalid request body"))
+		return
+	}
+	if err := leaderboardEntryRequest.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
 		return
 	}
 	leaderboardEntryRequest.AddUpdatedAt()
 
+	// Entries are submitted by the authenticated user, administrators can submit on behalf of others
+	userClaims, err := parseUserClaims(c)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+	if leaderboardEntryRequest.UserID == "" {
+		leaderboardEntryRequest.UserID = userClaims.UserID
+	}
+	if leaderboardEntryRequest.UserID != userClaims.UserID && userClaims.Role != "administrator" {
+		problems.RenderError(c, auth.ErrForbidden, "Leaderboard entry")
+		return
+	}
+
+	// Reject scores that break the leaderboard rules before they reach the ranking
+	if err := l.screenEntry(c.Request.Context(), &leaderboardEntryRequest); err != nil {
+		problems.RenderError(c, err, "Leaderboard")
+		return
+	}
+
 	// Create entry in the database
 	leaderboardEntry, err := l.repo.CreateEntry(c.Request.Context(), &leaderboardEntryRequest)
 	if err != nil {
-		var errorMessage string
-		switch err {
-		case storage.ErrConflict:
-			errorMessage = "Leaderboard entry already exists"
-		default:
-			errorMessage = "Something went wrong"
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   http.StatusInternalServerError,
-			"message": errorMessage,
-		})
+		problems.RenderError(c, err, "Leaderboard entry")
 		return
 	}
 
@@ -178,6 +523,35 @@ func (l LeaderboardController) CreateEntry(c *gin.Context) {
 			return // End cache update operation
 		}
 
+		// Only accepted entries are part of the public ranking, queued entries are added once verified
+		// Entries of shadowbanned users are stored but never ranked
+		// The sort key carries the tie-breakers, so a better tie-break at the same score also moves the user up
+		if models.IsRankedStatus(leaderboardEntry.Status) && !leaderboardEntryRequest.Hidden {
+			if err := l.redis.ZAddGT(
+				c.Request.Context(),
+				leaderboard.RankingKey(),
+				leaderboardEntry.User.ID,
+				leaderboardEntry.SortKey,
+			); err != nil {
+				log.Printf("Failed to update ranking: %v", err)
+			}
+
+			// The standing is read once the ranking holds the entry, a better previous score still counts above it
+			standing, err := l.percentiles.OfSortKey(c.Request.Context(), leaderboard, leaderboardEntry.SortKey)
+			if err != nil {
+				log.Printf("Failed to read standing of entry: %v", err)
+			} else {
+				leaderboardEntry.Standing = standing
+			}
+
+			// The team score is aggregated again from Postgres, a new best can change any aggregate
+			if leaderboard.TeamRanking != nil {
+				if err := l.teams.SyncUser(c.Request.Context(), leaderboardEntry.User.ID, leaderboard.ID); err != nil {
+					log.Printf("Failed to update team ranking: %v", err)
+				}
+			}
+		}
+
 		if leaderboard.Live {
 			// Update cache with new value
 			if err := l.updateCache(c.Request.Context(), leaderboard); err != nil {
@@ -186,34 +560,149 @@ func (l LeaderboardController) CreateEntry(c *gin.Context) {
 		}
 	}(c.Request.Context())
 
-	// Return success response
-	c.JSON(http.StatusCreated, gin.H{
+	// Wait until cache is updated, the response carries the standing of the entry in the updated ranking
+	wg.Wait()
+
+	// Flagged and pending entries are stored, but not ranked until they are reviewed
+	switch leaderboardEntry.Status {
+	case models.EntryStatusFlagged:
+		c.JSON(http.StatusAccepted, gin.H{
+			"data":    leaderboardEntry,
+			"message": "Leaderboard entry held for review",
+		})
+	case models.EntryStatusPending:
+		c.JSON(http.StatusAccepted, gin.H{
+			"data":    leaderboardEntry,
+			"message": "Leaderboard entry awaiting verification",
+		})
+	default:
+		c.JSON(http.StatusCreated, gin.H{
+			"data":    leaderboardEntry,
+			"message": "Leaderboard entry added",
+		})
+	}
+
+}
+
+// Replaces the proof and notes of an entry, administrators can also correct its score
+// The version read by the client is sent in the If-Match header, the update is rejected if the entry changed since
+func (l LeaderboardController) UpdateEntry(c *gin.Context) {
+	entryID := c.Param("id")
+	if entryID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing leaderboard entry id"))
+		return
+	}
+
+	version, ok := parseIfMatch(c)
+	if !ok {
+		problems.Render(c, problems.PreconditionRequired("The If-Match header must hold the entry version"))
+		return
+	}
+
+	update := models.UpdateEntryRequest{}
+	if err := c.ShouldBindBodyWithJSON(&update); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid request body"))
+		return
+	}
+	if err := update.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	update.ID = entryID
+	update.Version = version
+
+	actor, err := auditActor(c)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	// Players can only document their own entries, a new score would skip the screening
+	if actor.Role != "administrator" {
+		if update.Score != nil {
+			problems.RenderError(c, auth.ErrForbidden, "Leaderboard entry")
+			return
+		}
+		update.OwnerID = actor.UserID
+	}
+
+	leaderboardEntry, err := l.repo.UpdateEntry(c.Request.Context(), &update, actor)
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard entry")
+		return
+	}
+
+	// The corrected score can lower the best score of the user, so the ranking is rebuilt instead of raised
+	if update.Score != nil {
+		if err := l.rankings.SyncUser(c.Request.Context(), leaderboardEntry.User.ID); err != nil {
+			log.Printf("Failed to update ranking: %v", err)
+		}
+		if err := l.teams.SyncUser(c.Request.Context(), leaderboardEntry.User.ID, leaderboardEntry.LeaderboardID); err != nil {
+			log.Printf("Failed to update team ranking: %v", err)
+		}
+	}
+
+	c.Header("ETag", leaderboardEntry.ETag())
+	c.JSON(http.StatusOK, gin.H{
 		"data":    leaderboardEntry,
-		"message": "Leaderboard entry added",
+		"message": "Leaderboard entry updated",
 	})
-
-	// Wait until cache is updated to end operation
-	wg.Wait()
 }
 
 func (l LeaderboardController) Update(c *gin.Context) {
 
 	leaderboard := models.UpdateLeaderboardRequest{}
 	if err := c.ShouldBindBodyWithJSON(&leaderboard); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   http.StatusBadRequest,
-			"message": "Invalid request body",
-		})
+		problems.Render(c, problems.InvalidRequest("Invalid request body"))
+		return
+	}
+	if err := leaderboard.ScoreRules.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	if err := leaderboard.TeamRanking.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	if err := leaderboard.TieBreakers.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	if err := leaderboard.RankMode.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	if err := leaderboard.Tiers.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	if err := leaderboard.Metadata.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	if err := leaderboard.MetadataSchema.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	if err := leaderboard.Visibility.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	if err := leaderboard.Tags.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
 		return
 	}
 	leaderboard.AddUpdatedAt()
 
-	updatedLeaderboard, err := l.repo.Update(c.Request.Context(), &leaderboard)
+	actor, err := auditActor(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   http.StatusInternalServerError,
-			"message": "Something went wrong",
-		})
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	updatedLeaderboard, err := l.repo.Update(c.Request.Context(), &leaderboard, actor)
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard")
 		return
 	}
 
@@ -225,6 +714,19 @@ func (l LeaderboardController) Update(c *gin.Context) {
 		l.updateCache(c.Request.Context(), updatedLeaderboard)
 	}
 
+	// Postgres encodes the sort keys again when the tie-breakers change, including when they are cleared
+	if err := l.rankings.SyncLeaderboard(c.Request.Context(), updatedLeaderboard.ID); err != nil {
+		log.Printf("Failed to rebuild ranking: %v", err)
+	}
+
+	// The aggregate may have changed, so every team score is computed again
+	// A disabled team ranking is left behind in Redis, it is no longer served and is rebuilt if turned back on
+	if updatedLeaderboard.TeamRanking != nil {
+		if err := l.teams.SyncLeaderboard(c.Request.Context(), updatedLeaderboard.ID); err != nil {
+			log.Printf("Failed to rebuild team ranking: %v", err)
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"data":    updatedLeaderboard,
 		"message": "Leaderboard updated",
@@ -234,34 +736,159 @@ func (l LeaderboardController) Update(c *gin.Context) {
 func (l LeaderboardController) Delete(c *gin.Context) {
 	leaderboardID := c.Param("id")
 	if leaderboardID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "invalid request",
-			"message": "missing leaderboard id",
-		})
+		problems.Render(c, problems.InvalidRequest("Missing leaderboard id"))
 		return
 	}
 
-	if err := l.repo.Delete(c.Request.Context(), leaderboardID); err != nil {
-		var errorMessage string
-		var statusCode int
-		switch err {
-		case storage.ErrNotFound:
-			statusCode = http.StatusNotFound
-			errorMessage = "Leaderboard not found"
-		default:
-			statusCode = http.StatusInternalServerError
-			errorMessage = "Something went wrong"
-		}
-		c.JSON(statusCode, gin.H{
-			"error":   http.StatusInternalServerError,
-			"message": errorMessage,
-		})
+	actor, err := auditActor(c)
+	if err != nil {
+		problems.RenderError(c, err, "User")
 		return
 	}
 
+	if err := l.repo.Delete(c.Request.Context(), leaderboardID, actor); err != nil {
+		problems.RenderError(c, err, "Leaderboard")
+		return
+	}
+
+	// Deleted entries are no longer ranked, which leaves the ranking empty
+	if err := l.rankings.SyncLeaderboard(c.Request.Context(), leaderboardID); err != nil {
+		log.Printf("Failed to remove ranking of deleted leaderboard: %v", err)
+	}
+	if err := l.teams.SyncLeaderboard(c.Request.Context(), leaderboardID); err != nil {
+		log.Printf("Failed to remove team ranking of deleted leaderboard: %v", err)
+	}
+
 	c.JSON(http.StatusNoContent, nil)
 }
 
+// Brings back a soft deleted leaderboard with the entries deleted alongside it
+func (l LeaderboardController) Restore(c *gin.Context) {
+	leaderboardID := c.Param("id")
+	if leaderboardID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing leaderboard id"))
+		return
+	}
+
+	actor, err := auditActor(c)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	leaderboard, err := l.repo.Restore(c.Request.Context(), leaderboardID, actor)
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard")
+		return
+	}
+
+	if err := l.rankings.SyncLeaderboard(c.Request.Context(), leaderboardID); err != nil {
+		log.Printf("Failed to rebuild ranking of restored leaderboard: %v", err)
+	}
+	if leaderboard.TeamRanking != nil {
+		if err := l.teams.SyncLeaderboard(c.Request.Context(), leaderboardID); err != nil {
+			log.Printf("Failed to rebuild team ranking of restored leaderboard: %v", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    leaderboard,
+		"message": "Leaderboard restored",
+	})
+}
+
+// Checks the submitted score against the leaderboard rules and flags statistical anomalies
+// Flagged and pending entries are stored but held out of the public ranking until reviewed
+func (l LeaderboardController) screenEntry(ctx context.Context, entry *models.LeaderboardEntryRequest) error {
+	leaderboard, err := l.repo.Get(ctx, entry.LeaderboardID)
+	if err != nil {
+		return err
+	}
+	if leaderboard.Type == models.LeaderboardTypeRating {
+		return &models.ScoreRejection{Reason: "rating leaderboards are ranked by match results, not submitted scores"}
+	}
+
+	submissions, err := l.repo.GetUserSubmissions(ctx, entry.LeaderboardID, entry.UserID)
+	if err != nil {
+		return err
+	}
+
+	// Banned users are rejected, shadowbanned users go through as usual so they cannot tell
+	user := models.User{ID: entry.UserID, Ban: submissions.Ban}
+	if user.IsBanned(entry.UpdatedAt) {
+		return auth.ErrBanned
+	}
+	entry.Hidden = user.IsHidden(entry.UpdatedAt)
+
+	if err := leaderboard.ScoreRules.ValidateScore(entry.Score, *submissions, entry.UpdatedAt); err != nil {
+		return err
+	}
+	if err := leaderboard.TieBreakers.ValidateSecondary(entry.SecondaryScore); err != nil {
+		return err
+	}
+	if err := leaderboard.MetadataSchema.Check(entry.Metadata); err != nil {
+		return err
+	}
+	entry.Status = models.EntryStatusAccepted
+	if leaderboard.RequiresVerification {
+		entry.Status = models.EntryStatusPending
+	}
+
+	// The distribution is not trusted until the leaderboard has enough accepted scores
+	stats, err := l.repo.GetScoreStats(ctx, entry.LeaderboardID)
+	if err != nil {
+		return err
+	}
+	if stats.Count < models.AnomalyMinSamples {
+		return nil
+	}
+
+	percentiles := l.scorePercentiles(ctx, leaderboard, entry.Score, *submissions)
+	if reason := models.DetectAnomaly(entry.Score, *stats, *submissions, percentiles); reason != "" {
+		log.Printf("Flagging entry of user '%s' on leaderboard '%s': %s", entry.UserID, entry.LeaderboardID, reason)
+		entry.Status = models.EntryStatusFlagged
+		entry.FlagReason = reason
+	}
+
+	return nil
+}
+
+// Percentiles are read from the ranking, if it is unavailable they are reported as 0 and only the
+// distribution checks apply
+func (l LeaderboardController) scorePercentiles(
+	ctx context.Context,
+	leaderboard *models.Leaderboard,
+	score int,
+	previous models.UserSubmissions,
+) models.ScorePercentiles {
+	var percentiles models.ScorePercentiles
+
+	rankingKey := leaderboard.RankingKey()
+	total, err := l.redis.ZCard(ctx, rankingKey)
+	if err != nil || total == 0 {
+		if err != nil {
+			log.Printf("Failed to read ranking size: %v", err)
+		}
+		return percentiles
+	}
+
+	percentileOf := func(score int) float64 {
+		below, err := l.redis.ZCount(ctx, rankingKey, "-inf", fmt.Sprintf("(%d", score))
+		if err != nil {
+			log.Printf("Failed to count ranking members below %d: %v", score, err)
+			return 0
+		}
+		return float64(below) / float64(total) * 100
+	}
+
+	percentiles.Score = percentileOf(score)
+	if previous.Count > 0 {
+		percentiles.PreviousBest = percentileOf(previous.BestScore)
+	}
+
+	return percentiles
+}
+
 func (l LeaderboardController) updateCache(ctx context.Context, leaderboard *models.Leaderboard) error {
 	if err := l.redis.Set(
 		ctx,
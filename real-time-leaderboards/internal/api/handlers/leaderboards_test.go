@@ -1,14 +1,18 @@
 package handlers
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
+	"slices"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
 	"github.com/mochivi/go-real-time-leaderboards/internal/mocks"
 	"github.com/mochivi/go-real-time-leaderboards/internal/models"
 	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	cache "github.com/mochivi/go-real-time-leaderboards/internal/storage/redis"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -66,7 +70,7 @@ func TestLeaderboardsGet(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 
 			// Recreate controller with new mock on every testcase
-			uc := NewLeaderboardController(testCase.mockRepo, &mocks.MockRedisService{})
+			uc := NewLeaderboardController(testCase.mockRepo, &mocks.MockFriendRepo{}, &mocks.MockTeamRepo{}, &mocks.MockUserRepo{}, &mocks.MockRedisService{})
 
 			// Execute request and received recorded and decoded response
 			w := executeRequest(
@@ -83,18 +87,101 @@ func TestLeaderboardsGet(t *testing.T) {
 	}
 }
 
+func TestLeaderboardsList(t *testing.T) {
+
+	live := true
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockLeaderboardsRepo
+		query          map[string]string
+		expectedStatus int
+	}{
+		{
+			name: "newest leaderboards",
+			mockRepo: setupLeaderboardRepoMock(
+				"List",
+				[]any{&models.LeaderboardListFilter{
+					Sort:       models.LeaderboardSortNewest,
+					ViewerID:   "2",
+					Pagination: models.Pagination{Limit: models.DefaultPageLimit},
+				}},
+				[]any{[]models.LeaderboardListing{{ID: "1", Name: "Any%"}}, nil},
+			),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "search live leaderboards by tag",
+			mockRepo: setupLeaderboardRepoMock(
+				"List",
+				[]any{&models.LeaderboardListFilter{
+					Live:       &live,
+					Tag:        "pc",
+					Query:      "speedrun",
+					Sort:       models.LeaderboardSortRelevance,
+					ViewerID:   "2",
+					Pagination: models.Pagination{Limit: 10},
+				}},
+				[]any{[]models.LeaderboardListing{}, nil},
+			),
+			query:          map[string]string{"live": "true", "tag": " PC ", "q": " speedrun ", "limit": "10"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown sort",
+			mockRepo:       &mocks.MockLeaderboardsRepo{},
+			query:          map[string]string{"sort": "oldest"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "relevance without search",
+			mockRepo:       &mocks.MockLeaderboardsRepo{},
+			query:          map[string]string{"sort": models.LeaderboardSortRelevance},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			uc := NewLeaderboardController(testCase.mockRepo, &mocks.MockFriendRepo{}, &mocks.MockTeamRepo{}, &mocks.MockUserRepo{}, &mocks.MockRedisService{})
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAuthMiddleware(&auth.CustomClaims{UserID: "2", Role: "visitor"}),
+					uc.List,
+				},
+				requestOpts{query: testCase.query},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
 func TestLeaderboardsGetEntries(t *testing.T) {
 
 	// Setup test cases
 	testCases := []struct {
 		name           string
 		mockRepo       *mocks.MockLeaderboardsRepo
+		viewer         *auth.CustomClaims // Signed in user, nil for anonymous requests
 		expectedStatus int
 		requestOpts    requestOpts
 	}{
 		{
 			name:           "get leaderboard entry",
-			mockRepo:       setupLeaderboardRepoMock("GetEntries", []any{"1"}, []any{[]models.LeaderboardEntry{{ID: "1"}}, nil}),
+			mockRepo:       setupLeaderboardRepoMock("GetEntries", []any{"1", ""}, []any{[]models.LeaderboardEntry{{ID: "1"}}, nil}),
+			expectedStatus: http.StatusOK,
+			requestOpts: requestOpts{
+				params: map[string]string{
+					"id": "1",
+				},
+			},
+		},
+		{
+			name:           "get leaderboard entry signed in",
+			mockRepo:       setupLeaderboardRepoMock("GetEntries", []any{"1", "2"}, []any{[]models.LeaderboardEntry{{ID: "1"}}, nil}),
+			viewer:         &auth.CustomClaims{UserID: "2", Role: "visitor"},
 			expectedStatus: http.StatusOK,
 			requestOpts: requestOpts{
 				params: map[string]string{
@@ -113,7 +200,7 @@ func TestLeaderboardsGetEntries(t *testing.T) {
 		},
 		{
 			name:           "get leaderboard entry db error",
-			mockRepo:       setupLeaderboardRepoMock("GetEntries", []any{"1"}, []any{[]models.LeaderboardEntry{}, ErrRepoOperation}),
+			mockRepo:       setupLeaderboardRepoMock("GetEntries", []any{"1", ""}, []any{[]models.LeaderboardEntry{}, ErrRepoOperation}),
 			expectedStatus: http.StatusInternalServerError,
 			requestOpts: requestOpts{
 				params: map[string]string{
@@ -123,7 +210,7 @@ func TestLeaderboardsGetEntries(t *testing.T) {
 		},
 		{
 			name:           "get leaderboard entry db not found",
-			mockRepo:       setupLeaderboardRepoMock("GetEntries", []any{"1"}, []any{[]models.LeaderboardEntry{}, storage.ErrNotFound}),
+			mockRepo:       setupLeaderboardRepoMock("GetEntries", []any{"1", ""}, []any{[]models.LeaderboardEntry{}, storage.ErrNotFound}),
 			expectedStatus: http.StatusNotFound,
 			requestOpts: requestOpts{
 				params: map[string]string{
@@ -137,13 +224,15 @@ func TestLeaderboardsGetEntries(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 
 			// Recreate controller with new mock on every testcase
-			uc := NewLeaderboardController(testCase.mockRepo, &mocks.MockRedisService{})
+			uc := NewLeaderboardController(testCase.mockRepo, &mocks.MockFriendRepo{}, &mocks.MockTeamRepo{}, &mocks.MockUserRepo{}, &mocks.MockRedisService{})
+
+			testHandlers := []gin.HandlerFunc{uc.GetEntries}
+			if testCase.viewer != nil {
+				testHandlers = append([]gin.HandlerFunc{mocks.MockValidateAuthMiddleware(testCase.viewer)}, testHandlers...)
+			}
 
 			// Execute request and received recorded and decoded response
-			w := executeRequest(
-				[]gin.HandlerFunc{uc.GetEntries},
-				testCase.requestOpts,
-			)
+			w := executeRequest(testHandlers, testCase.requestOpts)
 
 			// Assert expectations
 			assert.Equal(t, testCase.expectedStatus, w.Code)
@@ -206,11 +295,52 @@ func TestLeaderboardsCreate(t *testing.T) {
 				[]any{mock.Anything, mock.Anything, mock.Anything, mock.Anything},
 				[]any{nil},
 			),
-			expectedStatus: http.StatusInternalServerError,
+			expectedStatus: http.StatusConflict,
 			requestOpts: requestOpts{
 				body: models.LeaderboardRequest{Name: "test-leaderboard"},
 			},
 		},
+		{
+			name:           "invalid tag",
+			mockRepo:       &mocks.MockLeaderboardsRepo{},
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusBadRequest,
+			requestOpts: requestOpts{
+				body: models.LeaderboardRequest{Name: "test-leaderboard", Tags: models.Tags{"speed run"}},
+			},
+		},
+		{
+			name:           "rating leaderboard without rating system",
+			mockRepo:       &mocks.MockLeaderboardsRepo{},
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusBadRequest,
+			requestOpts: requestOpts{
+				body: models.LeaderboardRequest{Name: "test-leaderboard", Type: models.LeaderboardTypeRating},
+			},
+		},
+		{
+			name:           "rating leaderboard with tie-breakers",
+			mockRepo:       &mocks.MockLeaderboardsRepo{},
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusBadRequest,
+			requestOpts: requestOpts{
+				body: models.LeaderboardRequest{
+					Name:         "test-leaderboard",
+					Type:         models.LeaderboardTypeRating,
+					RatingSystem: models.RatingSystemElo,
+					TieBreakers:  models.TieBreakers{models.TieBreakerEarliestSubmission},
+				},
+			},
+		},
+		{
+			name:           "unknown tie-breaker",
+			mockRepo:       &mocks.MockLeaderboardsRepo{},
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusBadRequest,
+			requestOpts: requestOpts{
+				body: models.LeaderboardRequest{Name: "test-leaderboard", TieBreakers: models.TieBreakers{"longest_streak"}},
+			},
+		},
 		{
 			name: "create leaderboard cache error",
 			mockRepo: setupLeaderboardRepoMock(
@@ -232,7 +362,7 @@ func TestLeaderboardsCreate(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 
 			// Recreate controller with new mock on every testcase
-			uc := NewLeaderboardController(testCase.mockRepo, testCase.mockCache)
+			uc := NewLeaderboardController(testCase.mockRepo, &mocks.MockFriendRepo{}, &mocks.MockTeamRepo{}, &mocks.MockUserRepo{}, testCase.mockCache)
 
 			// Execute request and received recorded and decoded response
 			w := executeRequest(
@@ -256,78 +386,174 @@ func TestLeaderboardsCreateEntry(t *testing.T) {
 		UserID:        "1",
 		Score:         10,
 	}
+	maxScore := 5
+	secondaryOverflow := 1500 // Milliseconds do not fit the 7 bits of the third tie-breaker
 
 	// Create the leaderboard mock repo for each testCase
-	createEntryLeaderboardMock := mocks.MockLeaderboardsRepo{}
-	createEntryLeaderboardMock.On(
+	// The entry must be submitted for the expected user
+	createEntryLeaderboardMock := func(userID string) *mocks.MockLeaderboardsRepo {
+		mockRepo := setupEntryValidationMock(&models.Leaderboard{Live: true, ID: "1"}, &models.UserSubmissions{}, &models.ScoreStats{})
+		mockRepo.On(
+			"CreateEntry",
+			mock.MatchedBy(func(entry *models.LeaderboardEntryRequest) bool {
+				return entry.UserID == userID
+			}),
+		).Return(&models.LeaderboardEntry{ID: "1", Status: models.EntryStatusAccepted}, nil).Once()
+		return mockRepo
+	}
+
+	dbErrorLeaderboardMock := setupEntryValidationMock(&models.Leaderboard{ID: "1"}, &models.UserSubmissions{}, &models.ScoreStats{})
+	dbErrorLeaderboardMock.On(
+		"CreateEntry",
+		mock.AnythingOfType("*models.LeaderboardEntryRequest"),
+	).Return(&models.LeaderboardEntry{ID: "1"}, errors.New("db error")).Once()
+
+	conflictLeaderboardMock := setupEntryValidationMock(&models.Leaderboard{ID: "1"}, &models.UserSubmissions{}, &models.ScoreStats{})
+	conflictLeaderboardMock.On(
 		"CreateEntry",
 		mock.AnythingOfType("*models.LeaderboardEntryRequest"),
-	).Return(&models.LeaderboardEntry{ID: "1"}, nil).Once()
-	createEntryLeaderboardMock.On(
-		"Get",
-		mock.AnythingOfType("string"),
-	).Return(&models.Leaderboard{Live: true, ID: "1"}, nil).Once()
+	).Return(&models.LeaderboardEntry{}, storage.ErrConflict).Once()
 
-	cacheErrorLeaderboardMock := mocks.MockLeaderboardsRepo{}
+	cacheErrorLeaderboardMock := setupEntryValidationMock(&models.Leaderboard{Live: true, ID: "1"}, &models.UserSubmissions{}, &models.ScoreStats{})
 	cacheErrorLeaderboardMock.On(
 		"CreateEntry",
 		mock.AnythingOfType("*models.LeaderboardEntryRequest"),
 	).Return(&models.LeaderboardEntry{}, nil).Once()
-	cacheErrorLeaderboardMock.On(
-		"Get",
-		mock.AnythingOfType("string"),
-	).Return(&models.Leaderboard{Live: true, ID: "1"}, nil).Once()
+
+	// Distribution with a mean of 100 and a standard deviation of 10
+	outlierStats := &models.ScoreStats{Count: 100, Mean: 100, M2: 99 * 100}
+	flaggedLeaderboardMock := setupEntryValidationMock(&models.Leaderboard{ID: "1"}, &models.UserSubmissions{}, outlierStats)
+	flaggedLeaderboardMock.On(
+		"CreateEntry",
+		mock.MatchedBy(func(entry *models.LeaderboardEntryRequest) bool {
+			return entry.Status == models.EntryStatusFlagged
+		}),
+	).Return(&models.LeaderboardEntry{ID: "1", Status: models.EntryStatusFlagged}, nil).Once()
+	flaggedCacheMock := setupRedisServiceMock("ZCard", []any{mock.Anything}, []any{int64(100), nil})
+	flaggedCacheMock.On("ZCount", mock.Anything, mock.Anything, mock.Anything).Return(int64(100), nil)
+
+	// Shadowbanned entries are stored as usual, but never reach the ranking
+	shadowban := &models.Ban{Type: models.BanTypeShadowban}
+	shadowbannedLeaderboardMock := setupEntryValidationMock(&models.Leaderboard{ID: "1"}, &models.UserSubmissions{Ban: shadowban}, &models.ScoreStats{})
+	shadowbannedLeaderboardMock.On(
+		"CreateEntry",
+		mock.MatchedBy(func(entry *models.LeaderboardEntryRequest) bool {
+			return entry.Hidden && entry.Status == models.EntryStatusAccepted
+		}),
+	).Return(&models.LeaderboardEntry{ID: "1", Status: models.EntryStatusAccepted}, nil).Once()
+
+	pendingLeaderboardMock := setupEntryValidationMock(&models.Leaderboard{ID: "1", RequiresVerification: true}, &models.UserSubmissions{}, &models.ScoreStats{})
+	pendingLeaderboardMock.On(
+		"CreateEntry",
+		mock.MatchedBy(func(entry *models.LeaderboardEntryRequest) bool {
+			return entry.Status == models.EntryStatusPending
+		}),
+	).Return(&models.LeaderboardEntry{ID: "1", Status: models.EntryStatusPending}, nil).Once()
 
 	// Setup test cases
 	testCases := []struct {
 		name           string
 		mockRepo       *mocks.MockLeaderboardsRepo
 		mockCache      *mocks.MockRedisService
+		role           string // Role of the user making the request, user ID is always "1"
 		expectedStatus int
 		requestOpts    requestOpts
 	}{
 		{
-			name:     "create leaderboard entry",
-			mockRepo: &createEntryLeaderboardMock,
-			mockCache: setupRedisServiceMock(
-				"Set",
-				[]any{mock.Anything, mock.Anything, mock.Anything, mock.Anything},
-				[]any{nil},
-			),
+			name:           "create leaderboard entry",
+			mockRepo:       createEntryLeaderboardMock("1"),
+			mockCache:      setupRankingCacheMock(nil),
 			expectedStatus: http.StatusCreated,
 			requestOpts:    requestOpts{body: exampleEntry},
 		},
 		{
-			name: "create leaderboard entry db error",
-			mockRepo: setupLeaderboardRepoMock(
-				"CreateEntry",
-				[]any{mock.AnythingOfType("*models.LeaderboardEntryRequest")},
-				[]any{&models.LeaderboardEntry{ID: "1"}, errors.New("db error")}),
-			mockCache: setupRedisServiceMock(
-				"Set",
-				[]any{mock.Anything, mock.Anything, mock.Anything, mock.Anything},
-				[]any{nil},
+			name:           "create leaderboard entry flagged as outlier",
+			mockRepo:       flaggedLeaderboardMock,
+			mockCache:      flaggedCacheMock,
+			expectedStatus: http.StatusAccepted,
+			requestOpts: requestOpts{body: models.LeaderboardEntryRequest{
+				LeaderboardID: "1",
+				UserID:        "1",
+				Score:         1000,
+			}},
+		},
+		{
+			name:           "create leaderboard entry for the signed in user",
+			mockRepo:       createEntryLeaderboardMock("1"),
+			mockCache:      setupRankingCacheMock(nil),
+			expectedStatus: http.StatusCreated,
+			requestOpts:    requestOpts{body: models.LeaderboardEntryRequest{LeaderboardID: "1", Score: 10}},
+		},
+		{
+			name:           "create leaderboard entry for another user",
+			expectedStatus: http.StatusForbidden,
+			requestOpts:    requestOpts{body: models.LeaderboardEntryRequest{LeaderboardID: "1", UserID: "2", Score: 10}},
+		},
+		{
+			name:           "admin create leaderboard entry for another user",
+			mockRepo:       createEntryLeaderboardMock("2"),
+			mockCache:      setupRankingCacheMock(nil),
+			role:           "administrator",
+			expectedStatus: http.StatusCreated,
+			requestOpts:    requestOpts{body: models.LeaderboardEntryRequest{LeaderboardID: "1", UserID: "2", Score: 10}},
+		},
+		{
+			name: "create leaderboard entry banned user",
+			mockRepo: setupEntryValidationMock(
+				&models.Leaderboard{ID: "1"},
+				&models.UserSubmissions{Ban: &models.Ban{Type: models.BanTypeBan}},
+				&models.ScoreStats{},
 			),
-			expectedStatus: http.StatusInternalServerError,
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusForbidden,
 			requestOpts:    requestOpts{body: exampleEntry},
 		},
 		{
-			name: "create leaderboard entry db conflict",
-			mockRepo: setupLeaderboardRepoMock(
-				"CreateEntry",
-				[]any{mock.AnythingOfType("*models.LeaderboardEntryRequest")},
-				[]any{&models.LeaderboardEntry{}, storage.ErrConflict}),
-			mockCache: setupRedisServiceMock(
-				"Set",
-				[]any{mock.Anything, mock.Anything, mock.Anything, mock.Anything},
-				[]any{nil},
-			),
+			name:           "create leaderboard entry shadowbanned user",
+			mockRepo:       shadowbannedLeaderboardMock,
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusCreated,
+			requestOpts:    requestOpts{body: exampleEntry},
+		},
+		{
+			name:           "create leaderboard entry awaiting verification",
+			mockRepo:       pendingLeaderboardMock,
+			mockCache:      &mocks.MockRedisService{}, // Pending entries never reach the ranking
+			expectedStatus: http.StatusAccepted,
+			requestOpts: requestOpts{body: models.LeaderboardEntryRequest{
+				LeaderboardID: "1",
+				UserID:        "1",
+				Score:         10,
+				ProofURL:      "https://videos.example.com/run/1",
+			}},
+		},
+		{
+			name:           "create leaderboard entry invalid proof url",
+			expectedStatus: http.StatusBadRequest,
+			requestOpts: requestOpts{body: models.LeaderboardEntryRequest{
+				LeaderboardID: "1",
+				UserID:        "1",
+				Score:         10,
+				ProofURL:      "javascript:alert(1)",
+			}},
+		},
+		{
+			name:           "create leaderboard entry db error",
+			mockRepo:       dbErrorLeaderboardMock,
+			mockCache:      &mocks.MockRedisService{},
 			expectedStatus: http.StatusInternalServerError,
 			requestOpts:    requestOpts{body: exampleEntry},
 		},
+		{
+			name:           "create leaderboard entry db conflict",
+			mockRepo:       conflictLeaderboardMock,
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusConflict,
+			requestOpts:    requestOpts{body: exampleEntry},
+		},
 		{
 			name:     "create leaderboard entry cache error",
-			mockRepo: &cacheErrorLeaderboardMock,
+			mockRepo: cacheErrorLeaderboardMock,
 			mockCache: setupRedisServiceMock(
 				"Set",
 				[]any{mock.Anything, mock.Anything, mock.Anything, mock.Anything},
@@ -337,17 +563,73 @@ func TestLeaderboardsCreateEntry(t *testing.T) {
 				body: models.LeaderboardRequest{Name: "test-leaderboard"},
 			},
 		},
+		{
+			name: "create leaderboard entry score rejected",
+			mockRepo: setupEntryValidationMock(
+				&models.Leaderboard{ID: "1", ScoreRules: models.ScoreRules{MaxScore: &maxScore}},
+				&models.UserSubmissions{},
+				&models.ScoreStats{},
+			),
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusUnprocessableEntity,
+			requestOpts:    requestOpts{body: exampleEntry},
+		},
+		{
+			name: "create leaderboard entry metadata rejected",
+			mockRepo: setupEntryValidationMock(
+				&models.Leaderboard{ID: "1", MetadataSchema: &models.MetadataSchema{Required: []string{"character"}}},
+				&models.UserSubmissions{},
+				&models.ScoreStats{},
+			),
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusUnprocessableEntity,
+			requestOpts:    requestOpts{body: exampleEntry},
+		},
+		{
+			name: "create leaderboard entry secondary score out of range",
+			mockRepo: setupEntryValidationMock(
+				&models.Leaderboard{
+					ID:          "1",
+					TieBreakers: models.TieBreakers{models.TieBreakerFewestAttempts, models.TieBreakerEarliestSubmission, models.TieBreakerHighestSecondary},
+				},
+				&models.UserSubmissions{},
+				&models.ScoreStats{},
+			),
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusUnprocessableEntity,
+			requestOpts: requestOpts{body: models.LeaderboardEntryRequest{
+				LeaderboardID:  "1",
+				UserID:         "1",
+				Score:          10,
+				SecondaryScore: &secondaryOverflow,
+			}},
+		},
+		{
+			name:           "create leaderboard entry leaderboard not found",
+			mockRepo:       setupLeaderboardRepoMock("Get", []any{"1"}, []any{&models.Leaderboard{}, storage.ErrNotFound}),
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusNotFound,
+			requestOpts:    requestOpts{body: exampleEntry},
+		},
 	}
 
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
 
 			// Recreate controller with new mock on every testcase
-			uc := NewLeaderboardController(testCase.mockRepo, testCase.mockCache)
+			uc := NewLeaderboardController(testCase.mockRepo, &mocks.MockFriendRepo{}, &mocks.MockTeamRepo{}, &mocks.MockUserRepo{}, testCase.mockCache)
+
+			role := testCase.role
+			if role == "" {
+				role = "visitor"
+			}
 
 			// Execute request and received recorded and decoded response
 			w := executeRequest(
-				[]gin.HandlerFunc{uc.CreateEntry},
+				[]gin.HandlerFunc{
+					mocks.MockValidateAuthMiddleware(&auth.CustomClaims{UserID: "1", Role: role}),
+					uc.CreateEntry,
+				},
 				testCase.requestOpts,
 			)
 
@@ -360,6 +642,151 @@ func TestLeaderboardsCreateEntry(t *testing.T) {
 	}
 }
 
+func TestLeaderboardsUpdateEntry(t *testing.T) {
+
+	newScore := 900
+	updatedEntry := &models.LeaderboardEntry{ID: "10", LeaderboardID: "1", User: models.User{ID: "2"}, Score: 900, Version: 4}
+	ifMatch := map[string]string{"If-Match": `"3"`}
+
+	testCases := []struct {
+		name           string
+		claims         *auth.CustomClaims
+		mockRepo       *mocks.MockLeaderboardsRepo
+		mockCache      *mocks.MockRedisService
+		expectedStatus int
+		requestOpts    requestOpts
+	}{
+		{
+			name:   "owner updates proof",
+			claims: &auth.CustomClaims{UserID: "2", Role: "visitor"},
+			mockRepo: setupLeaderboardRepoMock(
+				"UpdateEntry",
+				[]any{
+					&models.UpdateEntryRequest{ID: "10", OwnerID: "2", Version: 3, ProofURL: "https://videos.example.com/run"},
+					mock.AnythingOfType("*models.AuditActor"),
+				},
+				[]any{updatedEntry, nil},
+			),
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusOK,
+			requestOpts: requestOpts{
+				headers: ifMatch,
+				params:  map[string]string{"id": "10"},
+				body:    models.UpdateEntryRequest{ProofURL: "https://videos.example.com/run"},
+			},
+		},
+		{
+			// A lowered score must replace the ranked score, not only raise it
+			name:   "administrator corrects score",
+			claims: &auth.CustomClaims{UserID: "1", Role: "administrator"},
+			mockRepo: func() *mocks.MockLeaderboardsRepo {
+				mockRepo := setupLeaderboardRepoMock(
+					"UpdateEntry",
+					[]any{&models.UpdateEntryRequest{ID: "10", Version: 3, Score: &newScore}, mock.Anything},
+					[]any{updatedEntry, nil},
+				)
+				mockRepo.On("GetRankedScores", "2").Return([]models.RankedScore{{LeaderboardID: "1", Score: 900}}, nil)
+				return mockRepo
+			}(),
+			mockCache:      setupRedisServiceMock("ZAdd", []any{"leaderboard:1:ranking", "2", float64(900)}, []any{nil}),
+			expectedStatus: http.StatusOK,
+			requestOpts: requestOpts{
+				headers: map[string]string{"If-Match": `W/"3"`},
+				params:  map[string]string{"id": "10"},
+				body:    models.UpdateEntryRequest{Score: &newScore},
+			},
+		},
+		{
+			name:           "owner changes score",
+			claims:         &auth.CustomClaims{UserID: "2", Role: "visitor"},
+			mockRepo:       &mocks.MockLeaderboardsRepo{},
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusForbidden,
+			requestOpts: requestOpts{
+				headers: ifMatch,
+				params:  map[string]string{"id": "10"},
+				body:    models.UpdateEntryRequest{Score: &newScore},
+			},
+		},
+		{
+			name:           "update entry without If-Match",
+			claims:         &auth.CustomClaims{UserID: "2", Role: "visitor"},
+			mockRepo:       &mocks.MockLeaderboardsRepo{},
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusPreconditionRequired,
+			requestOpts: requestOpts{
+				params: map[string]string{"id": "10"},
+				body:   models.UpdateEntryRequest{Notes: "Any% run"},
+			},
+		},
+		{
+			name:           "update entry with wildcard If-Match",
+			claims:         &auth.CustomClaims{UserID: "2", Role: "visitor"},
+			mockRepo:       &mocks.MockLeaderboardsRepo{},
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusPreconditionRequired,
+			requestOpts: requestOpts{
+				headers: map[string]string{"If-Match": "*"},
+				params:  map[string]string{"id": "10"},
+				body:    models.UpdateEntryRequest{Notes: "Any% run"},
+			},
+		},
+		{
+			name:   "update entry modified since read",
+			claims: &auth.CustomClaims{UserID: "2", Role: "visitor"},
+			mockRepo: setupLeaderboardRepoMock(
+				"UpdateEntry",
+				[]any{mock.Anything, mock.Anything},
+				[]any{&models.LeaderboardEntry{}, storage.ErrVersionConflict},
+			),
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusConflict,
+			requestOpts: requestOpts{
+				headers: ifMatch,
+				params:  map[string]string{"id": "10"},
+				body:    models.UpdateEntryRequest{Notes: "Any% run"},
+			},
+		},
+		{
+			name:   "update entry of another user",
+			claims: &auth.CustomClaims{UserID: "3", Role: "visitor"},
+			mockRepo: setupLeaderboardRepoMock(
+				"UpdateEntry",
+				[]any{mock.Anything, mock.Anything},
+				[]any{&models.LeaderboardEntry{}, storage.ErrNotFound},
+			),
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusNotFound,
+			requestOpts: requestOpts{
+				headers: ifMatch,
+				params:  map[string]string{"id": "10"},
+				body:    models.UpdateEntryRequest{Notes: "Any% run"},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			lc := NewLeaderboardController(testCase.mockRepo, &mocks.MockFriendRepo{}, setupNoTeamsMock(), &mocks.MockUserRepo{}, testCase.mockCache)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAuthMiddleware(testCase.claims),
+					lc.UpdateEntry,
+				},
+				testCase.requestOpts,
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			if w.Code == http.StatusOK {
+				assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+			}
+			testCase.mockRepo.AssertExpectations(t)
+			testCase.mockCache.AssertExpectations(t)
+		})
+	}
+}
+
 func TestLeaderboardsUpdate(t *testing.T) {
 
 	exampleLeaderboardUpdate := models.UpdateLeaderboardRequest{
@@ -367,6 +794,25 @@ func TestLeaderboardsUpdate(t *testing.T) {
 		Name: "test-updated-name",
 	}
 
+	// Every update rebuilds the ranking, since the tie-breakers may have changed
+	rebuiltRanking := map[string]float64{"2": 1200.5}
+	rebuiltRepo := func(updated *models.Leaderboard, request *models.UpdateLeaderboardRequest) *mocks.MockLeaderboardsRepo {
+		var requestArg any = mock.AnythingOfType("*models.UpdateLeaderboardRequest")
+		if request != nil {
+			requestArg = mock.MatchedBy(func(r *models.UpdateLeaderboardRequest) bool {
+				return r.ID == request.ID && slices.Equal(r.TieBreakers, request.TieBreakers)
+			})
+		}
+		mockRepo := setupLeaderboardRepoMock(
+			"Update",
+			[]any{requestArg, mock.AnythingOfType("*models.AuditActor")},
+			[]any{updated, nil},
+		)
+		mockRepo.On("GetLeaderboardScores", "1").
+			Return([]models.RankedScore{{LeaderboardID: "1", UserID: "2", Score: 1200.5}}, nil)
+		return mockRepo
+	}
+
 	// Setup test cases
 	testCases := []struct {
 		name           string
@@ -376,42 +822,65 @@ func TestLeaderboardsUpdate(t *testing.T) {
 		requestOpts    requestOpts
 	}{
 		{
-			name: "update leaderboard",
-			mockRepo: setupLeaderboardRepoMock(
-				"Update",
-				[]any{mock.AnythingOfType("*models.UpdateLeaderboardRequest")},
-				[]any{&models.Leaderboard{ID: "1"}, nil},
-			),
-			mockCache: setupRedisServiceMock(
-				"Set",
-				[]any{mock.Anything, mock.Anything, mock.Anything, mock.Anything},
-				[]any{nil},
-			),
+			name:           "update leaderboard",
+			mockRepo:       rebuiltRepo(&models.Leaderboard{ID: "1"}, nil),
+			mockCache:      setupRedisServiceMock("ZReplace", []any{"leaderboard:1:ranking", rebuiltRanking}, []any{nil}),
 			expectedStatus: http.StatusOK,
 			requestOpts:    requestOpts{body: exampleLeaderboardUpdate},
 		},
+		{
+			// The ranking is rebuilt from the sort keys encoded with the new tie-breakers
+			name: "update tie-breakers",
+			mockRepo: rebuiltRepo(
+				&models.Leaderboard{ID: "1", TieBreakers: models.TieBreakers{models.TieBreakerFewestAttempts}},
+				&models.UpdateLeaderboardRequest{
+					ID:          "1",
+					Name:        "test-updated-name",
+					TieBreakers: models.TieBreakers{models.TieBreakerFewestAttempts},
+				},
+			),
+			mockCache:      setupRedisServiceMock("ZReplace", []any{"leaderboard:1:ranking", rebuiltRanking}, []any{nil}),
+			expectedStatus: http.StatusOK,
+			requestOpts: requestOpts{body: models.UpdateLeaderboardRequest{
+				ID:          "1",
+				Name:        "test-updated-name",
+				TieBreakers: models.TieBreakers{models.TieBreakerFewestAttempts},
+			}},
+		},
+		{
+			name:           "update with conflicting tie-breakers",
+			mockRepo:       &mocks.MockLeaderboardsRepo{},
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusBadRequest,
+			requestOpts: requestOpts{body: models.UpdateLeaderboardRequest{
+				ID:          "1",
+				Name:        "test-updated-name",
+				TieBreakers: models.TieBreakers{models.TieBreakerHighestSecondary, models.TieBreakerLowestSecondary},
+			}},
+		},
 		{
 			name: "update leaderboard db error",
 			mockRepo: setupLeaderboardRepoMock(
 				"Update",
-				[]any{mock.AnythingOfType("*models.UpdateLeaderboardRequest")},
+				[]any{mock.AnythingOfType("*models.UpdateLeaderboardRequest"), mock.AnythingOfType("*models.AuditActor")},
 				[]any{&models.Leaderboard{ID: "1"}, errors.New("db error")},
 			),
+			mockCache:      &mocks.MockRedisService{},
 			expectedStatus: http.StatusInternalServerError,
 			requestOpts:    requestOpts{body: exampleLeaderboardUpdate},
 		},
 		{
-			name: "update leaderboard cache error",
-			mockRepo: setupLeaderboardRepoMock(
-				"Update",
-				[]any{mock.AnythingOfType("*models.UpdateLeaderboardRequest")},
-				[]any{&models.Leaderboard{ID: "1"}, nil},
-			),
-			mockCache: setupRedisServiceMock(
-				"Set",
-				[]any{mock.Anything, mock.Anything, mock.Anything, mock.Anything},
-				[]any{errors.New("cache error")},
-			),
+			name:     "update leaderboard cache error",
+			mockRepo: rebuiltRepo(&models.Leaderboard{ID: "1", Live: true}, nil),
+			mockCache: func() *mocks.MockRedisService {
+				mockCache := setupRedisServiceMock(
+					"Set",
+					[]any{mock.Anything, mock.Anything, mock.Anything, mock.Anything},
+					[]any{errors.New("cache error")},
+				)
+				mockCache.On("ZReplace", "leaderboard:1:ranking", rebuiltRanking).Return(nil)
+				return mockCache
+			}(),
 			expectedStatus: http.StatusOK,
 			requestOpts:    requestOpts{body: exampleLeaderboardUpdate},
 		},
@@ -421,11 +890,14 @@ func TestLeaderboardsUpdate(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 
 			// Recreate controller with new mock on every testcase
-			uc := NewLeaderboardController(testCase.mockRepo, &mocks.MockRedisService{})
+			uc := NewLeaderboardController(testCase.mockRepo, &mocks.MockFriendRepo{}, &mocks.MockTeamRepo{}, &mocks.MockUserRepo{}, testCase.mockCache)
 
 			// Execute request and received recorded and decoded response
 			w := executeRequest(
-				[]gin.HandlerFunc{uc.Update},
+				[]gin.HandlerFunc{
+					mocks.MockValidateAdminMiddleware(&auth.CustomClaims{UserID: "1", Role: "administrator"}),
+					uc.Update,
+				},
 				testCase.requestOpts,
 			)
 
@@ -434,21 +906,30 @@ func TestLeaderboardsUpdate(t *testing.T) {
 			if testCase.mockRepo != nil {
 				testCase.mockRepo.AssertExpectations(t)
 			}
+			testCase.mockCache.AssertExpectations(t)
 		})
 	}
 }
 
 func TestLeaderboardsDelete(t *testing.T) {
+	// The entries are deleted with the leaderboard, which leaves its ranking empty
+	deletedRepo := setupLeaderboardRepoMock("Delete", []any{"1", mock.AnythingOfType("*models.AuditActor")}, []any{nil})
+	deletedRepo.On("GetLeaderboardScores", "1").Return([]models.RankedScore{}, nil)
+	deletedCache := setupRedisServiceMock("ZReplace", []any{"leaderboard:1:ranking", map[string]float64{}}, []any{nil})
+	deletedCache.On("ZReplace", "leaderboard:1:teams", map[string]float64{}).Return(nil)
+
 	// Setup test cases
 	testCases := []struct {
 		name           string
 		mockRepo       *mocks.MockLeaderboardsRepo
+		mockCache      *mocks.MockRedisService
 		expectedStatus int
 		requestOpts    requestOpts
 	}{
 		{
 			name:           "delete leaderboard",
-			mockRepo:       setupLeaderboardRepoMock("Delete", []any{"1"}, []any{nil}),
+			mockRepo:       deletedRepo,
+			mockCache:      deletedCache,
 			expectedStatus: http.StatusNoContent,
 			requestOpts: requestOpts{
 				params: map[string]string{
@@ -467,7 +948,7 @@ func TestLeaderboardsDelete(t *testing.T) {
 		},
 		{
 			name:           "delete leaderboard db not found",
-			mockRepo:       setupLeaderboardRepoMock("Delete", []any{"1"}, []any{storage.ErrNotFound}),
+			mockRepo:       setupLeaderboardRepoMock("Delete", []any{"1", mock.AnythingOfType("*models.AuditActor")}, []any{storage.ErrNotFound}),
 			expectedStatus: http.StatusNotFound,
 			requestOpts: requestOpts{
 				params: map[string]string{
@@ -477,7 +958,7 @@ func TestLeaderboardsDelete(t *testing.T) {
 		},
 		{
 			name:           "delete leaderboard db error",
-			mockRepo:       setupLeaderboardRepoMock("Delete", []any{"1"}, []any{ErrRepoOperation}),
+			mockRepo:       setupLeaderboardRepoMock("Delete", []any{"1", mock.AnythingOfType("*models.AuditActor")}, []any{ErrRepoOperation}),
 			expectedStatus: http.StatusInternalServerError,
 			requestOpts: requestOpts{
 				params: map[string]string{
@@ -491,11 +972,18 @@ func TestLeaderboardsDelete(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 
 			// Recreate controller with new mock on every testcase
-			uc := NewLeaderboardController(testCase.mockRepo, &mocks.MockRedisService{})
+			mockCache := testCase.mockCache
+			if mockCache == nil {
+				mockCache = &mocks.MockRedisService{}
+			}
+			uc := NewLeaderboardController(testCase.mockRepo, &mocks.MockFriendRepo{}, setupNoTeamsMock(), &mocks.MockUserRepo{}, mockCache)
 
 			// Execute request and received recorded and decoded response
 			w := executeRequest(
-				[]gin.HandlerFunc{uc.Delete},
+				[]gin.HandlerFunc{
+					mocks.MockValidateAdminMiddleware(&auth.CustomClaims{UserID: "1", Role: "administrator"}),
+					uc.Delete,
+				},
 				testCase.requestOpts,
 			)
 
@@ -504,6 +992,584 @@ func TestLeaderboardsDelete(t *testing.T) {
 			if testCase.mockRepo != nil {
 				testCase.mockRepo.AssertExpectations(t)
 			}
+			mockCache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLeaderboardsRestore(t *testing.T) {
+	restoredRepo := setupLeaderboardRepoMock(
+		"Restore",
+		[]any{"1", mock.AnythingOfType("*models.AuditActor")},
+		[]any{&models.Leaderboard{ID: "1"}, nil},
+	)
+	restoredRepo.On("GetLeaderboardScores", "1").Return([]models.RankedScore{{LeaderboardID: "1", UserID: "2", Score: 40}}, nil)
+	restoredCache := setupRedisServiceMock("ZReplace", []any{"leaderboard:1:ranking", map[string]float64{"2": 40}}, []any{nil})
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockLeaderboardsRepo
+		mockCache      *mocks.MockRedisService
+		expectedStatus int
+	}{
+		{
+			name:           "restore leaderboard",
+			mockRepo:       restoredRepo,
+			mockCache:      restoredCache,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "restore leaderboard not deleted",
+			mockRepo: setupLeaderboardRepoMock(
+				"Restore",
+				[]any{"1", mock.AnythingOfType("*models.AuditActor")},
+				[]any{&models.Leaderboard{}, storage.ErrStateConflict},
+			),
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "restore leaderboard not found",
+			mockRepo: setupLeaderboardRepoMock(
+				"Restore",
+				[]any{"1", mock.AnythingOfType("*models.AuditActor")},
+				[]any{&models.Leaderboard{}, storage.ErrNotFound},
+			),
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			uc := NewLeaderboardController(testCase.mockRepo, &mocks.MockFriendRepo{}, &mocks.MockTeamRepo{}, &mocks.MockUserRepo{}, testCase.mockCache)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAdminMiddleware(&auth.CustomClaims{UserID: "1", Role: "administrator"}),
+					uc.Restore,
+				},
+				requestOpts{params: map[string]string{"id": "1"}},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+			testCase.mockCache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLeaderboardsGetFriendsEntries(t *testing.T) {
+
+	// Players 2 and 4 are tied, player 5 is a friend without a ranked score and is not in the intersection
+	circle := []models.User{{ID: "2", Username: "me"}, {ID: "3", Username: "rival"}, {ID: "4", Username: "mate"}, {ID: "5"}}
+	rankedCircle := []cache.ScoredMember{{Member: "3", Score: 90}, {Member: "2", Score: 50}, {Member: "4", Score: 50}}
+	rankedUsers := []models.User{{ID: "2", Username: "me"}, {ID: "3", Username: "rival"}, {ID: "4", Username: "mate"}}
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockLeaderboardsRepo
+		mockFriends    *mocks.MockFriendRepo
+		mockUsers      *mocks.MockUserRepo
+		mockCache      *mocks.MockRedisService
+		viewer         *auth.CustomClaims
+		expectedStatus int
+		expectedRanks  []int
+	}{
+		{
+			name:        "rank among friends",
+			mockRepo:    setupLeaderboardRepoMock("Get", []any{"1"}, []any{&models.Leaderboard{ID: "1"}, nil}),
+			mockFriends: &mocks.MockFriendRepo{},
+			mockUsers:   setupUserRepoMock("GetByIDs", []any{[]string{"3", "2", "4"}}, []any{rankedUsers, nil}),
+			mockCache: func() *mocks.MockRedisService {
+				mockRedisService := setupRedisServiceMock("Exists", []any{"user:2:circle"}, []any{true, nil})
+				mockRedisService.On("ZInterWithSet", "leaderboard:1:ranking", "user:2:circle").Return(rankedCircle, nil)
+				return mockRedisService
+			}(),
+			viewer:         &auth.CustomClaims{UserID: "2", Role: "visitor"},
+			expectedStatus: http.StatusOK,
+			expectedRanks:  []int{1, 2, 2},
+		},
+		{
+			name:        "build missing circle",
+			mockRepo:    setupLeaderboardRepoMock("Get", []any{"1"}, []any{&models.Leaderboard{ID: "1"}, nil}),
+			mockFriends: setupFriendRepoMock("GetCircle", []any{"2"}, []any{circle, nil}),
+			mockUsers:   setupUserRepoMock("GetByIDs", []any{[]string{"3", "2", "4"}}, []any{rankedUsers, nil}),
+			mockCache: func() *mocks.MockRedisService {
+				mockRedisService := setupRedisServiceMock("Exists", []any{"user:2:circle"}, []any{false, nil})
+				mockRedisService.On("SReplace", "user:2:circle", []string{"2", "3", "4", "5"}).Return(nil)
+				mockRedisService.On("ZInterWithSet", "leaderboard:1:ranking", "user:2:circle").Return(rankedCircle, nil)
+				return mockRedisService
+			}(),
+			viewer:         &auth.CustomClaims{UserID: "2", Role: "visitor"},
+			expectedStatus: http.StatusOK,
+			expectedRanks:  []int{1, 2, 2},
+		},
+		{
+			name:        "skip deleted friend",
+			mockRepo:    setupLeaderboardRepoMock("Get", []any{"1"}, []any{&models.Leaderboard{ID: "1"}, nil}),
+			mockFriends: &mocks.MockFriendRepo{},
+			mockUsers:   setupUserRepoMock("GetByIDs", []any{[]string{"3", "2", "4"}}, []any{rankedUsers[:2], nil}),
+			mockCache: func() *mocks.MockRedisService {
+				mockRedisService := setupRedisServiceMock("Exists", []any{"user:2:circle"}, []any{true, nil})
+				mockRedisService.On("ZInterWithSet", "leaderboard:1:ranking", "user:2:circle").Return(rankedCircle, nil)
+				return mockRedisService
+			}(),
+			viewer:         &auth.CustomClaims{UserID: "2", Role: "visitor"},
+			expectedStatus: http.StatusOK,
+			expectedRanks:  []int{1, 2},
+		},
+		{
+			name:           "anonymous viewer",
+			mockRepo:       &mocks.MockLeaderboardsRepo{},
+			mockFriends:    &mocks.MockFriendRepo{},
+			mockUsers:      &mocks.MockUserRepo{},
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "unknown leaderboard",
+			mockRepo:       setupLeaderboardRepoMock("Get", []any{"1"}, []any{&models.Leaderboard{}, storage.ErrNotFound}),
+			mockFriends:    &mocks.MockFriendRepo{},
+			mockUsers:      &mocks.MockUserRepo{},
+			mockCache:      &mocks.MockRedisService{},
+			viewer:         &auth.CustomClaims{UserID: "2", Role: "visitor"},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			uc := NewLeaderboardController(testCase.mockRepo, testCase.mockFriends, &mocks.MockTeamRepo{}, testCase.mockUsers, testCase.mockCache)
+
+			handlers := []gin.HandlerFunc{uc.GetEntries}
+			if testCase.viewer != nil {
+				handlers = append([]gin.HandlerFunc{mocks.MockValidateAuthMiddleware(testCase.viewer)}, handlers...)
+			}
+			w := executeRequest(handlers, requestOpts{
+				params: map[string]string{"id": "1"},
+				query:  map[string]string{"scope": "friends"},
+			})
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			if testCase.expectedRanks != nil {
+				var response struct {
+					Data []models.RankedEntry `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				ranks := make([]int, 0, len(response.Data))
+				for _, entry := range response.Data {
+					ranks = append(ranks, entry.Rank)
+				}
+				assert.Equal(t, testCase.expectedRanks, ranks)
+			}
+			testCase.mockRepo.AssertExpectations(t)
+			testCase.mockFriends.AssertExpectations(t)
+			testCase.mockUsers.AssertExpectations(t)
+			testCase.mockCache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLeaderboardsGetUserPercentile(t *testing.T) {
+
+	tiered := &models.Leaderboard{ID: "1", Tiers: models.Tiers{{Name: "Gold", TopPercent: 5}, {Name: "Silver", TopPercent: 25}}}
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockLeaderboardsRepo
+		mockCache      *mocks.MockRedisService
+		expectedStatus int
+		expectedTier   string
+	}{
+		{
+			// The sort key carries a tie-break, only players with a better key are counted above
+			name:     "ranked user",
+			mockRepo: setupLeaderboardRepoMock("Get", []any{"1"}, []any{tiered, nil}),
+			mockCache: func() *mocks.MockRedisService {
+				mockCache := setupRedisServiceMock("ZScore", []any{"leaderboard:1:ranking", "2"}, []any{1200.25, nil})
+				mockCache.On("ZCard", "leaderboard:1:ranking").Return(int64(1000), nil)
+				mockCache.On("ZCount", "leaderboard:1:ranking", "(1200.25", "+inf").Return(int64(120), nil)
+				mockCache.On("ZCount", "leaderboard:1:ranking", "-inf", "(1200.25").Return(int64(879), nil)
+				return mockCache
+			}(),
+			expectedStatus: http.StatusOK,
+			expectedTier:   "Silver",
+		},
+		{
+			name:           "user not ranked",
+			mockRepo:       setupLeaderboardRepoMock("Get", []any{"1"}, []any{tiered, nil}),
+			mockCache:      setupRedisServiceMock("ZScore", []any{"leaderboard:1:ranking", "2"}, []any{float64(0), cache.ErrNotFound}),
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "unknown leaderboard",
+			mockRepo:       setupLeaderboardRepoMock("Get", []any{"1"}, []any{&models.Leaderboard{}, storage.ErrNotFound}),
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			uc := NewLeaderboardController(testCase.mockRepo, &mocks.MockFriendRepo{}, &mocks.MockTeamRepo{}, &mocks.MockUserRepo{}, testCase.mockCache)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{uc.GetUserPercentile},
+				requestOpts{params: map[string]string{"id": "1", "userId": "2"}},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			if testCase.expectedStatus == http.StatusOK {
+				var response struct {
+					Data models.Standing `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, "2", response.Data.UserID)
+				assert.Equal(t, float64(1200), response.Data.Score)
+				assert.Equal(t, testCase.expectedTier, response.Data.Tier)
+			}
+			testCase.mockRepo.AssertExpectations(t)
+			testCase.mockCache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLeaderboardsGetScorePercentile(t *testing.T) {
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockLeaderboardsRepo
+		mockCache      *mocks.MockRedisService
+		query          map[string]string
+		expectedStatus int
+	}{
+		{
+			// Every key from 1200 up to 1201 holds the same score, whatever its tie-break
+			name:     "score leaderboard",
+			mockRepo: setupLeaderboardRepoMock("Get", []any{"1"}, []any{&models.Leaderboard{ID: "1"}, nil}),
+			mockCache: func() *mocks.MockRedisService {
+				mockCache := setupRedisServiceMock("ZCard", []any{"leaderboard:1:ranking"}, []any{int64(1000), nil})
+				mockCache.On("ZCount", "leaderboard:1:ranking", "1201", "+inf").Return(int64(29), nil)
+				mockCache.On("ZCount", "leaderboard:1:ranking", "-inf", "(1200").Return(int64(960), nil)
+				return mockCache
+			}(),
+			query:          map[string]string{"score": "1200"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "rating leaderboard",
+			mockRepo: setupLeaderboardRepoMock(
+				"Get",
+				[]any{"1"},
+				[]any{&models.Leaderboard{ID: "1", Type: models.LeaderboardTypeRating}, nil},
+			),
+			mockCache: func() *mocks.MockRedisService {
+				mockCache := setupRedisServiceMock("ZCard", []any{"leaderboard:1:ranking"}, []any{int64(1000), nil})
+				mockCache.On("ZCount", "leaderboard:1:ranking", "(1812.5", "+inf").Return(int64(29), nil)
+				mockCache.On("ZCount", "leaderboard:1:ranking", "-inf", "(1812.5").Return(int64(970), nil)
+				return mockCache
+			}(),
+			query:          map[string]string{"score": "1812.5"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing score",
+			mockRepo:       &mocks.MockLeaderboardsRepo{},
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "score not a number",
+			mockRepo:       &mocks.MockLeaderboardsRepo{},
+			mockCache:      &mocks.MockRedisService{},
+			query:          map[string]string{"score": "NaN"},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			uc := NewLeaderboardController(testCase.mockRepo, &mocks.MockFriendRepo{}, &mocks.MockTeamRepo{}, &mocks.MockUserRepo{}, testCase.mockCache)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{uc.GetScorePercentile},
+				requestOpts{params: map[string]string{"id": "1"}, query: testCase.query},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			if testCase.expectedStatus == http.StatusOK {
+				var response struct {
+					Data models.Standing `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.InDelta(t, 3, response.Data.TopPercent, 0.0001)
+			}
+			testCase.mockRepo.AssertExpectations(t)
+			testCase.mockCache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLeaderboardsGetStats(t *testing.T) {
+
+	distribution := &models.ScoreDistribution{
+		Count:       4,
+		Min:         1000,
+		Max:         1180,
+		BucketWidth: 100,
+		Histogram:   []models.HistogramBucket{{From: 1000, To: 1100, Count: 3}, {From: 1100, To: 1200, Count: 1}},
+	}
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockLeaderboardsRepo
+		mockCache      *mocks.MockRedisService
+		query          map[string]string
+		expectedStatus int
+	}{
+		{
+			name: "compute and cache distribution",
+			mockRepo: func() *mocks.MockLeaderboardsRepo {
+				mockRepo := setupLeaderboardRepoMock("Get", []any{"1"}, []any{&models.Leaderboard{ID: "1"}, nil})
+				mockRepo.On("GetScoreDistribution", "1", float64(100)).Return(distribution, nil)
+				return mockRepo
+			}(),
+			mockCache: func() *mocks.MockRedisService {
+				mockCache := setupRedisServiceMock(
+					"Get",
+					[]any{"leaderboard:1:distribution:100", mock.Anything},
+					[]any{cache.ErrNotFound},
+				)
+				mockCache.On("Set", "leaderboard:1:distribution:100", distribution, models.DistributionCacheTTL).Return(nil)
+				return mockCache
+			}(),
+			query:          map[string]string{"bucket_width": "100"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:     "cached distribution",
+			mockRepo: &mocks.MockLeaderboardsRepo{},
+			mockCache: setupRedisServiceMock(
+				"Get",
+				[]any{"leaderboard:1:distribution:0", mock.AnythingOfType("*models.ScoreDistribution")},
+				[]any{nil},
+			),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "negative bucket width",
+			mockRepo:       &mocks.MockLeaderboardsRepo{},
+			mockCache:      &mocks.MockRedisService{},
+			query:          map[string]string{"bucket_width": "-5"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "unknown leaderboard",
+			mockRepo: setupLeaderboardRepoMock("Get", []any{"1"}, []any{&models.Leaderboard{}, storage.ErrNotFound}),
+			mockCache: setupRedisServiceMock(
+				"Get",
+				[]any{"leaderboard:1:distribution:0", mock.Anything},
+				[]any{cache.ErrNotFound},
+			),
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			uc := NewLeaderboardController(testCase.mockRepo, &mocks.MockFriendRepo{}, &mocks.MockTeamRepo{}, &mocks.MockUserRepo{}, testCase.mockCache)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{uc.GetStats},
+				requestOpts{params: map[string]string{"id": "1"}, query: testCase.query},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+			testCase.mockCache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLeaderboardsGetRanking(t *testing.T) {
+
+	leaderboard := &models.Leaderboard{ID: "1", Type: models.LeaderboardTypeScore, RankMode: models.RankModeDense}
+	page := models.Pagination{Limit: models.DefaultPageLimit}
+	ranked := []models.RankedEntry{
+		{Rank: 1, User: models.User{ID: "4"}, Score: 5400, Variables: map[string]string{"platform": "PC"}},
+	}
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockLeaderboardsRepo
+		query          map[string]string
+		expectedStatus int
+	}{
+		{
+			name: "filtered by platform",
+			mockRepo: func() *mocks.MockLeaderboardsRepo {
+				mockRepo := setupLeaderboardRepoMock("Get", []any{"1"}, []any{leaderboard, nil})
+				mockRepo.On("GetRankedEntries", &models.VariantFilter{
+					LeaderboardID: "1",
+					RankMode:      models.RankModeDense,
+					Values:        map[string]string{"platform": "PC"},
+					Metadata:      map[string]string{},
+					Page:          page,
+				}).Return(ranked, nil)
+				return mockRepo
+			}(),
+			query:          map[string]string{"variables[platform]": "PC"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "all variants",
+			mockRepo: func() *mocks.MockLeaderboardsRepo {
+				mockRepo := setupLeaderboardRepoMock("Get", []any{"1"}, []any{leaderboard, nil})
+				mockRepo.On("GetRankedEntries", &models.VariantFilter{
+					LeaderboardID: "1",
+					RankMode:      models.RankModeDense,
+					Values:        map[string]string{},
+					Metadata:      map[string]string{},
+					Page:          page,
+				}).Return(ranked, nil)
+				return mockRepo
+			}(),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "filtered by metadata",
+			mockRepo: func() *mocks.MockLeaderboardsRepo {
+				mockRepo := setupLeaderboardRepoMock("Get", []any{"1"}, []any{leaderboard, nil})
+				mockRepo.On("GetRankedEntries", &models.VariantFilter{
+					LeaderboardID: "1",
+					RankMode:      models.RankModeDense,
+					Values:        map[string]string{},
+					Metadata:      map[string]string{"character": "mario"},
+					Page:          page,
+				}).Return(ranked, nil)
+				return mockRepo
+			}(),
+			query:          map[string]string{"metadata[character]": "mario"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "rating leaderboard",
+			mockRepo: setupLeaderboardRepoMock(
+				"Get",
+				[]any{"1"},
+				[]any{&models.Leaderboard{ID: "1", Type: models.LeaderboardTypeRating}, nil},
+			),
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "unknown leaderboard",
+			mockRepo:       setupLeaderboardRepoMock("Get", []any{"1"}, []any{&models.Leaderboard{}, storage.ErrNotFound}),
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			uc := NewLeaderboardController(testCase.mockRepo, &mocks.MockFriendRepo{}, &mocks.MockTeamRepo{}, &mocks.MockUserRepo{}, &mocks.MockRedisService{})
+
+			w := executeRequest(
+				[]gin.HandlerFunc{uc.GetRanking},
+				requestOpts{params: map[string]string{"id": "1"}, query: testCase.query},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLeaderboardsGetRankingAroundMe(t *testing.T) {
+
+	// Player 2 is fourth with a radius of 2, the distinct keys above the window are counted to number dense ranks
+	// and player 6 was deleted since their score was ranked
+	leaderboard := &models.Leaderboard{ID: "1", Type: models.LeaderboardTypeScore, RankMode: models.RankModeDense}
+	window := []cache.ScoredMember{
+		{Member: "3", Score: 800}, {Member: "2", Score: 800}, {Member: "4", Score: 700}, {Member: "5", Score: 700}, {Member: "6", Score: 600},
+	}
+	users := []models.User{{ID: "2", Username: "me"}, {ID: "3", Username: "rival"}, {ID: "4"}, {ID: "5"}}
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockLeaderboardsRepo
+		mockUsers      *mocks.MockUserRepo
+		mockCache      *mocks.MockRedisService
+		viewer         *auth.CustomClaims
+		expectedStatus int
+		expectedRanks  []int
+	}{
+		{
+			name:      "dense ranks around the user",
+			mockRepo:  setupLeaderboardRepoMock("Get", []any{"1"}, []any{leaderboard, nil}),
+			mockUsers: setupUserRepoMock("GetByIDs", []any{[]string{"3", "2", "4", "5", "6"}}, []any{users, nil}),
+			mockCache: func() *mocks.MockRedisService {
+				mockCache := setupRedisServiceMock("ZRevRank", []any{"leaderboard:1:ranking", "2"}, []any{int64(3), nil})
+				mockCache.On("ZRevRangeWithScores", "leaderboard:1:ranking", int64(1), int64(5)).Return(window, nil)
+				mockCache.On("ZCountDistinct", "leaderboard:1:ranking", "(800", "+inf").Return(int64(1), nil)
+				return mockCache
+			}(),
+			viewer:         &auth.CustomClaims{UserID: "2", Role: "visitor"},
+			expectedStatus: http.StatusOK,
+			expectedRanks:  []int{2, 2, 3, 3},
+		},
+		{
+			name:           "user not ranked",
+			mockRepo:       setupLeaderboardRepoMock("Get", []any{"1"}, []any{leaderboard, nil}),
+			mockUsers:      &mocks.MockUserRepo{},
+			mockCache:      setupRedisServiceMock("ZRevRank", []any{"leaderboard:1:ranking", "2"}, []any{int64(0), cache.ErrNotFound}),
+			viewer:         &auth.CustomClaims{UserID: "2", Role: "visitor"},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "rating leaderboard",
+			mockRepo:       setupLeaderboardRepoMock("Get", []any{"1"}, []any{&models.Leaderboard{ID: "1", Type: models.LeaderboardTypeRating}, nil}),
+			mockUsers:      &mocks.MockUserRepo{},
+			mockCache:      &mocks.MockRedisService{},
+			viewer:         &auth.CustomClaims{UserID: "2", Role: "visitor"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "anonymous viewer",
+			mockRepo:       &mocks.MockLeaderboardsRepo{},
+			mockUsers:      &mocks.MockUserRepo{},
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			uc := NewLeaderboardController(testCase.mockRepo, &mocks.MockFriendRepo{}, &mocks.MockTeamRepo{}, testCase.mockUsers, testCase.mockCache)
+
+			handlers := []gin.HandlerFunc{uc.GetRankingAroundMe}
+			if testCase.viewer != nil {
+				handlers = append([]gin.HandlerFunc{mocks.MockValidateAuthMiddleware(testCase.viewer)}, handlers...)
+			}
+			w := executeRequest(handlers, requestOpts{
+				params: map[string]string{"id": "1"},
+				query:  map[string]string{"radius": "2"},
+			})
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			if testCase.expectedRanks != nil {
+				var response struct {
+					Data []models.RankedEntry `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				ranks := make([]int, 0, len(response.Data))
+				for _, entry := range response.Data {
+					ranks = append(ranks, entry.Rank)
+				}
+				assert.Equal(t, testCase.expectedRanks, ranks)
+			}
+			testCase.mockRepo.AssertExpectations(t)
+			testCase.mockUsers.AssertExpectations(t)
+			testCase.mockCache.AssertExpectations(t)
 		})
 	}
 }
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/api/problems"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+)
+
+// Members of private leaderboards are added by administrators, or join on their own with an invite code
+type MemberController struct {
+	repo storage.MemberRepo
+}
+
+func NewMemberController(repo storage.MemberRepo) MemberController {
+	return MemberController{
+		repo: repo,
+	}
+}
+
+func (m MemberController) AddMember(c *gin.Context) {
+	leaderboardID, userID := c.Param("id"), c.Param("userId")
+	if leaderboardID == "" || userID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing leaderboard or user id"))
+		return
+	}
+
+	member, err := m.repo.AddMember(c.Request.Context(), leaderboardID, userID)
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    member,
+		"message": "Leaderboard member added",
+	})
+}
+
+func (m MemberController) RemoveMember(c *gin.Context) {
+	leaderboardID, userID := c.Param("id"), c.Param("userId")
+	if leaderboardID == "" || userID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing leaderboard or user id"))
+		return
+	}
+
+	if err := m.repo.RemoveMember(c.Request.Context(), leaderboardID, userID); err != nil {
+		problems.RenderError(c, err, "Leaderboard member")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Leaderboard member removed",
+	})
+}
+
+// Creates an invite code for the leaderboard, optionally limited in uses and time
+func (m MemberController) CreateInvite(c *gin.Context) {
+	leaderboardID := c.Param("id")
+	if leaderboardID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing leaderboard id"))
+		return
+	}
+
+	request := models.InviteRequest{}
+	if err := c.ShouldBindBodyWithJSON(&request); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid request body"))
+		return
+	}
+	if err := request.Validate(time.Now()); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+
+	code, err := newInviteCode()
+	if err != nil {
+		log.Printf("Failed to generate invite code: %v", err)
+		problems.Render(c, problems.Internal())
+		return
+	}
+	request.LeaderboardID = leaderboardID
+	request.Code = code
+	request.CreatedBy = viewerID(c)
+
+	invite, err := m.repo.CreateInvite(c.Request.Context(), &request)
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    invite,
+		"message": "Invite created",
+	})
+}
+
+// Joins the signed in user to the leaderboard of the invite code
+func (m MemberController) Join(c *gin.Context) {
+	request := models.JoinRequest{}
+	if err := c.ShouldBindBodyWithJSON(&request); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid request body"))
+		return
+	}
+	request.Code = strings.TrimSpace(request.Code)
+	if request.Code == "" {
+		problems.Render(c, problems.InvalidRequest("Missing invite code"))
+		return
+	}
+
+	userClaims, err := parseUserClaims(c)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	member, err := m.repo.Join(c.Request.Context(), request.Code, userClaims.UserID)
+	if err != nil {
+		problems.RenderError(c, err, "Invite")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    member,
+		"message": "Joined leaderboard",
+	})
+}
+
+// Invite codes are shared by hand, so they are kept short and free of padding
+func newInviteCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b), nil
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
+	"github.com/mochivi/go-real-time-leaderboards/internal/mocks"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMembersCreateInvite(t *testing.T) {
+
+	matchesRequest := mock.MatchedBy(func(request *models.InviteRequest) bool {
+		return request.LeaderboardID == "5" && request.CreatedBy == "1" && len(request.Code) == 16
+	})
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockMemberRepo
+		body           any
+		expectedStatus int
+	}{
+		{
+			name: "create invite",
+			mockRepo: setupMemberRepoMock(
+				"CreateInvite",
+				[]any{matchesRequest},
+				[]any{&models.LeaderboardInvite{ID: "3", LeaderboardID: "5", Code: "ABCDEFGHIJKLMNOP"}, nil},
+			),
+			body:           map[string]any{"max_uses": 10},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "no uses",
+			mockRepo:       &mocks.MockMemberRepo{},
+			body:           map[string]any{"max_uses": 0},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "unknown leaderboard",
+			mockRepo: setupMemberRepoMock(
+				"CreateInvite",
+				[]any{matchesRequest},
+				[]any{&models.LeaderboardInvite{}, storage.ErrNotFound},
+			),
+			body:           map[string]any{},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			mc := NewMemberController(testCase.mockRepo)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAdminMiddleware(&auth.CustomClaims{UserID: "1", Role: "administrator"}),
+					mc.CreateInvite,
+				},
+				requestOpts{params: map[string]string{"id": "5"}, body: testCase.body},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMembersJoin(t *testing.T) {
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockMemberRepo
+		body           any
+		expectedStatus int
+	}{
+		{
+			name: "join leaderboard",
+			mockRepo: setupMemberRepoMock(
+				"Join",
+				[]any{"ABCDEFGHIJKLMNOP", "2"},
+				[]any{&models.LeaderboardMember{LeaderboardID: "5", UserID: "2"}, nil},
+			),
+			body:           models.JoinRequest{Code: " ABCDEFGHIJKLMNOP "},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "missing code",
+			mockRepo:       &mocks.MockMemberRepo{},
+			body:           models.JoinRequest{},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "expired invite",
+			mockRepo: setupMemberRepoMock(
+				"Join",
+				[]any{"ABCDEFGHIJKLMNOP", "2"},
+				[]any{&models.LeaderboardMember{}, storage.ErrNotFound},
+			),
+			body:           models.JoinRequest{Code: "ABCDEFGHIJKLMNOP"},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			mc := NewMemberController(testCase.mockRepo)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAuthMiddleware(&auth.CustomClaims{UserID: "2", Role: "visitor"}),
+					mc.Join,
+				},
+				requestOpts{body: testCase.body},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMembersRemoveMember(t *testing.T) {
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockMemberRepo
+		expectedStatus int
+	}{
+		{
+			name:           "remove member",
+			mockRepo:       setupMemberRepoMock("RemoveMember", []any{"5", "2"}, []any{nil}),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "not a member",
+			mockRepo:       setupMemberRepoMock("RemoveMember", []any{"5", "2"}, []any{storage.ErrNotFound}),
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			mc := NewMemberController(testCase.mockRepo)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAdminMiddleware(&auth.CustomClaims{UserID: "1", Role: "administrator"}),
+					mc.RemoveMember,
+				},
+				requestOpts{params: map[string]string{"id": "5", "userId": "2"}},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/api/problems"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/rankings"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	redis "github.com/mochivi/go-real-time-leaderboards/internal/storage/redis"
+)
+
+// Moderators review entries that are pending verification or were flagged as anomalous
+type ModerationController struct {
+	repo     storage.LeaderboardRepo
+	rankings rankings.Syncer
+	teams    rankings.Teams
+}
+
+func NewModerationController(
+	repo storage.LeaderboardRepo,
+	teamRepo storage.TeamRepo,
+	redisService redis.RedisService,
+) ModerationController {
+	return ModerationController{
+		repo:     repo,
+		rankings: rankings.NewSyncer(repo, redisService),
+		teams:    rankings.NewTeams(teamRepo, redisService),
+	}
+}
+
+// Returns the pending and flagged entries, optionally for a single leaderboard
+func (m ModerationController) GetQueue(c *gin.Context) {
+	filter := models.ModerationQueueFilter{}
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid query parameters"))
+		return
+	}
+	filter.Normalize()
+
+	entries, err := m.repo.GetModerationQueue(c.Request.Context(), &filter)
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard entry")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       entries,
+		"pagination": filter.Pagination,
+	})
+}
+
+// Verifies the entry and adds it to the ranking, the reason is optional
+func (m ModerationController) ApproveEntry(c *gin.Context) {
+	review, actor, ok := m.bindReview(c, models.EntryStatusVerified)
+	if !ok {
+		return
+	}
+
+	entry, err := m.repo.ReviewEntry(c.Request.Context(), review, actor)
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard entry")
+		return
+	}
+
+	m.syncRankings(c, entry)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    entry,
+		"message": "Leaderboard entry verified",
+	})
+}
+
+// Rejects the entry, keeping it out of the ranking, a reason is required so the player knows why
+func (m ModerationController) RejectEntry(c *gin.Context) {
+	review, actor, ok := m.bindReview(c, models.EntryStatusRejected)
+	if !ok {
+		return
+	}
+	if review.Reason == "" {
+		problems.Render(c, problems.InvalidRequest("A reason is required to reject an entry"))
+		return
+	}
+
+	entry, err := m.repo.ReviewEntry(c.Request.Context(), review, actor)
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard entry")
+		return
+	}
+
+	m.syncRankings(c, entry)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    entry,
+		"message": "Leaderboard entry rejected",
+	})
+}
+
+// Builds the review from the path and the optional body, the moderator is the audit actor
+// Renders the problem and returns false if the request is invalid
+func (m ModerationController) bindReview(c *gin.Context, status string) (*models.EntryReview, *models.AuditActor, bool) {
+	entryID := c.Param("id")
+	if entryID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing leaderboard entry id"))
+		return nil, nil, false
+	}
+
+	review := models.EntryReview{}
+	if err := c.ShouldBindBodyWithJSON(&review); err != nil && !errors.Is(err, io.EOF) {
+		problems.Render(c, problems.InvalidRequest("Invalid request body"))
+		return nil, nil, false
+	}
+
+	actor, err := auditActor(c)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return nil, nil, false
+	}
+
+	review.EntryID = entryID
+	review.Status = status
+
+	return &review, actor, true
+}
+
+// Postgres already holds the decision, the rankings are rebuilt from it so bans are respected
+func (m ModerationController) syncRankings(c *gin.Context, entry *models.LeaderboardEntry) {
+	if err := m.rankings.SyncUser(c.Request.Context(), entry.User.ID); err != nil {
+		log.Printf("Failed to update ranking: %v", err)
+	}
+	if err := m.teams.SyncUser(c.Request.Context(), entry.User.ID, entry.LeaderboardID); err != nil {
+		log.Printf("Failed to update team ranking: %v", err)
+	}
+}
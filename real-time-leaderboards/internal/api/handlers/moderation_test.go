@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
+	"github.com/mochivi/go-real-time-leaderboards/internal/mocks"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestModerationGetQueue(t *testing.T) {
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockLeaderboardsRepo
+		expectedStatus int
+	}{
+		{
+			name: "get moderation queue",
+			mockRepo: setupLeaderboardRepoMock(
+				"GetModerationQueue",
+				[]any{mock.MatchedBy(func(filter *models.ModerationQueueFilter) bool {
+					return filter.Limit == models.DefaultPageLimit
+				})},
+				[]any{[]models.LeaderboardEntry{{ID: "1", Status: models.EntryStatusPending}}, nil},
+			),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "get moderation queue db error",
+			mockRepo: setupLeaderboardRepoMock(
+				"GetModerationQueue",
+				[]any{mock.AnythingOfType("*models.ModerationQueueFilter")},
+				[]any{[]models.LeaderboardEntry{}, ErrRepoOperation},
+			),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			mc := NewModerationController(testCase.mockRepo, setupNoTeamsMock(), &mocks.MockRedisService{})
+
+			w := executeRequest([]gin.HandlerFunc{mc.GetQueue})
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestModerationApproveEntry(t *testing.T) {
+
+	verifiedEntry := &models.LeaderboardEntry{
+		ID:            "1",
+		LeaderboardID: "1",
+		User:          models.User{ID: "2"},
+		Score:         10,
+		Status:        models.EntryStatusVerified,
+	}
+
+	// Approving rebuilds the rankings of the entry owner from their ranked scores
+	approveRepoMock := setupLeaderboardRepoMock(
+		"ReviewEntry",
+		[]any{
+			mock.MatchedBy(func(review *models.EntryReview) bool {
+				return review.EntryID == "1" && review.Status == models.EntryStatusVerified
+			}),
+			mock.MatchedBy(func(actor *models.AuditActor) bool {
+				return actor.UserID == "1" && actor.Role == "moderator"
+			}),
+		},
+		[]any{verifiedEntry, nil},
+	)
+	approveRepoMock.On("GetRankedScores", "2").Return([]models.RankedScore{{LeaderboardID: "1", Score: 10}}, nil)
+
+	// Entries of shadowbanned users stay out of the ranking once approved
+	hiddenRepoMock := setupLeaderboardRepoMock(
+		"ReviewEntry",
+		[]any{mock.AnythingOfType("*models.EntryReview"), mock.AnythingOfType("*models.AuditActor")},
+		[]any{verifiedEntry, nil},
+	)
+	hiddenRepoMock.On("GetRankedScores", "2").Return([]models.RankedScore{{LeaderboardID: "1", Score: 10, Hidden: true}}, nil)
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockLeaderboardsRepo
+		mockCache      *mocks.MockRedisService
+		expectedStatus int
+		requestOpts    requestOpts
+	}{
+		{
+			name:           "approve entry",
+			mockRepo:       approveRepoMock,
+			mockCache:      setupRedisServiceMock("ZAdd", []any{"leaderboard:1:ranking", "2", float64(10)}, []any{nil}),
+			expectedStatus: http.StatusOK,
+			requestOpts:    requestOpts{params: map[string]string{"id": "1"}, body: models.EntryReview{}},
+		},
+		{
+			name:           "approve entry of shadowbanned user",
+			mockRepo:       hiddenRepoMock,
+			mockCache:      setupRedisServiceMock("ZRem", []any{"leaderboard:1:ranking", "2"}, []any{nil}),
+			expectedStatus: http.StatusOK,
+			requestOpts:    requestOpts{params: map[string]string{"id": "1"}, body: models.EntryReview{}},
+		},
+		{
+			name: "approve entry already reviewed",
+			mockRepo: setupLeaderboardRepoMock(
+				"ReviewEntry",
+				[]any{mock.AnythingOfType("*models.EntryReview"), mock.AnythingOfType("*models.AuditActor")},
+				[]any{&models.LeaderboardEntry{}, storage.ErrStateConflict},
+			),
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusConflict,
+			requestOpts:    requestOpts{params: map[string]string{"id": "1"}, body: models.EntryReview{}},
+		},
+		{
+			name: "approve entry not found",
+			mockRepo: setupLeaderboardRepoMock(
+				"ReviewEntry",
+				[]any{mock.AnythingOfType("*models.EntryReview"), mock.AnythingOfType("*models.AuditActor")},
+				[]any{&models.LeaderboardEntry{}, storage.ErrNotFound},
+			),
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusNotFound,
+			requestOpts:    requestOpts{params: map[string]string{"id": "1"}, body: models.EntryReview{}},
+		},
+		{
+			name:           "approve entry missing id",
+			mockRepo:       &mocks.MockLeaderboardsRepo{},
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusBadRequest,
+			requestOpts:    requestOpts{params: map[string]string{"id": ""}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			mc := NewModerationController(testCase.mockRepo, setupNoTeamsMock(), testCase.mockCache)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAuthMiddleware(&auth.CustomClaims{UserID: "1", Role: "moderator"}),
+					mc.ApproveEntry,
+				},
+				testCase.requestOpts,
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+			testCase.mockCache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestModerationRejectEntry(t *testing.T) {
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockLeaderboardsRepo
+		expectedStatus int
+		requestOpts    requestOpts
+	}{
+		{
+			name: "reject entry",
+			mockRepo: func() *mocks.MockLeaderboardsRepo {
+				mockRepo := setupLeaderboardRepoMock(
+					"ReviewEntry",
+					[]any{
+						mock.MatchedBy(func(review *models.EntryReview) bool {
+							return review.Status == models.EntryStatusRejected && review.Reason == "Spliced video"
+						}),
+						mock.AnythingOfType("*models.AuditActor"),
+					},
+					[]any{&models.LeaderboardEntry{ID: "1", LeaderboardID: "1", User: models.User{ID: "2"}, Status: models.EntryStatusRejected}, nil},
+				)
+				mockRepo.On("GetRankedScores", "2").Return([]models.RankedScore{}, nil)
+				return mockRepo
+			}(),
+			expectedStatus: http.StatusOK,
+			requestOpts: requestOpts{
+				params: map[string]string{"id": "1"},
+				body:   models.EntryReview{Reason: "Spliced video"},
+			},
+		},
+		{
+			name:           "reject entry missing reason",
+			mockRepo:       &mocks.MockLeaderboardsRepo{},
+			expectedStatus: http.StatusBadRequest,
+			requestOpts:    requestOpts{params: map[string]string{"id": "1"}, body: models.EntryReview{}},
+		},
+		{
+			name: "reject entry db error",
+			mockRepo: setupLeaderboardRepoMock(
+				"ReviewEntry",
+				[]any{mock.AnythingOfType("*models.EntryReview"), mock.AnythingOfType("*models.AuditActor")},
+				[]any{&models.LeaderboardEntry{}, ErrRepoOperation},
+			),
+			expectedStatus: http.StatusInternalServerError,
+			requestOpts: requestOpts{
+				params: map[string]string{"id": "1"},
+				body:   models.EntryReview{Reason: "Spliced video"},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			// Rejected entries were not ranked, syncing the rankings of their player leaves them unchanged
+			mc := NewModerationController(testCase.mockRepo, setupNoTeamsMock(), &mocks.MockRedisService{})
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAuthMiddleware(&auth.CustomClaims{UserID: "1", Role: "administrator"}),
+					mc.RejectEntry,
+				},
+				testCase.requestOpts,
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+		})
+	}
+}
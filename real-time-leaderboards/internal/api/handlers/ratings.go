@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/api/problems"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/rankings"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	redis "github.com/mochivi/go-real-time-leaderboards/internal/storage/redis"
+)
+
+// Rating leaderboards are fed with the results of head-to-head matches instead of submitted scores
+type RatingController struct {
+	repo         storage.RatingRepo
+	leaderboards storage.LeaderboardRepo
+	rankings     rankings.Syncer
+	ratings      rankings.Ratings
+}
+
+func NewRatingController(
+	repo storage.RatingRepo,
+	leaderboardRepo storage.LeaderboardRepo,
+	redisService redis.RedisService,
+) RatingController {
+	return RatingController{
+		repo:         repo,
+		leaderboards: leaderboardRepo,
+		rankings:     rankings.NewSyncer(leaderboardRepo, redisService),
+		ratings:      rankings.NewRatings(repo, redisService),
+	}
+}
+
+// Records the result of a match and rates both players with the rating system of the leaderboard
+func (r RatingController) RecordMatch(c *gin.Context) {
+	leaderboardID := c.Param("id")
+	if leaderboardID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing leaderboard id"))
+		return
+	}
+
+	request := models.RatingMatchRequest{}
+	if err := c.ShouldBindBodyWithJSON(&request); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid request body"))
+		return
+	}
+	if err := request.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	request.LeaderboardID = leaderboardID
+
+	match, err := r.repo.RecordMatch(c.Request.Context(), &request)
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard")
+		return
+	}
+
+	// The syncer leaves banned and shadowbanned players out of the ranking, as it does for scores
+	for _, playerID := range []string{match.Player1ID, match.Player2ID} {
+		if err := r.rankings.SyncUser(c.Request.Context(), playerID); err != nil {
+			log.Printf("Failed to update ranking of player '%s': %v", playerID, err)
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    match,
+		"message": "Match recorded",
+	})
+}
+
+// Returns a page of the players of a rating leaderboard, ranked by their conservative rating
+func (r RatingController) GetRatings(c *gin.Context) {
+	leaderboardID := c.Param("id")
+	if leaderboardID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing leaderboard id"))
+		return
+	}
+
+	page := models.Pagination{}
+	if err := c.ShouldBindQuery(&page); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid query parameters"))
+		return
+	}
+	page.Normalize()
+
+	leaderboard, ok := r.ratingLeaderboard(c, leaderboardID)
+	if !ok {
+		return
+	}
+
+	ratings, err := r.ratings.Rank(c.Request.Context(), leaderboard, &page)
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       ratings,
+		"pagination": page,
+	})
+}
+
+// Returns the rating of a player after each of their matches, most recent first
+func (r RatingController) GetHistory(c *gin.Context) {
+	leaderboardID, userID := c.Param("id"), c.Param("userId")
+	if leaderboardID == "" || userID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing leaderboard or user id"))
+		return
+	}
+
+	page := models.Pagination{}
+	if err := c.ShouldBindQuery(&page); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid query parameters"))
+		return
+	}
+	page.Normalize()
+
+	leaderboard, ok := r.ratingLeaderboard(c, leaderboardID)
+	if !ok {
+		return
+	}
+
+	history, err := r.repo.GetHistory(c.Request.Context(), leaderboard.ID, userID, &page)
+	if err != nil {
+		problems.RenderError(c, err, "Rating history")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       history,
+		"pagination": page,
+	})
+}
+
+// Renders a not found problem unless the leaderboard exists and ranks ratings
+func (r RatingController) ratingLeaderboard(c *gin.Context, leaderboardID string) (*models.Leaderboard, bool) {
+	leaderboard, err := r.leaderboards.Get(c.Request.Context(), leaderboardID)
+	if err != nil {
+		problems.RenderError(c, err, "Leaderboard")
+		return nil, false
+	}
+	if leaderboard.Type != models.LeaderboardTypeRating {
+		problems.Render(c, problems.NotFound("Leaderboard has no ratings"))
+		return nil, false
+	}
+	return leaderboard, true
+}
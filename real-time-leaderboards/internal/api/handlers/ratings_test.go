@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
+	"github.com/mochivi/go-real-time-leaderboards/internal/mocks"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	cache "github.com/mochivi/go-real-time-leaderboards/internal/storage/redis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRatingsRecordMatch(t *testing.T) {
+
+	request := &models.RatingMatchRequest{LeaderboardID: "1", Player1ID: "2", Player2ID: "3", WinnerID: "2"}
+	match := &models.RatingMatch{ID: "7", LeaderboardID: "1", Player1ID: "2", Player2ID: "3", WinnerID: "2"}
+
+	// Both players are synced, the banned loser is removed from the ranking
+	syncedLeaderboardRepo := setupLeaderboardRepoMock(
+		"GetRankedScores",
+		[]any{"2"},
+		[]any{[]models.RankedScore{{LeaderboardID: "1", UserID: "2", Score: 1216}}, nil},
+	)
+	syncedLeaderboardRepo.On("GetRankedScores", "3").
+		Return([]models.RankedScore{{LeaderboardID: "1", UserID: "3", Score: 784, Hidden: true}}, nil)
+	syncedCache := setupRedisServiceMock("ZAdd", []any{"leaderboard:1:ranking", "2", float64(1216)}, []any{nil})
+	syncedCache.On("ZRem", "leaderboard:1:ranking", "3").Return(nil)
+
+	testCases := []struct {
+		name            string
+		mockRepo        *mocks.MockRatingRepo
+		mockLeaderboard *mocks.MockLeaderboardsRepo
+		mockCache       *mocks.MockRedisService
+		body            any
+		expectedStatus  int
+	}{
+		{
+			name:            "record match",
+			mockRepo:        setupRatingRepoMock("RecordMatch", []any{request}, []any{match, nil}),
+			mockLeaderboard: syncedLeaderboardRepo,
+			mockCache:       syncedCache,
+			body:            request,
+			expectedStatus:  http.StatusCreated,
+		},
+		{
+			name:            "winner not playing",
+			mockRepo:        &mocks.MockRatingRepo{},
+			mockLeaderboard: &mocks.MockLeaderboardsRepo{},
+			mockCache:       &mocks.MockRedisService{},
+			body:            models.RatingMatchRequest{Player1ID: "2", Player2ID: "3", WinnerID: "4"},
+			expectedStatus:  http.StatusBadRequest,
+		},
+		{
+			name: "score leaderboard",
+			mockRepo: setupRatingRepoMock(
+				"RecordMatch",
+				[]any{request},
+				[]any{&models.RatingMatch{}, storage.ErrStateConflict},
+			),
+			mockLeaderboard: &mocks.MockLeaderboardsRepo{},
+			mockCache:       &mocks.MockRedisService{},
+			body:            request,
+			expectedStatus:  http.StatusConflict,
+		},
+		{
+			name: "unknown player",
+			mockRepo: setupRatingRepoMock(
+				"RecordMatch",
+				[]any{request},
+				[]any{&models.RatingMatch{}, storage.ErrInvalidReference},
+			),
+			mockLeaderboard: &mocks.MockLeaderboardsRepo{},
+			mockCache:       &mocks.MockRedisService{},
+			body:            request,
+			expectedStatus:  http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			rc := NewRatingController(testCase.mockRepo, testCase.mockLeaderboard, testCase.mockCache)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAdminMiddleware(&auth.CustomClaims{UserID: "1", Role: "administrator"}),
+					rc.RecordMatch,
+				},
+				requestOpts{
+					params: map[string]string{"id": "1"},
+					body:   testCase.body,
+				},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+			testCase.mockLeaderboard.AssertExpectations(t)
+			testCase.mockCache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRatingsGetRatings(t *testing.T) {
+
+	ratingLeaderboard := &models.Leaderboard{ID: "1", Type: models.LeaderboardTypeRating, RatingSystem: models.RatingSystemGlicko2}
+	ranked := []cache.ScoredMember{{Member: "3", Score: 1400}, {Member: "2", Score: 1200}, {Member: "4", Score: 1200}}
+	ratings := []models.PlayerRating{
+		{User: models.User{ID: "2"}, ConservativeRating: 1200},
+		{User: models.User{ID: "3"}, ConservativeRating: 1400},
+		{User: models.User{ID: "4"}, ConservativeRating: 1200},
+	}
+
+	testCases := []struct {
+		name            string
+		mockRepo        *mocks.MockRatingRepo
+		mockLeaderboard *mocks.MockLeaderboardsRepo
+		mockCache       *mocks.MockRedisService
+		query           map[string]string
+		expectedStatus  int
+		expectedRanks   []int
+	}{
+		{
+			name:            "rank players",
+			mockRepo:        setupRatingRepoMock("GetRatings", []any{"1", []string{"3", "2", "4"}}, []any{ratings, nil}),
+			mockLeaderboard: setupLeaderboardRepoMock("Get", []any{"1"}, []any{ratingLeaderboard, nil}),
+			mockCache: setupRedisServiceMock(
+				"ZRevRangeWithScores",
+				[]any{"leaderboard:1:ranking", int64(0), int64(models.DefaultPageLimit - 1)},
+				[]any{ranked, nil},
+			),
+			expectedStatus: http.StatusOK,
+			expectedRanks:  []int{1, 2, 2},
+		},
+		{
+			// The first player on the page ties with the last one above it, so it continues their dense rank
+			name: "dense ranks on a later page",
+			mockRepo: setupRatingRepoMock(
+				"GetRatings",
+				[]any{"1", []string{"2", "4"}},
+				[]any{[]models.PlayerRating{ratings[0], ratings[2]}, nil},
+			),
+			mockLeaderboard: setupLeaderboardRepoMock(
+				"Get",
+				[]any{"1"},
+				[]any{&models.Leaderboard{ID: "1", Type: models.LeaderboardTypeRating, RankMode: models.RankModeDense}, nil},
+			),
+			mockCache: func() *mocks.MockRedisService {
+				mockCache := setupRedisServiceMock(
+					"ZRevRangeWithScores",
+					[]any{"leaderboard:1:ranking", int64(2), int64(3)},
+					[]any{[]cache.ScoredMember{{Member: "2", Score: 1200}, {Member: "4", Score: 1100}}, nil},
+				)
+				mockCache.On("ZCountDistinct", "leaderboard:1:ranking", "(1200", "+inf").Return(int64(1), nil)
+				return mockCache
+			}(),
+			query:          map[string]string{"offset": "2", "limit": "2"},
+			expectedStatus: http.StatusOK,
+			expectedRanks:  []int{2, 3},
+		},
+		{
+			name:     "score leaderboard",
+			mockRepo: &mocks.MockRatingRepo{},
+			mockLeaderboard: setupLeaderboardRepoMock(
+				"Get",
+				[]any{"1"},
+				[]any{&models.Leaderboard{ID: "1", Type: models.LeaderboardTypeScore}, nil},
+			),
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:            "unknown leaderboard",
+			mockRepo:        &mocks.MockRatingRepo{},
+			mockLeaderboard: setupLeaderboardRepoMock("Get", []any{"1"}, []any{&models.Leaderboard{}, storage.ErrNotFound}),
+			mockCache:       &mocks.MockRedisService{},
+			expectedStatus:  http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			rc := NewRatingController(testCase.mockRepo, testCase.mockLeaderboard, testCase.mockCache)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{rc.GetRatings},
+				requestOpts{params: map[string]string{"id": "1"}, query: testCase.query},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			if testCase.expectedRanks != nil {
+				var response struct {
+					Data []models.PlayerRating `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				ranks := make([]int, 0, len(response.Data))
+				for _, rating := range response.Data {
+					ranks = append(ranks, rating.Rank)
+				}
+				assert.Equal(t, testCase.expectedRanks, ranks)
+			}
+			testCase.mockRepo.AssertExpectations(t)
+			testCase.mockLeaderboard.AssertExpectations(t)
+			testCase.mockCache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRatingsGetHistory(t *testing.T) {
+
+	ratingLeaderboard := &models.Leaderboard{ID: "1", Type: models.LeaderboardTypeRating, RatingSystem: models.RatingSystemElo}
+	history := []models.RatingChange{{MatchID: "7", UserID: "2", RatingBefore: 1500, Rating: 1516}}
+
+	mockRepo := setupRatingRepoMock("GetHistory", []any{"1", "2", mock.AnythingOfType("*models.Pagination")}, []any{history, nil})
+	mockLeaderboard := setupLeaderboardRepoMock("Get", []any{"1"}, []any{ratingLeaderboard, nil})
+	rc := NewRatingController(mockRepo, mockLeaderboard, &mocks.MockRedisService{})
+
+	w := executeRequest(
+		[]gin.HandlerFunc{rc.GetHistory},
+		requestOpts{params: map[string]string{"id": "1", "userId": "2"}},
+	)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockRepo.AssertExpectations(t)
+	mockLeaderboard.AssertExpectations(t)
+}
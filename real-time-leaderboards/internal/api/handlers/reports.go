@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/api/problems"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/rankings"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	redis "github.com/mochivi/go-real-time-leaderboards/internal/storage/redis"
+)
+
+// Players report suspicious entries, moderators go through the reports
+// Entries reaching the threshold are flagged and held out of the ranking until reviewed
+type ReportController struct {
+	repo      storage.ReportRepo
+	rankings  rankings.Syncer
+	teams     rankings.Teams
+	threshold int
+}
+
+func NewReportController(
+	repo storage.ReportRepo,
+	leaderboardRepo storage.LeaderboardRepo,
+	teamRepo storage.TeamRepo,
+	redisService redis.RedisService,
+	threshold int,
+) ReportController {
+	return ReportController{
+		repo:      repo,
+		rankings:  rankings.NewSyncer(leaderboardRepo, redisService),
+		teams:     rankings.NewTeams(teamRepo, redisService),
+		threshold: threshold,
+	}
+}
+
+// Reports the entry on behalf of the signed in player, each player can report an entry once
+func (r ReportController) Create(c *gin.Context) {
+	entryID := c.Param("id")
+	if entryID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing leaderboard entry id"))
+		return
+	}
+
+	report := models.EntryReportRequest{}
+	if err := c.ShouldBindBodyWithJSON(&report); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid request body"))
+		return
+	}
+	if err := report.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+
+	userClaims, err := parseUserClaims(c)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+	report.EntryID = entryID
+	report.ReporterID = userClaims.UserID
+
+	result, err := r.repo.Create(c.Request.Context(), &report, r.threshold)
+	if err != nil {
+		problems.RenderError(c, err, "Report")
+		return
+	}
+
+	// The escalated entry is no longer ranked, the owner best score may come from another entry now
+	if result.Escalated {
+		if err := r.rankings.SyncUser(c.Request.Context(), result.OwnerID); err != nil {
+			log.Printf("Failed to update ranking: %v", err)
+		}
+		if err := r.teams.SyncUser(c.Request.Context(), result.OwnerID, ""); err != nil {
+			log.Printf("Failed to update team ranking: %v", err)
+		}
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    result.Report,
+		"message": "Report submitted",
+	})
+}
+
+// Returns the reported entries with the number of reports by reason, the most reported first
+func (r ReportController) List(c *gin.Context) {
+	filter := models.ReportFilter{}
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid query parameters"))
+		return
+	}
+	filter.Normalize()
+
+	entries, err := r.repo.GetReportedEntries(c.Request.Context(), &filter)
+	if err != nil {
+		problems.RenderError(c, err, "Report")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       entries,
+		"pagination": filter.Pagination,
+	})
+}
+
+// Returns the individual reports on an entry, including the details written by the players
+func (r ReportController) ListEntryReports(c *gin.Context) {
+	entryID := c.Param("id")
+	if entryID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing leaderboard entry id"))
+		return
+	}
+
+	page := models.Pagination{}
+	if err := c.ShouldBindQuery(&page); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid query parameters"))
+		return
+	}
+	page.Normalize()
+
+	reports, err := r.repo.GetEntryReports(c.Request.Context(), entryID, &page)
+	if err != nil {
+		problems.RenderError(c, err, "Report")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       reports,
+		"pagination": page,
+	})
+}
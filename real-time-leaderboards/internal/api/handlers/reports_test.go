@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
+	"github.com/mochivi/go-real-time-leaderboards/internal/mocks"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testReportThreshold = 3
+
+func TestReportsCreate(t *testing.T) {
+
+	cheatingReport := models.EntryReportRequest{Reason: models.ReportReasonCheating, Details: "Clipped through the wall"}
+	expectedReport := models.EntryReportRequest{
+		EntryID:    "10",
+		ReporterID: "1",
+		Reason:     cheatingReport.Reason,
+		Details:    cheatingReport.Details,
+	}
+
+	testCases := []struct {
+		name            string
+		mockRepo        *mocks.MockReportRepo
+		mockLeaderboard *mocks.MockLeaderboardsRepo
+		mockCache       *mocks.MockRedisService
+		expectedStatus  int
+		body            any
+	}{
+		{
+			name: "report entry",
+			mockRepo: setupReportRepoMock(
+				"Create",
+				[]any{&expectedReport, testReportThreshold},
+				[]any{&models.EntryReportResult{Report: models.EntryReport{ID: "1"}, Reports: 1, OwnerID: "2"}, nil},
+			),
+			mockLeaderboard: &mocks.MockLeaderboardsRepo{},
+			mockCache:       &mocks.MockRedisService{},
+			expectedStatus:  http.StatusCreated,
+			body:            cheatingReport,
+		},
+		{
+			// The escalated entry was the only ranked one, so the owner leaves the ranking
+			name: "report entry reaching the threshold",
+			mockRepo: setupReportRepoMock(
+				"Create",
+				[]any{&expectedReport, testReportThreshold},
+				[]any{&models.EntryReportResult{Report: models.EntryReport{ID: "3"}, Reports: 3, Escalated: true, OwnerID: "2"}, nil},
+			),
+			mockLeaderboard: setupLeaderboardRepoMock(
+				"GetRankedScores",
+				[]any{"2"},
+				[]any{[]models.RankedScore{{LeaderboardID: "1", Hidden: true}}, nil},
+			),
+			mockCache:      setupRedisServiceMock("ZRem", []any{"leaderboard:1:ranking", "2"}, []any{nil}),
+			expectedStatus: http.StatusCreated,
+			body:           cheatingReport,
+		},
+		{
+			name:            "report entry invalid reason",
+			mockRepo:        &mocks.MockReportRepo{},
+			mockLeaderboard: &mocks.MockLeaderboardsRepo{},
+			mockCache:       &mocks.MockRedisService{},
+			expectedStatus:  http.StatusBadRequest,
+			body:            models.EntryReportRequest{Reason: "bad_mood"},
+		},
+		{
+			name:            "report entry other reason without details",
+			mockRepo:        &mocks.MockReportRepo{},
+			mockLeaderboard: &mocks.MockLeaderboardsRepo{},
+			mockCache:       &mocks.MockRedisService{},
+			expectedStatus:  http.StatusBadRequest,
+			body:            models.EntryReportRequest{Reason: models.ReportReasonOther},
+		},
+		{
+			name: "report entry twice",
+			mockRepo: setupReportRepoMock(
+				"Create",
+				[]any{mock.Anything, testReportThreshold},
+				[]any{&models.EntryReportResult{}, storage.ErrConflict},
+			),
+			mockLeaderboard: &mocks.MockLeaderboardsRepo{},
+			mockCache:       &mocks.MockRedisService{},
+			expectedStatus:  http.StatusConflict,
+			body:            cheatingReport,
+		},
+		{
+			name: "report entry not found",
+			mockRepo: setupReportRepoMock(
+				"Create",
+				[]any{mock.Anything, testReportThreshold},
+				[]any{&models.EntryReportResult{}, storage.ErrNotFound},
+			),
+			mockLeaderboard: &mocks.MockLeaderboardsRepo{},
+			mockCache:       &mocks.MockRedisService{},
+			expectedStatus:  http.StatusNotFound,
+			body:            cheatingReport,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			rc := NewReportController(testCase.mockRepo, testCase.mockLeaderboard, setupNoTeamsMock(), testCase.mockCache, testReportThreshold)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAuthMiddleware(&auth.CustomClaims{UserID: "1", Role: "visitor"}),
+					rc.Create,
+				},
+				requestOpts{params: map[string]string{"id": "10"}, body: testCase.body},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+			testCase.mockLeaderboard.AssertExpectations(t)
+			testCase.mockCache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestReportsList(t *testing.T) {
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockReportRepo
+		expectedStatus int
+		query          map[string]string
+	}{
+		{
+			name: "list reported entries",
+			mockRepo: setupReportRepoMock(
+				"GetReportedEntries",
+				[]any{&models.ReportFilter{
+					LeaderboardID: "1",
+					MinReports:    2,
+					Pagination:    models.Pagination{Limit: models.DefaultPageLimit},
+				}},
+				[]any{[]models.ReportedEntry{{Reports: 2, Reasons: map[string]int{models.ReportReasonCheating: 2}}}, nil},
+			),
+			expectedStatus: http.StatusOK,
+			query:          map[string]string{"leaderboard_id": "1", "min_reports": "2"},
+		},
+		{
+			name:           "list reported entries invalid query",
+			mockRepo:       &mocks.MockReportRepo{},
+			expectedStatus: http.StatusBadRequest,
+			query:          map[string]string{"min_reports": "many"},
+		},
+		{
+			name:           "list reported entries repo failure",
+			mockRepo:       setupReportRepoMock("GetReportedEntries", []any{mock.Anything}, []any{[]models.ReportedEntry{}, ErrRepoOperation}),
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			rc := NewReportController(testCase.mockRepo, &mocks.MockLeaderboardsRepo{}, setupNoTeamsMock(), &mocks.MockRedisService{}, testReportThreshold)
+
+			w := executeRequest([]gin.HandlerFunc{rc.List}, requestOpts{query: testCase.query})
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+		})
+	}
+}
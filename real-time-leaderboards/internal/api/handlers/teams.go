@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/api/problems"
+	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/rankings"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	redis "github.com/mochivi/go-real-time-leaderboards/internal/storage/redis"
+)
+
+// Players create and join teams, which are ranked on leaderboards configured with a team aggregate
+type TeamController struct {
+	repo  storage.TeamRepo
+	teams rankings.Teams
+}
+
+func NewTeamController(repo storage.TeamRepo, redisService redis.RedisService) TeamController {
+	return TeamController{
+		repo:  repo,
+		teams: rankings.NewTeams(repo, redisService),
+	}
+}
+
+// Returns the team with its members
+func (t TeamController) Get(c *gin.Context) {
+	teamID := c.Param("id")
+	if teamID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing team id"))
+		return
+	}
+
+	team, err := t.repo.Get(c.Request.Context(), teamID)
+	if err != nil {
+		problems.RenderError(c, err, "Team")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": team,
+	})
+}
+
+// Creates a team owned by the signed in player, who must not be in a team already
+func (t TeamController) Create(c *gin.Context) {
+	userClaims, err := parseUserClaims(c)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	var request models.TeamRequest
+	if err := c.ShouldBindBodyWithJSON(&request); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid request body"))
+		return
+	}
+	if err := request.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+
+	team, err := t.repo.Create(c.Request.Context(), &request, userClaims.UserID)
+	if err != nil {
+		problems.RenderError(c, err, "Team")
+		return
+	}
+	t.syncTeam(c, team.ID)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    team,
+		"message": "Team created",
+	})
+}
+
+// Adds the signed in player to the team as a member
+func (t TeamController) Join(c *gin.Context) {
+	teamID := c.Param("id")
+	if teamID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing team id"))
+		return
+	}
+
+	userClaims, err := parseUserClaims(c)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	member, err := t.repo.Join(c.Request.Context(), teamID, userClaims.UserID)
+	if err != nil {
+		problems.RenderError(c, err, "Team")
+		return
+	}
+	t.syncTeam(c, teamID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    member,
+		"message": "Joined team",
+	})
+}
+
+// Removes the signed in player from the team, the owner can only leave once alone, which disbands the team
+func (t TeamController) Leave(c *gin.Context) {
+	teamID := c.Param("id")
+	if teamID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing team id"))
+		return
+	}
+
+	userClaims, err := parseUserClaims(c)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	if err := t.repo.Leave(c.Request.Context(), teamID, userClaims.UserID); err != nil {
+		problems.RenderError(c, err, "Team member")
+		return
+	}
+	t.syncTeam(c, teamID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Left team",
+	})
+}
+
+// Changes the role of a member, only the owner manages roles and making a member the owner hands
+// the team over to them
+func (t TeamController) SetRole(c *gin.Context) {
+	var request models.TeamRoleRequest
+	if err := c.ShouldBindBodyWithJSON(&request); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid request body"))
+		return
+	}
+	if err := request.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+
+	teamID, actor, target, ok := t.managedMember(c)
+	if !ok {
+		return
+	}
+	if actor.Role != models.TeamRoleOwner {
+		problems.RenderError(c, auth.ErrForbidden, "Team member")
+		return
+	}
+
+	member, err := t.repo.SetRole(c.Request.Context(), teamID, target.User.ID, request.Role)
+	if err != nil {
+		problems.RenderError(c, err, "Team member")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    member,
+		"message": "Team member role updated",
+	})
+}
+
+// Removes a member from the team, the owner can remove anyone else and officers can remove members
+func (t TeamController) RemoveMember(c *gin.Context) {
+	teamID, _, target, ok := t.managedMember(c)
+	if !ok {
+		return
+	}
+
+	if err := t.repo.RemoveMember(c.Request.Context(), teamID, target.User.ID); err != nil {
+		problems.RenderError(c, err, "Team member")
+		return
+	}
+	t.syncTeam(c, teamID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Team member removed",
+	})
+}
+
+// Loads the signed in player and the member in the path, and checks the player can manage that member
+// Renders the error and reports false when the request cannot go on
+func (t TeamController) managedMember(c *gin.Context) (string, *models.TeamMember, *models.TeamMember, bool) {
+	teamID, userID := c.Param("id"), c.Param("userId")
+	if teamID == "" || userID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing team or user id"))
+		return "", nil, nil, false
+	}
+
+	userClaims, err := parseUserClaims(c)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return "", nil, nil, false
+	}
+
+	// Outsiders cannot manage the team, whether or not it exists
+	actor, err := t.repo.GetMember(c.Request.Context(), teamID, userClaims.UserID)
+	if errors.Is(err, storage.ErrNotFound) {
+		err = auth.ErrForbidden
+	}
+	if err != nil {
+		problems.RenderError(c, err, "Team member")
+		return "", nil, nil, false
+	}
+
+	target, err := t.repo.GetMember(c.Request.Context(), teamID, userID)
+	if err != nil {
+		problems.RenderError(c, err, "Team member")
+		return "", nil, nil, false
+	}
+
+	if !actor.CanManage(*target) {
+		problems.RenderError(c, auth.ErrForbidden, "Team member")
+		return "", nil, nil, false
+	}
+
+	return teamID, actor, target, true
+}
+
+// The membership change is already committed, a stale team ranking is fixed by the next change
+func (t TeamController) syncTeam(c *gin.Context, teamID string) {
+	if err := t.teams.SyncTeam(c.Request.Context(), teamID, ""); err != nil {
+		log.Printf("Failed to sync team rankings: %v", err)
+	}
+}
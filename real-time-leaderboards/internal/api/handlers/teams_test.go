@@ -0,0 +1,259 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
+	"github.com/mochivi/go-real-time-leaderboards/internal/mocks"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// Membership changes sync the team on every leaderboard with a team ranking
+func setupTeamSyncMocks(repo *mocks.MockTeamRepo) *mocks.MockRedisService {
+	repo.On("GetTeamScores", "7", "").Return([]models.TeamScore{
+		{LeaderboardID: "1", TeamID: "7", Score: 300},
+		{LeaderboardID: "4", TeamID: "7", Hidden: true},
+	}, nil)
+
+	mockRedisService := setupRedisServiceMock("ZAdd", []any{"leaderboard:1:teams", "7", float64(300)}, []any{nil})
+	mockRedisService.On("ZRem", "leaderboard:4:teams", "7").Return(nil)
+	return mockRedisService
+}
+
+// Mocks the membership of the signed in player and of the member they manage
+func setupTeamMembersMock(actorRole, targetRole string) *mocks.MockTeamRepo {
+	mockRepo := setupTeamRepoMock(
+		"GetMember",
+		[]any{"7", "2"},
+		[]any{&models.TeamMember{User: models.User{ID: "2"}, Role: actorRole}, nil},
+	)
+	mockRepo.On("GetMember", "7", "3").Return(&models.TeamMember{User: models.User{ID: "3"}, Role: targetRole}, nil)
+	return mockRepo
+}
+
+func TestTeamsCreate(t *testing.T) {
+
+	createdRepo := setupTeamRepoMock(
+		"Create",
+		[]any{&models.TeamRequest{Name: "Speedrunners"}, "2"},
+		[]any{&models.Team{ID: "7", Name: "Speedrunners"}, nil},
+	)
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockTeamRepo
+		mockCache      *mocks.MockRedisService
+		body           any
+		expectedStatus int
+	}{
+		{
+			name:           "create team",
+			mockRepo:       createdRepo,
+			mockCache:      setupTeamSyncMocks(createdRepo),
+			body:           models.TeamRequest{Name: " Speedrunners "},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "missing name",
+			mockRepo:       &mocks.MockTeamRepo{},
+			mockCache:      &mocks.MockRedisService{},
+			body:           models.TeamRequest{Name: "  "},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "already in a team",
+			mockRepo: setupTeamRepoMock(
+				"Create",
+				[]any{&models.TeamRequest{Name: "Speedrunners"}, "2"},
+				[]any{&models.Team{}, storage.ErrConflict},
+			),
+			mockCache:      &mocks.MockRedisService{},
+			body:           models.TeamRequest{Name: "Speedrunners"},
+			expectedStatus: http.StatusConflict,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tc := NewTeamController(testCase.mockRepo, testCase.mockCache)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAuthMiddleware(&auth.CustomClaims{UserID: "2", Role: "visitor"}),
+					tc.Create,
+				},
+				requestOpts{body: testCase.body},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+			testCase.mockCache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTeamsLeave(t *testing.T) {
+
+	leftRepo := setupTeamRepoMock("Leave", []any{"7", "2"}, []any{nil})
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockTeamRepo
+		mockCache      *mocks.MockRedisService
+		expectedStatus int
+	}{
+		{
+			name:           "leave team",
+			mockRepo:       leftRepo,
+			mockCache:      setupTeamSyncMocks(leftRepo),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "owner leaves members behind",
+			mockRepo:       setupTeamRepoMock("Leave", []any{"7", "2"}, []any{storage.ErrStateConflict}),
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "not a member",
+			mockRepo:       setupTeamRepoMock("Leave", []any{"7", "2"}, []any{storage.ErrNotFound}),
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tc := NewTeamController(testCase.mockRepo, testCase.mockCache)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAuthMiddleware(&auth.CustomClaims{UserID: "2", Role: "visitor"}),
+					tc.Leave,
+				},
+				requestOpts{params: map[string]string{"id": "7"}},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+			testCase.mockCache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTeamsSetRole(t *testing.T) {
+
+	promotedRepo := setupTeamMembersMock(models.TeamRoleOwner, models.TeamRoleMember)
+	promotedRepo.On("SetRole", "7", "3", models.TeamRoleOfficer).
+		Return(&models.TeamMember{User: models.User{ID: "3"}, Role: models.TeamRoleOfficer}, nil)
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockTeamRepo
+		body           any
+		expectedStatus int
+	}{
+		{
+			name:           "owner promotes member",
+			mockRepo:       promotedRepo,
+			body:           models.TeamRoleRequest{Role: models.TeamRoleOfficer},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "officer cannot change roles",
+			mockRepo:       setupTeamMembersMock(models.TeamRoleOfficer, models.TeamRoleMember),
+			body:           models.TeamRoleRequest{Role: models.TeamRoleOfficer},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name: "outsider cannot change roles",
+			mockRepo: setupTeamRepoMock(
+				"GetMember",
+				[]any{"7", "2"},
+				[]any{&models.TeamMember{}, storage.ErrNotFound},
+			),
+			body:           models.TeamRoleRequest{Role: models.TeamRoleOfficer},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "unknown role",
+			mockRepo:       &mocks.MockTeamRepo{},
+			body:           models.TeamRoleRequest{Role: "captain"},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tc := NewTeamController(testCase.mockRepo, &mocks.MockRedisService{})
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAuthMiddleware(&auth.CustomClaims{UserID: "2", Role: "visitor"}),
+					tc.SetRole,
+				},
+				requestOpts{
+					params: map[string]string{"id": "7", "userId": "3"},
+					body:   testCase.body,
+				},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTeamsRemoveMember(t *testing.T) {
+
+	removedRepo := setupTeamMembersMock(models.TeamRoleOfficer, models.TeamRoleMember)
+	removedRepo.On("RemoveMember", "7", "3").Return(nil)
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockTeamRepo
+		mockCache      *mocks.MockRedisService
+		expectedStatus int
+	}{
+		{
+			name:           "officer removes member",
+			mockRepo:       removedRepo,
+			mockCache:      setupTeamSyncMocks(removedRepo),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "officer cannot remove officer",
+			mockRepo:       setupTeamMembersMock(models.TeamRoleOfficer, models.TeamRoleOfficer),
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "member cannot remove member",
+			mockRepo:       setupTeamMembersMock(models.TeamRoleMember, models.TeamRoleMember),
+			mockCache:      &mocks.MockRedisService{},
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tc := NewTeamController(testCase.mockRepo, testCase.mockCache)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAuthMiddleware(&auth.CustomClaims{UserID: "2", Role: "visitor"}),
+					tc.RemoveMember,
+				},
+				requestOpts{params: map[string]string{"id": "7", "userId": "3"}},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+			testCase.mockCache.AssertExpectations(t)
+		})
+	}
+}
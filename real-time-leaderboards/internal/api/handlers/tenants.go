@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/api/problems"
+	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+)
+
+// Tenants, their members and API keys are managed by the administrators of the deployment
+type TenantController struct {
+	repo storage.TenantRepo
+}
+
+func NewTenantController(repo storage.TenantRepo) TenantController {
+	return TenantController{
+		repo: repo,
+	}
+}
+
+func (t TenantController) Get(c *gin.Context) {
+	tenantID := c.Param("id")
+	if tenantID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing tenant id"))
+		return
+	}
+
+	tenant, err := t.repo.Get(c.Request.Context(), tenantID)
+	if err != nil {
+		problems.RenderError(c, err, "Tenant")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": tenant,
+	})
+}
+
+func (t TenantController) Create(c *gin.Context) {
+	request := models.TenantRequest{}
+	if err := c.ShouldBindBodyWithJSON(&request); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid request body"))
+		return
+	}
+	if err := request.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+
+	tenant, err := t.repo.Create(c.Request.Context(), &request)
+	if err != nil {
+		problems.RenderError(c, err, "Tenant")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    tenant,
+		"message": "Tenant created",
+	})
+}
+
+// Adds the user to the tenant with the given role, or changes the role of a member
+func (t TenantController) SetMember(c *gin.Context) {
+	tenantID, userID := c.Param("id"), c.Param("userId")
+	if tenantID == "" || userID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing tenant or user id"))
+		return
+	}
+
+	request := models.TenantMemberRequest{}
+	if err := c.ShouldBindBodyWithJSON(&request); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid request body"))
+		return
+	}
+	if err := request.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+	request.TenantID = tenantID
+	request.UserID = userID
+
+	member, err := t.repo.SetMember(c.Request.Context(), &request)
+	if err != nil {
+		problems.RenderError(c, err, "Tenant member")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    member,
+		"message": "Tenant member updated",
+	})
+}
+
+// Creates an API key for the tenant, the key itself is only returned by this endpoint
+func (t TenantController) CreateAPIKey(c *gin.Context) {
+	tenantID := c.Param("id")
+	if tenantID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing tenant id"))
+		return
+	}
+
+	request := models.APIKeyRequest{}
+	if err := c.ShouldBindBodyWithJSON(&request); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid request body"))
+		return
+	}
+	if err := request.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+
+	key, err := auth.NewAPIKey()
+	if err != nil {
+		log.Printf("Failed to generate API key: %v", err)
+		problems.Render(c, problems.Internal())
+		return
+	}
+	request.TenantID = tenantID
+	request.Prefix = key[:auth.APIKeyPrefixLength]
+
+	apiKey, err := t.repo.CreateAPIKey(c.Request.Context(), &request, auth.HashAPIKey(key))
+	if err != nil {
+		problems.RenderError(c, err, "Tenant")
+		return
+	}
+	apiKey.Key = key
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    apiKey,
+		"message": "API key created, it will not be shown again",
+	})
+}
+
+func (t TenantController) RevokeAPIKey(c *gin.Context) {
+	tenantID, keyID := c.Param("id"), c.Param("keyId")
+	if tenantID == "" || keyID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing tenant or API key id"))
+		return
+	}
+
+	if err := t.repo.RevokeAPIKey(c.Request.Context(), tenantID, keyID); err != nil {
+		problems.RenderError(c, err, "API key")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "API key revoked",
+	})
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
+	"github.com/mochivi/go-real-time-leaderboards/internal/mocks"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTenantsSetMember(t *testing.T) {
+
+	request := &models.TenantMemberRequest{TenantID: "4", UserID: "2", Role: models.TenantRoleAdmin}
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockTenantRepo
+		body           any
+		expectedStatus int
+	}{
+		{
+			name: "promote member",
+			mockRepo: setupTenantRepoMock(
+				"SetMember",
+				[]any{request},
+				[]any{&models.TenantMember{TenantID: "4", UserID: "2", Role: models.TenantRoleAdmin}, nil},
+			),
+			body:           models.TenantMemberRequest{Role: models.TenantRoleAdmin},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "unknown role",
+			mockRepo:       &mocks.MockTenantRepo{},
+			body:           models.TenantMemberRequest{Role: "moderator"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "unknown user",
+			mockRepo: setupTenantRepoMock(
+				"SetMember",
+				[]any{request},
+				[]any{&models.TenantMember{}, storage.ErrInvalidReference},
+			),
+			body:           models.TenantMemberRequest{Role: models.TenantRoleAdmin},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tc := NewTenantController(testCase.mockRepo)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAdminMiddleware(&auth.CustomClaims{UserID: "1", Role: "administrator"}),
+					tc.SetMember,
+				},
+				requestOpts{params: map[string]string{"id": "4", "userId": "2"}, body: testCase.body},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTenantsCreateAPIKey(t *testing.T) {
+
+	matchesRequest := mock.MatchedBy(func(request *models.APIKeyRequest) bool {
+		return request.TenantID == "4" && request.Name == "game server" && strings.HasPrefix(request.Prefix, "lb_")
+	})
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockTenantRepo
+		body           any
+		expectedStatus int
+	}{
+		{
+			name: "create key",
+			mockRepo: setupTenantRepoMock(
+				"CreateAPIKey",
+				[]any{matchesRequest, mock.AnythingOfType("string")},
+				[]any{&models.APIKey{ID: "9", TenantID: "4", Name: "game server"}, nil},
+			),
+			body:           models.APIKeyRequest{Name: " game server "},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "missing name",
+			mockRepo:       &mocks.MockTenantRepo{},
+			body:           models.APIKeyRequest{},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tc := NewTenantController(testCase.mockRepo)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAdminMiddleware(&auth.CustomClaims{UserID: "1", Role: "administrator"}),
+					tc.CreateAPIKey,
+				},
+				requestOpts{params: map[string]string{"id": "4"}, body: testCase.body},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			if w.Code == http.StatusCreated {
+				assert.Contains(t, w.Body.String(), `"key":"lb_`)
+			}
+			testCase.mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTenantsRevokeAPIKey(t *testing.T) {
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockTenantRepo
+		expectedStatus int
+	}{
+		{
+			name:           "revoke key",
+			mockRepo:       setupTenantRepoMock("RevokeAPIKey", []any{"4", "9"}, []any{nil}),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "key already revoked",
+			mockRepo:       setupTenantRepoMock("RevokeAPIKey", []any{"4", "9"}, []any{storage.ErrNotFound}),
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tc := NewTenantController(testCase.mockRepo)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAdminMiddleware(&auth.CustomClaims{UserID: "1", Role: "administrator"}),
+					tc.RevokeAPIKey,
+				},
+				requestOpts{params: map[string]string{"id": "4", "keyId": "9"}},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/api/problems"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+)
+
+// Tournaments are set up and run by administrators, their brackets are public
+// Brackets seeded from a private leaderboard are only shown to its members
+type TournamentController struct {
+	repo    storage.TournamentRepo
+	members storage.MemberRepo
+}
+
+func NewTournamentController(repo storage.TournamentRepo, members storage.MemberRepo) TournamentController {
+	return TournamentController{
+		repo:    repo,
+		members: members,
+	}
+}
+
+// Returns the tournament with its participants and the state of its bracket
+// Tournaments of private leaderboards are reported as not found to non members, like the leaderboards themselves
+func (t TournamentController) Get(c *gin.Context) {
+	tournamentID := c.Param("id")
+	if tournamentID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing tournament id"))
+		return
+	}
+
+	tournament, err := t.repo.Get(c.Request.Context(), tournamentID)
+	if err != nil {
+		problems.RenderError(c, err, "Tournament")
+		return
+	}
+
+	if userClaims, err := parseUserClaims(c); err != nil || userClaims.Role != "administrator" {
+		access, err := t.members.GetAccess(c.Request.Context(), tournament.LeaderboardID, viewerID(c))
+		if err != nil {
+			problems.RenderError(c, err, "Tournament")
+			return
+		}
+		if !access.CanRead() {
+			problems.Render(c, problems.NotFound("Tournament not found"))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": tournament,
+	})
+}
+
+// Creates a tournament that qualifies the top players of a leaderboard once started
+func (t TournamentController) Create(c *gin.Context) {
+	request := models.TournamentRequest{}
+	if err := c.ShouldBindBodyWithJSON(&request); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid request body"))
+		return
+	}
+	if err := request.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+
+	tournament, err := t.repo.Create(c.Request.Context(), &request)
+	if err != nil {
+		problems.RenderError(c, err, "Tournament")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"data":    tournament,
+		"message": "Tournament created",
+	})
+}
+
+// Closes qualification, seeding the current top players of the leaderboard into the bracket
+func (t TournamentController) Start(c *gin.Context) {
+	tournamentID := c.Param("id")
+	if tournamentID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing tournament id"))
+		return
+	}
+
+	actor, err := auditActor(c)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	tournament, err := t.repo.Start(c.Request.Context(), tournamentID, actor)
+	if err != nil {
+		problems.RenderError(c, err, "Tournament")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    tournament,
+		"message": "Tournament started",
+	})
+}
+
+// Records the result of a match, the winner advances and in double elimination the loser drops
+// to the losers bracket
+func (t TournamentController) RecordResult(c *gin.Context) {
+	tournamentID := c.Param("id")
+	if tournamentID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing tournament id"))
+		return
+	}
+
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil {
+		problems.Render(c, problems.InvalidRequest("Match number must be an integer"))
+		return
+	}
+
+	result := models.MatchResult{}
+	if err := c.ShouldBindBodyWithJSON(&result); err != nil {
+		problems.Render(c, problems.InvalidRequest("Invalid request body"))
+		return
+	}
+	if err := result.Validate(); err != nil {
+		problems.Render(c, problems.InvalidRequest(err.Error()))
+		return
+	}
+
+	actor, err := auditActor(c)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	tournament, err := t.repo.RecordResult(c.Request.Context(), tournamentID, number, &result, actor)
+	if err != nil {
+		problems.RenderError(c, err, "Match")
+		return
+	}
+
+	message := "Match result recorded"
+	if tournament.Status == models.TournamentStatusCompleted {
+		message = "Match result recorded, tournament completed"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    tournament,
+		"message": message,
+	})
+}
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
+	"github.com/mochivi/go-real-time-leaderboards/internal/mocks"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTournamentsGet(t *testing.T) {
+
+	tournament := &models.Tournament{ID: "5", LeaderboardID: "1", Status: models.TournamentStatusPending}
+
+	testCases := []struct {
+		name           string
+		mockMembers    *mocks.MockMemberRepo
+		claims         *auth.CustomClaims
+		expectedStatus int
+	}{
+		{
+			name: "public leaderboard",
+			mockMembers: setupMemberRepoMock(
+				"GetAccess",
+				[]any{"1", ""},
+				[]any{&models.LeaderboardAccess{Visibility: models.VisibilityPublic}, nil},
+			),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "private leaderboard member",
+			mockMembers: setupMemberRepoMock(
+				"GetAccess",
+				[]any{"1", "2"},
+				[]any{&models.LeaderboardAccess{Visibility: models.VisibilityPrivate, Member: true}, nil},
+			),
+			claims:         &auth.CustomClaims{UserID: "2", Role: "visitor"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "private leaderboard non member",
+			mockMembers: setupMemberRepoMock(
+				"GetAccess",
+				[]any{"1", "3"},
+				[]any{&models.LeaderboardAccess{Visibility: models.VisibilityPrivate}, nil},
+			),
+			claims:         &auth.CustomClaims{UserID: "3", Role: "visitor"},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "administrator",
+			mockMembers:    &mocks.MockMemberRepo{},
+			claims:         &auth.CustomClaims{UserID: "1", Role: "administrator"},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			mockRepo := setupTournamentRepoMock("Get", []any{"5"}, []any{tournament, nil})
+			tc := NewTournamentController(mockRepo, testCase.mockMembers)
+
+			handlers := []gin.HandlerFunc{tc.Get}
+			if testCase.claims != nil {
+				handlers = append([]gin.HandlerFunc{mocks.MockValidateAuthMiddleware(testCase.claims)}, handlers...)
+			}
+			w := executeRequest(handlers, requestOpts{params: map[string]string{"id": "5"}})
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			mockRepo.AssertExpectations(t)
+			testCase.mockMembers.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTournamentsCreate(t *testing.T) {
+
+	request := &models.TournamentRequest{
+		Name:          "Spring Cup",
+		LeaderboardID: "1",
+		Format:        models.TournamentFormatDoubleElimination,
+		Size:          8,
+	}
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockTournamentRepo
+		body           any
+		expectedStatus int
+	}{
+		{
+			name: "create tournament",
+			mockRepo: setupTournamentRepoMock(
+				"Create",
+				[]any{request},
+				[]any{&models.Tournament{ID: "5", Status: models.TournamentStatusPending}, nil},
+			),
+			body:           request,
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:     "unknown format",
+			mockRepo: &mocks.MockTournamentRepo{},
+			body: models.TournamentRequest{
+				Name:          "Spring Cup",
+				LeaderboardID: "1",
+				Format:        "swiss",
+				Size:          8,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:     "too few qualifiers",
+			mockRepo: &mocks.MockTournamentRepo{},
+			body: models.TournamentRequest{
+				Name:          "Spring Cup",
+				LeaderboardID: "1",
+				Format:        models.TournamentFormatRoundRobin,
+				Size:          1,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "unknown leaderboard",
+			mockRepo: setupTournamentRepoMock(
+				"Create",
+				[]any{request},
+				[]any{&models.Tournament{}, storage.ErrInvalidReference},
+			),
+			body:           request,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tc := NewTournamentController(testCase.mockRepo, &mocks.MockMemberRepo{})
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAdminMiddleware(&auth.CustomClaims{UserID: "1", Role: "administrator"}),
+					tc.Create,
+				},
+				requestOpts{body: testCase.body},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTournamentsStart(t *testing.T) {
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockTournamentRepo
+		expectedStatus int
+	}{
+		{
+			name: "start tournament",
+			mockRepo: setupTournamentRepoMock(
+				"Start",
+				[]any{"5", actorIs("1")},
+				[]any{&models.Tournament{ID: "5", Status: models.TournamentStatusRunning}, nil},
+			),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "already started or not enough qualifiers",
+			mockRepo: setupTournamentRepoMock(
+				"Start",
+				[]any{"5", actorIs("1")},
+				[]any{&models.Tournament{}, storage.ErrStateConflict},
+			),
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "unknown tournament",
+			mockRepo: setupTournamentRepoMock(
+				"Start",
+				[]any{"5", actorIs("1")},
+				[]any{&models.Tournament{}, storage.ErrNotFound},
+			),
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tc := NewTournamentController(testCase.mockRepo, &mocks.MockMemberRepo{})
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAdminMiddleware(&auth.CustomClaims{UserID: "1", Role: "administrator"}),
+					tc.Start,
+				},
+				requestOpts{params: map[string]string{"id": "5"}},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTournamentsRecordResult(t *testing.T) {
+
+	three, one := 3, 1
+	result := &models.MatchResult{WinnerID: "2", Player1Score: &three, Player2Score: &one}
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockTournamentRepo
+		number         string
+		body           any
+		expectedStatus int
+	}{
+		{
+			name: "record result",
+			mockRepo: setupTournamentRepoMock(
+				"RecordResult",
+				[]any{"5", 0, result, actorIs("1")},
+				[]any{&models.Tournament{ID: "5", Status: models.TournamentStatusRunning}, nil},
+			),
+			number:         "0",
+			body:           result,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "match number not an integer",
+			mockRepo:       &mocks.MockTournamentRepo{},
+			number:         "final",
+			body:           result,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "score of a single player",
+			mockRepo:       &mocks.MockTournamentRepo{},
+			number:         "0",
+			body:           models.MatchResult{WinnerID: "2", Player1Score: &three},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "match not ready",
+			mockRepo: setupTournamentRepoMock(
+				"RecordResult",
+				[]any{"5", 4, result, actorIs("1")},
+				[]any{&models.Tournament{}, storage.ErrStateConflict},
+			),
+			number:         "4",
+			body:           result,
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "winner not in the match",
+			mockRepo: setupTournamentRepoMock(
+				"RecordResult",
+				[]any{"5", 0, result, actorIs("1")},
+				[]any{&models.Tournament{}, storage.ErrInvalidReference},
+			),
+			number:         "0",
+			body:           result,
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tc := NewTournamentController(testCase.mockRepo, &mocks.MockMemberRepo{})
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAdminMiddleware(&auth.CustomClaims{UserID: "1", Role: "administrator"}),
+					tc.RecordResult,
+				},
+				requestOpts{
+					params: map[string]string{"id": "5", "number": testCase.number},
+					body:   testCase.body,
+				},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+		})
+	}
+}
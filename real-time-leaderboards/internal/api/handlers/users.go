@@ -1,21 +1,35 @@
 package handlers
 
 import (
+	"fmt"
 	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/api/problems"
+	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
 	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/rankings"
 	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	redis "github.com/mochivi/go-real-time-leaderboards/internal/storage/redis"
 )
 
 type UserController struct {
-	repo storage.UserRepo
+	repo     storage.UserRepo
+	rankings rankings.Syncer
+	teams    rankings.Teams
 }
 
-func NewUserController(repo storage.UserRepo) UserController {
+func NewUserController(
+	repo storage.UserRepo,
+	leaderboardRepo storage.LeaderboardRepo,
+	teamRepo storage.TeamRepo,
+	redisService redis.RedisService,
+) UserController {
 	return UserController{
-		repo: repo,
+		repo:     repo,
+		rankings: rankings.NewSyncer(leaderboardRepo, redisService),
+		teams:    rankings.NewTeams(teamRepo, redisService),
 	}
 }
 
@@ -24,23 +38,22 @@ func (u UserController) Register(c *gin.Context) {
 
 	var registerUser models.RegisterUser
 	if err := c.ShouldBindBodyWithJSON(&registerUser); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{})
+		problems.Render(c, problems.InvalidRequest("Invalid request body"))
 		return
 	}
 
 	// hash the provided password
 	passwordHash, err := registerUser.HashPassword()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{})
+		log.Printf("Failed to hash password: %v", err)
+		problems.Render(c, problems.Internal())
 		return
 	}
 
 	// add user to database
 	user, err := u.repo.Create(c.Request.Context(), &registerUser, passwordHash)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Database error",
-		})
+		problems.RenderError(c, err, "User")
 		return
 	}
 
@@ -50,25 +63,40 @@ func (u UserController) Register(c *gin.Context) {
 	})
 }
 
+// Returns the public profile of a user, with the stats of their ranked entries
+func (u UserController) Profile(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing user id"))
+		return
+	}
+
+	// Banned and shadowbanned users still see their own entries
+	profile, err := u.repo.GetProfile(c.Request.Context(), userID, viewerID(c))
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data": profile,
+	})
+}
+
+// Returns the whole account of a user, including private fields, restricted to administrators
 func (u UserController) Get(c *gin.Context) {
 
 	// Get id from request
 	userID, ok := c.Params.Get("id")
 	if !ok || userID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "ID not provided in the request",
-			"message": "Bad request",
-		})
+		problems.Render(c, problems.InvalidRequest("Missing user id"))
 		return
 	}
 
 	// Request user from db
 	user, err := u.repo.GetByID(c.Request.Context(), userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error":   "User not found by ID",
-			"message": "User not found",
-		})
+		problems.RenderError(c, err, "User")
 		return
 	}
 
@@ -83,41 +111,34 @@ func (u UserController) Update(c *gin.Context) {
 	// Receive data
 	var updateUser models.UpdateUser
 	if err := c.ShouldBindBodyWithJSON(&updateUser); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{})
+		problems.Render(c, problems.InvalidRequest("Invalid request body"))
 		return
 	}
 
-	// Get user claims
-	userClaims, err := parseUserClaims(c)
+	// Get user claims, the actor is recorded in the audit log
+	actor, err := auditActor(c)
 	if err != nil {
 		log.Printf("Failed to parse user claims from context: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		problems.RenderError(c, err, "User")
 		return
 	}
 
 	// Validate user can update the provided user
-	if userClaims.UserID != updateUser.ID && userClaims.Role != "administrator" {
+	if actor.UserID != updateUser.ID && actor.Role != "administrator" {
 		log.Printf(
 			"User with claimed ID '%s' and role '%s' tried updating user of ID '%s'",
-			userClaims.UserID,
-			userClaims.Role,
+			actor.UserID,
+			actor.Role,
 			updateUser.ID,
 		)
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"message": "Not enough priviliges for this operation",
-		})
+		problems.RenderError(c, auth.ErrForbidden, "User")
 		return
 	}
 
 	// Update user to database
-	user, err := u.repo.Update(c.Request.Context(), &updateUser)
+	user, err := u.repo.Update(c.Request.Context(), &updateUser, actor)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   err.Error(),
-			"message": "Database error",
-		})
+		problems.RenderError(c, err, "User")
 		return
 	}
 
@@ -131,40 +152,133 @@ func (u UserController) Delete(c *gin.Context) {
 
 	// Get id from request
 	userID, ok := c.Params.Get("id")
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "ID not provided in the request",
-			"message": "Bad request",
-		})
+	if !ok || userID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing user id"))
 		return
 	}
 
-	// Get user claims
-	userClaims, err := parseUserClaims(c)
+	// Get user claims, the actor is recorded in the audit log
+	actor, err := auditActor(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		problems.RenderError(c, err, "User")
 		return
 	}
 
 	// Validate user can delete the provided user
-	if userClaims.UserID != userID && userClaims.Role != "administrator" {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"message": "Not enough priviliges for this operation",
-		})
+	if actor.UserID != userID && actor.Role != "administrator" {
+		problems.RenderError(c, auth.ErrForbidden, "User")
 		return
 	}
 
-	if err := u.repo.Delete(c.Request.Context(), userID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   err.Error(),
-			"message": "Could not delete user",
-		})
+	if err := u.repo.Delete(c.Request.Context(), userID, actor); err != nil {
+		problems.RenderError(c, err, "User")
 		return
 	}
 
+	// The entries of the user are deleted with them, a failed sync only leaves the Redis rankings stale
+	if err := u.rankings.SyncUser(c.Request.Context(), userID); err != nil {
+		log.Printf("Failed to remove deleted user from rankings: %v", err)
+	}
+	if err := u.teams.SyncUser(c.Request.Context(), userID, ""); err != nil {
+		log.Printf("Failed to update team rankings of deleted user: %v", err)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "User deleted",
 	})
 }
+
+// Brings back a soft deleted user with the entries deleted alongside them
+func (u UserController) Restore(c *gin.Context) {
+	userID := c.Param("id")
+	if userID == "" {
+		problems.Render(c, problems.InvalidRequest("Missing user id"))
+		return
+	}
+
+	actor, err := auditActor(c)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	user, err := u.repo.Restore(c.Request.Context(), userID, actor)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	if err := u.rankings.SyncUser(c.Request.Context(), userID); err != nil {
+		log.Printf("Failed to restore rankings of user: %v", err)
+	}
+	if err := u.teams.SyncUser(c.Request.Context(), userID, ""); err != nil {
+		log.Printf("Failed to update team rankings of restored user: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    user,
+		"message": "User restored",
+	})
+}
+
+// Returns everything stored about the authenticated user as a downloadable JSON archive
+func (u UserController) Export(c *gin.Context) {
+	userClaims, err := parseUserClaims(c)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	export, err := u.repo.Export(c.Request.Context(), userClaims.UserID)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	// Tokens are stateless, the claims of the request are all that is known about the session
+	session := models.Session{Current: true}
+	if userClaims.IssuedAt != nil {
+		session.IssuedAt = &userClaims.IssuedAt.Time
+	}
+	if userClaims.ExpiresAt != nil {
+		session.ExpiresAt = &userClaims.ExpiresAt.Time
+	}
+	export.Sessions = []models.Session{session}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%s-export.json"`, userClaims.UserID))
+	c.JSON(http.StatusOK, gin.H{
+		"data": export,
+	})
+}
+
+// Erases the personal data of a user, either the authenticated user or, on the admin route, the user in the path
+// Their entries stay ranked under a placeholder identity
+func (u UserController) Erase(c *gin.Context) {
+	actor, err := auditActor(c)
+	if err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	userID := c.Param("id")
+	if userID == "" {
+		userID = actor.UserID
+	}
+
+	if err := u.repo.Erase(c.Request.Context(), userID, actor); err != nil {
+		problems.RenderError(c, err, "User")
+		return
+	}
+
+	// Rankings are keyed by user id, which the placeholder identity keeps, the sync drops any stale score
+	if err := u.rankings.SyncUser(c.Request.Context(), userID); err != nil {
+		log.Printf("Failed to sync rankings of erased user: %v", err)
+	}
+	if err := u.teams.SyncUser(c.Request.Context(), userID, ""); err != nil {
+		log.Printf("Failed to sync team rankings of erased user: %v", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "User data erased",
+	})
+}
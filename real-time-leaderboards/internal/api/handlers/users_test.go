@@ -8,6 +8,7 @@ import (
 	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
 	"github.com/mochivi/go-real-time-leaderboards/internal/mocks"
 	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -40,10 +41,20 @@ func TestUsersGet(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:           "get user db not found",
+			mockRepo:       setupUserRepoMock("GetByID", []any{"1"}, []any{&models.User{}, storage.ErrNotFound}),
+			expectedStatus: http.StatusNotFound,
+			requestOpts: requestOpts{
+				params: map[string]string{
+					"id": "1",
+				},
+			},
+		},
 		{
 			name:           "get user db error",
 			mockRepo:       setupUserRepoMock("GetByID", []any{"1"}, []any{&models.User{}, ErrRepoOperation}),
-			expectedStatus: http.StatusNotFound,
+			expectedStatus: http.StatusInternalServerError,
 			requestOpts: requestOpts{
 				params: map[string]string{
 					"id": "1",
@@ -56,7 +67,7 @@ func TestUsersGet(t *testing.T) {
 		t.Run(testCase.name, func(t *testing.T) {
 
 			// Recreate controller with new mock on every testcase
-			uc := NewUserController(testCase.mockRepo)
+			uc := NewUserController(testCase.mockRepo, &mocks.MockLeaderboardsRepo{}, setupNoTeamsMock(), &mocks.MockRedisService{})
 
 			// Execute request and received recorded and decoded response
 			w := executeRequest(
@@ -73,6 +84,56 @@ func TestUsersGet(t *testing.T) {
 	}
 }
 
+func TestUsersProfile(t *testing.T) {
+
+	profile := &models.PlayerProfile{
+		ID:        "2",
+		Username:  "player",
+		Stats:     models.PlayerStats{LeaderboardsPlayed: 2, TotalSubmissions: 5, WorldRecords: 1},
+		BestRanks: []models.BoardRank{{LeaderboardID: "1", LeaderboardName: "Any%", BestScore: 90, Rank: 1}},
+	}
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockUserRepo
+		viewer         *auth.CustomClaims
+		expectedStatus int
+	}{
+		{
+			name:           "anonymous viewer",
+			mockRepo:       setupUserRepoMock("GetProfile", []any{"2", ""}, []any{profile, nil}),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "player viewing their own profile",
+			mockRepo:       setupUserRepoMock("GetProfile", []any{"2", "2"}, []any{profile, nil}),
+			viewer:         &auth.CustomClaims{UserID: "2", Role: "visitor"},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "profile not found",
+			mockRepo:       setupUserRepoMock("GetProfile", []any{"2", ""}, []any{&models.PlayerProfile{}, storage.ErrNotFound}),
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			uc := NewUserController(testCase.mockRepo, &mocks.MockLeaderboardsRepo{}, setupNoTeamsMock(), &mocks.MockRedisService{})
+
+			handlers := []gin.HandlerFunc{uc.Profile}
+			if testCase.viewer != nil {
+				handlers = append([]gin.HandlerFunc{mocks.MockValidateAuthMiddleware(testCase.viewer)}, handlers...)
+			}
+			w := executeRequest(handlers, requestOpts{params: map[string]string{"id": "2"}})
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			assert.NotContains(t, w.Body.String(), "email")
+			testCase.mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
 func TestUsersRegister(t *testing.T) {
 
 	// Create request body
@@ -95,6 +156,12 @@ func TestUsersRegister(t *testing.T) {
 			expectedStatus: http.StatusCreated,
 			requestOpts:    requestOpts{body: registerUser},
 		},
+		{
+			name:           "register user db conflict",
+			mockRepo:       setupUserRepoMock("Create", []any{&registerUser}, []any{&models.User{}, storage.ErrConflict}),
+			expectedStatus: http.StatusConflict,
+			requestOpts:    requestOpts{body: registerUser},
+		},
 		{
 			name:           "register user db error",
 			mockRepo:       setupUserRepoMock("Create", []any{&registerUser}, []any{&models.User{}, ErrRepoOperation}),
@@ -105,7 +172,7 @@ func TestUsersRegister(t *testing.T) {
 
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
-			uc := NewUserController(testCase.mockRepo)
+			uc := NewUserController(testCase.mockRepo, &mocks.MockLeaderboardsRepo{}, setupNoTeamsMock(), &mocks.MockRedisService{})
 
 			// Execute request and received recorded and decoded response
 			w := executeRequest(
@@ -121,6 +188,13 @@ func TestUsersRegister(t *testing.T) {
 	}
 }
 
+// The audit actor must carry the claims of the user making the request
+func actorIs(userID string) any {
+	return mock.MatchedBy(func(actor *models.AuditActor) bool {
+		return actor.UserID == userID
+	})
+}
+
 func TestUsersUpdate(t *testing.T) {
 
 	// Setup test cases
@@ -134,7 +208,7 @@ func TestUsersUpdate(t *testing.T) {
 	}{
 		{
 			name:           "succesful update user",
-			mockRepo:       setupUserRepoMock("Update", []any{mock.AnythingOfType("*models.UpdateUser")}, []any{&models.User{ID: "1"}, nil}),
+			mockRepo:       setupUserRepoMock("Update", []any{mock.AnythingOfType("*models.UpdateUser"), actorIs("1")}, []any{&models.User{ID: "1"}, nil}),
 			userID:         "1", // The ID of the user making the request
 			userRole:       "visitor",
 			expectedStatus: http.StatusCreated,
@@ -147,7 +221,7 @@ func TestUsersUpdate(t *testing.T) {
 		},
 		{
 			name:           "admin update another user",
-			mockRepo:       setupUserRepoMock("Update", []any{mock.AnythingOfType("*models.UpdateUser")}, []any{&models.User{ID: "3"}, nil}),
+			mockRepo:       setupUserRepoMock("Update", []any{mock.AnythingOfType("*models.UpdateUser"), actorIs("1")}, []any{&models.User{ID: "3"}, nil}),
 			userID:         "1",
 			userRole:       "administrator",
 			expectedStatus: http.StatusCreated,
@@ -163,7 +237,7 @@ func TestUsersUpdate(t *testing.T) {
 			mockRepo:       &mocks.MockUserRepo{},
 			userID:         "1",
 			userRole:       "visitor",
-			expectedStatus: http.StatusUnauthorized,
+			expectedStatus: http.StatusForbidden,
 			requestOpts: requestOpts{body: models.UpdateUser{
 				ID:       "3",
 				Username: "New username",
@@ -175,7 +249,7 @@ func TestUsersUpdate(t *testing.T) {
 			name: "update user db error",
 			mockRepo: setupUserRepoMock(
 				"Update",
-				[]any{mock.AnythingOfType("*models.UpdateUser")},
+				[]any{mock.AnythingOfType("*models.UpdateUser"), mock.AnythingOfType("*models.AuditActor")},
 				[]any{new(models.User), ErrRepoOperation},
 			),
 			userID:         "1",
@@ -192,7 +266,7 @@ func TestUsersUpdate(t *testing.T) {
 
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
-			uc := NewUserController(testCase.mockRepo)
+			uc := NewUserController(testCase.mockRepo, &mocks.MockLeaderboardsRepo{}, setupNoTeamsMock(), &mocks.MockRedisService{})
 
 			// Execute request and received recorded and decoded response
 			w := executeRequest(
@@ -214,53 +288,87 @@ func TestUsersUpdate(t *testing.T) {
 	}
 }
 
+// Rankings sync of a user whose only score is hidden, which removes them from the ranking
+func setupRankingRemovalMock(userID string) (*mocks.MockLeaderboardsRepo, *mocks.MockRedisService) {
+	mockLeaderboard := setupLeaderboardRepoMock(
+		"GetRankedScores",
+		[]any{userID},
+		[]any{[]models.RankedScore{{LeaderboardID: "1", Score: 10, Hidden: true}}, nil},
+	)
+	mockCache := setupRedisServiceMock("ZRem", []any{"leaderboard:1:ranking", userID}, []any{nil})
+	return mockLeaderboard, mockCache
+}
+
 func TestUsersDelete(t *testing.T) {
 
+	ownLeaderboard, ownCache := setupRankingRemovalMock("1")
+	otherLeaderboard, otherCache := setupRankingRemovalMock("3")
+
 	testCases := []struct {
-		name           string
-		mockRepo       *mocks.MockUserRepo
-		userID         string
-		userRole       string
-		expectedStatus int
-		requestOpts    requestOpts
+		name            string
+		mockRepo        *mocks.MockUserRepo
+		mockLeaderboard *mocks.MockLeaderboardsRepo
+		mockCache       *mocks.MockRedisService
+		userID          string
+		userRole        string
+		expectedStatus  int
+		requestOpts     requestOpts
 	}{
 		{
-			name:           "succesful delete user",
-			mockRepo:       setupUserRepoMock("Delete", []any{"1"}, []any{nil}),
-			userID:         "1", // The ID of the user making the request
-			userRole:       "visitor",
-			expectedStatus: http.StatusOK,
-			requestOpts:    requestOpts{params: map[string]string{"id": "1"}},
+			name:            "succesful delete user",
+			mockRepo:        setupUserRepoMock("Delete", []any{"1", actorIs("1")}, []any{nil}),
+			mockLeaderboard: ownLeaderboard,
+			mockCache:       ownCache,
+			userID:          "1", // The ID of the user making the request
+			userRole:        "visitor",
+			expectedStatus:  http.StatusOK,
+			requestOpts:     requestOpts{params: map[string]string{"id": "1"}},
 		},
 		{
-			name:           "admin delete another user",
-			mockRepo:       setupUserRepoMock("Delete", []any{"3"}, []any{nil}),
-			userID:         "1",
-			userRole:       "administrator",
-			expectedStatus: http.StatusOK,
-			requestOpts:    requestOpts{params: map[string]string{"id": "3"}},
+			name:            "admin delete another user",
+			mockRepo:        setupUserRepoMock("Delete", []any{"3", actorIs("1")}, []any{nil}),
+			mockLeaderboard: otherLeaderboard,
+			mockCache:       otherCache,
+			userID:          "1",
+			userRole:        "administrator",
+			expectedStatus:  http.StatusOK,
+			requestOpts:     requestOpts{params: map[string]string{"id": "3"}},
 		},
 		{
-			name:           "error delete another user",
-			mockRepo:       &mocks.MockUserRepo{},
-			userID:         "1",
-			userRole:       "visitor",
-			expectedStatus: http.StatusUnauthorized,
-			requestOpts:    requestOpts{params: map[string]string{"id": "3"}},
+			name:            "error delete another user",
+			mockRepo:        &mocks.MockUserRepo{},
+			mockLeaderboard: &mocks.MockLeaderboardsRepo{},
+			mockCache:       &mocks.MockRedisService{},
+			userID:          "1",
+			userRole:        "visitor",
+			expectedStatus:  http.StatusForbidden,
+			requestOpts:     requestOpts{params: map[string]string{"id": "3"}},
 		},
 		{
-			name:           "update user db error",
-			mockRepo:       setupUserRepoMock("Delete", []any{"1"}, []any{ErrRepoOperation}),
-			userID:         "1",
-			userRole:       "visitor",
-			expectedStatus: http.StatusInternalServerError,
-			requestOpts:    requestOpts{params: map[string]string{"id": "1"}},
+			name:            "delete user not found",
+			mockRepo:        setupUserRepoMock("Delete", []any{"3", actorIs("1")}, []any{storage.ErrNotFound}),
+			mockLeaderboard: &mocks.MockLeaderboardsRepo{},
+			mockCache:       &mocks.MockRedisService{},
+			userID:          "1",
+			userRole:        "administrator",
+			expectedStatus:  http.StatusNotFound,
+			requestOpts:     requestOpts{params: map[string]string{"id": "3"}},
+		},
+		{
+			name:            "update user db error",
+			mockRepo:        setupUserRepoMock("Delete", []any{"1", actorIs("1")}, []any{ErrRepoOperation}),
+			mockLeaderboard: &mocks.MockLeaderboardsRepo{},
+			mockCache:       &mocks.MockRedisService{},
+			userID:          "1",
+			userRole:        "visitor",
+			expectedStatus:  http.StatusInternalServerError,
+			requestOpts:     requestOpts{params: map[string]string{"id": "1"}},
 		},
 	}
 
 	for _, testCase := range testCases {
 		t.Run(testCase.name, func(t *testing.T) {
-			uc := NewUserController(testCase.mockRepo)
+			uc := NewUserController(testCase.mockRepo, testCase.mockLeaderboard, setupNoTeamsMock(), testCase.mockCache)
 
 			// Execute request and received recorded and decoded response
 			w := executeRequest(
@@ -275,15 +383,16 @@ func TestUsersDelete(t *testing.T) {
 			)
 
 			assert.Equal(t, testCase.expectedStatus, w.Code)
-			if testCase.mockRepo != nil {
-				testCase.mockRepo.AssertExpectations(t)
-			}
+			testCase.mockRepo.AssertExpectations(t)
+			testCase.mockLeaderboard.AssertExpectations(t)
+			testCase.mockCache.AssertExpectations(t)
 		})
 	}
 
 	// Setup mock repo, assign functions to call and handlers to call in order
-	mockUserRepo := setupUserRepoMock("Delete", []any{"1"}, []any{nil})
-	uc := NewUserController(mockUserRepo)
+	mockUserRepo := setupUserRepoMock("Delete", []any{"1", actorIs("1")}, []any{nil})
+	mockLeaderboard, mockCache := setupRankingRemovalMock("1")
+	uc := NewUserController(mockUserRepo, mockLeaderboard, setupNoTeamsMock(), mockCache)
 	testHandlers := []gin.HandlerFunc{
 		mocks.MockValidateAuthMiddleware(&auth.CustomClaims{
 			UserID: "1",
@@ -296,3 +405,168 @@ func TestUsersDelete(t *testing.T) {
 	// Assert responses
 	assert.Equal(t, http.StatusOK, w.Code)
 }
+
+func TestUsersRestore(t *testing.T) {
+
+	restoredLeaderboard := setupLeaderboardRepoMock(
+		"GetRankedScores",
+		[]any{"3"},
+		[]any{[]models.RankedScore{{LeaderboardID: "1", Score: 10}}, nil},
+	)
+	restoredCache := setupRedisServiceMock("ZAdd", []any{"leaderboard:1:ranking", "3", float64(10)}, []any{nil})
+
+	testCases := []struct {
+		name            string
+		mockRepo        *mocks.MockUserRepo
+		mockLeaderboard *mocks.MockLeaderboardsRepo
+		mockCache       *mocks.MockRedisService
+		expectedStatus  int
+	}{
+		{
+			name: "restore user",
+			mockRepo: setupUserRepoMock(
+				"Restore",
+				[]any{"3", actorIs("1")},
+				[]any{&models.User{ID: "3"}, nil},
+			),
+			mockLeaderboard: restoredLeaderboard,
+			mockCache:       restoredCache,
+			expectedStatus:  http.StatusOK,
+		},
+		{
+			name:            "restore user not deleted",
+			mockRepo:        setupUserRepoMock("Restore", []any{"3", actorIs("1")}, []any{&models.User{}, storage.ErrStateConflict}),
+			mockLeaderboard: &mocks.MockLeaderboardsRepo{},
+			mockCache:       &mocks.MockRedisService{},
+			expectedStatus:  http.StatusConflict,
+		},
+		{
+			name:            "restore user not found",
+			mockRepo:        setupUserRepoMock("Restore", []any{"3", actorIs("1")}, []any{&models.User{}, storage.ErrNotFound}),
+			mockLeaderboard: &mocks.MockLeaderboardsRepo{},
+			mockCache:       &mocks.MockRedisService{},
+			expectedStatus:  http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			uc := NewUserController(testCase.mockRepo, testCase.mockLeaderboard, setupNoTeamsMock(), testCase.mockCache)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAdminMiddleware(&auth.CustomClaims{UserID: "1", Role: "administrator"}),
+					uc.Restore,
+				},
+				requestOpts{params: map[string]string{"id": "3"}},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+			testCase.mockLeaderboard.AssertExpectations(t)
+			testCase.mockCache.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUsersExport(t *testing.T) {
+
+	testCases := []struct {
+		name           string
+		mockRepo       *mocks.MockUserRepo
+		expectedStatus int
+	}{
+		{
+			name: "export own data",
+			mockRepo: setupUserRepoMock(
+				"Export",
+				[]any{"2"},
+				[]any{&models.UserExport{Profile: models.User{ID: "2"}}, nil},
+			),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "export deleted user",
+			mockRepo:       setupUserRepoMock("Export", []any{"2"}, []any{&models.UserExport{}, storage.ErrNotFound}),
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			uc := NewUserController(testCase.mockRepo, &mocks.MockLeaderboardsRepo{}, setupNoTeamsMock(), &mocks.MockRedisService{})
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAuthMiddleware(&auth.CustomClaims{UserID: "2", Role: "visitor"}),
+					uc.Export,
+				},
+				requestOpts{},
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			if w.Code == http.StatusOK {
+				assert.Contains(t, w.Header().Get("Content-Disposition"), "user-2-export.json")
+				assert.Contains(t, w.Body.String(), `"current":true`)
+			}
+			testCase.mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUsersErase(t *testing.T) {
+
+	ownLeaderboard, ownCache := setupRankingRemovalMock("2")
+	otherLeaderboard, otherCache := setupRankingRemovalMock("3")
+
+	testCases := []struct {
+		name            string
+		mockRepo        *mocks.MockUserRepo
+		mockLeaderboard *mocks.MockLeaderboardsRepo
+		mockCache       *mocks.MockRedisService
+		requestOpts     requestOpts
+		expectedStatus  int
+	}{
+		{
+			name:            "erase own data",
+			mockRepo:        setupUserRepoMock("Erase", []any{"2", actorIs("2")}, []any{nil}),
+			mockLeaderboard: ownLeaderboard,
+			mockCache:       ownCache,
+			expectedStatus:  http.StatusOK,
+		},
+		{
+			name:            "erase user in path",
+			mockRepo:        setupUserRepoMock("Erase", []any{"3", actorIs("2")}, []any{nil}),
+			mockLeaderboard: otherLeaderboard,
+			mockCache:       otherCache,
+			requestOpts:     requestOpts{params: map[string]string{"id": "3"}},
+			expectedStatus:  http.StatusOK,
+		},
+		{
+			name:            "erase user already erased",
+			mockRepo:        setupUserRepoMock("Erase", []any{"2", actorIs("2")}, []any{storage.ErrStateConflict}),
+			mockLeaderboard: &mocks.MockLeaderboardsRepo{},
+			mockCache:       &mocks.MockRedisService{},
+			expectedStatus:  http.StatusConflict,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			uc := NewUserController(testCase.mockRepo, testCase.mockLeaderboard, setupNoTeamsMock(), testCase.mockCache)
+
+			w := executeRequest(
+				[]gin.HandlerFunc{
+					mocks.MockValidateAuthMiddleware(&auth.CustomClaims{UserID: "2", Role: "administrator"}),
+					uc.Erase,
+				},
+				testCase.requestOpts,
+			)
+
+			assert.Equal(t, testCase.expectedStatus, w.Code)
+			testCase.mockRepo.AssertExpectations(t)
+			testCase.mockLeaderboard.AssertExpectations(t)
+			testCase.mockCache.AssertExpectations(t)
+		})
+	}
+}
@@ -3,9 +3,12 @@ package handlers
 import (
 	"errors"
 	"log"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
 )
 
 // Parses the user claims from the request
@@ -17,7 +20,7 @@ func parseUserClaims(c *gin.Context) (*auth.CustomClaims, error) {
 	log.Printf("Admin request: %+v", claims)
 	if !ok {
 		log.Println("Failed to get UserClaims from request")
-		return nil, errors.New("not enough privileges")
+		return nil, auth.ErrUnauthorized
 	}
 
 	// Ensure userClaims is of the correct type
@@ -28,4 +31,46 @@ func parseUserClaims(c *gin.Context) (*auth.CustomClaims, error) {
 	}
 
 	return userClaims, nil
-}
\ No newline at end of file
+}
+
+// Returns the id of the user making the request, empty for anonymous requests
+// Used on public endpoints behind the OptionalAuth middleware
+func viewerID(c *gin.Context) string {
+	claims, ok := c.Get("UserClaims")
+	if !ok {
+		return ""
+	}
+
+	userClaims, ok := claims.(*auth.CustomClaims)
+	if !ok {
+		return ""
+	}
+
+	return userClaims.UserID
+}
+
+// Identifies who is making the request, recorded in the audit log by the repositories
+func auditActor(c *gin.Context) (*models.AuditActor, error) {
+	userClaims, err := parseUserClaims(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuditActor{
+		UserID:    userClaims.UserID,
+		Role:      userClaims.Role,
+		RequestID: c.GetString("RequestID"),
+	}, nil
+}
+
+// Reads the version from an If-Match header holding a single entity tag, such as "3" or W/"3"
+// The wildcard is not accepted, updates must always state the version they were based on
+func parseIfMatch(c *gin.Context) (int, bool) {
+	tag := strings.TrimPrefix(strings.TrimSpace(c.GetHeader("If-Match")), "W/")
+	version, err := strconv.Atoi(strings.Trim(tag, `"`))
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+
+	return version, true
+}
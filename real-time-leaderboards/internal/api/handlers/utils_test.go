@@ -7,9 +7,13 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mochivi/go-real-time-leaderboards/internal/mocks"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	"github.com/stretchr/testify/mock"
 )
 
 // This package is not for testign the utils.go file
@@ -25,24 +29,116 @@ func setupLeaderboardRepoMock(funcName string, args, returns []any) *mocks.MockL
 	return &mockRepo
 }
 
+// Prepares the repo calls made to screen an entry against the leaderboard rules and score distribution
+func setupEntryValidationMock(
+	leaderboard *models.Leaderboard,
+	submissions *models.UserSubmissions,
+	stats *models.ScoreStats,
+) *mocks.MockLeaderboardsRepo {
+	mockRepo := mocks.MockLeaderboardsRepo{}
+	mockRepo.On("Get", mock.AnythingOfType("string")).Return(leaderboard, nil)
+	mockRepo.On(
+		"GetUserSubmissions",
+		mock.AnythingOfType("string"),
+		mock.AnythingOfType("string"),
+	).Return(submissions, nil)
+	mockRepo.On("GetScoreStats", mock.AnythingOfType("string")).Return(stats, nil).Maybe()
+	return &mockRepo
+}
+
 func setupUserRepoMock(funcName string, args, returns []any) *mocks.MockUserRepo {
 	mockUserRepo := mocks.MockUserRepo{}
 	mockUserRepo.On(funcName, args...).Return(returns...)
 	return &mockUserRepo
 }
 
+func setupReportRepoMock(funcName string, args, returns []any) *mocks.MockReportRepo {
+	mockRepo := mocks.MockReportRepo{}
+	mockRepo.On(funcName, args...).Return(returns...)
+	return &mockRepo
+}
+
+func setupChangeSetRepoMock(funcName string, args, returns []any) *mocks.MockChangeSetRepo {
+	mockRepo := mocks.MockChangeSetRepo{}
+	mockRepo.On(funcName, args...).Return(returns...)
+	return &mockRepo
+}
+
+func setupFriendRepoMock(funcName string, args, returns []any) *mocks.MockFriendRepo {
+	mockRepo := mocks.MockFriendRepo{}
+	mockRepo.On(funcName, args...).Return(returns...)
+	return &mockRepo
+}
+
+func setupTeamRepoMock(funcName string, args, returns []any) *mocks.MockTeamRepo {
+	mockRepo := mocks.MockTeamRepo{}
+	mockRepo.On(funcName, args...).Return(returns...)
+	return &mockRepo
+}
+
+// Mocks players without a team on leaderboards without a team ranking, so syncing the team rankings changes nothing
+func setupNoTeamsMock() *mocks.MockTeamRepo {
+	mockRepo := &mocks.MockTeamRepo{}
+	mockRepo.On("GetUserTeam", mock.Anything).Return((*models.Team)(nil), storage.ErrNotFound).Maybe()
+	mockRepo.On("GetLeaderboardTeamScores", mock.Anything).Return([]models.TeamScore{}, nil).Maybe()
+	return mockRepo
+}
+
+func setupTournamentRepoMock(funcName string, args, returns []any) *mocks.MockTournamentRepo {
+	mockRepo := mocks.MockTournamentRepo{}
+	mockRepo.On(funcName, args...).Return(returns...)
+	return &mockRepo
+}
+
+func setupGameRepoMock(funcName string, args, returns []any) *mocks.MockGameRepo {
+	mockRepo := mocks.MockGameRepo{}
+	mockRepo.On(funcName, args...).Return(returns...)
+	return &mockRepo
+}
+
+func setupRatingRepoMock(funcName string, args, returns []any) *mocks.MockRatingRepo {
+	mockRepo := mocks.MockRatingRepo{}
+	mockRepo.On(funcName, args...).Return(returns...)
+	return &mockRepo
+}
+
+func setupMemberRepoMock(funcName string, args, returns []any) *mocks.MockMemberRepo {
+	mockRepo := mocks.MockMemberRepo{}
+	mockRepo.On(funcName, args...).Return(returns...)
+	return &mockRepo
+}
+
+func setupTenantRepoMock(funcName string, args, returns []any) *mocks.MockTenantRepo {
+	mockRepo := mocks.MockTenantRepo{}
+	mockRepo.On(funcName, args...).Return(returns...)
+	return &mockRepo
+}
+
 func setupRedisServiceMock(funcName string, args, returns []any) *mocks.MockRedisService {
 	mockRedisService := mocks.MockRedisService{}
 	mockRedisService.On(funcName, args...).Return(returns...)
 	return &mockRedisService
 }
 
+// Cache mock for entries that reach the ranking, setErr is returned when caching the leaderboard
+// The ranking holds 40 players, one of them above the entry and 30 below
+func setupRankingCacheMock(setErr error) *mocks.MockRedisService {
+	mockRedisService := mocks.MockRedisService{}
+	mockRedisService.On("ZAddGT", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mockRedisService.On("ZCard", mock.Anything).Return(int64(40), nil)
+	mockRedisService.On("ZCount", mock.Anything, mock.Anything, "+inf").Return(int64(1), nil)
+	mockRedisService.On("ZCount", mock.Anything, "-inf", mock.Anything).Return(int64(30), nil)
+	mockRedisService.On("Set", mock.Anything, mock.Anything, mock.Anything).Return(setErr)
+	return &mockRedisService
+}
+
 // Functions to help making the request to the handler below
 
 type requestOpts struct {
 	headers map[string]string
 	body    any
 	params  map[string]string
+	query   map[string]string
 }
 
 func (r requestOpts) Body() ([]byte, bool) {
@@ -60,6 +156,17 @@ func (r requestOpts) Headers() (map[string]string, bool) {
 	return nil, false
 }
 
+func (r requestOpts) Query() (string, bool) {
+	if r.query != nil {
+		values := url.Values{}
+		for k, v := range r.query {
+			values.Set(k, v)
+		}
+		return values.Encode(), true
+	}
+	return "", false
+}
+
 func (r requestOpts) Params() (map[string]string, bool) {
 	if r.params != nil {
 		return r.params, true
@@ -87,6 +194,11 @@ func executeRequest(testHandlers []gin.HandlerFunc, requestOpts ...requestOpts)
 			c.Request.Body = io.NopCloser(bytes.NewReader(body))
 		}
 
+		// Set query string
+		if query, ok := requestOpts.Query(); ok {
+			c.Request.URL.RawQuery = query
+		}
+
 		// Set headers
 		if headers, ok := requestOpts.Headers(); ok {
 			for k, v := range headers {
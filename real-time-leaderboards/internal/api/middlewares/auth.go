@@ -7,18 +7,28 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/mochivi/go-real-time-leaderboards/internal/api/problems"
 	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
 )
 
 func abortWithError(c *gin.Context, statusCode int, message string) {
-	c.JSON(statusCode, gin.H{"error": message})
-	c.Abort()
+	var problem *problems.Problem
+	switch statusCode {
+	case http.StatusBadRequest:
+		problem = problems.InvalidRequest(message)
+	case http.StatusUnauthorized:
+		problem = problems.Unauthorized(message)
+	case http.StatusForbidden:
+		problem = problems.Forbidden(message)
+	default:
+		problem = problems.Internal()
+	}
+	problems.Abort(c, problem)
 }
 
 func abortWithLoginRedirection(c *gin.Context, statusCode int, message string) {
-	c.JSON(statusCode, gin.H{"error": message})
-	c.Redirect(http.StatusFound, "api/v1/auth/login")
-	c.Abort()
+	c.Header("Location", "api/v1/auth/login")
+	abortWithError(c, statusCode, message)
 }
 
 /*
@@ -100,9 +110,8 @@ func ValidateAuth(j auth.JWTService) gin.HandlerFunc {
 		// Update user cookies with the validated roles
 		tokens, err := j.CreateAccessTokens(userClaims.UserID, userClaims.Role)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Failed to generate JWT",
-			})
+			log.Printf("Failed to generate JWT: %v", err)
+			abortWithError(c, http.StatusInternalServerError, "Failed to generate JWT")
 			return
 		}
 
@@ -124,6 +133,33 @@ func ValidateAuth(j auth.JWTService) gin.HandlerFunc {
 	}
 }
 
+// Optional auth is used by public endpoints that show more to the signed in user
+// A valid access token sets the user claims, anything else is treated as an anonymous request
+func OptionalAuth(j auth.JWTService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.GetHeader("Authorization")
+		if authHeader == "" {
+			c.Next()
+			return
+		}
+
+		tokenString, ok := j.ParseTokenFromHeader(authHeader)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		userClaims, err := j.VerifyToken(tokenString)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		c.Set("UserClaims", userClaims)
+		c.Next()
+	}
+}
+
 // Admin validation should only be called from authenticated endpoints
 func ValidateAdmin() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -144,10 +180,39 @@ func ValidateAdmin() gin.HandlerFunc {
 
 		// Check if user role is administrator
 		if userClaims.Role != "administrator" {
-			abortWithError(c, http.StatusUnauthorized, "Administrator priviliges required")
+			abortWithError(c, http.StatusForbidden, "Administrator priviliges required")
 			return
 		}
 
 		c.Next()
 	}
 }
+
+// Role validation should only be called from authenticated endpoints, any of the roles is enough
+func ValidateRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		// Receive userClaims from context
+		claims, ok := c.Get("UserClaims")
+		if !ok {
+			abortWithError(c, http.StatusUnauthorized, "Missing user claims")
+			return
+		}
+
+		// Ensure userClaims is of the correct type
+		userClaims, ok := claims.(*auth.CustomClaims)
+		if !ok {
+			abortWithError(c, http.StatusInternalServerError, "Invalid user claims type")
+			return
+		}
+
+		for _, role := range roles {
+			if userClaims.Role == role {
+				c.Next()
+				return
+			}
+		}
+
+		abortWithError(c, http.StatusForbidden, "Not enough priviliges")
+	}
+}
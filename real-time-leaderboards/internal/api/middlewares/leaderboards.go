@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/api/problems"
+	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+)
+
+// Leaderboard access validation should be called after the user claims are set, if any
+// Private leaderboards named by the id parameter are reported as not found to anyone but their members
+// Administrators read every leaderboard, requests not naming a leaderboard are let through
+func ValidateLeaderboardAccess(members storage.MemberRepo) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		leaderboardID := c.Param("id")
+		if leaderboardID == "" {
+			c.Next()
+			return
+		}
+
+		var userID string
+		if claims, ok := c.Get("UserClaims"); ok {
+			if userClaims, ok := claims.(*auth.CustomClaims); ok {
+				if userClaims.Role == "administrator" {
+					c.Next()
+					return
+				}
+				userID = userClaims.UserID
+			}
+		}
+
+		access, err := members.GetAccess(c.Request.Context(), leaderboardID, userID)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				problems.Abort(c, problems.NotFound("Leaderboard not found"))
+			} else {
+				log.Printf("Failed to get leaderboard access: %v", err)
+				abortWithError(c, http.StatusInternalServerError, "Internal server error")
+			}
+			return
+		}
+		if !access.CanRead() {
+			problems.Abort(c, problems.NotFound("Leaderboard not found"))
+			return
+		}
+
+		c.Next()
+	}
+}
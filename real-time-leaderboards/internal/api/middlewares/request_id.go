@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+
+	"github.com/gin-gonic/gin"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// Longer client provided ids are replaced, they end up in logs and the audit log
+const maxRequestIDLength = 128
+
+// Tags every request with an id, reusing the one sent by the client or a proxy if present
+// The id is stored as "RequestID" in the context and echoed in the response headers
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = newRequestID()
+		}
+
+		c.Set("RequestID", requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Printf("Failed to generate request id: %v", err)
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
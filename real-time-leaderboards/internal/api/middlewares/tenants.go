@@ -0,0 +1,78 @@
+package middlewares
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// Resolves the tenant of the request from its API key, requests without a key belong to the default tenant
+// The tenant is carried by the request context for the repositories and stored as "TenantID" in the gin context
+func ResolveTenant(tenants storage.TenantRepo) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := models.DefaultTenantID
+
+		if key := c.GetHeader(APIKeyHeader); key != "" {
+			apiKey, err := tenants.GetByAPIKey(c.Request.Context(), auth.HashAPIKey(key))
+			if err != nil {
+				if errors.Is(err, storage.ErrNotFound) {
+					abortWithError(c, http.StatusUnauthorized, "Invalid API key")
+				} else {
+					log.Printf("Failed to resolve API key: %v", err)
+					abortWithError(c, http.StatusInternalServerError, "Internal server error")
+				}
+				return
+			}
+			tenantID = apiKey.TenantID
+		}
+
+		c.Request = c.Request.WithContext(models.ContextWithTenant(c.Request.Context(), tenantID))
+		c.Set("TenantID", tenantID)
+		c.Next()
+	}
+}
+
+// Tenant admin validation should only be called from authenticated endpoints, after the tenant is resolved
+// Administrators of the deployment administer every tenant
+func ValidateTenantAdmin(tenants storage.TenantRepo) gin.HandlerFunc {
+	return func(c *gin.Context) {
+
+		claims, ok := c.Get("UserClaims")
+		if !ok {
+			abortWithError(c, http.StatusUnauthorized, "Missing user claims")
+			return
+		}
+
+		userClaims, ok := claims.(*auth.CustomClaims)
+		if !ok {
+			abortWithError(c, http.StatusInternalServerError, "Invalid user claims type")
+			return
+		}
+
+		if userClaims.Role == "administrator" {
+			c.Next()
+			return
+		}
+
+		tenantID := models.TenantFromContext(c.Request.Context())
+		role, err := tenants.GetMemberRole(c.Request.Context(), tenantID, userClaims.UserID)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("Failed to get tenant role: %v", err)
+			abortWithError(c, http.StatusInternalServerError, "Internal server error")
+			return
+		}
+		if role != models.TenantRoleAdmin {
+			abortWithError(c, http.StatusForbidden, "Tenant administrator priviliges required")
+			return
+		}
+
+		c.Next()
+	}
+}
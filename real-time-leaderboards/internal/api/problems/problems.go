@@ -0,0 +1,148 @@
+package problems
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+)
+
+// ContentType is the media type defined by RFC 7807 for problem details
+const ContentType = "application/problem+json"
+
+// Stable error codes, clients should rely on these instead of the title or detail
+const (
+	CodeInvalidRequest     = "invalid_request"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeUnauthorized       = "unauthorized"
+	CodeForbidden          = "forbidden"
+	CodeAccountBanned      = "account_banned"
+	CodeNotFound           = "not_found"
+	CodeConflict           = "conflict"
+	CodeInvalidReference   = "invalid_reference"
+	CodeStateConflict      = "state_conflict"
+	CodeVersionConflict    = "version_conflict"
+	CodePreconditionNeeded = "precondition_required"
+	CodeScoreRejected      = "score_rejected"
+	CodeInternal           = "internal_error"
+)
+
+// Problem is the RFC 7807 problem details object, extended with a stable error code
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+}
+
+func (p *Problem) Error() string {
+	return fmt.Sprintf("%s: %s", p.Code, p.Detail)
+}
+
+// Returns a copy of the problem with the provided detail
+func (p *Problem) WithDetail(detail string) *Problem {
+	problem := *p
+	problem.Detail = detail
+	return &problem
+}
+
+func New(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   "urn:leaderboards:problem:" + code,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+func InvalidRequest(detail string) *Problem {
+	return New(http.StatusBadRequest, CodeInvalidRequest, detail)
+}
+
+func Unauthorized(detail string) *Problem {
+	return New(http.StatusUnauthorized, CodeUnauthorized, detail)
+}
+
+func Forbidden(detail string) *Problem {
+	return New(http.StatusForbidden, CodeForbidden, detail)
+}
+
+func NotFound(detail string) *Problem {
+	return New(http.StatusNotFound, CodeNotFound, detail)
+}
+
+func PreconditionRequired(detail string) *Problem {
+	return New(http.StatusPreconditionRequired, CodePreconditionNeeded, detail)
+}
+
+func Internal() *Problem {
+	return New(http.StatusInternalServerError, CodeInternal, "Something went wrong")
+}
+
+// FromError maps storage and domain errors to a problem, resource names the entity the request was about
+// Unknown errors are never exposed to the client and become a generic internal error
+func FromError(err error, resource string) *Problem {
+	var problem *Problem
+	if errors.As(err, &problem) {
+		return problem
+	}
+
+	var scoreRejection *models.ScoreRejection
+	if errors.As(err, &scoreRejection) {
+		return New(http.StatusUnprocessableEntity, CodeScoreRejected, scoreRejection.Reason)
+	}
+
+	switch {
+	case errors.Is(err, storage.ErrNotFound):
+		return NotFound(fmt.Sprintf("%s not found", resource))
+	case errors.Is(err, storage.ErrConflict):
+		return New(http.StatusConflict, CodeConflict, fmt.Sprintf("%s already exists", resource))
+	case errors.Is(err, storage.ErrVersionConflict):
+		return New(http.StatusConflict, CodeVersionConflict, fmt.Sprintf("%s was modified since it was read, fetch it again and retry", resource))
+	case errors.Is(err, storage.ErrStateConflict):
+		return New(http.StatusConflict, CodeStateConflict, fmt.Sprintf("%s cannot be modified in its current state", resource))
+	case errors.Is(err, storage.ErrInvalidReference):
+		return New(http.StatusUnprocessableEntity, CodeInvalidReference, fmt.Sprintf("%s references a resource that does not exist", resource))
+	case errors.Is(err, auth.ErrInvalidCredentials):
+		return New(http.StatusUnauthorized, CodeInvalidCredentials, "Username or password incorrect")
+	case errors.Is(err, auth.ErrUnauthorized):
+		return Unauthorized("Authentication required")
+	case errors.Is(err, auth.ErrBanned):
+		return New(http.StatusForbidden, CodeAccountBanned, "This account is banned")
+	case errors.Is(err, auth.ErrForbidden):
+		return Forbidden("Not enough privileges for this operation")
+	default:
+		return Internal()
+	}
+}
+
+// Render writes the problem as the response body
+func Render(c *gin.Context, problem *Problem) {
+	// Problems are shared values, copy before attaching the request path
+	if problem.Instance == "" && c.Request != nil && c.Request.URL != nil {
+		withInstance := *problem
+		withInstance.Instance = c.Request.URL.Path
+		problem = &withInstance
+	}
+
+	c.Header("Content-Type", ContentType)
+	c.JSON(problem.Status, problem)
+}
+
+// RenderError maps the error to a problem and writes it as the response body
+func RenderError(c *gin.Context, err error, resource string) {
+	Render(c, FromError(err, resource))
+}
+
+// Abort renders the problem and stops the handler chain, used by middlewares
+func Abort(c *gin.Context, problem *Problem) {
+	Render(c, problem)
+	c.Abort()
+}
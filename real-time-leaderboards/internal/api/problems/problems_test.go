@@ -0,0 +1,65 @@
+package problems
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromError(t *testing.T) {
+	testCases := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"not found", storage.ErrNotFound, http.StatusNotFound, CodeNotFound},
+		{"wrapped not found", fmt.Errorf("failed to get leaderboard: %w", storage.ErrNotFound), http.StatusNotFound, CodeNotFound},
+		{"conflict", storage.ErrConflict, http.StatusConflict, CodeConflict},
+		{"state conflict", storage.ErrStateConflict, http.StatusConflict, CodeStateConflict},
+		{"version conflict", fmt.Errorf("failed to update entry: %w", storage.ErrVersionConflict), http.StatusConflict, CodeVersionConflict},
+		{"invalid reference", storage.ErrInvalidReference, http.StatusUnprocessableEntity, CodeInvalidReference},
+		{"invalid credentials", auth.ErrInvalidCredentials, http.StatusUnauthorized, CodeInvalidCredentials},
+		{"forbidden", auth.ErrForbidden, http.StatusForbidden, CodeForbidden},
+		{"banned", auth.ErrBanned, http.StatusForbidden, CodeAccountBanned},
+		{"score rejected", &models.ScoreRejection{Reason: "too high"}, http.StatusUnprocessableEntity, CodeScoreRejected},
+		{"problem passthrough", InvalidRequest("bad"), http.StatusBadRequest, CodeInvalidRequest},
+		{"unknown error", errors.New("connection refused"), http.StatusInternalServerError, CodeInternal},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			problem := FromError(testCase.err, "Leaderboard")
+
+			assert.Equal(t, testCase.expectedStatus, problem.Status)
+			assert.Equal(t, testCase.expectedCode, problem.Code)
+			assert.NotContains(t, problem.Detail, "connection refused")
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/api/v1/leaderboards/1", nil)
+
+	RenderError(c, storage.ErrNotFound, "Leaderboard")
+
+	var problem Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, ContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, CodeNotFound, problem.Code)
+	assert.Equal(t, "Leaderboard not found", problem.Detail)
+	assert.Equal(t, "/api/v1/leaderboards/1", problem.Instance)
+}
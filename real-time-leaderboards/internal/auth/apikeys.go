@@ -0,0 +1,31 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// Tenant API keys are shown once when created, only their hash is stored
+const (
+	apiKeyPrefix = "lb_"
+
+	// Leading characters of a key kept in clear, to tell the keys of a tenant apart
+	APIKeyPrefixLength = len(apiKeyPrefix) + 8
+)
+
+// Generates a new random API key
+func NewAPIKey() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate API key: %w", err)
+	}
+	return apiKeyPrefix + hex.EncodeToString(b), nil
+}
+
+// Hash the API key is stored and looked up by
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,5 +1,15 @@
 package auth
 
+import "errors"
+
+// Authentication and authorization errors
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrUnauthorized       = errors.New("unauthorized")
+	ErrForbidden          = errors.New("not enough privileges")
+	ErrBanned             = errors.New("account banned")
+)
+
 // User sends username and password
 type AuthRequest struct {
 	Username string `json:"username"`
@@ -10,4 +20,4 @@ type AuthRequest struct {
 type AuthResponse struct {
 	AccessToken string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
-}
\ No newline at end of file
+}
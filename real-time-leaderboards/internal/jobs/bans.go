@@ -0,0 +1,68 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mochivi/go-real-time-leaderboards/internal/rankings"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+)
+
+// BanExpiry lifts bans that reached their expiry time and restores the rankings of the affected users
+// Postgres queries stop enforcing a ban as soon as it expires, only the Redis rankings wait for this job
+type BanExpiry struct {
+	users    storage.UserRepo
+	rankings rankings.Syncer
+	teams    rankings.Teams
+	interval time.Duration
+}
+
+func NewBanExpiry(users storage.UserRepo, rankingsSyncer rankings.Syncer, teams rankings.Teams, interval time.Duration) BanExpiry {
+	return BanExpiry{
+		users:    users,
+		rankings: rankingsSyncer,
+		teams:    teams,
+		interval: interval,
+	}
+}
+
+// Runs the job every interval until the context is cancelled
+func (j BanExpiry) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := j.RunOnce(ctx); err != nil {
+				log.Printf("Ban expiry job failed: %v", err)
+			}
+		}
+	}
+}
+
+func (j BanExpiry) RunOnce(ctx context.Context) error {
+	userIDs, err := j.users.ExpireBans(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to expire bans: %w", err)
+	}
+
+	// The bans are already cleared, a failed sync must not stop the rankings of the other users from being restored
+	for _, userID := range userIDs {
+		if err := j.rankings.SyncUser(ctx, userID); err != nil {
+			log.Printf("Failed to restore rankings after ban expiry: %v", err)
+		}
+		if err := j.teams.SyncUser(ctx, userID, ""); err != nil {
+			log.Printf("Failed to restore team rankings after ban expiry: %v", err)
+		}
+	}
+	if len(userIDs) > 0 {
+		log.Printf("Lifted %d expired bans", len(userIDs))
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+)
+
+// Purge permanently removes the leaderboards, entries and users that stayed soft deleted past the retention period
+// Until then administrators can restore them
+type Purge struct {
+	leaderboards storage.LeaderboardRepo
+	users        storage.UserRepo
+	retention    time.Duration
+	interval     time.Duration
+}
+
+func NewPurge(leaderboards storage.LeaderboardRepo, users storage.UserRepo, retention, interval time.Duration) Purge {
+	return Purge{
+		leaderboards: leaderboards,
+		users:        users,
+		retention:    retention,
+		interval:     interval,
+	}
+}
+
+// Runs the job every interval until the context is cancelled
+func (j Purge) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := j.RunOnce(ctx); err != nil {
+				log.Printf("Purge job failed: %v", err)
+			}
+		}
+	}
+}
+
+func (j Purge) RunOnce(ctx context.Context) error {
+	before := time.Now().Add(-j.retention)
+
+	// Leaderboards go first, the entries of purged users would be removed by the cascade anyway
+	leaderboardRows, err := j.leaderboards.PurgeDeleted(ctx, before)
+	if err != nil {
+		return fmt.Errorf("failed to purge deleted leaderboards: %w", err)
+	}
+
+	users, err := j.users.PurgeDeleted(ctx, before)
+	if err != nil {
+		return fmt.Errorf("failed to purge deleted users: %w", err)
+	}
+
+	if leaderboardRows > 0 || users > 0 {
+		log.Printf("Purged %d leaderboard rows and %d users deleted before %s", leaderboardRows, users, before.Format(time.RFC3339))
+	}
+
+	return nil
+}
@@ -1,9 +1,8 @@
 package mocks
 
 import (
-	"net/http"
-
 	"github.com/gin-gonic/gin"
+	"github.com/mochivi/go-real-time-leaderboards/internal/api/problems"
 	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
 )
 
@@ -20,10 +19,7 @@ func MockValidateAdminMiddleware(userClaims *auth.CustomClaims) gin.HandlerFunc
 
 		// Check if user role is administrator
 		if userClaims.Role != "administrator" {
-			c.Abort()
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"message": "Not enough priviliges",
-			})
+			problems.Abort(c, problems.Forbidden("Not enough priviliges"))
 			return
 		}
 
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/mochivi/go-real-time-leaderboards/internal/models"
 	"github.com/stretchr/testify/mock"
@@ -27,16 +28,66 @@ func (m *MockUserRepo) GetByID(ctx context.Context, userID string) (*models.User
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserRepo) Update(ctx context.Context, updateUser *models.UpdateUser) (*models.User, error) {
-	args := m.Called(updateUser)
+func (m *MockUserRepo) GetByIDs(ctx context.Context, userIDs []string) ([]models.User, error) {
+	args := m.Called(userIDs)
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *MockUserRepo) Update(ctx context.Context, updateUser *models.UpdateUser, actor *models.AuditActor) (*models.User, error) {
+	args := m.Called(updateUser, actor)
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepo) Delete(ctx context.Context, userID string, actor *models.AuditActor) error {
+	args := m.Called(userID, actor)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) Restore(ctx context.Context, userID string, actor *models.AuditActor) (*models.User, error) {
+	args := m.Called(userID, actor)
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserRepo) Delete(ctx context.Context, userID string) error {
+func (m *MockUserRepo) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserRepo) Export(ctx context.Context, userID string) (*models.UserExport, error) {
 	args := m.Called(userID)
+	return args.Get(0).(*models.UserExport), args.Error(1)
+}
+
+func (m *MockUserRepo) GetProfile(ctx context.Context, userID, viewerID string) (*models.PlayerProfile, error) {
+	args := m.Called(userID, viewerID)
+	return args.Get(0).(*models.PlayerProfile), args.Error(1)
+}
+
+func (m *MockUserRepo) Erase(ctx context.Context, userID string, actor *models.AuditActor) error {
+	args := m.Called(userID, actor)
+	return args.Error(0)
+}
+
+func (m *MockUserRepo) Ban(ctx context.Context, userID string, request *models.BanRequest, actor *models.AuditActor) (*models.Ban, error) {
+	args := m.Called(userID, request, actor)
+	return args.Get(0).(*models.Ban), args.Error(1)
+}
+
+func (m *MockUserRepo) Unban(ctx context.Context, userID string, actor *models.AuditActor) error {
+	args := m.Called(userID, actor)
 	return args.Error(0)
 }
 
+func (m *MockUserRepo) GetBans(ctx context.Context, filter *models.BanFilter) ([]models.Ban, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]models.Ban), args.Error(1)
+}
+
+func (m *MockUserRepo) ExpireBans(ctx context.Context) ([]string, error) {
+	args := m.Called()
+	return args.Get(0).([]string), args.Error(1)
+}
+
 
 type MockLeaderboardsRepo struct {
 	mock.Mock
@@ -47,11 +98,21 @@ func (m *MockLeaderboardsRepo) Get(ctx context.Context, leaderboardID string) (*
 	return args.Get(0).(*models.Leaderboard), args.Error(1)
 }
 
-func (m *MockLeaderboardsRepo) GetEntries(ctx context.Context, leaderboardID string) ([]models.LeaderboardEntry, error) {
-	args := m.Called(leaderboardID)
+func (m *MockLeaderboardsRepo) List(ctx context.Context, filter *models.LeaderboardListFilter) ([]models.LeaderboardListing, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]models.LeaderboardListing), args.Error(1)
+}
+
+func (m *MockLeaderboardsRepo) GetEntries(ctx context.Context, leaderboardID, viewerID string) ([]models.LeaderboardEntry, error) {
+	args := m.Called(leaderboardID, viewerID)
 	return args.Get(0).([]models.LeaderboardEntry), args.Error(1)
 }
 
+func (m *MockLeaderboardsRepo) GetRankedEntries(ctx context.Context, filter *models.VariantFilter) ([]models.RankedEntry, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]models.RankedEntry), args.Error(1)
+}
+
 func (m *MockLeaderboardsRepo) Create(ctx context.Context, newLeaderboard *models.LeaderboardRequest) (*models.Leaderboard, error) {
 	args := m.Called(newLeaderboard)
 	return args.Get(0).(*models.Leaderboard), args.Error(1)
@@ -62,12 +123,350 @@ func (m *MockLeaderboardsRepo) CreateEntry(ctx context.Context, entry *models.Le
 	return args.Get(0).(*models.LeaderboardEntry), args.Error(1)
 }
 
-func (m *MockLeaderboardsRepo) Update(ctx context.Context, leaderboard *models.UpdateLeaderboardRequest) (*models.Leaderboard, error) {
-	args := m.Called(leaderboard)
+func (m *MockLeaderboardsRepo) GetUserSubmissions(ctx context.Context, leaderboardID, userID string) (*models.UserSubmissions, error) {
+	args := m.Called(leaderboardID, userID)
+	return args.Get(0).(*models.UserSubmissions), args.Error(1)
+}
+
+func (m *MockLeaderboardsRepo) GetRankedScores(ctx context.Context, userID string) ([]models.RankedScore, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.RankedScore), args.Error(1)
+}
+
+func (m *MockLeaderboardsRepo) GetLeaderboardScores(ctx context.Context, leaderboardID string) ([]models.RankedScore, error) {
+	args := m.Called(leaderboardID)
+	return args.Get(0).([]models.RankedScore), args.Error(1)
+}
+
+func (m *MockLeaderboardsRepo) GetScoreDistribution(ctx context.Context, leaderboardID string, bucketWidth float64) (*models.ScoreDistribution, error) {
+	args := m.Called(leaderboardID, bucketWidth)
+	return args.Get(0).(*models.ScoreDistribution), args.Error(1)
+}
+
+func (m *MockLeaderboardsRepo) GetScoreStats(ctx context.Context, leaderboardID string) (*models.ScoreStats, error) {
+	args := m.Called(leaderboardID)
+	return args.Get(0).(*models.ScoreStats), args.Error(1)
+}
+
+func (m *MockLeaderboardsRepo) GetModerationQueue(ctx context.Context, filter *models.ModerationQueueFilter) ([]models.LeaderboardEntry, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]models.LeaderboardEntry), args.Error(1)
+}
+
+func (m *MockLeaderboardsRepo) ReviewEntry(ctx context.Context, review *models.EntryReview, actor *models.AuditActor) (*models.LeaderboardEntry, error) {
+	args := m.Called(review, actor)
+	return args.Get(0).(*models.LeaderboardEntry), args.Error(1)
+}
+
+func (m *MockLeaderboardsRepo) UpdateEntry(ctx context.Context, update *models.UpdateEntryRequest, actor *models.AuditActor) (*models.LeaderboardEntry, error) {
+	args := m.Called(update, actor)
+	return args.Get(0).(*models.LeaderboardEntry), args.Error(1)
+}
+
+func (m *MockLeaderboardsRepo) Update(ctx context.Context, leaderboard *models.UpdateLeaderboardRequest, actor *models.AuditActor) (*models.Leaderboard, error) {
+	args := m.Called(leaderboard, actor)
+	return args.Get(0).(*models.Leaderboard), args.Error(1)
+}
+
+func (m *MockLeaderboardsRepo) Delete(ctx context.Context, leaderboardID string, actor *models.AuditActor) error {
+	args := m.Called(leaderboardID, actor)
+	return args.Error(0)
+}
+
+func (m *MockLeaderboardsRepo) Restore(ctx context.Context, leaderboardID string, actor *models.AuditActor) (*models.Leaderboard, error) {
+	args := m.Called(leaderboardID, actor)
 	return args.Get(0).(*models.Leaderboard), args.Error(1)
 }
 
-func (m *MockLeaderboardsRepo) Delete(ctx context.Context, leaderboardID string) error {
+func (m *MockLeaderboardsRepo) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+
+type MockAuditRepo struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepo) GetAuditLog(ctx context.Context, filter *models.AuditLogFilter) ([]models.AuditEntry, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]models.AuditEntry), args.Error(1)
+}
+
+
+type MockReportRepo struct {
+	mock.Mock
+}
+
+func (m *MockReportRepo) Create(ctx context.Context, report *models.EntryReportRequest, threshold int) (*models.EntryReportResult, error) {
+	args := m.Called(report, threshold)
+	return args.Get(0).(*models.EntryReportResult), args.Error(1)
+}
+
+func (m *MockReportRepo) GetReportedEntries(ctx context.Context, filter *models.ReportFilter) ([]models.ReportedEntry, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]models.ReportedEntry), args.Error(1)
+}
+
+func (m *MockReportRepo) GetEntryReports(ctx context.Context, entryID string, page *models.Pagination) ([]models.EntryReport, error) {
+	args := m.Called(entryID, page)
+	return args.Get(0).([]models.EntryReport), args.Error(1)
+}
+
+
+type MockChangeSetRepo struct {
+	mock.Mock
+}
+
+func (m *MockChangeSetRepo) Apply(ctx context.Context, request *models.BulkEntryRequest, actor *models.AuditActor) (*models.EntryChangeSet, error) {
+	args := m.Called(request, actor)
+	return args.Get(0).(*models.EntryChangeSet), args.Error(1)
+}
+
+func (m *MockChangeSetRepo) Revert(ctx context.Context, changeSetID string, actor *models.AuditActor) (*models.EntryChangeSet, error) {
+	args := m.Called(changeSetID, actor)
+	return args.Get(0).(*models.EntryChangeSet), args.Error(1)
+}
+
+func (m *MockChangeSetRepo) GetChangeSets(ctx context.Context, filter *models.ChangeSetFilter) ([]models.EntryChangeSet, error) {
+	args := m.Called(filter)
+	return args.Get(0).([]models.EntryChangeSet), args.Error(1)
+}
+
+type MockFriendRepo struct {
+	mock.Mock
+}
+
+func (m *MockFriendRepo) Request(ctx context.Context, requesterID, addresseeID string) (*models.Friendship, error) {
+	args := m.Called(requesterID, addresseeID)
+	return args.Get(0).(*models.Friendship), args.Error(1)
+}
+
+func (m *MockFriendRepo) Accept(ctx context.Context, userID, requesterID string) (*models.Friendship, error) {
+	args := m.Called(userID, requesterID)
+	return args.Get(0).(*models.Friendship), args.Error(1)
+}
+
+func (m *MockFriendRepo) Remove(ctx context.Context, userID, otherID string) error {
+	args := m.Called(userID, otherID)
+	return args.Error(0)
+}
+
+func (m *MockFriendRepo) GetFriends(ctx context.Context, userID string, page *models.Pagination) ([]models.Friendship, error) {
+	args := m.Called(userID, page)
+	return args.Get(0).([]models.Friendship), args.Error(1)
+}
+
+func (m *MockFriendRepo) GetRequests(ctx context.Context, userID string, page *models.Pagination) ([]models.Friendship, error) {
+	args := m.Called(userID, page)
+	return args.Get(0).([]models.Friendship), args.Error(1)
+}
+
+func (m *MockFriendRepo) GetCircle(ctx context.Context, userID string) ([]models.User, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+type MockTeamRepo struct {
+	mock.Mock
+}
+
+func (m *MockTeamRepo) Create(ctx context.Context, request *models.TeamRequest, ownerID string) (*models.Team, error) {
+	args := m.Called(request, ownerID)
+	return args.Get(0).(*models.Team), args.Error(1)
+}
+
+func (m *MockTeamRepo) Get(ctx context.Context, teamID string) (*models.Team, error) {
+	args := m.Called(teamID)
+	return args.Get(0).(*models.Team), args.Error(1)
+}
+
+func (m *MockTeamRepo) GetByIDs(ctx context.Context, teamIDs []string) ([]models.Team, error) {
+	args := m.Called(teamIDs)
+	return args.Get(0).([]models.Team), args.Error(1)
+}
+
+func (m *MockTeamRepo) GetUserTeam(ctx context.Context, userID string) (*models.Team, error) {
+	args := m.Called(userID)
+	return args.Get(0).(*models.Team), args.Error(1)
+}
+
+func (m *MockTeamRepo) GetMember(ctx context.Context, teamID, userID string) (*models.TeamMember, error) {
+	args := m.Called(teamID, userID)
+	return args.Get(0).(*models.TeamMember), args.Error(1)
+}
+
+func (m *MockTeamRepo) Join(ctx context.Context, teamID, userID string) (*models.TeamMember, error) {
+	args := m.Called(teamID, userID)
+	return args.Get(0).(*models.TeamMember), args.Error(1)
+}
+
+func (m *MockTeamRepo) Leave(ctx context.Context, teamID, userID string) error {
+	args := m.Called(teamID, userID)
+	return args.Error(0)
+}
+
+func (m *MockTeamRepo) SetRole(ctx context.Context, teamID, userID, role string) (*models.TeamMember, error) {
+	args := m.Called(teamID, userID, role)
+	return args.Get(0).(*models.TeamMember), args.Error(1)
+}
+
+func (m *MockTeamRepo) RemoveMember(ctx context.Context, teamID, userID string) error {
+	args := m.Called(teamID, userID)
+	return args.Error(0)
+}
+
+func (m *MockTeamRepo) GetTeamScores(ctx context.Context, teamID, leaderboardID string) ([]models.TeamScore, error) {
+	args := m.Called(teamID, leaderboardID)
+	return args.Get(0).([]models.TeamScore), args.Error(1)
+}
+
+func (m *MockTeamRepo) GetLeaderboardTeamScores(ctx context.Context, leaderboardID string) ([]models.TeamScore, error) {
 	args := m.Called(leaderboardID)
+	return args.Get(0).([]models.TeamScore), args.Error(1)
+}
+
+type MockTournamentRepo struct {
+	mock.Mock
+}
+
+func (m *MockTournamentRepo) Create(ctx context.Context, request *models.TournamentRequest) (*models.Tournament, error) {
+	args := m.Called(request)
+	return args.Get(0).(*models.Tournament), args.Error(1)
+}
+
+func (m *MockTournamentRepo) Get(ctx context.Context, tournamentID string) (*models.Tournament, error) {
+	args := m.Called(tournamentID)
+	return args.Get(0).(*models.Tournament), args.Error(1)
+}
+
+func (m *MockTournamentRepo) Start(ctx context.Context, tournamentID string, actor *models.AuditActor) (*models.Tournament, error) {
+	args := m.Called(tournamentID, actor)
+	return args.Get(0).(*models.Tournament), args.Error(1)
+}
+
+func (m *MockTournamentRepo) RecordResult(
+	ctx context.Context,
+	tournamentID string,
+	number int,
+	result *models.MatchResult,
+	actor *models.AuditActor,
+) (*models.Tournament, error) {
+	args := m.Called(tournamentID, number, result, actor)
+	return args.Get(0).(*models.Tournament), args.Error(1)
+}
+
+type MockRatingRepo struct {
+	mock.Mock
+}
+
+func (m *MockRatingRepo) RecordMatch(ctx context.Context, request *models.RatingMatchRequest) (*models.RatingMatch, error) {
+	args := m.Called(request)
+	return args.Get(0).(*models.RatingMatch), args.Error(1)
+}
+
+func (m *MockRatingRepo) GetRatings(ctx context.Context, leaderboardID string, userIDs []string) ([]models.PlayerRating, error) {
+	args := m.Called(leaderboardID, userIDs)
+	return args.Get(0).([]models.PlayerRating), args.Error(1)
+}
+
+func (m *MockRatingRepo) GetHistory(
+	ctx context.Context,
+	leaderboardID, userID string,
+	page *models.Pagination,
+) ([]models.RatingChange, error) {
+	args := m.Called(leaderboardID, userID, page)
+	return args.Get(0).([]models.RatingChange), args.Error(1)
+}
+
+type MockGameRepo struct {
+	mock.Mock
+}
+
+func (m *MockGameRepo) Create(ctx context.Context, request *models.GameRequest) (*models.Game, error) {
+	args := m.Called(request)
+	return args.Get(0).(*models.Game), args.Error(1)
+}
+
+func (m *MockGameRepo) Get(ctx context.Context, gameID string) (*models.Game, error) {
+	args := m.Called(gameID)
+	return args.Get(0).(*models.Game), args.Error(1)
+}
+
+func (m *MockGameRepo) CreateCategory(ctx context.Context, request *models.CategoryRequest) (*models.Category, error) {
+	args := m.Called(request)
+	return args.Get(0).(*models.Category), args.Error(1)
+}
+
+func (m *MockGameRepo) CreateVariable(ctx context.Context, request *models.VariableRequest) (*models.Variable, error) {
+	args := m.Called(request)
+	return args.Get(0).(*models.Variable), args.Error(1)
+}
+
+type MockMemberRepo struct {
+	mock.Mock
+}
+
+func (m *MockMemberRepo) GetAccess(ctx context.Context, leaderboardID, userID string) (*models.LeaderboardAccess, error) {
+	args := m.Called(leaderboardID, userID)
+	return args.Get(0).(*models.LeaderboardAccess), args.Error(1)
+}
+
+func (m *MockMemberRepo) AddMember(ctx context.Context, leaderboardID, userID string) (*models.LeaderboardMember, error) {
+	args := m.Called(leaderboardID, userID)
+	return args.Get(0).(*models.LeaderboardMember), args.Error(1)
+}
+
+func (m *MockMemberRepo) RemoveMember(ctx context.Context, leaderboardID, userID string) error {
+	args := m.Called(leaderboardID, userID)
 	return args.Error(0)
 }
+
+func (m *MockMemberRepo) CreateInvite(ctx context.Context, request *models.InviteRequest) (*models.LeaderboardInvite, error) {
+	args := m.Called(request)
+	return args.Get(0).(*models.LeaderboardInvite), args.Error(1)
+}
+
+func (m *MockMemberRepo) Join(ctx context.Context, code, userID string) (*models.LeaderboardMember, error) {
+	args := m.Called(code, userID)
+	return args.Get(0).(*models.LeaderboardMember), args.Error(1)
+}
+
+type MockTenantRepo struct {
+	mock.Mock
+}
+
+func (m *MockTenantRepo) Create(ctx context.Context, request *models.TenantRequest) (*models.Tenant, error) {
+	args := m.Called(request)
+	return args.Get(0).(*models.Tenant), args.Error(1)
+}
+
+func (m *MockTenantRepo) Get(ctx context.Context, tenantID string) (*models.Tenant, error) {
+	args := m.Called(tenantID)
+	return args.Get(0).(*models.Tenant), args.Error(1)
+}
+
+func (m *MockTenantRepo) SetMember(ctx context.Context, request *models.TenantMemberRequest) (*models.TenantMember, error) {
+	args := m.Called(request)
+	return args.Get(0).(*models.TenantMember), args.Error(1)
+}
+
+func (m *MockTenantRepo) GetMemberRole(ctx context.Context, tenantID, userID string) (string, error) {
+	args := m.Called(tenantID, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockTenantRepo) CreateAPIKey(ctx context.Context, request *models.APIKeyRequest, keyHash string) (*models.APIKey, error) {
+	args := m.Called(request, keyHash)
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockTenantRepo) RevokeAPIKey(ctx context.Context, tenantID, keyID string) error {
+	args := m.Called(tenantID, keyID)
+	return args.Error(0)
+}
+
+func (m *MockTenantRepo) GetByAPIKey(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	args := m.Called(keyHash)
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
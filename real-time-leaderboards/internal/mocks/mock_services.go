@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
+	cache "github.com/mochivi/go-real-time-leaderboards/internal/storage/redis"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -50,3 +51,68 @@ func (m *MockRedisService) JSONGet(ctx context.Context, key string, path string,
 	args := m.Called(key, path, target)
 	return args.Error(0)
 }
+
+func (m *MockRedisService) ZAdd(ctx context.Context, key string, member string, score float64) error {
+	args := m.Called(key, member, score)
+	return args.Error(0)
+}
+
+func (m *MockRedisService) ZRem(ctx context.Context, key string, member string) error {
+	args := m.Called(key, member)
+	return args.Error(0)
+}
+
+func (m *MockRedisService) ZReplace(ctx context.Context, key string, members map[string]float64) error {
+	args := m.Called(key, members)
+	return args.Error(0)
+}
+
+func (m *MockRedisService) ZAddGT(ctx context.Context, key string, member string, score float64) error {
+	args := m.Called(key, member, score)
+	return args.Error(0)
+}
+
+func (m *MockRedisService) ZScore(ctx context.Context, key string, member string) (float64, error) {
+	args := m.Called(key, member)
+	return args.Get(0).(float64), args.Error(1)
+}
+
+func (m *MockRedisService) ZRevRank(ctx context.Context, key string, member string) (int64, error) {
+	args := m.Called(key, member)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRedisService) ZCountDistinct(ctx context.Context, key string, min string, max string) (int64, error) {
+	args := m.Called(key, min, max)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRedisService) ZCount(ctx context.Context, key string, min string, max string) (int64, error) {
+	args := m.Called(key, min, max)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRedisService) ZCard(ctx context.Context, key string) (int64, error) {
+	args := m.Called(key)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRedisService) ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) ([]cache.ScoredMember, error) {
+	args := m.Called(key, start, stop)
+	return args.Get(0).([]cache.ScoredMember), args.Error(1)
+}
+
+func (m *MockRedisService) SReplace(ctx context.Context, key string, members []string) error {
+	args := m.Called(key, members)
+	return args.Error(0)
+}
+
+func (m *MockRedisService) Exists(ctx context.Context, key string) (bool, error) {
+	args := m.Called(key)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRedisService) ZInterWithSet(ctx context.Context, zsetKey, setKey string) ([]cache.ScoredMember, error) {
+	args := m.Called(zsetKey, setKey)
+	return args.Get(0).([]cache.ScoredMember), args.Error(1)
+}
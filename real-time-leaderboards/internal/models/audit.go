@@ -0,0 +1,76 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Audited actions, named after the target they change
+const (
+	AuditActionLeaderboardUpdate  = "leaderboard.update"
+	AuditActionLeaderboardDelete  = "leaderboard.delete"
+	AuditActionLeaderboardRestore = "leaderboard.restore"
+	AuditActionUserUpdate         = "user.update"
+	AuditActionUserDelete         = "user.delete"
+	AuditActionUserRestore        = "user.restore"
+	AuditActionUserErase          = "user.erase"
+	AuditActionUserBan            = "user.ban"
+	AuditActionUserUnban          = "user.unban"
+	AuditActionEntryVerify        = "entry.verify"
+	AuditActionEntryReject        = "entry.reject"
+	AuditActionEntryUpdate        = "entry.update"
+	AuditActionEntriesDelete      = "entries.bulk_delete"
+	AuditActionEntriesRescore     = "entries.bulk_rescore"
+	AuditActionEntriesRevert      = "entries.revert"
+	AuditActionTournamentStart    = "tournament.start"
+	AuditActionTournamentResult   = "tournament.result"
+)
+
+const (
+	AuditTargetLeaderboard = "leaderboard"
+	AuditTargetUser        = "user"
+	AuditTargetEntry       = "leaderboard_entry"
+	AuditTargetChangeSet   = "entry_change_set"
+	AuditTargetTournament  = "tournament"
+)
+
+// Who made an audited change, taken from the request claims
+type AuditActor struct {
+	UserID    string
+	Role      string
+	RequestID string
+}
+
+// A single row of the append-only audit log, only erasures redact the personal data of its snapshots
+// Before is empty for creations and After is empty for deletions
+type AuditEntry struct {
+	ID         string          `json:"id"`
+	ActorID    string          `json:"actor_id"`
+	ActorRole  string          `json:"actor_role"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type"`
+	TargetID   string          `json:"target_id"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+// Filters for the audit log query, empty fields are not applied
+type AuditLogFilter struct {
+	ActorID    string    `form:"actor_id"`
+	Action     string    `form:"action"`
+	TargetType string    `form:"target_type"`
+	TargetID   string    `form:"target_id"`
+	From       time.Time `form:"from"`
+	To         time.Time `form:"to"`
+	Pagination
+}
+
+func (f AuditLogFilter) Validate() error {
+	if !f.From.IsZero() && !f.To.IsZero() && f.To.Before(f.From) {
+		return errors.New("to must not be before from")
+	}
+	return nil
+}
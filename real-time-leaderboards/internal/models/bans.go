@@ -0,0 +1,62 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Banned users cannot log in or submit entries
+// Shadowbanned users keep playing, but their entries are only visible to themselves
+const (
+	BanTypeBan       = "ban"
+	BanTypeShadowban = "shadowban"
+)
+
+type Ban struct {
+	UserID    string     `json:"user_id"`
+	Username  string     `json:"username,omitempty"`
+	Type      string     `json:"type"`
+	Reason    string     `json:"reason"`
+	ExpiresAt *time.Time `json:"expires_at"` // Nil for permanent bans
+	BannedBy  string     `json:"banned_by,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Expired bans are kept until the expiry job clears them, they must not be enforced meanwhile
+func (b *Ban) Active(now time.Time) bool {
+	return b != nil && (b.ExpiresAt == nil || b.ExpiresAt.After(now))
+}
+
+type BanRequest struct {
+	Type      string     `json:"type"`
+	Reason    string     `json:"reason"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+func (r BanRequest) Validate(now time.Time) error {
+	if r.Type != BanTypeBan && r.Type != BanTypeShadowban {
+		return errors.New("type must be either 'ban' or 'shadowban'")
+	}
+	if r.Reason == "" {
+		return errors.New("reason is required")
+	}
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(now) {
+		return errors.New("expires_at must be in the future")
+	}
+	return nil
+}
+
+// Filters for the list of active bans, an empty Type lists both kinds
+type BanFilter struct {
+	Type string `form:"type"`
+	Pagination
+}
+
+func (u User) IsBanned(now time.Time) bool {
+	return u.Ban.Active(now) && u.Ban.Type == BanTypeBan
+}
+
+// Reports if the entries of the user are hidden from everyone else, true for both kinds of ban
+func (u User) IsHidden(now time.Time) bool {
+	return u.Ban.Active(now)
+}
@@ -0,0 +1,360 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+var (
+	ErrNotEnoughParticipants = errors.New("a tournament needs at least two participants")
+	ErrMatchNotFound         = errors.New("match does not exist")
+	ErrMatchNotReady         = errors.New("match is not ready to be played")
+	ErrNotInMatch            = errors.New("winner is not a player of the match")
+)
+
+// Creates the matches of a tournament between the participants, given from the first seed down
+// Elimination brackets are padded with byes up to a power of two, the top seeds get the byes
+func BuildBracket(format string, seeded []string) ([]TournamentMatch, error) {
+	if len(seeded) < 2 {
+		return nil, ErrNotEnoughParticipants
+	}
+
+	switch format {
+	case TournamentFormatSingleElimination:
+		matches := eliminationBracket(seeded, false)
+		resolveBracket(matches, map[int]bool{})
+		return matches, nil
+	case TournamentFormatDoubleElimination:
+		matches := eliminationBracket(seeded, true)
+		resolveBracket(matches, map[int]bool{})
+		return matches, nil
+	case TournamentFormatRoundRobin:
+		return roundRobinSchedule(seeded), nil
+	}
+
+	return nil, fmt.Errorf("unknown tournament format %q", format)
+}
+
+// Records the result of a ready match and advances the players, byes met on the way are resolved too
+// Returns the numbers of every match that changed, in order
+func RecordResult(matches []TournamentMatch, number int, result MatchResult, now time.Time) ([]int, error) {
+	if number < 0 || number >= len(matches) {
+		return nil, ErrMatchNotFound
+	}
+
+	match := &matches[number]
+	if match.Status != MatchStatusReady {
+		return nil, ErrMatchNotReady
+	}
+	if result.WinnerID != match.Player1ID && result.WinnerID != match.Player2ID {
+		return nil, ErrNotInMatch
+	}
+
+	match.WinnerID = result.WinnerID
+	match.Player1Score = result.Player1Score
+	match.Player2Score = result.Player2Score
+	match.Status = MatchStatusCompleted
+	match.CompletedAt = &now
+
+	changed := map[int]bool{number: true}
+	advance(matches, number, changed)
+	resolveBracket(matches, changed)
+
+	numbers := make([]int, 0, len(changed))
+	for n := range changed {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	return numbers, nil
+}
+
+// Returns the winner once the tournament is decided, empty while matches remain to be played
+// Elimination tournaments are won by the winner of the final, the only match that leads nowhere
+func TournamentWinner(format string, matches []TournamentMatch, participants []TournamentParticipant) string {
+	if format == TournamentFormatRoundRobin {
+		for _, match := range matches {
+			if match.Status != MatchStatusCompleted {
+				return ""
+			}
+		}
+		standings := RoundRobinStandings(participants, matches)
+		if len(standings) == 0 {
+			return ""
+		}
+		return standings[0].User.ID
+	}
+
+	for _, match := range matches {
+		if match.Next == nil && match.LoserNext == nil && match.Status == MatchStatusCompleted {
+			return match.WinnerID
+		}
+	}
+	return ""
+}
+
+// Tallies the completed round robin matches of every participant
+func RoundRobinStandings(participants []TournamentParticipant, matches []TournamentMatch) []TournamentStanding {
+	standings := make([]TournamentStanding, 0, len(participants))
+	index := make(map[string]int, len(participants))
+	for _, participant := range participants {
+		index[participant.User.ID] = len(standings)
+		standings = append(standings, TournamentStanding{User: participant.User, Seed: participant.Seed})
+	}
+
+	tally := func(playerID string, won bool, scoreFor, scoreAgainst *int) {
+		i, ok := index[playerID]
+		if !ok {
+			return
+		}
+		if won {
+			standings[i].Wins++
+		} else {
+			standings[i].Losses++
+		}
+		if scoreFor != nil && scoreAgainst != nil {
+			standings[i].ScoreFor += *scoreFor
+			standings[i].ScoreAgainst += *scoreAgainst
+		}
+	}
+
+	for _, match := range matches {
+		if match.Status != MatchStatusCompleted {
+			continue
+		}
+		tally(match.Player1ID, match.WinnerID == match.Player1ID, match.Player1Score, match.Player2Score)
+		tally(match.Player2ID, match.WinnerID == match.Player2ID, match.Player2Score, match.Player1Score)
+	}
+
+	sort.SliceStable(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+		if a.Wins != b.Wins {
+			return a.Wins > b.Wins
+		}
+		if diffA, diffB := a.ScoreFor-a.ScoreAgainst, b.ScoreFor-b.ScoreAgainst; diffA != diffB {
+			return diffA > diffB
+		}
+		return a.Seed < b.Seed
+	})
+	return standings
+}
+
+// Seeds in bracket order, so that seed 1 and 2 can only meet in the final
+func seedOrder(size int) []int {
+	order := []int{1}
+	for len(order) < size {
+		n := len(order) * 2
+		next := make([]int, 0, n)
+		for _, seed := range order {
+			next = append(next, seed, n+1-seed)
+		}
+		order = next
+	}
+	return order
+}
+
+// Builds the winners bracket and, for double elimination, the losers bracket and the grand final
+// The losers bracket alternates rounds between its own survivors and the players dropping from the
+// winners bracket, who are fed in reverse order to avoid early rematches
+// The grand final is a single match, there is no reset when the losers bracket player wins it
+func eliminationBracket(seeded []string, double bool) []TournamentMatch {
+	size, rounds := 2, 1
+	for size < len(seeded) {
+		size *= 2
+		rounds++
+	}
+
+	matches := make([]TournamentMatch, 0, 2*size)
+	add := func(bracket string, round, position int) int {
+		matches = append(matches, TournamentMatch{
+			Number:   len(matches),
+			Bracket:  bracket,
+			Round:    round,
+			Position: position,
+			Status:   MatchStatusPending,
+		})
+		return len(matches) - 1
+	}
+	link := func(match, slot int) *MatchSlot {
+		return &MatchSlot{Match: match, Slot: slot}
+	}
+
+	winners := make([][]int, rounds+1)
+	for round := 1; round <= rounds; round++ {
+		for position := 0; position < size>>round; position++ {
+			winners[round] = append(winners[round], add(BracketWinners, round, position))
+		}
+	}
+
+	order := seedOrder(size)
+	seedAt := func(seed int) string {
+		if seed > len(seeded) {
+			return ""
+		}
+		return seeded[seed-1]
+	}
+	for position, number := range winners[1] {
+		matches[number].Player1ID = seedAt(order[2*position])
+		matches[number].Player2ID = seedAt(order[2*position+1])
+	}
+
+	for round := 1; round < rounds; round++ {
+		for position, number := range winners[round] {
+			matches[number].Next = link(winners[round+1][position/2], position%2+1)
+		}
+	}
+
+	if !double {
+		return matches
+	}
+
+	final := winners[rounds][0]
+	if rounds == 1 {
+		grandFinal := add(BracketGrandFinal, 1, 0)
+		matches[final].Next = link(grandFinal, 1)
+		matches[final].LoserNext = link(grandFinal, 2)
+		return matches
+	}
+
+	loserRounds := 2 * (rounds - 1)
+	losers := make([][]int, loserRounds+1)
+	for round := 1; round <= loserRounds; round++ {
+		for position := 0; position < size>>((round+1)/2+1); position++ {
+			losers[round] = append(losers[round], add(BracketLosers, round, position))
+		}
+	}
+
+	for position, number := range winners[1] {
+		matches[number].LoserNext = link(losers[1][position/2], position%2+1)
+	}
+	for round := 2; round <= rounds; round++ {
+		drop := losers[2*(round-1)]
+		for position, number := range winners[round] {
+			matches[number].LoserNext = link(drop[len(drop)-1-position], 2)
+		}
+	}
+
+	for round := 1; round < loserRounds; round++ {
+		for position, number := range losers[round] {
+			if round%2 == 1 {
+				matches[number].Next = link(losers[round+1][position], 1)
+			} else {
+				matches[number].Next = link(losers[round+1][position/2], position%2+1)
+			}
+		}
+	}
+
+	grandFinal := add(BracketGrandFinal, 1, 0)
+	matches[final].Next = link(grandFinal, 1)
+	matches[losers[loserRounds][0]].Next = link(grandFinal, 2)
+	return matches
+}
+
+// Pairs every participant with every other one using the circle method, a participant sits a round
+// out when the count is odd
+func roundRobinSchedule(seeded []string) []TournamentMatch {
+	players := append([]string(nil), seeded...)
+	if len(players)%2 == 1 {
+		players = append(players, "")
+	}
+	n := len(players)
+
+	matches := make([]TournamentMatch, 0, n*(n-1)/2)
+	for round := 1; round < n; round++ {
+		position := 0
+		for i := 0; i < n/2; i++ {
+			player1, player2 := players[i], players[n-1-i]
+			if player1 == "" || player2 == "" {
+				continue
+			}
+			matches = append(matches, TournamentMatch{
+				Number:    len(matches),
+				Bracket:   BracketRoundRobin,
+				Round:     round,
+				Position:  position,
+				Player1ID: player1,
+				Player2ID: player2,
+				Status:    MatchStatusReady,
+			})
+			position++
+		}
+
+		// The first player stays in place while the others rotate
+		rotated := make([]string, 0, n)
+		rotated = append(rotated, players[0], players[n-1])
+		players = append(rotated, players[1:n-1]...)
+	}
+	return matches
+}
+
+// Moves the winner and loser of a completed match into the matches they lead to
+func advance(matches []TournamentMatch, number int, changed map[int]bool) {
+	match := matches[number]
+	if match.Next != nil {
+		setPlayer(&matches[match.Next.Match], match.Next.Slot, match.WinnerID)
+		changed[match.Next.Match] = true
+	}
+	if match.LoserNext != nil {
+		setPlayer(&matches[match.LoserNext.Match], match.LoserNext.Slot, match.LoserID())
+		changed[match.LoserNext.Match] = true
+	}
+}
+
+func setPlayer(match *TournamentMatch, slot int, playerID string) {
+	if slot == 1 {
+		match.Player1ID = playerID
+	} else {
+		match.Player2ID = playerID
+	}
+}
+
+// Marks the matches whose players are both known as ready, and completes the matches left with a single
+// player or none, which cascades through the bracket until nothing changes
+func resolveBracket(matches []TournamentMatch, changed map[int]bool) {
+	// Every slot is fed by at most one earlier match, slots without one were seeded
+	feeders := make([][2]int, len(matches))
+	for i := range feeders {
+		feeders[i] = [2]int{-1, -1}
+	}
+	for _, match := range matches {
+		if match.Next != nil {
+			feeders[match.Next.Match][match.Next.Slot-1] = match.Number
+		}
+		if match.LoserNext != nil {
+			feeders[match.LoserNext.Match][match.LoserNext.Slot-1] = match.Number
+		}
+	}
+	decided := func(number, slot int) bool {
+		feeder := feeders[number][slot]
+		return feeder == -1 || matches[feeder].Status == MatchStatusCompleted
+	}
+
+	for progress := true; progress; {
+		progress = false
+		for i := range matches {
+			match := &matches[i]
+			if match.Status == MatchStatusCompleted || !decided(i, 0) || !decided(i, 1) {
+				continue
+			}
+
+			if match.Player1ID != "" && match.Player2ID != "" {
+				if match.Status != MatchStatusReady {
+					match.Status = MatchStatusReady
+					changed[i] = true
+				}
+				continue
+			}
+
+			// Byes are not played, the remaining player goes through without a score
+			match.WinnerID = match.Player1ID
+			if match.WinnerID == "" {
+				match.WinnerID = match.Player2ID
+			}
+			match.Status = MatchStatusCompleted
+			changed[i] = true
+			advance(matches, i, changed)
+			progress = true
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Plays every ready match, the lower seed always wins
+func playBracket(t *testing.T, matches []TournamentMatch, seeds map[string]int) {
+	for played := true; played; {
+		played = false
+		for _, match := range matches {
+			if match.Status != MatchStatusReady {
+				continue
+			}
+			winner := match.Player1ID
+			if seeds[match.Player2ID] < seeds[winner] {
+				winner = match.Player2ID
+			}
+			_, err := RecordResult(matches, match.Number, MatchResult{WinnerID: winner}, time.Now())
+			assert.NoError(t, err)
+			played = true
+		}
+	}
+}
+
+func seedsOf(players []string) map[string]int {
+	seeds := make(map[string]int, len(players))
+	for i, player := range players {
+		seeds[player] = i + 1
+	}
+	return seeds
+}
+
+func TestSeedOrder(t *testing.T) {
+	assert.Equal(t, []int{1, 2}, seedOrder(2))
+	assert.Equal(t, []int{1, 4, 2, 3}, seedOrder(4))
+	assert.Equal(t, []int{1, 8, 4, 5, 2, 7, 3, 6}, seedOrder(8))
+}
+
+func TestBuildBracketSingleElimination(t *testing.T) {
+	players := []string{"a", "b", "c", "d", "e"}
+	matches, err := BuildBracket(TournamentFormatSingleElimination, players)
+	assert.NoError(t, err)
+	assert.Len(t, matches, 7)
+
+	// Seeds 1 to 3 get byes and are already in the second round
+	assert.Equal(t, MatchStatusCompleted, matches[0].Status)
+	assert.Equal(t, "a", matches[0].WinnerID)
+	assert.Equal(t, MatchStatusReady, matches[1].Status)
+	assert.Equal(t, []string{"d", "e"}, []string{matches[1].Player1ID, matches[1].Player2ID})
+	assert.Equal(t, "a", matches[4].Player1ID)
+	assert.Equal(t, MatchStatusPending, matches[4].Status)
+	assert.Equal(t, MatchStatusReady, matches[5].Status)
+
+	playBracket(t, matches, seedsOf(players))
+	assert.Equal(t, "a", TournamentWinner(TournamentFormatSingleElimination, matches, nil))
+	assert.Equal(t, "b", matches[6].LoserID())
+}
+
+func TestBuildBracketDoubleElimination(t *testing.T) {
+	players := []string{"a", "b", "c", "d"}
+	matches, err := BuildBracket(TournamentFormatDoubleElimination, players)
+	assert.NoError(t, err)
+
+	// 3 winners bracket matches, 2 losers bracket matches and the grand final
+	assert.Len(t, matches, 6)
+	assert.Equal(t, BracketGrandFinal, matches[5].Bracket)
+	assert.Equal(t, "", TournamentWinner(TournamentFormatDoubleElimination, matches, nil))
+
+	// The first seed loses its first match and must come back through the losers bracket
+	_, err = RecordResult(matches, 0, MatchResult{WinnerID: "d"}, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, "a", matches[3].Player1ID)
+
+	playBracket(t, matches, seedsOf(players))
+	assert.Equal(t, "a", matches[4].WinnerID)
+	assert.Equal(t, "a", TournamentWinner(TournamentFormatDoubleElimination, matches, nil))
+}
+
+func TestRecordResult(t *testing.T) {
+	matches, err := BuildBracket(TournamentFormatSingleElimination, []string{"a", "b", "c", "d"})
+	assert.NoError(t, err)
+
+	_, err = RecordResult(matches, 9, MatchResult{WinnerID: "a"}, time.Now())
+	assert.ErrorIs(t, err, ErrMatchNotFound)
+	_, err = RecordResult(matches, 2, MatchResult{WinnerID: "a"}, time.Now())
+	assert.ErrorIs(t, err, ErrMatchNotReady)
+	_, err = RecordResult(matches, 0, MatchResult{WinnerID: "b"}, time.Now())
+	assert.ErrorIs(t, err, ErrNotInMatch)
+
+	changed, err := RecordResult(matches, 0, MatchResult{WinnerID: "d"}, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 2}, changed)
+	assert.Equal(t, "d", matches[2].Player1ID)
+	assert.Equal(t, MatchStatusPending, matches[2].Status)
+}
+
+func TestBuildBracketRoundRobin(t *testing.T) {
+	players := []string{"a", "b", "c"}
+	matches, err := BuildBracket(TournamentFormatRoundRobin, players)
+	assert.NoError(t, err)
+	assert.Len(t, matches, 3)
+
+	pairs := map[[2]string]bool{}
+	for _, match := range matches {
+		assert.Equal(t, MatchStatusReady, match.Status)
+		pair := [2]string{match.Player1ID, match.Player2ID}
+		if pair[0] > pair[1] {
+			pair = [2]string{pair[1], pair[0]}
+		}
+		pairs[pair] = true
+	}
+	assert.Len(t, pairs, 3)
+
+	participants := []TournamentParticipant{
+		{Seed: 1, User: User{ID: "a"}},
+		{Seed: 2, User: User{ID: "b"}},
+		{Seed: 3, User: User{ID: "c"}},
+	}
+	playBracket(t, matches, seedsOf(players))
+	standings := RoundRobinStandings(participants, matches)
+	assert.Equal(t, "a", standings[0].User.ID)
+	assert.Equal(t, 2, standings[0].Wins)
+	assert.Equal(t, "a", TournamentWinner(TournamentFormatRoundRobin, matches, participants))
+}
+
+func TestBuildBracketNotEnoughParticipants(t *testing.T) {
+	_, err := BuildBracket(TournamentFormatSingleElimination, []string{"a"})
+	assert.ErrorIs(t, err, ErrNotEnoughParticipants)
+}
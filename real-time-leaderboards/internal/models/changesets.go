@@ -0,0 +1,98 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Bulk operations on the entries of a leaderboard, every one of them can be reverted from its change set
+const (
+	ChangeSetOperationDelete  = "delete"
+	ChangeSetOperationRescore = "rescore"
+)
+
+const maxBulkEntryIDs = 1000
+
+// Selects the entries of a bulk operation, all the provided criteria must match
+// At least one is required so a mistake cannot wipe a whole leaderboard
+type BulkEntryFilter struct {
+	UserID   string     `json:"user_id,omitempty"`
+	From     *time.Time `json:"from,omitempty"` // Inclusive, on the submission time
+	To       *time.Time `json:"to,omitempty"`   // Exclusive
+	MinScore *int       `json:"min_score,omitempty"`
+	MaxScore *int       `json:"max_score,omitempty"`
+	EntryIDs []string   `json:"entry_ids,omitempty"`
+}
+
+func (f BulkEntryFilter) Validate() error {
+	if f.UserID == "" && f.From == nil && f.To == nil && f.MinScore == nil && f.MaxScore == nil && len(f.EntryIDs) == 0 {
+		return errors.New("filter must have at least one criterion")
+	}
+	if f.From != nil && f.To != nil && !f.To.After(*f.From) {
+		return errors.New("filter to must be after from")
+	}
+	if f.MinScore != nil && f.MaxScore != nil && *f.MaxScore < *f.MinScore {
+		return errors.New("filter max_score must not be lower than min_score")
+	}
+	if len(f.EntryIDs) > maxBulkEntryIDs {
+		return errors.New("filter can have at most 1000 entry_ids")
+	}
+	return nil
+}
+
+// A bulk delete or rescore, the new score is either Set or computed as round(score * Multiply) + Add
+type BulkEntryRequest struct {
+	LeaderboardID string          `json:"-"`
+	Operation     string          `json:"operation"`
+	Filter        BulkEntryFilter `json:"filter"`
+	Set           *int            `json:"set,omitempty"`
+	Multiply      *float64        `json:"multiply,omitempty"`
+	Add           int             `json:"add,omitempty"`
+}
+
+func (r BulkEntryRequest) Validate() error {
+	if err := r.Filter.Validate(); err != nil {
+		return err
+	}
+
+	rescores := r.Set != nil || r.Multiply != nil || r.Add != 0
+	switch r.Operation {
+	case ChangeSetOperationDelete:
+		if rescores {
+			return errors.New("set, multiply and add are only allowed when rescoring")
+		}
+	case ChangeSetOperationRescore:
+		if !rescores {
+			return errors.New("rescoring requires set, multiply or add")
+		}
+		if r.Set != nil && (r.Multiply != nil || r.Add != 0) {
+			return errors.New("set cannot be combined with multiply or add")
+		}
+		if r.Multiply != nil && *r.Multiply < 0 {
+			return errors.New("multiply must not be negative")
+		}
+	default:
+		return errors.New("operation must be either 'delete' or 'rescore'")
+	}
+
+	return nil
+}
+
+// Record of a bulk operation, holding a snapshot of every entry it changed so it can be reverted
+type EntryChangeSet struct {
+	ID            string           `json:"id"`
+	LeaderboardID string           `json:"leaderboard_id"`
+	Operation     string           `json:"operation"`
+	Request       BulkEntryRequest `json:"request"`
+	Entries       int              `json:"entries"`
+	CreatedBy     string           `json:"created_by,omitempty"`
+	CreatedAt     time.Time        `json:"created_at"`
+	RevertedBy    string           `json:"reverted_by,omitempty"`
+	RevertedAt    *time.Time       `json:"reverted_at,omitempty"`
+}
+
+// Filters for the change sets, an empty LeaderboardID lists every leaderboard
+type ChangeSetFilter struct {
+	LeaderboardID string `form:"leaderboard_id"`
+	Pagination
+}
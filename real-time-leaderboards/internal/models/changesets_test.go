@@ -0,0 +1,41 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkEntryRequestValidate(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+	multiply := 0.5
+	negative := -1.0
+
+	testCases := []struct {
+		name    string
+		request BulkEntryRequest
+		valid   bool
+	}{
+		{"delete by user", BulkEntryRequest{Operation: "delete", Filter: BulkEntryFilter{UserID: "1"}}, true},
+		{"delete by time range", BulkEntryRequest{Operation: "delete", Filter: BulkEntryFilter{From: &earlier, To: &now}}, true},
+		{"delete without filter", BulkEntryRequest{Operation: "delete"}, false},
+		{"delete with inverted time range", BulkEntryRequest{Operation: "delete", Filter: BulkEntryFilter{From: &now, To: &earlier}}, false},
+		{"delete with inverted score range", BulkEntryRequest{Operation: "delete", Filter: BulkEntryFilter{MinScore: intPtr(10), MaxScore: intPtr(5)}}, false},
+		{"delete with new score", BulkEntryRequest{Operation: "delete", Filter: BulkEntryFilter{UserID: "1"}, Add: 5}, false},
+		{"rescore with multiply and add", BulkEntryRequest{Operation: "rescore", Filter: BulkEntryFilter{UserID: "1"}, Multiply: &multiply, Add: 5}, true},
+		{"rescore with set", BulkEntryRequest{Operation: "rescore", Filter: BulkEntryFilter{UserID: "1"}, Set: intPtr(0)}, true},
+		{"rescore without new score", BulkEntryRequest{Operation: "rescore", Filter: BulkEntryFilter{UserID: "1"}}, false},
+		{"rescore with set and multiply", BulkEntryRequest{Operation: "rescore", Filter: BulkEntryFilter{UserID: "1"}, Set: intPtr(0), Multiply: &multiply}, false},
+		{"rescore with negative multiply", BulkEntryRequest{Operation: "rescore", Filter: BulkEntryFilter{UserID: "1"}, Multiply: &negative}, false},
+		{"unknown operation", BulkEntryRequest{Operation: "archive", Filter: BulkEntryFilter{UserID: "1"}}, false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.request.Validate()
+			assert.Equal(t, testCase.valid, err == nil, err)
+		})
+	}
+}
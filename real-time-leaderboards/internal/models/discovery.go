@@ -0,0 +1,113 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	TagsMaxCount          = 10
+	TagMaxLength          = 32
+	SearchQueryMaxLength  = 100
+	LeaderboardActiveDays = 7 // Entries submitted within this many days make a leaderboard active
+)
+
+// Orders of the leaderboard listing, searches are ordered by relevance unless another order is asked for
+const (
+	LeaderboardSortNewest      = "newest"
+	LeaderboardSortMostActive  = "most_active"  // Most entries submitted in the last LeaderboardActiveDays days
+	LeaderboardSortMostPlayers = "most_players" // Most users with a ranked entry
+	LeaderboardSortRelevance   = "relevance"
+)
+
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Labels grouping leaderboards for discovery, such as the platform or season
+type Tags []string
+
+// Tags are lowercased and trimmed, repeated tags are dropped
+func (t *Tags) Validate() error {
+	if *t == nil {
+		return nil
+	}
+	if len(*t) > TagsMaxCount {
+		return fmt.Errorf("at most %d tags can be set", TagsMaxCount)
+	}
+
+	tags := make(Tags, 0, len(*t))
+	seen := make(map[string]bool, len(*t))
+	for _, tag := range *t {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !tagPattern.MatchString(tag) || len(tag) > TagMaxLength {
+			return fmt.Errorf("tags must be up to %d lowercase letters, digits and dashes", TagMaxLength)
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	*t = tags
+	return nil
+}
+
+// Filters of the leaderboard listing, empty fields are not applied
+// Leaderboards are always listed from the tenant of the request, only public ones and private ones the viewer
+// is a member of are listed
+type LeaderboardListFilter struct {
+	Live       *bool  `form:"live"`
+	CategoryID string `form:"category_id"`
+	Tag        string `form:"tag"`
+	Query      string `form:"q"` // Searched in the name and description
+	Sort       string `form:"sort"`
+	ViewerID   string `form:"-"`
+	Pagination
+}
+
+// Defaults the order to relevance when searching and to newest otherwise
+func (f *LeaderboardListFilter) Validate() error {
+	f.Query = strings.TrimSpace(f.Query)
+	if utf8.RuneCountInString(f.Query) > SearchQueryMaxLength {
+		return fmt.Errorf("q must not be longer than %d characters", SearchQueryMaxLength)
+	}
+	f.Tag = strings.ToLower(strings.TrimSpace(f.Tag))
+
+	switch f.Sort {
+	case "":
+		f.Sort = LeaderboardSortNewest
+		if f.Query != "" {
+			f.Sort = LeaderboardSortRelevance
+		}
+	case LeaderboardSortNewest, LeaderboardSortMostActive, LeaderboardSortMostPlayers:
+	case LeaderboardSortRelevance:
+		if f.Query == "" {
+			return errors.New("sorting by relevance requires a search query")
+		}
+	default:
+		return fmt.Errorf(
+			"sort must be one of %s, %s, %s or %s",
+			LeaderboardSortNewest, LeaderboardSortMostActive, LeaderboardSortMostPlayers, LeaderboardSortRelevance,
+		)
+	}
+	return nil
+}
+
+// Summary of a leaderboard in the listing, its configuration and entries are returned by the leaderboard itself
+type LeaderboardListing struct {
+	ID            string     `json:"id"`
+	TenantID      string     `json:"tenant_id"`
+	Name          string     `json:"name"`
+	Description   string     `json:"description"`
+	Type          string     `json:"type"`
+	CategoryID    string     `json:"category_id,omitempty"`
+	Live          bool       `json:"live"`
+	Visibility    Visibility `json:"visibility"`
+	Tags          Tags       `json:"tags"`
+	Players       int        `json:"players"`
+	RecentEntries int        `json:"recent_entries"` // Submitted in the last LeaderboardActiveDays days
+	CreatedAt     time.Time  `json:"created_at"`
+}
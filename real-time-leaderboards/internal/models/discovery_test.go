@@ -0,0 +1,57 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTagsValidate(t *testing.T) {
+	tags := Tags{" PC ", "season-3", "pc"}
+	assert.NoError(t, tags.Validate())
+	assert.Equal(t, Tags{"pc", "season-3"}, tags)
+
+	var none Tags
+	assert.NoError(t, none.Validate())
+	assert.Nil(t, none)
+
+	invalid := Tags{"speed run"}
+	assert.Error(t, invalid.Validate())
+
+	tooMany := make(Tags, TagsMaxCount+1)
+	for i := range tooMany {
+		tooMany[i] = "tag"
+	}
+	assert.Error(t, tooMany.Validate())
+}
+
+func TestLeaderboardListFilterValidate(t *testing.T) {
+	testCases := []struct {
+		name         string
+		filter       LeaderboardListFilter
+		expectedSort string
+		wantErr      bool
+	}{
+		{name: "newest by default", filter: LeaderboardListFilter{}, expectedSort: LeaderboardSortNewest},
+		{name: "relevance when searching", filter: LeaderboardListFilter{Query: "speedrun"}, expectedSort: LeaderboardSortRelevance},
+		{
+			name:         "most players when searching",
+			filter:       LeaderboardListFilter{Query: "speedrun", Sort: LeaderboardSortMostPlayers},
+			expectedSort: LeaderboardSortMostPlayers,
+		},
+		{name: "relevance without search", filter: LeaderboardListFilter{Sort: LeaderboardSortRelevance}, wantErr: true},
+		{name: "unknown sort", filter: LeaderboardListFilter{Sort: "oldest"}, wantErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.filter.Validate()
+			if testCase.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedSort, testCase.filter.Sort)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Friend requests wait as pending until the other user accepts them, friendships are mutual
+const (
+	FriendshipStatusPending  = "pending"
+	FriendshipStatusAccepted = "accepted"
+)
+
+// Scopes of the ranked entries of a leaderboard
+const (
+	EntryScopeGlobal  = "global"
+	EntryScopeFriends = "friends"
+)
+
+type FriendRequest struct {
+	UserID string `json:"user_id"`
+}
+
+func (r FriendRequest) Validate(requesterID string) error {
+	if r.UserID == "" {
+		return errors.New("user_id is required")
+	}
+	if r.UserID == requesterID {
+		return errors.New("players cannot befriend themselves")
+	}
+	return nil
+}
+
+// Relationship as seen by one of the users, User is the other side
+type Friendship struct {
+	User       User       `json:"user"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+}
+
+// Set holding the user and their friends, intersected with a ranking to rank a leaderboard among friends
+func (u User) CircleKey() string {
+	return fmt.Sprintf("user:%s:circle", u.ID)
+}
+
+// Position of a user in a ranking, players with the same score share the rank
+type RankedEntry struct {
+	Rank      int               `json:"rank"`
+	User      User              `json:"user"`
+	Score     int               `json:"score"`
+	Variables map[string]string `json:"variables,omitempty"` // Variant of the ranked run, in rankings filtered by variables
+	Metadata  Metadata          `json:"metadata,omitempty"`  // Details of the ranked run, in rankings filtered by variables or metadata
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	GameNameMaxLength      = 100
+	CategoryNameMaxLength  = 50
+	VariableNameMaxLength  = 30
+	VariableMaxValues      = 50
+	VariableValueMaxLength = 50
+)
+
+// Games group the categories their runs are ranked in, such as Any% and 100%
+// Every category is ranked by its own leaderboard, its variables split the ranking into variants
+type Game struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Categories []Category `json:"categories"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+type GameRequest struct {
+	Name string `json:"name"`
+}
+
+func (r *GameRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(r.Name) > GameNameMaxLength {
+		return fmt.Errorf("name must not be longer than %d characters", GameNameMaxLength)
+	}
+	return nil
+}
+
+// Category of a game, LeaderboardID is empty until a leaderboard is created for the category
+type Category struct {
+	ID            string     `json:"id"`
+	GameID        string     `json:"game_id"`
+	Name          string     `json:"name"`
+	LeaderboardID string     `json:"leaderboard_id,omitempty"`
+	Variables     []Variable `json:"variables"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type CategoryRequest struct {
+	GameID string `json:"-"`
+	Name   string `json:"name"`
+}
+
+func (r *CategoryRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(r.Name) > CategoryNameMaxLength {
+		return fmt.Errorf("name must not be longer than %d characters", CategoryNameMaxLength)
+	}
+	return nil
+}
+
+// Variable of a category, such as the platform, version or region of a run
+// Runs on a category with variables pick one of the allowed values, required variables cannot be left out
+type Variable struct {
+	ID         string    `json:"id"`
+	CategoryID string    `json:"category_id"`
+	Name       string    `json:"name"`
+	Values     []string  `json:"values"`
+	Required   bool      `json:"required"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type VariableRequest struct {
+	CategoryID string   `json:"-"`
+	Name       string   `json:"name"`
+	Values     []string `json:"values"`
+	Required   bool     `json:"required"`
+}
+
+func (r *VariableRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(r.Name) > VariableNameMaxLength {
+		return fmt.Errorf("name must not be longer than %d characters", VariableNameMaxLength)
+	}
+
+	if len(r.Values) == 0 || len(r.Values) > VariableMaxValues {
+		return fmt.Errorf("a variable needs between 1 and %d values", VariableMaxValues)
+	}
+	for i, value := range r.Values {
+		value = strings.TrimSpace(value)
+		if value == "" || len(value) > VariableValueMaxLength {
+			return fmt.Errorf("values must not be empty nor longer than %d characters", VariableValueMaxLength)
+		}
+		if slices.Contains(r.Values[:i], value) {
+			return fmt.Errorf("value %s is declared more than once", value)
+		}
+		r.Values[i] = value
+	}
+	return nil
+}
+
+// Checks the variable values of a run against the variables of its category
+// Returns a *ScoreRejection so the run is refused the same way as a score breaking the leaderboard rules
+func ValidateVariableValues(variables []Variable, values map[string]string) error {
+	for _, variable := range variables {
+		value, ok := values[variable.Name]
+		if !ok {
+			if variable.Required {
+				return &ScoreRejection{Reason: fmt.Sprintf("variable %s is required", variable.Name)}
+			}
+			continue
+		}
+		if !slices.Contains(variable.Values, value) {
+			return &ScoreRejection{Reason: fmt.Sprintf(
+				"%s must be one of %s", variable.Name, strings.Join(variable.Values, ", "),
+			)}
+		}
+	}
+
+	for name := range values {
+		if !slices.ContainsFunc(variables, func(variable Variable) bool { return variable.Name == name }) {
+			return &ScoreRejection{Reason: fmt.Sprintf("variable %s is not defined for this leaderboard", name)}
+		}
+	}
+	return nil
+}
+
+// Filters the ranked runs of a leaderboard by variable values, variables left out are aggregated
+// No values at all ranks every variant together, as the leaderboard ranking does
+// Metadata further restricts the runs to the ones with the given text value for each metadata key
+type VariantFilter struct {
+	LeaderboardID string
+	RankMode      RankMode
+	Values        map[string]string
+	Metadata      map[string]string
+	Page          Pagination
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVariableRequestValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		request VariableRequest
+		wantErr bool
+	}{
+		{name: "platform", request: VariableRequest{Name: "platform", Values: []string{"PC", " Switch "}}},
+		{name: "missing name", request: VariableRequest{Name: " ", Values: []string{"PC"}}, wantErr: true},
+		{name: "no values", request: VariableRequest{Name: "platform"}, wantErr: true},
+		{name: "empty value", request: VariableRequest{Name: "platform", Values: []string{"PC", ""}}, wantErr: true},
+		{name: "duplicated value", request: VariableRequest{Name: "platform", Values: []string{"PC", "PC "}}, wantErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.request.Validate()
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateVariableValues(t *testing.T) {
+	variables := []Variable{
+		{Name: "platform", Values: []string{"PC", "Switch"}, Required: true},
+		{Name: "version", Values: []string{"1.0", "1.1"}},
+	}
+
+	testCases := []struct {
+		name    string
+		values  map[string]string
+		wantErr bool
+	}{
+		{name: "every variable", values: map[string]string{"platform": "PC", "version": "1.1"}},
+		{name: "optional variable left out", values: map[string]string{"platform": "Switch"}},
+		{name: "required variable left out", values: map[string]string{"version": "1.0"}, wantErr: true},
+		{name: "value not allowed", values: map[string]string{"platform": "Xbox"}, wantErr: true},
+		{name: "unknown variable", values: map[string]string{"platform": "PC", "region": "EU"}, wantErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := ValidateVariableValues(variables, testCase.values)
+			if testCase.wantErr {
+				var rejection *ScoreRejection
+				assert.True(t, errors.As(err, &rejection))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	// Leaderboards without a category only take runs without variables
+	assert.NoError(t, ValidateVariableValues(nil, nil))
+	assert.Error(t, ValidateVariableValues(nil, map[string]string{"platform": "PC"}))
+}
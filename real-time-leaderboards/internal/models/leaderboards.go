@@ -6,10 +6,24 @@ import (
 )
 
 type LeaderboardRequest struct {
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Live        bool      `json:"live"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	Name                 string          `json:"name"`
+	Description          string          `json:"description"`
+	Type                 string          `json:"type"`                    // Cannot be changed once the leaderboard is created
+	RatingSystem         string          `json:"rating_system,omitempty"` // Rating leaderboards only
+	CategoryID           string          `json:"category_id,omitempty"`   // Game category ranked, fixed at creation as entries carry its variables
+	Live                 bool            `json:"live"`
+	Visibility           Visibility      `json:"visibility"`
+	Tags                 Tags            `json:"tags,omitempty"`
+	RequiresVerification bool            `json:"requires_verification"`
+	ScoreRules           ScoreRules      `json:"score_rules"`
+	TieBreakers          TieBreakers     `json:"tie_breakers,omitempty"`
+	RankMode             RankMode        `json:"rank_mode"`
+	Tiers                Tiers           `json:"tiers,omitempty"`
+	TeamRanking          *TeamRanking    `json:"team_ranking,omitempty"`
+	Metadata             Metadata        `json:"metadata,omitempty"`
+	MetadataSchema       *MetadataSchema `json:"metadata_schema,omitempty"` // Checked against the metadata of every new entry
+	CreatedBy            string          `json:"-"`                         // Joins private leaderboards as their first member
+	UpdatedAt            time.Time       `json:"updated_at"`
 }
 
 func (l *LeaderboardRequest) AddUpdatedAt() {
@@ -17,11 +31,21 @@ func (l *LeaderboardRequest) AddUpdatedAt() {
 }
 
 type UpdateLeaderboardRequest struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Live        bool      `json:"live"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID                   string          `json:"id"`
+	Name                 string          `json:"name"`
+	Description          string          `json:"description"`
+	Live                 bool            `json:"live"`
+	Visibility           Visibility      `json:"visibility"`
+	Tags                 Tags            `json:"tags,omitempty"`
+	RequiresVerification bool            `json:"requires_verification"`
+	ScoreRules           ScoreRules      `json:"score_rules"`
+	TieBreakers          TieBreakers     `json:"tie_breakers,omitempty"`
+	RankMode             RankMode        `json:"rank_mode"`
+	Tiers                Tiers           `json:"tiers,omitempty"`
+	TeamRanking          *TeamRanking    `json:"team_ranking,omitempty"`
+	Metadata             Metadata        `json:"metadata,omitempty"`
+	MetadataSchema       *MetadataSchema `json:"metadata_schema,omitempty"` // Checked against the metadata of every new entry
+	UpdatedAt            time.Time       `json:"updated_at"`
 }
 
 // Identify which fields changes have been submitted to
@@ -30,35 +54,130 @@ func (l *UpdateLeaderboardRequest) AddUpdatedAt() {
 }
 
 type Leaderboard struct {
-	ID          string             `json:"id"`
-	Name        string             `json:"name"`
-	Description string             `json:"description"`
-	Live        bool               `json:"live"`
-	Entries     []LeaderboardEntry `json:"entries"`
-	CreatedAt   time.Time          `json:"created_at"`
-	UpdatedAt   time.Time          `json:"updated_at"`
+	ID                   string             `json:"id"`
+	TenantID             string             `json:"tenant_id"`
+	Name                 string             `json:"name"`
+	Description          string             `json:"description"`
+	Type                 string             `json:"type"`
+	RatingSystem         string             `json:"rating_system,omitempty"`
+	CategoryID           string             `json:"category_id,omitempty"`
+	Live                 bool               `json:"live"`
+	Visibility           Visibility         `json:"visibility"`
+	Tags                 Tags               `json:"tags,omitempty"`
+	RequiresVerification bool               `json:"requires_verification"`
+	ScoreRules           ScoreRules         `json:"score_rules"`
+	TieBreakers          TieBreakers        `json:"tie_breakers,omitempty"`
+	RankMode             RankMode           `json:"rank_mode"`
+	Tiers                Tiers              `json:"tiers,omitempty"`
+	TeamRanking          *TeamRanking       `json:"team_ranking,omitempty"`
+	Metadata             Metadata           `json:"metadata,omitempty"`
+	MetadataSchema       *MetadataSchema    `json:"metadata_schema,omitempty"`
+	Entries              []LeaderboardEntry `json:"entries"`
+	CreatedAt            time.Time          `json:"created_at"`
+	UpdatedAt            time.Time          `json:"updated_at"`
 }
 
+// Redis keys of the leaderboard are scoped by its tenant, see TenantKey
 func (l Leaderboard) RedisKey() string {
-	return fmt.Sprintf("leaderboard:%s", l.ID)
+	return TenantKey(l.TenantID, fmt.Sprintf("leaderboard:%s", l.ID))
+}
+
+// Sorted set holding the best accepted score of every user on the leaderboard, encoded with its tie-breakers
+// in a sort key
+func (l Leaderboard) RankingKey() string {
+	return TenantKey(l.TenantID, fmt.Sprintf("leaderboard:%s:ranking", l.ID))
+}
+
+// Entry statuses, only accepted and verified entries are part of the public ranking
+// Pending and flagged entries wait in the moderation queue
+const (
+	EntryStatusAccepted = "accepted"
+	EntryStatusFlagged  = "flagged"
+	EntryStatusPending  = "pending"
+	EntryStatusVerified = "verified"
+	EntryStatusRejected = "rejected"
+)
+
+// Sort key of the best ranked entry of a user on a leaderboard, or their conservative rating on a rating leaderboard
+// Hidden scores belong to banned or shadowbanned users, or to users left without a ranked entry,
+// and must not be in the Redis ranking
+type RankedScore struct {
+	TenantID      string
+	LeaderboardID string
+	UserID        string
+	Score         float64
+	Hidden        bool
+}
+
+// Reports if entries with the status are part of the public ranking
+func IsRankedStatus(status string) bool {
+	return status == EntryStatusAccepted || status == EntryStatusVerified
 }
 
 type LeaderboardEntryRequest struct {
-	LeaderboardID string    `json:"leaderboard_id"`
-	UserID        string    `json:"user_id"`
-	Score         int       `json:"score"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	LeaderboardID  string            `json:"leaderboard_id"`
+	UserID         string            `json:"user_id"`
+	Score          int               `json:"score"`
+	SecondaryScore *int              `json:"secondary_score,omitempty"` // Breaks ties on leaderboards ranking a secondary score
+	ProofURL       string            `json:"proof_url"`                 // Video or screenshot backing the run, checked by moderators
+	Notes          string            `json:"notes"`
+	Variables      map[string]string `json:"variables,omitempty"` // Variant of the run, on leaderboards ranking a game category
+	Metadata       Metadata          `json:"metadata,omitempty"`  // Details of the run, matching the metadata schema of the leaderboard
+	Status         string            `json:"-"`                   // Decided by the server when screening the entry
+	FlagReason     string            `json:"-"`
+	Hidden         bool              `json:"-"` // Submitted by a shadowbanned user, kept out of the rankings and distribution
+	UpdatedAt      time.Time         `json:"updated_at"`
 }
 
 func (l *LeaderboardEntryRequest) AddUpdatedAt() {
 	l.UpdatedAt = time.Now()
 }
 
+func (l *LeaderboardEntryRequest) Validate() error {
+	if err := validateProofURL(l.ProofURL); err != nil {
+		return err
+	}
+	return l.Metadata.Validate()
+}
+
 type LeaderboardEntry struct {
-	ID            string    `json:"id"`
-	LeaderboardID string    `json:"leaderboard_id"`
-	User          User      `json:"user"`
-	Score         int       `json:"score"`
-	CreatedAt     time.Time `json:"created_at"`
-	UpdatedAt     time.Time `json:"updated_at"`
+	ID             string            `json:"id"`
+	LeaderboardID  string            `json:"leaderboard_id"`
+	User           User              `json:"user"`
+	Score          int               `json:"score"`
+	SecondaryScore *int              `json:"secondary_score,omitempty"`
+	Attempt        int               `json:"attempt"`            // Submissions of the user on the leaderboard up to this entry
+	SortKey        float64           `json:"-"`                  // Score and tie-breakers as ranked in Redis
+	Standing       *Standing         `json:"standing,omitempty"` // Percentile of the score, returned when it is submitted
+	Status         string            `json:"status"`
+	FlagReason     string            `json:"flag_reason,omitempty"`
+	ProofURL       string            `json:"proof_url,omitempty"`
+	Notes          string            `json:"notes,omitempty"`
+	Variables      map[string]string `json:"variables,omitempty"`
+	Metadata       Metadata          `json:"metadata,omitempty"`
+	ReviewReason   string            `json:"review_reason,omitempty"`
+	ReviewedBy     string            `json:"reviewed_by,omitempty"`
+	ReviewedAt     *time.Time        `json:"reviewed_at,omitempty"`
+	Version        int               `json:"version"` // Bumped on every change, sent back in If-Match to update the entry
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// Replaces the proof and notes of an entry, Score is left unchanged when nil and can only be set by administrators
+type UpdateEntryRequest struct {
+	ID       string `json:"-"`
+	OwnerID  string `json:"-"` // Restricts the update to entries of this user, empty for administrators
+	Version  int    `json:"-"` // Taken from the If-Match header
+	Score    *int   `json:"score"`
+	ProofURL string `json:"proof_url"`
+	Notes    string `json:"notes"`
+}
+
+func (r *UpdateEntryRequest) Validate() error {
+	return validateProofURL(r.ProofURL)
+}
+
+// Entity tag of the entry, the version is the only thing that changes it
+func (l LeaderboardEntry) ETag() string {
+	return fmt.Sprintf(`"%d"`, l.Version)
 }
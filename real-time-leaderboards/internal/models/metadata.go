@@ -0,0 +1,235 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	MetadataMaxBytes       = 4096
+	MetadataFilterMaxKeys  = 5
+	MetadataSchemaMaxDepth = 5
+)
+
+// Free-form details attached to entries and leaderboards, such as the character or build version of a run
+type Metadata map[string]any
+
+func (m Metadata) Validate() error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return errors.New("metadata must be a JSON object")
+	}
+	if len(data) > MetadataMaxBytes {
+		return fmt.Errorf("metadata must not be larger than %d bytes", MetadataMaxBytes)
+	}
+	return nil
+}
+
+// Value types a MetadataSchema can require, as named by JSON Schema
+var metadataSchemaTypes = []string{"object", "array", "string", "number", "integer", "boolean", "null"}
+
+// Compiled schema patterns, the schema is read with the leaderboard for every submitted run
+var metadataPatterns sync.Map
+
+func compileMetadataPattern(pattern string) (*regexp.Regexp, error) {
+	if compiled, ok := metadataPatterns.Load(pattern); ok {
+		return compiled.(*regexp.Regexp), nil
+	}
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	metadataPatterns.Store(pattern, compiled)
+	return compiled, nil
+}
+
+// Subset of JSON Schema the metadata of the entries of a leaderboard must match
+// Keywords outside of this subset are ignored
+type MetadataSchema struct {
+	Type                 string                     `json:"type,omitempty"`
+	Properties           map[string]*MetadataSchema `json:"properties,omitempty"`
+	Required             []string                   `json:"required,omitempty"`
+	AdditionalProperties *bool                      `json:"additionalProperties,omitempty"`
+	Items                *MetadataSchema            `json:"items,omitempty"`
+	Enum                 []any                      `json:"enum,omitempty"`
+	Minimum              *float64                   `json:"minimum,omitempty"`
+	Maximum              *float64                   `json:"maximum,omitempty"`
+	MinLength            *int                       `json:"minLength,omitempty"`
+	MaxLength            *int                       `json:"maxLength,omitempty"`
+	Pattern              string                     `json:"pattern,omitempty"`
+}
+
+// Validates the schema itself, used when creating or updating a leaderboard
+// A nil schema accepts any metadata, the schema must describe an object when set
+func (s *MetadataSchema) Validate() error {
+	if s == nil {
+		return nil
+	}
+	if s.Type != "" && s.Type != "object" {
+		return errors.New("metadata_schema must describe an object")
+	}
+	return s.validate("metadata_schema", 0)
+}
+
+func (s *MetadataSchema) validate(path string, depth int) error {
+	if depth > MetadataSchemaMaxDepth {
+		return fmt.Errorf("metadata_schema must not be nested deeper than %d levels", MetadataSchemaMaxDepth)
+	}
+	if s.Type != "" && !slices.Contains(metadataSchemaTypes, s.Type) {
+		return fmt.Errorf("%s has unknown type %s", path, s.Type)
+	}
+	if s.Minimum != nil && s.Maximum != nil && *s.Minimum > *s.Maximum {
+		return fmt.Errorf("%s minimum must not be greater than maximum", path)
+	}
+	if s.MinLength != nil && s.MaxLength != nil && *s.MinLength > *s.MaxLength {
+		return fmt.Errorf("%s minLength must not be greater than maxLength", path)
+	}
+	if s.Pattern != "" {
+		if _, err := compileMetadataPattern(s.Pattern); err != nil {
+			return fmt.Errorf("%s pattern is not a valid regular expression", path)
+		}
+	}
+
+	for name, property := range s.Properties {
+		if property == nil {
+			return fmt.Errorf("%s.%s must be a schema", path, name)
+		}
+		if err := property.validate(path+"."+name, depth+1); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		return s.Items.validate(path+"[]", depth+1)
+	}
+	return nil
+}
+
+// Checks the metadata of a run against the schema, a nil schema accepts any metadata
+// Returns a *ScoreRejection so the run is refused the same way as a score breaking the leaderboard rules
+func (s *MetadataSchema) Check(metadata Metadata) error {
+	if s == nil {
+		return nil
+	}
+	value := map[string]any(metadata)
+	if value == nil {
+		value = map[string]any{}
+	}
+	if reason := s.check("metadata", value); reason != "" {
+		return &ScoreRejection{Reason: reason}
+	}
+	return nil
+}
+
+// Returns why the value does not match the schema, empty if it does
+func (s *MetadataSchema) check(path string, value any) string {
+	if s.Type != "" && !matchesType(s.Type, value) {
+		return fmt.Sprintf("%s must be of type %s", path, s.Type)
+	}
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(allowed any) bool { return reflect.DeepEqual(allowed, value) }) {
+		return fmt.Sprintf("%s is not one of the allowed values", path)
+	}
+
+	switch v := value.(type) {
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			return fmt.Sprintf("%s must not be below %g", path, *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			return fmt.Sprintf("%s must not be above %g", path, *s.Maximum)
+		}
+	case string:
+		length := len([]rune(v))
+		if s.MinLength != nil && length < *s.MinLength {
+			return fmt.Sprintf("%s must not be shorter than %d characters", path, *s.MinLength)
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			return fmt.Sprintf("%s must not be longer than %d characters", path, *s.MaxLength)
+		}
+		if s.Pattern != "" {
+			if pattern, err := compileMetadataPattern(s.Pattern); err != nil || !pattern.MatchString(v) {
+				return fmt.Sprintf("%s does not match the pattern %s", path, s.Pattern)
+			}
+		}
+	case []any:
+		if s.Items == nil {
+			break
+		}
+		for i, item := range v {
+			if reason := s.Items.check(fmt.Sprintf("%s[%d]", path, i), item); reason != "" {
+				return reason
+			}
+		}
+	case map[string]any:
+		return s.checkObject(path, v)
+	}
+	return ""
+}
+
+func (s *MetadataSchema) checkObject(path string, object map[string]any) string {
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			return fmt.Sprintf("%s.%s is required", path, name)
+		}
+	}
+
+	// Keys are checked in order so the same metadata is always rejected for the same reason
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		property, ok := s.Properties[name]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				return fmt.Sprintf("%s.%s is not allowed", path, name)
+			}
+			continue
+		}
+		if reason := property.check(path+"."+name, object[name]); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+// Values are decoded from JSON, so every number is a float64
+func matchesType(schemaType string, value any) bool {
+	switch v := value.(type) {
+	case map[string]any:
+		return schemaType == "object"
+	case []any:
+		return schemaType == "array"
+	case string:
+		return schemaType == "string"
+	case float64:
+		return schemaType == "number" || (schemaType == "integer" && v == math.Trunc(v))
+	case bool:
+		return schemaType == "boolean"
+	case nil:
+		return schemaType == "null"
+	}
+	return false
+}
+
+// Validates the metadata filter of a ranking, values are compared with the text of the metadata values
+func ValidateMetadataFilter(filter map[string]string) error {
+	if len(filter) > MetadataFilterMaxKeys {
+		return fmt.Errorf("at most %d metadata keys can be filtered", MetadataFilterMaxKeys)
+	}
+	for key := range filter {
+		if strings.TrimSpace(key) == "" {
+			return errors.New("metadata keys must not be empty")
+		}
+	}
+	return nil
+}
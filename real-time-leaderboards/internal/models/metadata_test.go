@@ -0,0 +1,104 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataValidate(t *testing.T) {
+	assert.NoError(t, Metadata{"character": "mario", "build": "1.2.0"}.Validate())
+	assert.NoError(t, Metadata(nil).Validate())
+	assert.Error(t, Metadata{"replay": strings.Repeat("a", MetadataMaxBytes)}.Validate())
+}
+
+func TestMetadataSchemaValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		schema  string
+		wantErr bool
+	}{
+		{name: "object schema", schema: `{"type":"object","properties":{"character":{"type":"string","enum":["mario","luigi"]}}}`},
+		{name: "not an object", schema: `{"type":"string"}`, wantErr: true},
+		{name: "unknown type", schema: `{"properties":{"build":{"type":"version"}}}`, wantErr: true},
+		{name: "invalid pattern", schema: `{"properties":{"build":{"type":"string","pattern":"("}}}`, wantErr: true},
+		{name: "minimum above maximum", schema: `{"properties":{"lap":{"type":"number","minimum":5,"maximum":1}}}`, wantErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var schema MetadataSchema
+			assert.NoError(t, json.Unmarshal([]byte(testCase.schema), &schema))
+
+			err := schema.Validate()
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestMetadataSchemaCheck(t *testing.T) {
+	var schema MetadataSchema
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"type": "object",
+		"required": ["character"],
+		"additionalProperties": false,
+		"properties": {
+			"character": {"type": "string", "enum": ["mario", "luigi"]},
+			"build": {"type": "string", "pattern": "^[0-9]+\\.[0-9]+\\.[0-9]+$"},
+			"loadout": {"type": "array", "items": {"type": "string", "maxLength": 10}},
+			"lap": {"type": "integer", "minimum": 1}
+		}
+	}`), &schema))
+
+	testCases := []struct {
+		name     string
+		metadata string
+		wantErr  bool
+	}{
+		{name: "matching metadata", metadata: `{"character":"mario","build":"1.2.0","loadout":["shell"],"lap":3}`},
+		{name: "missing required key", metadata: `{"build":"1.2.0"}`, wantErr: true},
+		{name: "value not allowed", metadata: `{"character":"peach"}`, wantErr: true},
+		{name: "pattern not matched", metadata: `{"character":"mario","build":"latest"}`, wantErr: true},
+		{name: "item too long", metadata: `{"character":"mario","loadout":["a very long item"]}`, wantErr: true},
+		{name: "not an integer", metadata: `{"character":"mario","lap":1.5}`, wantErr: true},
+		{name: "below minimum", metadata: `{"character":"mario","lap":0}`, wantErr: true},
+		{name: "additional key", metadata: `{"character":"mario","replay":"abc"}`, wantErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var metadata Metadata
+			assert.NoError(t, json.Unmarshal([]byte(testCase.metadata), &metadata))
+
+			err := schema.Check(metadata)
+			if testCase.wantErr {
+				var rejection *ScoreRejection
+				assert.True(t, errors.As(err, &rejection))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	var noSchema *MetadataSchema
+	assert.NoError(t, noSchema.Check(Metadata{"anything": true}))
+}
+
+func TestCompileMetadataPattern(t *testing.T) {
+	// Every run on a leaderboard reuses the pattern compiled for the first one
+	first, err := compileMetadataPattern(`^[0-9]+$`)
+	assert.NoError(t, err)
+	second, err := compileMetadataPattern(`^[0-9]+$`)
+	assert.NoError(t, err)
+	assert.True(t, first == second)
+
+	_, err = compileMetadataPattern(`(`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"errors"
+	"net/url"
+)
+
+// Filters for the moderation queue, an empty LeaderboardID lists every leaderboard
+type ModerationQueueFilter struct {
+	LeaderboardID string `form:"leaderboard_id"`
+	Pagination
+}
+
+// A moderator decision on a queued entry
+type EntryReview struct {
+	EntryID string `json:"-"`
+	Status  string `json:"-"` // Either EntryStatusVerified or EntryStatusRejected
+	Reason  string `json:"reason"`
+}
+
+// Proof links must be absolute http(s) URLs so moderators can open them
+func validateProofURL(proofURL string) error {
+	if proofURL == "" {
+		return nil
+	}
+
+	parsed, err := url.Parse(proofURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("proof_url must be an absolute http or https URL")
+	}
+
+	return nil
+}
+
+func (r EntryReview) AuditAction() string {
+	if r.Status == EntryStatusVerified {
+		return AuditActionEntryVerify
+	}
+	return AuditActionEntryReject
+}
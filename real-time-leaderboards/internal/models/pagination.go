@@ -0,0 +1,25 @@
+package models
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+)
+
+// Pagination is bound from the limit and offset query parameters
+type Pagination struct {
+	Limit  int `form:"limit" json:"limit"`
+	Offset int `form:"offset" json:"offset"`
+}
+
+// Applies the default page size and caps it so a single request cannot scan a whole table
+func (p *Pagination) Normalize() {
+	if p.Limit <= 0 {
+		p.Limit = DefaultPageLimit
+	}
+	if p.Limit > MaxPageLimit {
+		p.Limit = MaxPageLimit
+	}
+	if p.Offset < 0 {
+		p.Offset = 0
+	}
+}
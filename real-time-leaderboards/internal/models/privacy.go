@@ -0,0 +1,39 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Everything stored about a user, returned to them on request
+// Bans are left out, a shadowbanned user must not be able to tell
+type UserExport struct {
+	ExportedAt        time.Time          `json:"exported_at"`
+	Profile           User               `json:"profile"`
+	Entries           []LeaderboardEntry `json:"entries"`
+	SubmissionHistory []EntryEvent       `json:"submission_history"`
+	Reports           []EntryReport      `json:"reports"`
+	Sessions          []Session          `json:"sessions"`
+}
+
+// Change made to one of the entries of a user, taken from the audit log
+type EntryEvent struct {
+	EntryID   string          `json:"entry_id"`
+	Action    string          `json:"action"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Tokens are not stored by the server, the only known session is the one making the request
+type Session struct {
+	IssuedAt  *time.Time `json:"issued_at,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Current   bool       `json:"current"`
+}
+
+// Username given to a user once their personal data is erased
+func ErasedUsername(userID string) string {
+	return fmt.Sprintf("erased-user-%s", userID)
+}
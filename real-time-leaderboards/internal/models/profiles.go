@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Number of entries listed as recent activity on a profile
+const ProfileRecentActivityLimit = 10
+
+// Public view of a user, private fields such as the email are never part of it
+// Stats only count the entries that are publicly ranked, as seen by the viewer
+type PlayerProfile struct {
+	ID             string            `json:"id"`
+	Username       string            `json:"username"`
+	Stats          PlayerStats       `json:"stats"`
+	BestRanks      []BoardRank       `json:"best_ranks"`
+	RecentActivity []ProfileActivity `json:"recent_activity"`
+	CreatedAt      time.Time         `json:"created_at"`
+}
+
+type PlayerStats struct {
+	LeaderboardsPlayed int        `json:"leaderboards_played"`
+	TotalSubmissions   int        `json:"total_submissions"`
+	WorldRecords       int        `json:"world_records"` // Leaderboards where the player holds rank 1, ties included
+	LastSubmissionAt   *time.Time `json:"last_submission_at,omitempty"`
+}
+
+// Best score of the player on a leaderboard and the rank it holds, players with the same score share the rank
+type BoardRank struct {
+	LeaderboardID   string `json:"leaderboard_id"`
+	LeaderboardName string `json:"leaderboard_name"`
+	BestScore       int    `json:"best_score"`
+	Rank            int    `json:"rank"`
+}
+
+type ProfileActivity struct {
+	EntryID         string    `json:"entry_id"`
+	LeaderboardID   string    `json:"leaderboard_id"`
+	LeaderboardName string    `json:"leaderboard_name"`
+	Score           int       `json:"score"`
+	CreatedAt       time.Time `json:"created_at"`
+}
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"fmt"
+	"math"
+)
+
+// How ranks are numbered between members with the same sort key
+const (
+	RankModeCompetition RankMode = "competition" // 1, 2, 2, 4
+	RankModeDense       RankMode = "dense"       // 1, 2, 2, 3
+	RankModeOrdinal     RankMode = "ordinal"     // 1, 2, 3, 4, ties are numbered in ranking order
+)
+
+// Players shown on each side of the user in an around-me window of a ranking
+const (
+	DefaultAroundRadius = 5
+	MaxAroundRadius     = 25
+)
+
+// Rank mode of a leaderboard, honoured by every ranking it serves including team, friends and around-me rankings
+type RankMode string
+
+// A missing rank mode defaults to competition ranking
+func (m *RankMode) Validate() error {
+	switch *m {
+	case "":
+		*m = RankModeCompetition
+	case RankModeCompetition, RankModeDense, RankModeOrdinal:
+	default:
+		return fmt.Errorf("rank_mode must be one of %s, %s or %s", RankModeCompetition, RankModeDense, RankModeOrdinal)
+	}
+	return nil
+}
+
+// Numbers the members of a ranking as they are read in order, best first
+type Ranker struct {
+	mode     RankMode
+	position int
+	rank     int
+	previous float64
+}
+
+func NewRanker(mode RankMode) *Ranker {
+	return &Ranker{mode: mode, previous: math.NaN()}
+}
+
+// Places the ranker after the members above a page, the last of them ranked with the given rank and sort key
+// A key that cannot tie, such as +Inf, is enough when the last member is known to rank above the page
+func (r *Ranker) Skip(position, rank int, key float64) {
+	r.position = position
+	r.rank = rank
+	r.previous = key
+}
+
+// Returns the rank of the next member, given its sort key
+func (r *Ranker) Next(key float64) int {
+	r.position++
+	switch {
+	case key == r.previous && r.mode != RankModeOrdinal:
+	case r.mode == RankModeDense:
+		r.rank++
+	default:
+		r.rank = r.position
+	}
+	r.previous = key
+	return r.rank
+}
+
+// Window of a ranking centred on a user, with up to Radius players above and below them
+type AroundFilter struct {
+	Radius int `form:"radius" json:"radius"`
+}
+
+// Applies the default radius and caps it like the page size of a ranking
+func (f *AroundFilter) Normalize() {
+	if f.Radius <= 0 {
+		f.Radius = DefaultAroundRadius
+	}
+	if f.Radius > MaxAroundRadius {
+		f.Radius = MaxAroundRadius
+	}
+}
+
+// Page of the ranking holding the window around the zero based position of the user
+func (f AroundFilter) Page(position int) Pagination {
+	offset := max(position-f.Radius, 0)
+	return Pagination{
+		Offset: offset,
+		Limit:  position + f.Radius + 1 - offset,
+	}
+}
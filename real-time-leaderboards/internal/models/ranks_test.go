@@ -0,0 +1,70 @@
+package models
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankModeValidate(t *testing.T) {
+	mode := RankMode("")
+	assert.NoError(t, mode.Validate())
+	assert.Equal(t, RankModeCompetition, mode)
+
+	mode = RankModeDense
+	assert.NoError(t, mode.Validate())
+	assert.Equal(t, RankModeDense, mode)
+
+	mode = RankMode("fractional")
+	assert.Error(t, mode.Validate())
+}
+
+func TestRanker(t *testing.T) {
+	keys := []float64{900, 800, 800, 700, 700, 700, 600}
+
+	testCases := []struct {
+		mode     RankMode
+		expected []int
+	}{
+		{mode: RankModeCompetition, expected: []int{1, 2, 2, 4, 4, 4, 7}},
+		{mode: RankModeDense, expected: []int{1, 2, 2, 3, 3, 3, 4}},
+		{mode: RankModeOrdinal, expected: []int{1, 2, 3, 4, 5, 6, 7}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(string(testCase.mode), func(t *testing.T) {
+			ranker := NewRanker(testCase.mode)
+			ranks := make([]int, 0, len(keys))
+			for _, key := range keys {
+				ranks = append(ranks, ranker.Next(key))
+			}
+			assert.Equal(t, testCase.expected, ranks)
+		})
+	}
+}
+
+func TestRankerSkip(t *testing.T) {
+	// The page starts at the fifth member, tied with the two members above it ranked 3rd
+	ranker := NewRanker(RankModeCompetition)
+	ranker.Skip(4, 3, 700)
+	assert.Equal(t, 3, ranker.Next(700))
+	assert.Equal(t, 6, ranker.Next(600))
+
+	// The page starts right after a better member
+	ranker = NewRanker(RankModeCompetition)
+	ranker.Skip(4, 4, math.Inf(1))
+	assert.Equal(t, 5, ranker.Next(700))
+}
+
+func TestAroundFilterPage(t *testing.T) {
+	filter := AroundFilter{Radius: 2}
+	assert.Equal(t, Pagination{Offset: 3, Limit: 5}, filter.Page(5))
+
+	// The window is cut at the top of the ranking instead of being shifted down
+	assert.Equal(t, Pagination{Offset: 0, Limit: 4}, filter.Page(1))
+
+	filter = AroundFilter{Radius: MaxAroundRadius + 1}
+	filter.Normalize()
+	assert.Equal(t, MaxAroundRadius, filter.Radius)
+}
@@ -0,0 +1,255 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Score leaderboards rank submitted entries, rating leaderboards rank players by the results of their matches
+const (
+	LeaderboardTypeScore  = "score"
+	LeaderboardTypeRating = "rating"
+)
+
+// Rating systems of rating leaderboards, Elo keeps no deviation so its conservative rating is the rating itself
+const (
+	RatingSystemElo     = "elo"
+	RatingSystemGlicko2 = "glicko2"
+)
+
+const (
+	InitialRating     = 1500.0
+	InitialDeviation  = 350.0 // Glicko-2 only
+	InitialVolatility = 0.06  // Glicko-2 only
+
+	EloKFactor = 32.0
+	Glicko2Tau = 0.5 // Constrains how fast the volatility changes
+
+	// Players are ranked by their rating minus this many deviations, so a few lucky wins do not top the ranking
+	ConservativeRatingDeviations = 2.0
+
+	glicko2Scale     = 173.7178
+	glicko2Tolerance = 0.000001
+)
+
+// Validates the type of a new leaderboard, a missing type defaults to a score leaderboard
+// Team rankings, tie-breakers and game categories work on submitted entries, so rating leaderboards cannot have them
+func (l *LeaderboardRequest) ValidateType() error {
+	switch l.Type {
+	case "", LeaderboardTypeScore:
+		l.Type = LeaderboardTypeScore
+		if l.RatingSystem != "" {
+			return fmt.Errorf("rating_system is only used by %s leaderboards", LeaderboardTypeRating)
+		}
+	case LeaderboardTypeRating:
+		if l.RatingSystem != RatingSystemElo && l.RatingSystem != RatingSystemGlicko2 {
+			return fmt.Errorf("rating_system must be either %s or %s", RatingSystemElo, RatingSystemGlicko2)
+		}
+		if l.TeamRanking != nil {
+			return fmt.Errorf("%s leaderboards cannot have a team ranking", LeaderboardTypeRating)
+		}
+		if len(l.TieBreakers) > 0 {
+			return fmt.Errorf("%s leaderboards cannot have tie_breakers", LeaderboardTypeRating)
+		}
+		if l.CategoryID != "" {
+			return fmt.Errorf("%s leaderboards cannot rank a game category", LeaderboardTypeRating)
+		}
+	default:
+		return fmt.Errorf("type must be either %s or %s", LeaderboardTypeScore, LeaderboardTypeRating)
+	}
+	return nil
+}
+
+// Rating of a player on a rating leaderboard
+type PlayerRating struct {
+	Rank               int       `json:"rank,omitempty"`
+	User               User      `json:"user"`
+	Rating             float64   `json:"rating"`
+	Deviation          float64   `json:"deviation"`
+	Volatility         float64   `json:"volatility"`
+	ConservativeRating float64   `json:"conservative_rating"`
+	Matches            int       `json:"matches"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// Rating of a player who has not played on the leaderboard yet
+func NewPlayerRating(system string, userID string) PlayerRating {
+	rating := PlayerRating{User: User{ID: userID}, Rating: InitialRating}
+	if system == RatingSystemGlicko2 {
+		rating.Deviation = InitialDeviation
+		rating.Volatility = InitialVolatility
+	}
+	rating.ConservativeRating = rating.Conservative()
+	return rating
+}
+
+// Rating the player is ranked by
+func (r PlayerRating) Conservative() float64 {
+	return r.Rating - ConservativeRatingDeviations*r.Deviation
+}
+
+// Result of a head-to-head match on a rating leaderboard, a draw has no winner
+type RatingMatchRequest struct {
+	LeaderboardID string `json:"-"`
+	Player1ID     string `json:"player1_id"`
+	Player2ID     string `json:"player2_id"`
+	WinnerID      string `json:"winner_id"`
+	Draw          bool   `json:"draw"`
+}
+
+func (r RatingMatchRequest) Validate() error {
+	if r.Player1ID == "" || r.Player2ID == "" {
+		return errors.New("player1_id and player2_id are required")
+	}
+	if r.Player1ID == r.Player2ID {
+		return errors.New("a player cannot play against themselves")
+	}
+	if r.Draw {
+		if r.WinnerID != "" {
+			return errors.New("a draw has no winner")
+		}
+		return nil
+	}
+	if r.WinnerID != r.Player1ID && r.WinnerID != r.Player2ID {
+		return errors.New("winner_id must be one of the players, or draw must be set")
+	}
+	return nil
+}
+
+// Score of the player in the match, 1 for a win, 0.5 for a draw and 0 for a loss
+func (r RatingMatchRequest) ScoreOf(playerID string) float64 {
+	switch {
+	case r.Draw:
+		return 0.5
+	case r.WinnerID == playerID:
+		return 1
+	}
+	return 0
+}
+
+type RatingMatch struct {
+	ID            string         `json:"id"`
+	LeaderboardID string         `json:"leaderboard_id"`
+	Player1ID     string         `json:"player1_id"`
+	Player2ID     string         `json:"player2_id"`
+	WinnerID      string         `json:"winner_id,omitempty"`
+	Draw          bool           `json:"draw"`
+	Changes       []RatingChange `json:"changes"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+// Rating of a player after a match, kept as the rating history of the player
+type RatingChange struct {
+	MatchID      string    `json:"match_id"`
+	UserID       string    `json:"user_id"`
+	RatingBefore float64   `json:"rating_before"`
+	Rating       float64   `json:"rating"`
+	Deviation    float64   `json:"deviation"`
+	Volatility   float64   `json:"volatility"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Result of a game against an opponent, Score is 1 for a win, 0.5 for a draw and 0 for a loss
+type RatingResult struct {
+	Opponent PlayerRating
+	Score    float64
+}
+
+// Rates the player after a match against the opponent with the given system
+// Both players must be rated with their ratings from before the match
+func RateMatch(system string, player, opponent PlayerRating, score float64) PlayerRating {
+	var rated PlayerRating
+	if system == RatingSystemGlicko2 {
+		rated = Glicko2(player, []RatingResult{{Opponent: opponent, Score: score}})
+	} else {
+		rated = Elo(player, opponent, score)
+	}
+	rated.Matches = player.Matches + 1
+	rated.ConservativeRating = rated.Conservative()
+	return rated
+}
+
+// Moves the rating by the K-factor times the difference between the actual and the expected score
+func Elo(player, opponent PlayerRating, score float64) PlayerRating {
+	expected := 1 / (1 + math.Pow(10, (opponent.Rating-player.Rating)/400))
+	player.Rating += EloKFactor * (score - expected)
+	return player
+}
+
+// Rates the player over a rating period with the Glicko-2 system, a match is rated as its own period
+// A period without results only increases the deviation
+func Glicko2(player PlayerRating, results []RatingResult) PlayerRating {
+	mu := (player.Rating - InitialRating) / glicko2Scale
+	phi := player.Deviation / glicko2Scale
+	sigma := player.Volatility
+
+	if len(results) == 0 {
+		player.Deviation = math.Sqrt(phi*phi+sigma*sigma) * glicko2Scale
+		return player
+	}
+
+	// Estimated variance of the rating based on the results only, and the improvement they suggest
+	var variance, improvement float64
+	for _, result := range results {
+		muJ := (result.Opponent.Rating - InitialRating) / glicko2Scale
+		g := glicko2G(result.Opponent.Deviation / glicko2Scale)
+		expected := 1 / (1 + math.Exp(-g*(mu-muJ)))
+		variance += g * g * expected * (1 - expected)
+		improvement += g * (result.Score - expected)
+	}
+	variance = 1 / variance
+	delta := variance * improvement
+
+	sigma = glicko2Volatility(delta, phi, variance, sigma)
+
+	phiStar := math.Sqrt(phi*phi + sigma*sigma)
+	phi = 1 / math.Sqrt(1/(phiStar*phiStar)+1/variance)
+	mu += phi * phi * improvement
+
+	player.Rating = mu*glicko2Scale + InitialRating
+	player.Deviation = phi * glicko2Scale
+	player.Volatility = sigma
+	return player
+}
+
+func glicko2G(phi float64) float64 {
+	return 1 / math.Sqrt(1+3*phi*phi/(math.Pi*math.Pi))
+}
+
+// Finds the new volatility with the Illinois algorithm, as described by Glickman
+func glicko2Volatility(delta, phi, variance, sigma float64) float64 {
+	a := math.Log(sigma * sigma)
+	f := func(x float64) float64 {
+		ex := math.Exp(x)
+		d := phi*phi + variance + ex
+		return ex*(delta*delta-phi*phi-variance-ex)/(2*d*d) - (x-a)/(Glicko2Tau*Glicko2Tau)
+	}
+
+	lower := a
+	var upper float64
+	if delta*delta > phi*phi+variance {
+		upper = math.Log(delta*delta - phi*phi - variance)
+	} else {
+		k := 1.0
+		for f(a-k*Glicko2Tau) < 0 {
+			k++
+		}
+		upper = a - k*Glicko2Tau
+	}
+
+	fLower, fUpper := f(lower), f(upper)
+	for math.Abs(upper-lower) > glicko2Tolerance {
+		next := lower + (lower-upper)*fLower/(fUpper-fLower)
+		fNext := f(next)
+		if fNext*fUpper <= 0 {
+			lower, fLower = upper, fUpper
+		} else {
+			fLower /= 2
+		}
+		upper, fUpper = next, fNext
+	}
+
+	return math.Exp(lower / 2)
+}
@@ -0,0 +1,126 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeaderboardRequestValidateType(t *testing.T) {
+	testCases := []struct {
+		name    string
+		request LeaderboardRequest
+		valid   bool
+	}{
+		{"defaults to score", LeaderboardRequest{}, true},
+		{"score", LeaderboardRequest{Type: LeaderboardTypeScore}, true},
+		{"elo", LeaderboardRequest{Type: LeaderboardTypeRating, RatingSystem: RatingSystemElo}, true},
+		{"glicko2", LeaderboardRequest{Type: LeaderboardTypeRating, RatingSystem: RatingSystemGlicko2}, true},
+		{"rating without system", LeaderboardRequest{Type: LeaderboardTypeRating}, false},
+		{"unknown system", LeaderboardRequest{Type: LeaderboardTypeRating, RatingSystem: "trueskill"}, false},
+		{"system on score", LeaderboardRequest{Type: LeaderboardTypeScore, RatingSystem: RatingSystemElo}, false},
+		{
+			"rating with team ranking",
+			LeaderboardRequest{
+				Type:         LeaderboardTypeRating,
+				RatingSystem: RatingSystemElo,
+				TeamRanking:  &TeamRanking{Aggregate: TeamAggregateSum},
+			},
+			false,
+		},
+		{
+			"rating with game category",
+			LeaderboardRequest{Type: LeaderboardTypeRating, RatingSystem: RatingSystemElo, CategoryID: "7"},
+			false,
+		},
+		{"unknown type", LeaderboardRequest{Type: "time"}, false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.request.ValidateType()
+			assert.Equal(t, testCase.valid, err == nil, err)
+		})
+	}
+
+	request := LeaderboardRequest{}
+	assert.NoError(t, request.ValidateType())
+	assert.Equal(t, LeaderboardTypeScore, request.Type)
+}
+
+func TestRatingMatchRequestValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		request RatingMatchRequest
+		valid   bool
+	}{
+		{"player1 wins", RatingMatchRequest{Player1ID: "1", Player2ID: "2", WinnerID: "1"}, true},
+		{"player2 wins", RatingMatchRequest{Player1ID: "1", Player2ID: "2", WinnerID: "2"}, true},
+		{"draw", RatingMatchRequest{Player1ID: "1", Player2ID: "2", Draw: true}, true},
+		{"missing player", RatingMatchRequest{Player1ID: "1", WinnerID: "1"}, false},
+		{"same player", RatingMatchRequest{Player1ID: "1", Player2ID: "1", WinnerID: "1"}, false},
+		{"winner not playing", RatingMatchRequest{Player1ID: "1", Player2ID: "2", WinnerID: "3"}, false},
+		{"no winner nor draw", RatingMatchRequest{Player1ID: "1", Player2ID: "2"}, false},
+		{"draw with winner", RatingMatchRequest{Player1ID: "1", Player2ID: "2", WinnerID: "1", Draw: true}, false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.request.Validate()
+			assert.Equal(t, testCase.valid, err == nil, err)
+		})
+	}
+}
+
+func TestElo(t *testing.T) {
+	player := NewPlayerRating(RatingSystemElo, "1")
+	opponent := NewPlayerRating(RatingSystemElo, "2")
+
+	winner := RateMatch(RatingSystemElo, player, opponent, 1)
+	loser := RateMatch(RatingSystemElo, opponent, player, 0)
+	assert.InDelta(t, 1516, winner.Rating, 0.001)
+	assert.InDelta(t, 1484, loser.Rating, 0.001)
+	assert.Equal(t, winner.Rating, winner.ConservativeRating)
+	assert.Equal(t, 1, winner.Matches)
+
+	// Beating a much stronger player is worth more than beating an equal one
+	opponent.Rating = 1900
+	upset := Elo(player, opponent, 1)
+	assert.InDelta(t, 1529.09, upset.Rating, 0.01)
+
+	draw := Elo(player, opponent, 0.5)
+	assert.Greater(t, draw.Rating, player.Rating)
+}
+
+// Example from Glickman's description of the Glicko-2 system
+func TestGlicko2(t *testing.T) {
+	player := PlayerRating{Rating: 1500, Deviation: 200, Volatility: 0.06}
+	results := []RatingResult{
+		{Opponent: PlayerRating{Rating: 1400, Deviation: 30}, Score: 1},
+		{Opponent: PlayerRating{Rating: 1550, Deviation: 100}, Score: 0},
+		{Opponent: PlayerRating{Rating: 1700, Deviation: 300}, Score: 0},
+	}
+
+	rated := Glicko2(player, results)
+	assert.InDelta(t, 1464.06, rated.Rating, 0.01)
+	assert.InDelta(t, 151.52, rated.Deviation, 0.01)
+	assert.InDelta(t, 0.05999, rated.Volatility, 0.00001)
+
+	idle := Glicko2(player, nil)
+	assert.Equal(t, player.Rating, idle.Rating)
+	assert.Greater(t, idle.Deviation, player.Deviation)
+}
+
+func TestRateMatchGlicko2(t *testing.T) {
+	player := NewPlayerRating(RatingSystemGlicko2, "1")
+	opponent := NewPlayerRating(RatingSystemGlicko2, "2")
+	assert.InDelta(t, 800, player.ConservativeRating, 0.001)
+
+	winner := RateMatch(RatingSystemGlicko2, player, opponent, 1)
+	loser := RateMatch(RatingSystemGlicko2, opponent, player, 0)
+	assert.Greater(t, winner.Rating, InitialRating)
+	assert.Less(t, loser.Rating, InitialRating)
+	assert.Less(t, winner.Deviation, InitialDeviation)
+	assert.InDelta(t, winner.Rating-2*winner.Deviation, winner.ConservativeRating, 0.001)
+	assert.Equal(t, 1, winner.Matches)
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Reasons players can pick when reporting an entry, ReportReasonOther requires details
+const (
+	ReportReasonCheating        = "cheating"
+	ReportReasonImpossibleScore = "impossible_score"
+	ReportReasonInvalidProof    = "invalid_proof"
+	ReportReasonOther           = "other"
+)
+
+const maxReportDetailsLength = 1000
+
+type EntryReportRequest struct {
+	EntryID    string `json:"-"`
+	ReporterID string `json:"-"`
+	Reason     string `json:"reason"`
+	Details    string `json:"details"`
+}
+
+func (r EntryReportRequest) Validate() error {
+	switch r.Reason {
+	case ReportReasonCheating, ReportReasonImpossibleScore, ReportReasonInvalidProof:
+	case ReportReasonOther:
+		if r.Details == "" {
+			return errors.New("details are required when the reason is 'other'")
+		}
+	default:
+		return errors.New("reason must be one of 'cheating', 'impossible_score', 'invalid_proof' or 'other'")
+	}
+	if len(r.Details) > maxReportDetailsLength {
+		return errors.New("details must be at most 1000 characters")
+	}
+	return nil
+}
+
+type EntryReport struct {
+	ID        string    `json:"id"`
+	EntryID   string    `json:"entry_id"`
+	Reporter  User      `json:"reporter"`
+	Reason    string    `json:"reason"`
+	Details   string    `json:"details,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Outcome of a report, Escalated is set when this report moved the entry into the moderation queue
+// Only the report is returned to the player, the rest is used to keep the owner ranking in sync
+type EntryReportResult struct {
+	Report    EntryReport
+	Reports   int
+	Escalated bool
+	OwnerID   string
+}
+
+// Reports aggregated per entry, as listed to moderators
+type ReportedEntry struct {
+	Entry          LeaderboardEntry `json:"entry"`
+	Reports        int              `json:"reports"`
+	Reasons        map[string]int   `json:"reasons"`
+	LastReportedAt time.Time        `json:"last_reported_at"`
+	EscalatedAt    *time.Time       `json:"escalated_at,omitempty"`
+}
+
+// Filters for the reported entries, an empty LeaderboardID lists every leaderboard
+type ReportFilter struct {
+	LeaderboardID string `form:"leaderboard_id"`
+	MinReports    int    `form:"min_reports"`
+	Pagination
+}
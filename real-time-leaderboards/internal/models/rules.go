@@ -0,0 +1,95 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ScoreRules are enforced on every entry submitted to a leaderboard
+// A nil rule is not enforced
+type ScoreRules struct {
+	MinScore              *int `json:"min_score,omitempty"`
+	MaxScore              *int `json:"max_score,omitempty"`
+	MaxImprovement        *int `json:"max_improvement,omitempty"`         // Largest allowed increase over the user's best score
+	MinSubmissionInterval *int `json:"min_submission_interval,omitempty"` // Seconds a user must wait between submissions
+	ScoreStep             *int `json:"score_step,omitempty"`              // Scores must be a multiple of the step, counted from MinScore
+}
+
+// UserSubmissions summarises the previous entries of a user on a leaderboard, along with their standing
+type UserSubmissions struct {
+	Count           int
+	BestScore       int
+	LastSubmittedAt time.Time
+	Ban             *Ban
+}
+
+// ScoreRejection is returned when a score breaks one of the leaderboard rules
+type ScoreRejection struct {
+	Reason string
+}
+
+func (e *ScoreRejection) Error() string {
+	return fmt.Sprintf("score rejected: %s", e.Reason)
+}
+
+// Validates the rules are consistent with each other, used when creating or updating a leaderboard
+func (r ScoreRules) Validate() error {
+	if r.MinScore != nil && r.MaxScore != nil && *r.MinScore > *r.MaxScore {
+		return errors.New("min_score must not be greater than max_score")
+	}
+	if r.MaxImprovement != nil && *r.MaxImprovement < 0 {
+		return errors.New("max_improvement must not be negative")
+	}
+	if r.MinSubmissionInterval != nil && *r.MinSubmissionInterval < 0 {
+		return errors.New("min_submission_interval must not be negative")
+	}
+	if r.ScoreStep != nil && *r.ScoreStep <= 0 {
+		return errors.New("score_step must be positive")
+	}
+	return nil
+}
+
+// Checks a submitted score against the rules, returns a *ScoreRejection if any rule is broken
+func (r ScoreRules) ValidateScore(score int, previous UserSubmissions, now time.Time) error {
+	if r.MinScore != nil && score < *r.MinScore {
+		return &ScoreRejection{Reason: fmt.Sprintf("score %d is below the minimum of %d", score, *r.MinScore)}
+	}
+
+	if r.MaxScore != nil && score > *r.MaxScore {
+		return &ScoreRejection{Reason: fmt.Sprintf("score %d is above the maximum of %d", score, *r.MaxScore)}
+	}
+
+	if r.ScoreStep != nil {
+		base := 0
+		if r.MinScore != nil {
+			base = *r.MinScore
+		}
+		if (score-base)%*r.ScoreStep != 0 {
+			return &ScoreRejection{Reason: fmt.Sprintf("score %d is not a multiple of %d", score, *r.ScoreStep)}
+		}
+	}
+
+	// Remaining rules compare against the user's previous entries
+	if previous.Count == 0 {
+		return nil
+	}
+
+	if r.MinSubmissionInterval != nil {
+		interval := time.Duration(*r.MinSubmissionInterval) * time.Second
+		if wait := previous.LastSubmittedAt.Add(interval).Sub(now); wait > 0 {
+			return &ScoreRejection{Reason: fmt.Sprintf("submissions are limited to one every %s, retry in %s", interval, wait.Round(time.Second))}
+		}
+	}
+
+	if r.MaxImprovement != nil && score-previous.BestScore > *r.MaxImprovement {
+		return &ScoreRejection{Reason: fmt.Sprintf(
+			"score %d improves on the previous best of %d by more than %d",
+			score,
+			previous.BestScore,
+			*r.MaxImprovement,
+		)}
+	}
+
+	return nil
+}
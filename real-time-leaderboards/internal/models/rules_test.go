@@ -0,0 +1,58 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func TestScoreRulesValidateScore(t *testing.T) {
+	now := time.Now()
+	rules := ScoreRules{
+		MinScore:              intPtr(10),
+		MaxScore:              intPtr(1000),
+		MaxImprovement:        intPtr(100),
+		MinSubmissionInterval: intPtr(60),
+		ScoreStep:             intPtr(5),
+	}
+
+	testCases := []struct {
+		name     string
+		score    int
+		previous UserSubmissions
+		rejected bool
+	}{
+		{"first submission", 500, UserSubmissions{}, false},
+		{"below minimum", 5, UserSubmissions{}, true},
+		{"above maximum", 1005, UserSubmissions{}, true},
+		{"off step", 502, UserSubmissions{}, true},
+		{"step counted from minimum", 15, UserSubmissions{}, false},
+		{"too soon", 500, UserSubmissions{Count: 1, BestScore: 450, LastSubmittedAt: now.Add(-30 * time.Second)}, true},
+		{"improvement too large", 600, UserSubmissions{Count: 1, BestScore: 450, LastSubmittedAt: now.Add(-time.Hour)}, true},
+		{"valid improvement", 550, UserSubmissions{Count: 1, BestScore: 450, LastSubmittedAt: now.Add(-time.Hour)}, false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := rules.ValidateScore(testCase.score, testCase.previous, now)
+			if testCase.rejected {
+				var rejection *ScoreRejection
+				assert.ErrorAs(t, err, &rejection)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestScoreRulesValidate(t *testing.T) {
+	assert.NoError(t, ScoreRules{}.Validate())
+	assert.Error(t, ScoreRules{MinScore: intPtr(10), MaxScore: intPtr(5)}.Validate())
+	assert.Error(t, ScoreRules{ScoreStep: intPtr(0)}.Validate())
+	assert.Error(t, ScoreRules{MinSubmissionInterval: intPtr(-1)}.Validate())
+}
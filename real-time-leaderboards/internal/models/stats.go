@@ -0,0 +1,141 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Thresholds used to flag suspicious submissions
+const (
+	AnomalyMinSamples        = 30   // Below this many accepted scores the distribution is not trusted
+	AnomalyOutlierZScore     = 4.0  // Scores this many standard deviations above the mean are flagged
+	AnomalyImprovementZScore = 3.0  // Personal best improvements larger than this many standard deviations...
+	AnomalyPercentileJump    = 50.0 // ...that also climb this many percentiles in a single submission are flagged
+)
+
+// ScoreStats is the running distribution of accepted scores on a leaderboard
+// Mean and M2 are maintained incrementally by the storage layer using Welford's algorithm
+type ScoreStats struct {
+	Count int64   `json:"count"`
+	Mean  float64 `json:"mean"`
+	M2    float64 `json:"-"`
+	Min   int     `json:"min"`
+	Max   int     `json:"max"`
+}
+
+// Sample standard deviation of the accepted scores
+func (s ScoreStats) StdDev() float64 {
+	if s.Count < 2 {
+		return 0
+	}
+	return math.Sqrt(s.M2 / float64(s.Count-1))
+}
+
+// Number of standard deviations the score is away from the mean
+func (s ScoreStats) ZScore(score float64) float64 {
+	stdDev := s.StdDev()
+	if stdDev == 0 {
+		return 0
+	}
+	return (score - s.Mean) / stdDev
+}
+
+// Percentiles, from 0 to 100, of a submitted score and of the user's previous best within the ranking
+type ScorePercentiles struct {
+	Score        float64
+	PreviousBest float64
+}
+
+// DetectAnomaly returns why a submission looks suspicious, or an empty string if it does not
+func DetectAnomaly(score int, stats ScoreStats, previous UserSubmissions, percentiles ScorePercentiles) string {
+	if stats.Count < AnomalyMinSamples || stats.StdDev() == 0 {
+		return ""
+	}
+
+	// Extreme outlier for the whole leaderboard
+	if zScore := stats.ZScore(float64(score)); zScore > AnomalyOutlierZScore {
+		return fmt.Sprintf(
+			"score is %.1f standard deviations above the mean of %.1f (percentile %.1f)",
+			zScore,
+			stats.Mean,
+			percentiles.Score,
+		)
+	}
+
+	// Implausible improvement for this user, a large jump that also skips most of the ranking
+	if previous.Count == 0 || score <= previous.BestScore {
+		return ""
+	}
+	improvement := float64(score-previous.BestScore) / stats.StdDev()
+	percentileJump := percentiles.Score - percentiles.PreviousBest
+	if improvement > AnomalyImprovementZScore && percentileJump > AnomalyPercentileJump {
+		return fmt.Sprintf(
+			"score improves the personal best by %.1f standard deviations, from percentile %.1f to %.1f",
+			improvement,
+			percentiles.PreviousBest,
+			percentiles.Score,
+		)
+	}
+
+	return ""
+}
+
+const (
+	HistogramDefaultBuckets = 20   // Buckets of a histogram requested without a bucket width
+	HistogramMaxBuckets     = 1000 // A requested bucket width that needs more buckets is widened to fit
+
+	// Distributions are cached briefly, designers look at their shape rather than at every new score
+	DistributionCacheTTL = 30 * time.Second
+)
+
+// Distribution of the best ranked score of every player, rating leaderboards use the conservative rating
+type ScoreDistribution struct {
+	Count       int64             `json:"count"`
+	Min         float64           `json:"min"`
+	Max         float64           `json:"max"`
+	Mean        float64           `json:"mean"`
+	Median      float64           `json:"median"`
+	StdDev      float64           `json:"stddev"`
+	BucketWidth float64           `json:"bucket_width"`
+	Histogram   []HistogramBucket `json:"histogram"`
+}
+
+// Players with a score from From, inclusive, up to To, exclusive
+type HistogramBucket struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Count int64   `json:"count"`
+}
+
+// Builds a histogram with every bucket between the lowest and the highest bucket holding a score
+// counts is keyed by the index of the bucket, counted in widths from the lower bound of the first bucket
+func NewHistogram(from, width float64, counts map[int64]int64) []HistogramBucket {
+	histogram := make([]HistogramBucket, 0, len(counts))
+	if len(counts) == 0 {
+		return histogram
+	}
+
+	lowest, highest := int64(math.MaxInt64), int64(math.MinInt64)
+	for bucket := range counts {
+		lowest = min(lowest, bucket)
+		highest = max(highest, bucket)
+	}
+	for bucket := lowest; bucket <= highest; bucket++ {
+		histogram = append(histogram, HistogramBucket{
+			From:  from + float64(bucket)*width,
+			To:    from + float64(bucket+1)*width,
+			Count: counts[bucket],
+		})
+	}
+	return histogram
+}
+
+// Cached distribution of the leaderboard for a bucket width, 0 being the automatic width
+func (l Leaderboard) DistributionKey(bucketWidth float64) string {
+	return TenantKey(
+		l.TenantID,
+		fmt.Sprintf("leaderboard:%s:distribution:%s", l.ID, strconv.FormatFloat(bucketWidth, 'g', -1, 64)),
+	)
+}
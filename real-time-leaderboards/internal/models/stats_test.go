@@ -0,0 +1,66 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectAnomaly(t *testing.T) {
+	// Mean of 100 and a standard deviation of 10
+	stats := ScoreStats{Count: 100, Mean: 100, M2: 99 * 100}
+
+	testCases := []struct {
+		name        string
+		score       int
+		stats       ScoreStats
+		previous    UserSubmissions
+		percentiles ScorePercentiles
+		flagged     bool
+	}{
+		{"typical score", 110, stats, UserSubmissions{}, ScorePercentiles{Score: 80}, false},
+		{"extreme outlier", 150, stats, UserSubmissions{}, ScorePercentiles{Score: 100}, true},
+		{"not enough samples", 150, ScoreStats{Count: 5, Mean: 100, M2: 4 * 100}, UserSubmissions{}, ScorePercentiles{}, false},
+		{
+			"implausible improvement",
+			135,
+			stats,
+			UserSubmissions{Count: 3, BestScore: 90},
+			ScorePercentiles{Score: 99, PreviousBest: 20},
+			true,
+		},
+		{
+			"large improvement near the top",
+			135,
+			stats,
+			UserSubmissions{Count: 3, BestScore: 100},
+			ScorePercentiles{Score: 99, PreviousBest: 70},
+			false,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			reason := DetectAnomaly(testCase.score, testCase.stats, testCase.previous, testCase.percentiles)
+			assert.Equal(t, testCase.flagged, reason != "", reason)
+		})
+	}
+}
+
+func TestScoreStatsStdDev(t *testing.T) {
+	assert.Equal(t, 0.0, ScoreStats{Count: 1, Mean: 10}.StdDev())
+	assert.InDelta(t, 10.0, ScoreStats{Count: 100, Mean: 100, M2: 99 * 100}.StdDev(), 1e-9)
+}
+
+func TestNewHistogram(t *testing.T) {
+	// Empty buckets between the lowest and highest score are kept so the shape is not distorted
+	histogram := NewHistogram(1000, 50, map[int64]int64{0: 3, 2: 1, 3: 5})
+	assert.Equal(t, []HistogramBucket{
+		{From: 1000, To: 1050, Count: 3},
+		{From: 1050, To: 1100, Count: 0},
+		{From: 1100, To: 1150, Count: 1},
+		{From: 1150, To: 1200, Count: 5},
+	}, histogram)
+
+	assert.Empty(t, NewHistogram(1000, 50, map[int64]int64{}))
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Owners manage the team and its officers, officers manage plain members
+const (
+	TeamRoleOwner   = "owner"
+	TeamRoleOfficer = "officer"
+	TeamRoleMember  = "member"
+)
+
+// How the best scores of the members of a team are combined into the team score
+const (
+	TeamAggregateSum     = "sum"
+	TeamAggregateAverage = "average"
+	TeamAggregateTopK    = "top_k" // Sum of the TopK best members
+)
+
+const TeamNameMaxLength = 50
+
+type TeamRequest struct {
+	Name string `json:"name"`
+}
+
+func (r *TeamRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(r.Name) > TeamNameMaxLength {
+		return fmt.Errorf("name must not be longer than %d characters", TeamNameMaxLength)
+	}
+	return nil
+}
+
+type TeamRoleRequest struct {
+	Role string `json:"role"`
+}
+
+func (r TeamRoleRequest) Validate() error {
+	switch r.Role {
+	case TeamRoleOwner, TeamRoleOfficer, TeamRoleMember:
+		return nil
+	}
+	return fmt.Errorf("role must be one of %s, %s or %s", TeamRoleOwner, TeamRoleOfficer, TeamRoleMember)
+}
+
+type Team struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name"`
+	Members   []TeamMember `json:"members,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+type TeamMember struct {
+	User     User      `json:"user"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// Reports if the member can change the role of the other member or remove them from the team
+// Only the owner manages officers, and nobody manages the owner
+func (m TeamMember) CanManage(other TeamMember) bool {
+	switch m.Role {
+	case TeamRoleOwner:
+		return other.Role != TeamRoleOwner
+	case TeamRoleOfficer:
+		return other.Role == TeamRoleMember
+	}
+	return false
+}
+
+// TeamRanking configures the derived team ranking of a leaderboard, a nil ranking disables it
+type TeamRanking struct {
+	Aggregate string `json:"aggregate"`
+	TopK      *int   `json:"top_k,omitempty"` // Members counted by the top_k aggregate
+}
+
+func (r *TeamRanking) Validate() error {
+	if r == nil {
+		return nil
+	}
+
+	switch r.Aggregate {
+	case TeamAggregateSum, TeamAggregateAverage:
+		if r.TopK != nil {
+			return fmt.Errorf("top_k is only used by the %s aggregate", TeamAggregateTopK)
+		}
+	case TeamAggregateTopK:
+		if r.TopK == nil || *r.TopK <= 0 {
+			return errors.New("top_k must be positive")
+		}
+	default:
+		return fmt.Errorf(
+			"aggregate must be one of %s, %s or %s",
+			TeamAggregateSum, TeamAggregateAverage, TeamAggregateTopK,
+		)
+	}
+	return nil
+}
+
+// Sorted set holding the aggregated score of every team with a ranked member on the leaderboard
+func (l Leaderboard) TeamRankingKey() string {
+	return TenantKey(l.TenantID, fmt.Sprintf("leaderboard:%s:teams", l.ID))
+}
+
+// Aggregated score of a team on a leaderboard with a team ranking
+// Hidden scores belong to teams left without a ranked member and must not be in the Redis ranking
+type TeamScore struct {
+	TenantID      string
+	LeaderboardID string
+	TeamID        string
+	Score         float64
+	Hidden        bool
+}
+
+// Position of a team in a team ranking, teams with the same score share the rank
+type RankedTeam struct {
+	Rank  int     `json:"rank"`
+	Team  Team    `json:"team"`
+	Score float64 `json:"score"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeamRankingValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		ranking *TeamRanking
+		valid   bool
+	}{
+		{"disabled", nil, true},
+		{"sum", &TeamRanking{Aggregate: TeamAggregateSum}, true},
+		{"average", &TeamRanking{Aggregate: TeamAggregateAverage}, true},
+		{"top k", &TeamRanking{Aggregate: TeamAggregateTopK, TopK: intPtr(3)}, true},
+		{"top k without k", &TeamRanking{Aggregate: TeamAggregateTopK}, false},
+		{"top k not positive", &TeamRanking{Aggregate: TeamAggregateTopK, TopK: intPtr(0)}, false},
+		{"k on sum", &TeamRanking{Aggregate: TeamAggregateSum, TopK: intPtr(3)}, false},
+		{"unknown aggregate", &TeamRanking{Aggregate: "median"}, false},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.ranking.Validate()
+			assert.Equal(t, testCase.valid, err == nil, err)
+		})
+	}
+}
+
+func TestTeamMemberCanManage(t *testing.T) {
+	owner := TeamMember{Role: TeamRoleOwner}
+	officer := TeamMember{Role: TeamRoleOfficer}
+	member := TeamMember{Role: TeamRoleMember}
+
+	assert.True(t, owner.CanManage(officer))
+	assert.True(t, owner.CanManage(member))
+	assert.True(t, officer.CanManage(member))
+	assert.False(t, officer.CanManage(officer))
+	assert.False(t, officer.CanManage(owner))
+	assert.False(t, member.CanManage(member))
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Leaderboards created before tenants were introduced, and requests without an API key, belong to the default tenant
+const DefaultTenantID = "1"
+
+// Roles of the members of a tenant, admins administer the leaderboards of their tenant only
+const (
+	TenantRolePlayer = "player"
+	TenantRoleAdmin  = "admin"
+)
+
+const (
+	TenantNameMaxLength = 100
+	APIKeyNameMaxLength = 50
+)
+
+var tenantSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,49}$`)
+
+// Game or project hosted by the deployment, owning its leaderboards, the memberships of its players and its API keys
+type Tenant struct {
+	ID        string    `json:"id"`
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TenantRequest struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+func (r *TenantRequest) Validate() error {
+	r.Slug = strings.TrimSpace(r.Slug)
+	if !tenantSlugPattern.MatchString(r.Slug) {
+		return errors.New("slug must be made of lowercase letters, digits and dashes, up to 50 characters")
+	}
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(r.Name) > TenantNameMaxLength {
+		return fmt.Errorf("name must not be longer than %d characters", TenantNameMaxLength)
+	}
+	return nil
+}
+
+// Membership of a user in a tenant, players join the tenant they register or submit entries in
+type TenantMember struct {
+	TenantID  string    `json:"tenant_id"`
+	UserID    string    `json:"user_id"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type TenantMemberRequest struct {
+	TenantID string `json:"-"`
+	UserID   string `json:"-"`
+	Role     string `json:"role"`
+}
+
+func (r TenantMemberRequest) Validate() error {
+	if r.Role != TenantRolePlayer && r.Role != TenantRoleAdmin {
+		return fmt.Errorf("role must be either %s or %s", TenantRolePlayer, TenantRoleAdmin)
+	}
+	return nil
+}
+
+// API key naming the tenant of a request, Key is only returned when the key is created
+type APIKey struct {
+	ID        string     `json:"id"`
+	TenantID  string     `json:"tenant_id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"` // First characters of the key, to tell keys apart
+	Key       string     `json:"key,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+type APIKeyRequest struct {
+	TenantID string `json:"-"`
+	Prefix   string `json:"-"`
+	Name     string `json:"name"`
+}
+
+func (r *APIKeyRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(r.Name) > APIKeyNameMaxLength {
+		return fmt.Errorf("name must not be longer than %d characters", APIKeyNameMaxLength)
+	}
+	return nil
+}
+
+type tenantContextKey struct{}
+
+// Carries the tenant of the request down to the repositories, which scope their queries by it
+func ContextWithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// Tenant of the request, the default tenant when none was resolved such as in background jobs
+func TenantFromContext(ctx context.Context) string {
+	if tenantID, ok := ctx.Value(tenantContextKey{}).(string); ok && tenantID != "" {
+		return tenantID
+	}
+	return DefaultTenantID
+}
+
+// Scopes a Redis key by tenant, keys of the default tenant are left unprefixed so the rankings built
+// before tenants were introduced stay valid
+func TenantKey(tenantID, key string) string {
+	if tenantID == "" || tenantID == DefaultTenantID {
+		return key
+	}
+	return fmt.Sprintf("tenant:%s:%s", tenantID, key)
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantRequestValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		request TenantRequest
+		wantErr bool
+	}{
+		{name: "tenant", request: TenantRequest{Slug: " speedrun-club ", Name: "Speedrun Club"}},
+		{name: "uppercase slug", request: TenantRequest{Slug: "Speedrun", Name: "Speedrun"}, wantErr: true},
+		{name: "slug starting with a dash", request: TenantRequest{Slug: "-speedrun", Name: "Speedrun"}, wantErr: true},
+		{name: "missing name", request: TenantRequest{Slug: "speedrun", Name: " "}, wantErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.request.Validate()
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTenantMemberRequestValidate(t *testing.T) {
+	assert.NoError(t, TenantMemberRequest{Role: TenantRolePlayer}.Validate())
+	assert.NoError(t, TenantMemberRequest{Role: TenantRoleAdmin}.Validate())
+	assert.Error(t, TenantMemberRequest{Role: "moderator"}.Validate())
+}
+
+func TestTenantFromContext(t *testing.T) {
+	assert.Equal(t, DefaultTenantID, TenantFromContext(context.Background()))
+	assert.Equal(t, "4", TenantFromContext(ContextWithTenant(context.Background(), "4")))
+}
+
+func TestTenantKey(t *testing.T) {
+	assert.Equal(t, "leaderboard:5", Leaderboard{ID: "5"}.RedisKey())
+	assert.Equal(t, "leaderboard:5", Leaderboard{ID: "5", TenantID: DefaultTenantID}.RedisKey())
+	assert.Equal(t, "tenant:4:leaderboard:5", Leaderboard{ID: "5", TenantID: "4"}.RedisKey())
+}
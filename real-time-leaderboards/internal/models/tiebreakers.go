@@ -0,0 +1,98 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Tie-breakers decide between entries with the same score, in the order the leaderboard declares them
+// Entries still tied after every tie-breaker are ordered by user id, the same way Redis orders equal scores
+const (
+	TieBreakerEarliestSubmission = "earliest_submission" // The entry that reached the score first wins
+	TieBreakerFewestAttempts     = "fewest_attempts"     // The entry submitted in fewer attempts by its user wins
+	TieBreakerHighestSecondary   = "highest_secondary"   // The entry with the highest secondary score wins
+	TieBreakerLowestSecondary    = "lowest_secondary"    // The entry with the lowest secondary score wins
+)
+
+const (
+	TieBreakersMaxCount = 3
+
+	// Fractional bits of the sort key a double keeps exactly for any 32 bit score, split between the tie-breakers
+	// The encoding itself is done by Postgres when entries are written, see the entry_sort_key function
+	TieBreakBits = 21
+)
+
+// Ordered tie-breakers of a leaderboard, no tie-breakers leaves ties ordered by user id only
+type TieBreakers []string
+
+func (t TieBreakers) Validate() error {
+	if len(t) > TieBreakersMaxCount {
+		return fmt.Errorf("at most %d tie_breakers can be declared", TieBreakersMaxCount)
+	}
+
+	seen := make(map[string]bool, len(t))
+	for _, breaker := range t {
+		switch breaker {
+		case TieBreakerEarliestSubmission, TieBreakerFewestAttempts, TieBreakerHighestSecondary, TieBreakerLowestSecondary:
+		default:
+			return fmt.Errorf(
+				"tie_breakers must be among %s, %s, %s and %s",
+				TieBreakerEarliestSubmission, TieBreakerFewestAttempts, TieBreakerHighestSecondary, TieBreakerLowestSecondary,
+			)
+		}
+		if seen[breaker] {
+			return fmt.Errorf("tie breaker %s is declared more than once", breaker)
+		}
+		seen[breaker] = true
+	}
+
+	if seen[TieBreakerHighestSecondary] && seen[TieBreakerLowestSecondary] {
+		return errors.New("the secondary score can only be ranked in one direction")
+	}
+	return nil
+}
+
+// Reports if the entries of the leaderboard carry a secondary score
+func (t TieBreakers) UsesSecondary() bool {
+	for _, breaker := range t {
+		if breaker == TieBreakerHighestSecondary || breaker == TieBreakerLowestSecondary {
+			return true
+		}
+	}
+	return false
+}
+
+// Largest value the tie-breaker at the index can tell apart, larger values saturate and tie
+// The TieBreakBits are split evenly between the tie-breakers in order, as entry_sort_key does
+func (t TieBreakers) MaxValue(index int) int {
+	bits := TieBreakBits / len(t)
+	if index < TieBreakBits%len(t) {
+		bits++
+	}
+	return 1<<bits - 1
+}
+
+// Rejects secondary scores the sort key cannot tell apart, which would otherwise tie and fall back to user id order
+// Entries without a secondary score are accepted and ranked after the ones with one
+func (t TieBreakers) ValidateSecondary(secondary *int) error {
+	if secondary == nil {
+		return nil
+	}
+	for i, breaker := range t {
+		if breaker != TieBreakerHighestSecondary && breaker != TieBreakerLowestSecondary {
+			continue
+		}
+		if maxValue := t.MaxValue(i); *secondary < 0 || *secondary > maxValue {
+			return &ScoreRejection{
+				Reason: fmt.Sprintf("secondary_score must be between 0 and %d with the tie-breakers of this leaderboard", maxValue),
+			}
+		}
+	}
+	return nil
+}
+
+// Score encoded in a sort key, the tie-breakers only ever fill its fractional part
+func ScoreOfSortKey(key float64) int {
+	return int(math.Floor(key))
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTieBreakersValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		tieBreakers TieBreakers
+		wantErr     bool
+	}{
+		{name: "no tie-breakers", tieBreakers: nil},
+		{
+			name:        "ordered tie-breakers",
+			tieBreakers: TieBreakers{TieBreakerHighestSecondary, TieBreakerFewestAttempts, TieBreakerEarliestSubmission},
+		},
+		{name: "unknown tie-breaker", tieBreakers: TieBreakers{"longest_streak"}, wantErr: true},
+		{
+			name:        "duplicated tie-breaker",
+			tieBreakers: TieBreakers{TieBreakerFewestAttempts, TieBreakerFewestAttempts},
+			wantErr:     true,
+		},
+		{
+			name:        "secondary in both directions",
+			tieBreakers: TieBreakers{TieBreakerHighestSecondary, TieBreakerLowestSecondary},
+			wantErr:     true,
+		},
+		{
+			name: "too many tie-breakers",
+			tieBreakers: TieBreakers{
+				TieBreakerEarliestSubmission, TieBreakerFewestAttempts, TieBreakerHighestSecondary, TieBreakerLowestSecondary,
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.tieBreakers.Validate()
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTieBreakersMaxValue(t *testing.T) {
+	assert.Equal(t, 1<<21-1, TieBreakers{TieBreakerHighestSecondary}.MaxValue(0))
+
+	// 21 bits split as 11 and 10
+	two := TieBreakers{TieBreakerLowestSecondary, TieBreakerEarliestSubmission}
+	assert.Equal(t, 1<<11-1, two.MaxValue(0))
+	assert.Equal(t, 1<<10-1, two.MaxValue(1))
+
+	three := TieBreakers{TieBreakerFewestAttempts, TieBreakerEarliestSubmission, TieBreakerHighestSecondary}
+	assert.Equal(t, 127, three.MaxValue(2))
+}
+
+func TestTieBreakersValidateSecondary(t *testing.T) {
+	three := TieBreakers{TieBreakerFewestAttempts, TieBreakerEarliestSubmission, TieBreakerHighestSecondary}
+	inRange, tooHigh, negative := 127, 128, -1
+
+	assert.NoError(t, three.ValidateSecondary(nil))
+	assert.NoError(t, three.ValidateSecondary(&inRange))
+	var rejection *ScoreRejection
+	if assert.ErrorAs(t, three.ValidateSecondary(&tooHigh), &rejection) {
+		assert.Contains(t, rejection.Reason, "between 0 and 127")
+	}
+	assert.Error(t, three.ValidateSecondary(&negative))
+
+	// Leaderboards not ranking the secondary score accept any value
+	assert.NoError(t, TieBreakers{TieBreakerFewestAttempts}.ValidateSecondary(&tooHigh))
+	assert.NoError(t, TieBreakers(nil).ValidateSecondary(&tooHigh))
+}
+
+func TestScoreOfSortKey(t *testing.T) {
+	assert.Equal(t, 1200, ScoreOfSortKey(1200))
+	assert.Equal(t, 1200, ScoreOfSortKey(1200.999))
+	// The tie-break is added on top of negative scores too, so the score is floored rather than truncated
+	assert.Equal(t, -5, ScoreOfSortKey(-4.7))
+}
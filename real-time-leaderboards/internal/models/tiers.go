@@ -0,0 +1,87 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	TiersMaxCount     = 10
+	TierNameMaxLength = 30
+)
+
+// Tier reached by the players within the top TopPercent of the ranking, such as Gold for the top 5%
+type Tier struct {
+	Name       string  `json:"name"`
+	TopPercent float64 `json:"top_percent"`
+}
+
+// Tiers of a leaderboard, declared from the best one with increasing TopPercent
+// Players below the last tier are left without one
+type Tiers []Tier
+
+func (t Tiers) Validate() error {
+	if len(t) > TiersMaxCount {
+		return fmt.Errorf("at most %d tiers can be declared", TiersMaxCount)
+	}
+
+	seen := make(map[string]bool, len(t))
+	for i, tier := range t {
+		name := strings.TrimSpace(tier.Name)
+		if name == "" {
+			return errors.New("every tier needs a name")
+		}
+		if len(name) > TierNameMaxLength {
+			return fmt.Errorf("tier names must not be longer than %d characters", TierNameMaxLength)
+		}
+		if seen[name] {
+			return fmt.Errorf("tier %s is declared more than once", name)
+		}
+		seen[name] = true
+		t[i].Name = name
+
+		if tier.TopPercent <= 0 || tier.TopPercent > 100 {
+			return errors.New("top_percent of a tier must be above 0 and at most 100")
+		}
+		if i > 0 && tier.TopPercent <= t[i-1].TopPercent {
+			return errors.New("tiers must be declared from the best one, with increasing top_percent")
+		}
+	}
+	return nil
+}
+
+// Name of the best tier including the given top percent, empty if the player is below every tier
+func (t Tiers) Of(topPercent float64) string {
+	for _, tier := range t {
+		if topPercent <= tier.TopPercent {
+			return tier.Name
+		}
+	}
+	return ""
+}
+
+// Where a score stands within the ranking of a leaderboard
+// Percentile is the share of ranked players below the score, TopPercent the share at or above its rank
+type Standing struct {
+	UserID     string  `json:"user_id,omitempty"`
+	Score      float64 `json:"score"`
+	Players    int64   `json:"players"`
+	Percentile float64 `json:"percentile"`
+	TopPercent float64 `json:"top_percent"`
+	Tier       string  `json:"tier,omitempty"`
+}
+
+// Computes the standing from the number of ranked players with a better and a worse score
+func NewStanding(tiers Tiers, score float64, above, below, players int64) Standing {
+	standing := Standing{Score: score, Players: players}
+	if players > 0 {
+		standing.Percentile = float64(below) / float64(players) * 100
+		standing.TopPercent = math.Min(float64(above+1)/float64(players)*100, 100)
+	} else {
+		standing.TopPercent = 100
+	}
+	standing.Tier = tiers.Of(standing.TopPercent)
+	return standing
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTiersValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		tiers   Tiers
+		wantErr bool
+	}{
+		{name: "no tiers", tiers: nil},
+		{name: "ordered tiers", tiers: Tiers{{Name: "Gold", TopPercent: 5}, {Name: "Silver", TopPercent: 25}, {Name: "Bronze", TopPercent: 60}}},
+		{name: "missing name", tiers: Tiers{{Name: " ", TopPercent: 5}}, wantErr: true},
+		{name: "duplicated name", tiers: Tiers{{Name: "Gold", TopPercent: 5}, {Name: "Gold", TopPercent: 10}}, wantErr: true},
+		{name: "top percent above 100", tiers: Tiers{{Name: "Gold", TopPercent: 120}}, wantErr: true},
+		{name: "worst tier first", tiers: Tiers{{Name: "Bronze", TopPercent: 60}, {Name: "Gold", TopPercent: 5}}, wantErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.tiers.Validate()
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNewStanding(t *testing.T) {
+	tiers := Tiers{{Name: "Gold", TopPercent: 5}, {Name: "Silver", TopPercent: 25}}
+
+	// 2 players above out of 100 puts the score in the top 3%
+	standing := NewStanding(tiers, 1200, 2, 97, 100)
+	assert.InDelta(t, 97, standing.Percentile, 0.0001)
+	assert.InDelta(t, 3, standing.TopPercent, 0.0001)
+	assert.Equal(t, "Gold", standing.Tier)
+
+	standing = NewStanding(tiers, 800, 40, 59, 100)
+	assert.InDelta(t, 41, standing.TopPercent, 0.0001)
+	assert.Equal(t, "", standing.Tier)
+
+	// A score above every ranked player of an empty ranking is not divided by zero
+	standing = NewStanding(tiers, 800, 0, 0, 0)
+	assert.Equal(t, float64(100), standing.TopPercent)
+}
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Tournament formats, elimination brackets are seeded so the best qualifiers meet as late as possible
+const (
+	TournamentFormatSingleElimination = "single_elimination"
+	TournamentFormatDoubleElimination = "double_elimination"
+	TournamentFormatRoundRobin        = "round_robin"
+)
+
+// Tournaments wait for qualification until started, and complete once a winner is known
+const (
+	TournamentStatusPending   = "pending"
+	TournamentStatusRunning   = "running"
+	TournamentStatusCompleted = "completed"
+)
+
+// Brackets a match is part of
+const (
+	BracketWinners    = "winners"
+	BracketLosers     = "losers"
+	BracketGrandFinal = "grand_final"
+	BracketRoundRobin = "round_robin"
+)
+
+// Pending matches wait for the players of earlier matches, ready matches can be played
+const (
+	MatchStatusPending   = "pending"
+	MatchStatusReady     = "ready"
+	MatchStatusCompleted = "completed"
+)
+
+const (
+	TournamentNameMaxLength = 100
+	TournamentMaxSize       = 256
+)
+
+type TournamentRequest struct {
+	Name          string `json:"name"`
+	LeaderboardID string `json:"leaderboard_id"` // Qualification leaderboard, its top Size players are seeded
+	Format        string `json:"format"`
+	Size          int    `json:"size"`
+}
+
+func (r *TournamentRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(r.Name) > TournamentNameMaxLength {
+		return fmt.Errorf("name must not be longer than %d characters", TournamentNameMaxLength)
+	}
+	if r.LeaderboardID == "" {
+		return errors.New("leaderboard_id is required")
+	}
+
+	switch r.Format {
+	case TournamentFormatSingleElimination, TournamentFormatDoubleElimination, TournamentFormatRoundRobin:
+	default:
+		return fmt.Errorf(
+			"format must be one of %s, %s or %s",
+			TournamentFormatSingleElimination, TournamentFormatDoubleElimination, TournamentFormatRoundRobin,
+		)
+	}
+
+	if r.Size < 2 || r.Size > TournamentMaxSize {
+		return fmt.Errorf("size must be between 2 and %d", TournamentMaxSize)
+	}
+	return nil
+}
+
+type Tournament struct {
+	ID            string                  `json:"id"`
+	Name          string                  `json:"name"`
+	LeaderboardID string                  `json:"leaderboard_id"`
+	Format        string                  `json:"format"`
+	Size          int                     `json:"size"`
+	Status        string                  `json:"status"`
+	WinnerID      string                  `json:"winner_id,omitempty"`
+	Participants  []TournamentParticipant `json:"participants,omitempty"`
+	Matches       []TournamentMatch       `json:"matches,omitempty"`
+	Standings     []TournamentStanding    `json:"standings,omitempty"` // Round robin only
+	CreatedAt     time.Time               `json:"created_at"`
+	UpdatedAt     time.Time               `json:"updated_at"`
+	StartedAt     *time.Time              `json:"started_at,omitempty"`
+	CompletedAt   *time.Time              `json:"completed_at,omitempty"`
+}
+
+// Qualified player, seeds follow the qualification ranking starting at 1
+type TournamentParticipant struct {
+	Seed            int  `json:"seed"`
+	User            User `json:"user"`
+	QualifyingScore int  `json:"qualifying_score"`
+}
+
+// Slot of a match a player advances into, slots are 1 or 2
+type MatchSlot struct {
+	Match int `json:"match"`
+	Slot  int `json:"slot"`
+}
+
+// Matches are numbered from 0 within the tournament, links between them use those numbers
+// An empty player after the match is decided is a bye, the other player goes through without playing
+type TournamentMatch struct {
+	Number       int        `json:"number"`
+	Bracket      string     `json:"bracket"`
+	Round        int        `json:"round"`
+	Position     int        `json:"position"`
+	Player1ID    string     `json:"player1_id,omitempty"`
+	Player2ID    string     `json:"player2_id,omitempty"`
+	Player1Score *int       `json:"player1_score,omitempty"`
+	Player2Score *int       `json:"player2_score,omitempty"`
+	WinnerID     string     `json:"winner_id,omitempty"`
+	Status       string     `json:"status"`
+	Next         *MatchSlot `json:"next,omitempty"`       // Where the winner plays next
+	LoserNext    *MatchSlot `json:"loser_next,omitempty"` // Where the loser drops in double elimination
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+}
+
+// Loser of a completed match, empty for byes
+func (m TournamentMatch) LoserID() string {
+	if m.WinnerID == m.Player1ID {
+		return m.Player2ID
+	}
+	return m.Player1ID
+}
+
+type MatchResult struct {
+	WinnerID     string `json:"winner_id"`
+	Player1Score *int   `json:"player1_score"`
+	Player2Score *int   `json:"player2_score"`
+}
+
+func (r MatchResult) Validate() error {
+	if r.WinnerID == "" {
+		return errors.New("winner_id is required")
+	}
+	if (r.Player1Score == nil) != (r.Player2Score == nil) {
+		return errors.New("scores must be given for both players or neither")
+	}
+	if r.Player1Score != nil && (*r.Player1Score < 0 || *r.Player2Score < 0) {
+		return errors.New("scores must not be negative")
+	}
+	return nil
+}
+
+// Round robin record of a participant, standings are ordered by wins, then score difference, then seed
+type TournamentStanding struct {
+	User         User `json:"user"`
+	Seed         int  `json:"seed"`
+	Wins         int  `json:"wins"`
+	Losses       int  `json:"losses"`
+	ScoreFor     int  `json:"score_for"`
+	ScoreAgainst int  `json:"score_against"`
+}
@@ -13,6 +13,7 @@ type User struct {
 	Email string `json:"email"`
 	PasswordHash string `json:"-"`
 	Role string	`json:"role"`
+	Ban *Ban `json:"-"` // Never exposed, a shadowbanned user must not be able to tell
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
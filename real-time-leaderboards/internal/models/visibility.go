@@ -0,0 +1,81 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Who can read a leaderboard, only public leaderboards are listed
+const (
+	VisibilityPublic   Visibility = "public"
+	VisibilityUnlisted Visibility = "unlisted" // Readable by anyone knowing its id
+	VisibilityPrivate  Visibility = "private"  // Readable and writable by its members only
+)
+
+type Visibility string
+
+// A missing visibility defaults to public
+func (v *Visibility) Validate() error {
+	switch *v {
+	case "":
+		*v = VisibilityPublic
+	case VisibilityPublic, VisibilityUnlisted, VisibilityPrivate:
+	default:
+		return fmt.Errorf("visibility must be one of %s, %s or %s", VisibilityPublic, VisibilityUnlisted, VisibilityPrivate)
+	}
+	return nil
+}
+
+const InviteMaxUses = 10000
+
+// Visibility of a leaderboard and whether the viewer is one of its members
+type LeaderboardAccess struct {
+	Visibility Visibility
+	Member     bool
+}
+
+// Anonymous viewers and non members can read every leaderboard but the private ones
+func (a LeaderboardAccess) CanRead() bool {
+	return a.Visibility != VisibilityPrivate || a.Member
+}
+
+type LeaderboardMember struct {
+	LeaderboardID string    `json:"leaderboard_id"`
+	UserID        string    `json:"user_id"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Invite code joining its users to the leaderboard, unlimited and never expiring when MaxUses and ExpiresAt are nil
+type LeaderboardInvite struct {
+	ID            string     `json:"id"`
+	LeaderboardID string     `json:"leaderboard_id"`
+	Code          string     `json:"code"`
+	CreatedBy     string     `json:"created_by,omitempty"`
+	MaxUses       *int       `json:"max_uses,omitempty"`
+	Uses          int        `json:"uses"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type InviteRequest struct {
+	LeaderboardID string     `json:"-"`
+	Code          string     `json:"-"` // Generated by the server
+	CreatedBy     string     `json:"-"`
+	MaxUses       *int       `json:"max_uses"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+}
+
+func (r InviteRequest) Validate(now time.Time) error {
+	if r.MaxUses != nil && (*r.MaxUses <= 0 || *r.MaxUses > InviteMaxUses) {
+		return fmt.Errorf("max_uses must be between 1 and %d", InviteMaxUses)
+	}
+	if r.ExpiresAt != nil && !r.ExpiresAt.After(now) {
+		return errors.New("expires_at must be in the future")
+	}
+	return nil
+}
+
+type JoinRequest struct {
+	Code string `json:"code"`
+}
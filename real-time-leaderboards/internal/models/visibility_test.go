@@ -0,0 +1,56 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVisibilityValidate(t *testing.T) {
+	var visibility Visibility
+	assert.NoError(t, visibility.Validate())
+	assert.Equal(t, VisibilityPublic, visibility)
+
+	visibility = VisibilityPrivate
+	assert.NoError(t, visibility.Validate())
+	assert.Equal(t, VisibilityPrivate, visibility)
+
+	visibility = "hidden"
+	assert.Error(t, visibility.Validate())
+}
+
+func TestLeaderboardAccessCanRead(t *testing.T) {
+	assert.True(t, LeaderboardAccess{Visibility: VisibilityPublic}.CanRead())
+	assert.True(t, LeaderboardAccess{Visibility: VisibilityUnlisted}.CanRead())
+	assert.False(t, LeaderboardAccess{Visibility: VisibilityPrivate}.CanRead())
+	assert.True(t, LeaderboardAccess{Visibility: VisibilityPrivate, Member: true}.CanRead())
+}
+
+func TestInviteRequestValidate(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	zero, uses := 0, 5
+
+	testCases := []struct {
+		name    string
+		request InviteRequest
+		wantErr bool
+	}{
+		{name: "unlimited invite", request: InviteRequest{}},
+		{name: "limited invite", request: InviteRequest{MaxUses: &uses, ExpiresAt: &future}},
+		{name: "no uses", request: InviteRequest{MaxUses: &zero}, wantErr: true},
+		{name: "already expired", request: InviteRequest{ExpiresAt: &past}, wantErr: true},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.request.Validate(now)
+			if testCase.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package rankings
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	cache "github.com/mochivi/go-real-time-leaderboards/internal/storage/redis"
+)
+
+// Circles keeps a Redis set with every user and their friends, and ranks leaderboards among them
+// by intersecting that set with the leaderboard ranking, so the whole ranking is never read
+type Circles struct {
+	repo  storage.FriendRepo
+	users storage.UserRepo
+	redis cache.RedisService
+}
+
+func NewCircles(repo storage.FriendRepo, userRepo storage.UserRepo, redisService cache.RedisService) Circles {
+	return Circles{
+		repo:  repo,
+		users: userRepo,
+		redis: redisService,
+	}
+}
+
+// Makes the circle set of the user match their friendships in Postgres
+func (c Circles) Sync(ctx context.Context, userID string) error {
+	members, err := c.repo.GetCircle(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get circle of user '%s': %w", userID, err)
+	}
+
+	ids := make([]string, 0, len(members))
+	for _, member := range members {
+		ids = append(ids, member.ID)
+	}
+
+	user := models.User{ID: userID}
+	if err := c.redis.SReplace(ctx, user.CircleKey(), ids); err != nil {
+		return fmt.Errorf("failed to store circle of user '%s': %w", userID, err)
+	}
+
+	return nil
+}
+
+// Ranks the leaderboard among the user and their friends, the circle is built on first use
+// Only the ranked members are read from Postgres, so renamed and erased users show their current username
+func (c Circles) Rank(ctx context.Context, leaderboard *models.Leaderboard, userID string) ([]models.RankedEntry, error) {
+	user := models.User{ID: userID}
+	exists, err := c.redis.Exists(ctx, user.CircleKey())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read circle of user '%s': %w", userID, err)
+	}
+	if !exists {
+		if err := c.Sync(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	scores, err := c.redis.ZInterWithSet(ctx, leaderboard.RankingKey(), user.CircleKey())
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank leaderboard '%s' among friends: %w", leaderboard.ID, err)
+	}
+	if len(scores) == 0 {
+		return []models.RankedEntry{}, nil
+	}
+
+	ids := make([]string, 0, len(scores))
+	for _, score := range scores {
+		ids = append(ids, score.Member)
+	}
+
+	users, err := c.users.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ranked friends: %w", err)
+	}
+	usernames := make(map[string]string, len(users))
+	for _, member := range users {
+		usernames[member.ID] = member.Username
+	}
+
+	// Users are tied only when their sort keys are equal, a tie-break decides between equal scores
+	// Deleted users are skipped until the circle is synced again
+	ranker := models.NewRanker(leaderboard.RankMode)
+	ranked := make([]models.RankedEntry, 0, len(scores))
+	for _, score := range scores {
+		username, ok := usernames[score.Member]
+		if !ok {
+			continue
+		}
+
+		ranked = append(ranked, models.RankedEntry{
+			Rank:  ranker.Next(score.Score),
+			User:  models.User{ID: score.Member, Username: username},
+			Score: models.ScoreOfSortKey(score.Score),
+		})
+	}
+
+	return ranked, nil
+}
@@ -0,0 +1,88 @@
+package rankings
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	cache "github.com/mochivi/go-real-time-leaderboards/internal/storage/redis"
+)
+
+// Neighbours reads the players ranked around a user from the Redis ranking, numbered in the
+// rank mode of the leaderboard as if the window was a page of the whole ranking
+type Neighbours struct {
+	repo  storage.UserRepo
+	redis cache.RedisService
+}
+
+func NewNeighbours(repo storage.UserRepo, redisService cache.RedisService) Neighbours {
+	return Neighbours{
+		repo:  repo,
+		redis: redisService,
+	}
+}
+
+// Ranks the window around the user, cache.ErrNotFound if the user is not in the ranking
+func (n Neighbours) Around(
+	ctx context.Context,
+	leaderboard *models.Leaderboard,
+	userID string,
+	filter *models.AroundFilter,
+) ([]models.RankedEntry, error) {
+	position, err := n.redis.ZRevRank(ctx, leaderboard.RankingKey(), userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ranking position of user '%s': %w", userID, err)
+	}
+
+	page := filter.Page(int(position))
+	scores, err := n.redis.ZRevRangeWithScores(
+		ctx,
+		leaderboard.RankingKey(),
+		int64(page.Offset),
+		int64(page.Offset+page.Limit-1),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ranking of leaderboard '%s': %w", leaderboard.ID, err)
+	}
+	if len(scores) == 0 {
+		return []models.RankedEntry{}, nil
+	}
+
+	ids := make([]string, 0, len(scores))
+	for _, score := range scores {
+		ids = append(ids, score.Member)
+	}
+
+	users, err := n.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ranked users: %w", err)
+	}
+	usernames := make(map[string]string, len(users))
+	for _, user := range users {
+		usernames[user.ID] = user.Username
+	}
+
+	ranker, err := pageRanker(ctx, n.redis, leaderboard.RankingKey(), leaderboard.RankMode, &page, scores[0].Score)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank players of leaderboard '%s': %w", leaderboard.ID, err)
+	}
+
+	// Users deleted since their score was ranked keep their position until the ranking is synced
+	ranked := make([]models.RankedEntry, 0, len(scores))
+	for _, score := range scores {
+		rank := ranker.Next(score.Score)
+		username, ok := usernames[score.Member]
+		if !ok {
+			continue
+		}
+
+		ranked = append(ranked, models.RankedEntry{
+			Rank:  rank,
+			User:  models.User{ID: score.Member, Username: username},
+			Score: models.ScoreOfSortKey(score.Score),
+		})
+	}
+
+	return ranked, nil
+}
@@ -0,0 +1,89 @@
+package rankings
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	cache "github.com/mochivi/go-real-time-leaderboards/internal/storage/redis"
+)
+
+// Percentiles places users and scores within a ranking by counting the players around them,
+// so very large rankings are never read
+type Percentiles struct {
+	redis cache.RedisService
+}
+
+func NewPercentiles(redisService cache.RedisService) Percentiles {
+	return Percentiles{
+		redis: redisService,
+	}
+}
+
+// Standing of a ranked user, cache.ErrNotFound if the user is not in the ranking
+func (p Percentiles) OfUser(ctx context.Context, leaderboard *models.Leaderboard, userID string) (*models.Standing, error) {
+	key, err := p.redis.ZScore(ctx, leaderboard.RankingKey(), userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ranked score of user '%s': %w", userID, err)
+	}
+
+	standing, err := p.OfSortKey(ctx, leaderboard, key)
+	if err != nil {
+		return nil, err
+	}
+	standing.UserID = userID
+	return standing, nil
+}
+
+// Standing of a sort key as stored in the ranking, only players with the exact same key tie with it
+func (p Percentiles) OfSortKey(ctx context.Context, leaderboard *models.Leaderboard, key float64) (*models.Standing, error) {
+	bound := formatBound(key)
+	score := key
+	if leaderboard.Type != models.LeaderboardTypeRating {
+		score = float64(models.ScoreOfSortKey(key))
+	}
+	return p.standing(ctx, leaderboard, score, "("+bound, "("+bound)
+}
+
+// Standing of an arbitrary score, tied with every player on the same score whatever their tie-breaks
+func (p Percentiles) OfScore(ctx context.Context, leaderboard *models.Leaderboard, score float64) (*models.Standing, error) {
+	if leaderboard.Type == models.LeaderboardTypeRating {
+		return p.OfSortKey(ctx, leaderboard, score)
+	}
+
+	// Tie-breakers only fill the fractional part of the sort keys, so a score covers keys from itself to the next one
+	score = math.Floor(score)
+	return p.standing(ctx, leaderboard, score, formatBound(score+1), "("+formatBound(score))
+}
+
+// aboveMin and belowMax are Redis range bounds of the keys ranked above and below the score
+func (p Percentiles) standing(
+	ctx context.Context,
+	leaderboard *models.Leaderboard,
+	score float64,
+	aboveMin, belowMax string,
+) (*models.Standing, error) {
+	rankingKey := leaderboard.RankingKey()
+	players, err := p.redis.ZCard(ctx, rankingKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ranking size of leaderboard '%s': %w", leaderboard.ID, err)
+	}
+	above, err := p.redis.ZCount(ctx, rankingKey, aboveMin, "+inf")
+	if err != nil {
+		return nil, fmt.Errorf("failed to count players above score %v: %w", score, err)
+	}
+	below, err := p.redis.ZCount(ctx, rankingKey, "-inf", belowMax)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count players below score %v: %w", score, err)
+	}
+
+	standing := models.NewStanding(leaderboard.Tiers, score, above, below, players)
+	return &standing, nil
+}
+
+// Formats a score as a Redis range bound, without losing the precision of the sort key
+func formatBound(score float64) string {
+	return strconv.FormatFloat(score, 'g', -1, 64)
+}
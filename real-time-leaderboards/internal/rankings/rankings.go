@@ -0,0 +1,117 @@
+package rankings
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	cache "github.com/mochivi/go-real-time-leaderboards/internal/storage/redis"
+)
+
+// Syncer rebuilds the Redis rankings from Postgres, which is always the source of truth
+// New entries are added to the rankings as they are created, the syncer handles the changes that
+// can remove or restore scores, such as bans and moderation decisions
+type Syncer struct {
+	repo  storage.LeaderboardRepo
+	redis cache.RedisService
+}
+
+func NewSyncer(repo storage.LeaderboardRepo, redisService cache.RedisService) Syncer {
+	return Syncer{
+		repo:  repo,
+		redis: redisService,
+	}
+}
+
+// Makes every ranking of the user match their best ranked scores in Postgres
+// Scores of banned and shadowbanned users are removed, and restored once the ban is lifted
+func (s Syncer) SyncUser(ctx context.Context, userID string) error {
+	scores, err := s.repo.GetRankedScores(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get ranked scores of user '%s': %w", userID, err)
+	}
+
+	// Keep going on failures so a single unavailable ranking does not leave the others stale
+	var syncErr error
+	for _, score := range scores {
+		leaderboard := models.Leaderboard{ID: score.LeaderboardID, TenantID: score.TenantID}
+		if score.Hidden {
+			err = s.redis.ZRem(ctx, leaderboard.RankingKey(), userID)
+		} else {
+			err = s.redis.ZAdd(ctx, leaderboard.RankingKey(), userID, score.Score)
+		}
+		if err != nil {
+			log.Printf("Failed to sync ranking of leaderboard '%s' for user '%s': %v", score.LeaderboardID, userID, err)
+			syncErr = fmt.Errorf("failed to sync rankings of user '%s': %w", userID, err)
+		}
+	}
+
+	return syncErr
+}
+
+// Rebuilds the whole ranking of the leaderboard from Postgres
+// Used after bulk changes that can touch any number of users, including removing all of their entries
+func (s Syncer) SyncLeaderboard(ctx context.Context, leaderboardID string) error {
+	scores, err := s.repo.GetLeaderboardScores(ctx, leaderboardID)
+	if err != nil {
+		return fmt.Errorf("failed to get scores of leaderboard '%s': %w", leaderboardID, err)
+	}
+
+	members := make(map[string]float64, len(scores))
+	for _, score := range scores {
+		members[score.UserID] = score.Score
+	}
+
+	// The scores were read within the tenant of the context, which the leaderboard belongs to
+	leaderboard := models.Leaderboard{ID: leaderboardID, TenantID: models.TenantFromContext(ctx)}
+	if err := s.redis.ZReplace(ctx, leaderboard.RankingKey(), members); err != nil {
+		return fmt.Errorf("failed to sync ranking of leaderboard '%s': %w", leaderboardID, err)
+	}
+
+	return nil
+}
+
+// Ranker for a page of a Redis ranking, numbered from the members above the page in the given mode
+// Competition ranks count the members with a better key, dense ranks count the distinct better keys
+func pageRanker(
+	ctx context.Context,
+	redisService cache.RedisService,
+	key string,
+	mode models.RankMode,
+	page *models.Pagination,
+	first float64,
+) (*models.Ranker, error) {
+	ranker := models.NewRanker(mode)
+	if page.Offset == 0 {
+		return ranker, nil
+	}
+
+	switch mode {
+	case models.RankModeDense:
+		better, err := redisService.ZCountDistinct(ctx, key, "("+strconv.FormatFloat(first, 'g', -1, 64), "+inf")
+		if err != nil {
+			return nil, fmt.Errorf("failed to count distinct keys above offset %d: %w", page.Offset, err)
+		}
+		// The first member of the page ranks right after the better keys, whether or not it ties with the members above
+		ranker.Skip(page.Offset, int(better), math.Inf(1))
+	case models.RankModeCompetition:
+		better, err := redisService.ZCount(ctx, key, "("+strconv.FormatFloat(first, 'g', -1, 64), "+inf")
+		if err != nil {
+			return nil, fmt.Errorf("failed to count ranking members above offset %d: %w", page.Offset, err)
+		}
+		// Members above the page tie with its first member when fewer than the offset have a better key
+		lastKey := math.Inf(1)
+		if int(better) < page.Offset {
+			lastKey = first
+		}
+		ranker.Skip(page.Offset, min(int(better)+1, page.Offset), lastKey)
+	default:
+		ranker.Skip(page.Offset, page.Offset, math.Inf(1))
+	}
+
+	return ranker, nil
+}
@@ -0,0 +1,73 @@
+package rankings
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	cache "github.com/mochivi/go-real-time-leaderboards/internal/storage/redis"
+)
+
+// Ratings ranks rating leaderboards, their Redis ranking holds the conservative rating of every player
+// and is kept by the Syncer like the ranking of any score leaderboard
+type Ratings struct {
+	repo  storage.RatingRepo
+	redis cache.RedisService
+}
+
+func NewRatings(repo storage.RatingRepo, redisService cache.RedisService) Ratings {
+	return Ratings{
+		repo:  repo,
+		redis: redisService,
+	}
+}
+
+// Returns a page of the ranking with the current rating of every player on it
+func (r Ratings) Rank(ctx context.Context, leaderboard *models.Leaderboard, page *models.Pagination) ([]models.PlayerRating, error) {
+	scores, err := r.redis.ZRevRangeWithScores(
+		ctx,
+		leaderboard.RankingKey(),
+		int64(page.Offset),
+		int64(page.Offset+page.Limit-1),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ranking of leaderboard '%s': %w", leaderboard.ID, err)
+	}
+	if len(scores) == 0 {
+		return []models.PlayerRating{}, nil
+	}
+
+	ids := make([]string, 0, len(scores))
+	for _, score := range scores {
+		ids = append(ids, score.Member)
+	}
+
+	ratings, err := r.repo.GetRatings(ctx, leaderboard.ID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ranked ratings: %w", err)
+	}
+	byID := make(map[string]models.PlayerRating, len(ratings))
+	for _, rating := range ratings {
+		byID[rating.User.ID] = rating
+	}
+
+	ranker, err := pageRanker(ctx, r.redis, leaderboard.RankingKey(), leaderboard.RankMode, page, scores[0].Score)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank players of leaderboard '%s': %w", leaderboard.ID, err)
+	}
+
+	ranked := make([]models.PlayerRating, 0, len(scores))
+	for _, score := range scores {
+		rank := ranker.Next(score.Score)
+		rating, ok := byID[score.Member]
+		if !ok {
+			continue
+		}
+
+		rating.Rank = rank
+		ranked = append(ranked, rating)
+	}
+
+	return ranked, nil
+}
@@ -0,0 +1,140 @@
+package rankings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
+	cache "github.com/mochivi/go-real-time-leaderboards/internal/storage/redis"
+)
+
+// Teams keeps the team rankings of the leaderboards configured with a team aggregate
+// Team scores are aggregated in Postgres and written to Redis whenever a member's score or the
+// membership of the team changes
+type Teams struct {
+	repo  storage.TeamRepo
+	redis cache.RedisService
+}
+
+func NewTeams(repo storage.TeamRepo, redisService cache.RedisService) Teams {
+	return Teams{
+		repo:  repo,
+		redis: redisService,
+	}
+}
+
+// Makes the team rankings match the scores of the team in Postgres, on every leaderboard with a team
+// ranking or only on the given one when it is not empty
+func (t Teams) SyncTeam(ctx context.Context, teamID, leaderboardID string) error {
+	scores, err := t.repo.GetTeamScores(ctx, teamID, leaderboardID)
+	if err != nil {
+		return fmt.Errorf("failed to get scores of team '%s': %w", teamID, err)
+	}
+
+	// Keep going on failures so a single unavailable ranking does not leave the others stale
+	var syncErr error
+	for _, score := range scores {
+		leaderboard := models.Leaderboard{ID: score.LeaderboardID, TenantID: score.TenantID}
+		if score.Hidden {
+			err = t.redis.ZRem(ctx, leaderboard.TeamRankingKey(), teamID)
+		} else {
+			err = t.redis.ZAdd(ctx, leaderboard.TeamRankingKey(), teamID, score.Score)
+		}
+		if err != nil {
+			log.Printf("Failed to sync team ranking of leaderboard '%s' for team '%s': %v", score.LeaderboardID, teamID, err)
+			syncErr = fmt.Errorf("failed to sync rankings of team '%s': %w", teamID, err)
+		}
+	}
+
+	return syncErr
+}
+
+// Rebuilds the whole team ranking of the leaderboard, used when its team aggregate changes or
+// after bulk changes to its entries
+func (t Teams) SyncLeaderboard(ctx context.Context, leaderboardID string) error {
+	scores, err := t.repo.GetLeaderboardTeamScores(ctx, leaderboardID)
+	if err != nil {
+		return fmt.Errorf("failed to get team scores of leaderboard '%s': %w", leaderboardID, err)
+	}
+
+	members := make(map[string]float64, len(scores))
+	for _, score := range scores {
+		members[score.TeamID] = score.Score
+	}
+
+	leaderboard := models.Leaderboard{ID: leaderboardID, TenantID: models.TenantFromContext(ctx)}
+	if err := t.redis.ZReplace(ctx, leaderboard.TeamRankingKey(), members); err != nil {
+		return fmt.Errorf("failed to sync team ranking of leaderboard '%s': %w", leaderboardID, err)
+	}
+
+	return nil
+}
+
+// Syncs the team of the user on the leaderboard, users without a team have nothing to sync
+func (t Teams) SyncUser(ctx context.Context, userID, leaderboardID string) error {
+	team, err := t.repo.GetUserTeam(ctx, userID)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get team of user '%s': %w", userID, err)
+	}
+
+	return t.SyncTeam(ctx, team.ID, leaderboardID)
+}
+
+// Returns a page of the team ranking of the leaderboard, teams disbanded since they were ranked are skipped
+func (t Teams) Rank(ctx context.Context, leaderboard *models.Leaderboard, page *models.Pagination) ([]models.RankedTeam, error) {
+	scores, err := t.redis.ZRevRangeWithScores(
+		ctx,
+		leaderboard.TeamRankingKey(),
+		int64(page.Offset),
+		int64(page.Offset+page.Limit-1),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read team ranking of leaderboard '%s': %w", leaderboard.ID, err)
+	}
+	if len(scores) == 0 {
+		return []models.RankedTeam{}, nil
+	}
+
+	ids := make([]string, 0, len(scores))
+	for _, score := range scores {
+		ids = append(ids, score.Member)
+	}
+
+	teams, err := t.repo.GetByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ranked teams: %w", err)
+	}
+	byID := make(map[string]models.Team, len(teams))
+	for _, team := range teams {
+		byID[team.ID] = team
+	}
+
+	ranker, err := pageRanker(ctx, t.redis, leaderboard.TeamRankingKey(), leaderboard.RankMode, page, scores[0].Score)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank teams of leaderboard '%s': %w", leaderboard.ID, err)
+	}
+
+	// Disbanded teams still hold their place in Redis until the next sync, so they are numbered before skipping
+	ranked := make([]models.RankedTeam, 0, len(scores))
+	for _, score := range scores {
+		rank := ranker.Next(score.Score)
+		team, ok := byID[score.Member]
+		if !ok {
+			continue
+		}
+
+		ranked = append(ranked, models.RankedTeam{
+			Rank:  rank,
+			Team:  team,
+			Score: score.Score,
+		})
+	}
+
+	return ranked, nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/mochivi/go-real-time-leaderboards/internal/api/handlers"
 	"github.com/mochivi/go-real-time-leaderboards/internal/api/middlewares"
 	"github.com/mochivi/go-real-time-leaderboards/internal/auth"
+	"github.com/mochivi/go-real-time-leaderboards/internal/storage"
 	redis "github.com/mochivi/go-real-time-leaderboards/internal/storage/redis"
 )
 
@@ -17,10 +18,24 @@ type DependencyContainer struct {
 		Leaderboards handlers.LeaderboardController
 		Auth         handlers.AuthController
 		Users        handlers.UserController
+		Moderation   handlers.ModerationController
+		Audit        handlers.AuditController
+		Bans         handlers.BanController
+		Reports      handlers.ReportController
+		ChangeSets   handlers.ChangeSetController
+		Friends      handlers.FriendController
+		Teams        handlers.TeamController
+		Tournaments  handlers.TournamentController
+		Ratings      handlers.RatingController
+		Games        handlers.GameController
+		Tenants      handlers.TenantController
+		Members      handlers.MemberController
 	}
 	Services struct {
 		JWTService   auth.JWTService
 		RedisService redis.RedisService
+		TenantRepo   storage.TenantRepo // Resolves the tenant of every request
+		MemberRepo   storage.MemberRepo // Keeps private leaderboards to their members
 	}
 }
 
@@ -41,8 +56,12 @@ func NewServer(cfg config.ServerConfig, dependencies DependencyContainer) *Serve
 }
 
 func (s *Server) mount() {
+	// Every request is tagged with an id, used to correlate logs and audit log entries
+	s.Engine.Use(middlewares.RequestID())
+
 	apiGroup := s.Engine.Group("/api")
-	v1Group := apiGroup.Group("/v1")
+	// Requests are scoped to the tenant named by their API key, the default tenant without one
+	v1Group := apiGroup.Group("/v1", middlewares.ResolveTenant(s.dependencies.Services.TenantRepo))
 
 	// Auth endpoints
 	authGoup := v1Group.Group("/auth")
@@ -60,26 +79,135 @@ func (s *Server) mount() {
 	publicUsersGroup := v1Group.Group("/users")
 	{ // Viewing and registering users does not require authentication
 		publicUsersGroup.POST("/register", s.dependencies.Controllers.Users.Register)
-		publicUsersGroup.GET("/:id", s.dependencies.Controllers.Users.Get)
+		publicUsersGroup.GET(
+			"/:id",
+			middlewares.OptionalAuth(s.dependencies.Services.JWTService),
+			s.dependencies.Controllers.Users.Profile,
+		)
 	}
 	authUsersGroup := v1Group.Group("/users", middlewares.ValidateAuth(s.dependencies.Services.JWTService))
 	{ // Updating and deleting users requires authentication
 		authUsersGroup.PUT("/", s.dependencies.Controllers.Users.Update)
+		authUsersGroup.GET("/me/export", s.dependencies.Controllers.Users.Export)
+		authUsersGroup.POST("/me/erasure", s.dependencies.Controllers.Users.Erase)
 		authUsersGroup.DELETE("/:id", s.dependencies.Controllers.Users.Delete)
 	}
 
-	// Leaderboard endpoints
-	publicleaderboardsGroup := v1Group.Group("/leaderboards")
+	// Friend endpoints, always scoped to the signed in player
+	friendsGroup := v1Group.Group("/friends", middlewares.ValidateAuth(s.dependencies.Services.JWTService))
 	{
+		friendsGroup.GET("", s.dependencies.Controllers.Friends.List)
+		friendsGroup.DELETE("/:id", s.dependencies.Controllers.Friends.Remove)
+		friendsGroup.GET("/requests", s.dependencies.Controllers.Friends.ListRequests)
+		friendsGroup.POST("/requests", s.dependencies.Controllers.Friends.Request)
+		friendsGroup.POST("/requests/:id/accept", s.dependencies.Controllers.Friends.Accept)
+	}
+
+	// Team endpoints, members are managed by the team owner and officers
+	v1Group.GET("/teams/:id", s.dependencies.Controllers.Teams.Get)
+	teamsGroup := v1Group.Group("/teams", middlewares.ValidateAuth(s.dependencies.Services.JWTService))
+	{
+		teamsGroup.POST("", s.dependencies.Controllers.Teams.Create)
+		teamsGroup.POST("/:id/join", s.dependencies.Controllers.Teams.Join)
+		teamsGroup.POST("/:id/leave", s.dependencies.Controllers.Teams.Leave)
+		teamsGroup.PUT("/:id/members/:userId", s.dependencies.Controllers.Teams.SetRole)
+		teamsGroup.DELETE("/:id/members/:userId", s.dependencies.Controllers.Teams.RemoveMember)
+	}
+
+	// Games and their categories are public, they are declared from the administration endpoints
+	v1Group.GET("/games/:id", s.dependencies.Controllers.Games.Get)
+
+	// Tournament brackets are public but for private leaderboards, tournaments are run from the administration endpoints
+	v1Group.GET(
+		"/tournaments/:id",
+		middlewares.OptionalAuth(s.dependencies.Services.JWTService),
+		s.dependencies.Controllers.Tournaments.Get,
+	)
+
+	// Leaderboard endpoints
+	publicleaderboardsGroup := v1Group.Group(
+		"/leaderboards",
+		middlewares.OptionalAuth(s.dependencies.Services.JWTService),
+		middlewares.ValidateLeaderboardAccess(s.dependencies.Services.MemberRepo),
+	)
+	{ // Signed in users also see their own entries while shadowbanned, private leaderboards are shown to members only
+		publicleaderboardsGroup.GET("", s.dependencies.Controllers.Leaderboards.List)
 		publicleaderboardsGroup.GET("/:id", s.dependencies.Controllers.Leaderboards.Get)
 		publicleaderboardsGroup.GET("/entries/:id", s.dependencies.Controllers.Leaderboards.GetEntries)
+		publicleaderboardsGroup.GET("/:id/ranking", s.dependencies.Controllers.Leaderboards.GetRanking)
+		publicleaderboardsGroup.GET("/:id/ranking/around-me", s.dependencies.Controllers.Leaderboards.GetRankingAroundMe)
+		publicleaderboardsGroup.GET("/:id/teams", s.dependencies.Controllers.Leaderboards.GetTeamRanking)
+		publicleaderboardsGroup.GET("/:id/stats", s.dependencies.Controllers.Leaderboards.GetStats)
+		publicleaderboardsGroup.GET("/:id/percentiles", s.dependencies.Controllers.Leaderboards.GetScorePercentile)
+		publicleaderboardsGroup.GET("/:id/percentiles/:userId", s.dependencies.Controllers.Leaderboards.GetUserPercentile)
+		publicleaderboardsGroup.GET("/:id/ratings", s.dependencies.Controllers.Ratings.GetRatings)
+		publicleaderboardsGroup.GET("/:id/ratings/:userId/history", s.dependencies.Controllers.Ratings.GetHistory)
 	}
-	adminleaderboardsGroup := v1Group.Group("/leaderboards")
+	authLeaderboardsGroup := v1Group.Group("/leaderboards", middlewares.ValidateAuth(s.dependencies.Services.JWTService))
 	{
+		authLeaderboardsGroup.POST("/entries", s.dependencies.Controllers.Leaderboards.CreateEntry)
+		authLeaderboardsGroup.PUT("/entries/:id", s.dependencies.Controllers.Leaderboards.UpdateEntry)
+		authLeaderboardsGroup.POST("/entries/:id/reports", s.dependencies.Controllers.Reports.Create)
+		authLeaderboardsGroup.POST("/join", s.dependencies.Controllers.Members.Join)
+	}
+	adminleaderboardsGroup := v1Group.Group(
+		"/leaderboards",
+		middlewares.ValidateAuth(s.dependencies.Services.JWTService),
+		middlewares.ValidateTenantAdmin(s.dependencies.Services.TenantRepo),
+	)
+	{ // Changes made by administrators are recorded in the audit log, tenant admins manage the leaderboards of their tenant
 		adminleaderboardsGroup.POST("/", s.dependencies.Controllers.Leaderboards.Create)
-		adminleaderboardsGroup.POST("/entries", s.dependencies.Controllers.Leaderboards.CreateEntry)
 		adminleaderboardsGroup.PUT("/", s.dependencies.Controllers.Leaderboards.Update)
 		adminleaderboardsGroup.DELETE("/:id", s.dependencies.Controllers.Leaderboards.Delete)
+		adminleaderboardsGroup.POST("/:id/invites", s.dependencies.Controllers.Members.CreateInvite)
+		adminleaderboardsGroup.PUT("/:id/members/:userId", s.dependencies.Controllers.Members.AddMember)
+		adminleaderboardsGroup.DELETE("/:id/members/:userId", s.dependencies.Controllers.Members.RemoveMember)
+	}
+
+	// Moderation endpoints, entries waiting for verification or flagged as anomalous
+	moderationGroup := v1Group.Group(
+		"/moderation",
+		middlewares.ValidateAuth(s.dependencies.Services.JWTService),
+		middlewares.ValidateRole("administrator", "moderator"),
+	)
+	{
+		moderationGroup.GET("/queue", s.dependencies.Controllers.Moderation.GetQueue)
+		moderationGroup.POST("/entries/:id/approve", s.dependencies.Controllers.Moderation.ApproveEntry)
+		moderationGroup.POST("/entries/:id/reject", s.dependencies.Controllers.Moderation.RejectEntry)
+		moderationGroup.GET("/reports", s.dependencies.Controllers.Reports.List)
+		moderationGroup.GET("/entries/:id/reports", s.dependencies.Controllers.Reports.ListEntryReports)
+	}
+
+	// Administration endpoints
+	adminGroup := v1Group.Group(
+		"/admin",
+		middlewares.ValidateAuth(s.dependencies.Services.JWTService),
+		middlewares.ValidateAdmin(),
+	)
+	{
+		adminGroup.GET("/audit-log", s.dependencies.Controllers.Audit.List)
+		adminGroup.GET("/bans", s.dependencies.Controllers.Bans.List)
+		adminGroup.POST("/users/:id/ban", s.dependencies.Controllers.Bans.Ban)
+		adminGroup.DELETE("/users/:id/ban", s.dependencies.Controllers.Bans.Unban)
+		adminGroup.POST("/leaderboards/:id/entries/bulk", s.dependencies.Controllers.ChangeSets.Apply)
+		adminGroup.GET("/change-sets", s.dependencies.Controllers.ChangeSets.List)
+		adminGroup.POST("/change-sets/:id/revert", s.dependencies.Controllers.ChangeSets.Revert)
+		adminGroup.POST("/leaderboards/:id/restore", s.dependencies.Controllers.Leaderboards.Restore)
+		adminGroup.GET("/users/:id", s.dependencies.Controllers.Users.Get)
+		adminGroup.POST("/users/:id/restore", s.dependencies.Controllers.Users.Restore)
+		adminGroup.POST("/users/:id/erasure", s.dependencies.Controllers.Users.Erase)
+		adminGroup.POST("/games", s.dependencies.Controllers.Games.Create)
+		adminGroup.POST("/games/:id/categories", s.dependencies.Controllers.Games.CreateCategory)
+		adminGroup.POST("/categories/:id/variables", s.dependencies.Controllers.Games.CreateVariable)
+		adminGroup.POST("/tournaments", s.dependencies.Controllers.Tournaments.Create)
+		adminGroup.POST("/tournaments/:id/start", s.dependencies.Controllers.Tournaments.Start)
+		adminGroup.POST("/tournaments/:id/matches/:number/result", s.dependencies.Controllers.Tournaments.RecordResult)
+		adminGroup.POST("/leaderboards/:id/matches", s.dependencies.Controllers.Ratings.RecordMatch)
+		adminGroup.POST("/tenants", s.dependencies.Controllers.Tenants.Create)
+		adminGroup.GET("/tenants/:id", s.dependencies.Controllers.Tenants.Get)
+		adminGroup.PUT("/tenants/:id/members/:userId", s.dependencies.Controllers.Tenants.SetMember)
+		adminGroup.POST("/tenants/:id/api-keys", s.dependencies.Controllers.Tenants.CreateAPIKey)
+		adminGroup.DELETE("/tenants/:id/api-keys/:keyId", s.dependencies.Controllers.Tenants.RevokeAPIKey)
 	}
 
 	s.Engine.GET("/", func(c *gin.Context) {
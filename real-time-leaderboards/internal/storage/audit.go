@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+)
+
+// Audit entries are written by the other repositories inside the transaction of the change they record
+// This repository only reads them back
+type AuditRepo interface {
+	GetAuditLog(context.Context, *models.AuditLogFilter) ([]models.AuditEntry, error)
+}
+
+type AuditRepoPG struct {
+	db *sql.DB
+}
+
+func NewAuditRepoPG(db *sql.DB) *AuditRepoPG {
+	return &AuditRepoPG{
+		db: db,
+	}
+}
+
+func (ar *AuditRepoPG) GetAuditLog(ctx context.Context, filter *models.AuditLogFilter) ([]models.AuditEntry, error) {
+	stmt, err := ar.db.PrepareContext(ctx, `
+		SELECT
+			id
+			,actor_id
+			,actor_role
+			,action
+			,target_type
+			,target_id
+			,before
+			,after
+			,COALESCE(request_id, '')
+			,created_at
+		FROM audit_log
+		WHERE ($1 = '' OR actor_id::TEXT = $1)
+			AND ($2 = '' OR action = $2)
+			AND ($3 = '' OR target_type = $3)
+			AND ($4 = '' OR target_id = $4)
+			AND ($5::TIMESTAMPTZ IS NULL OR created_at >= $5)
+			AND ($6::TIMESTAMPTZ IS NULL OR created_at < $6)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $7 OFFSET $8`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare audit log statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := stmt.QueryContext(
+		ctx,
+		filter.ActorID,
+		filter.Action,
+		filter.TargetType,
+		filter.TargetID,
+		sql.NullTime{Time: filter.From, Valid: !filter.From.IsZero()},
+		sql.NullTime{Time: filter.To, Valid: !filter.To.IsZero()},
+		filter.Limit,
+		filter.Offset,
+	)
+	if err != nil {
+		log.Printf("Failed to query audit log: %v", err)
+		return nil, fmt.Errorf("failed to get audit log: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	entries := make([]models.AuditEntry, 0)
+	for rows.Next() {
+		var entry models.AuditEntry
+		var before, after []byte
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.ActorID,
+			&entry.ActorRole,
+			&entry.Action,
+			&entry.TargetType,
+			&entry.TargetID,
+			&before,
+			&after,
+			&entry.RequestID,
+			&entry.CreatedAt,
+		); err != nil {
+			log.Printf("Failed to scan audit log entry: %v", err)
+			return nil, fmt.Errorf("failed to scan audit log entry: %w", err)
+		}
+		entry.Before = before
+		entry.After = after
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan audit log: %v", err)
+		return nil, fmt.Errorf("failed to scan audit log: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Appends an entry to the audit log, must run in the same transaction as the audited change
+// so a change is never committed without its record, before and after are nil when they do not apply
+func writeAudit(
+	ctx context.Context,
+	tx *sql.Tx,
+	actor *models.AuditActor,
+	action, targetType, targetID string,
+	before, after any,
+) error {
+	beforeJSON, err := auditSnapshot(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := auditSnapshot(after)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO audit_log (actor_id, actor_role, action, target_type, target_id, before, after, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''))`,
+		actor.UserID,
+		actor.Role,
+		action,
+		targetType,
+		targetID,
+		beforeJSON,
+		afterJSON,
+		actor.RequestID,
+	); err != nil {
+		log.Printf("Failed to write audit log entry: %v", err)
+		return fmt.Errorf("failed to write audit log entry: %w", translateError(err))
+	}
+
+	return nil
+}
+
+// Encodes the state of a target for the audit log, nil values, including nil pointers, are stored as NULL
+func auditSnapshot(state any) ([]byte, error) {
+	if state == nil {
+		return nil, nil
+	}
+
+	snapshot, err := json.Marshal(state)
+	if err != nil {
+		log.Printf("Failed to encode audit snapshot: %v", err)
+		return nil, fmt.Errorf("failed to encode audit snapshot: %w", err)
+	}
+	if string(snapshot) == "null" {
+		return nil, nil
+	}
+
+	return snapshot, nil
+}
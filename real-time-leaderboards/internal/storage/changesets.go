@@ -0,0 +1,334 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+)
+
+// Bulk operations on leaderboard entries, each one is recorded as a change set that can be reverted
+type ChangeSetRepo interface {
+	Apply(context.Context, *models.BulkEntryRequest, *models.AuditActor) (*models.EntryChangeSet, error)
+	Revert(context.Context, string, *models.AuditActor) (*models.EntryChangeSet, error)
+	GetChangeSets(context.Context, *models.ChangeSetFilter) ([]models.EntryChangeSet, error)
+}
+
+type ChangeSetRepoPG struct {
+	db *sql.DB
+}
+
+func NewChangeSetRepoPG(db *sql.DB) *ChangeSetRepoPG {
+	return &ChangeSetRepoPG{
+		db: db,
+	}
+}
+
+// Entries selected by a bulk operation, the arguments are built by bulkFilterArgs
+const bulkEntryFilter = `
+	e.leaderboard_id = $1
+	AND e.deleted_at IS NULL
+	AND ($2 = '' OR e.user_id::TEXT = $2)
+	AND ($3::TIMESTAMPTZ IS NULL OR e.created_at >= $3)
+	AND ($4::TIMESTAMPTZ IS NULL OR e.created_at < $4)
+	AND ($5::INT IS NULL OR e.score >= $5)
+	AND ($6::INT IS NULL OR e.score <= $6)
+	AND (CARDINALITY($7::TEXT[]) = 0 OR e.id::TEXT = ANY($7))`
+
+// The whole row of every selected entry is saved in the change set before it is deleted or rescored
+const (
+	bulkDeleteEntries = `
+		WITH targeted AS (
+			DELETE FROM leaderboard_entries e
+			WHERE ` + bulkEntryFilter + `
+			RETURNING e.*
+		)
+		INSERT INTO entry_change_set_items (change_set_id, entry_id, before)
+		SELECT $8, targeted.id, to_jsonb(targeted)
+		FROM targeted`
+
+	bulkRescoreEntries = `
+		WITH targeted AS (
+			SELECT e.*
+			FROM leaderboard_entries e
+			WHERE ` + bulkEntryFilter + `
+			FOR UPDATE
+		), snapshot AS (
+			INSERT INTO entry_change_set_items (change_set_id, entry_id, before)
+			SELECT $8, targeted.id, to_jsonb(targeted)
+			FROM targeted
+		)
+		UPDATE leaderboard_entries e
+		SET
+			score = COALESCE($9::INT, ROUND(e.score * COALESCE($10::DOUBLE PRECISION, 1))::INT + $11),
+			updated_at = CURRENT_TIMESTAMP
+		FROM targeted
+		WHERE e.id = targeted.id`
+)
+
+func bulkFilterArgs(request *models.BulkEntryRequest) []any {
+	filter := request.Filter
+	entryIDs := filter.EntryIDs
+	if entryIDs == nil {
+		entryIDs = []string{}
+	}
+
+	return []any{
+		request.LeaderboardID,
+		filter.UserID,
+		filter.From,
+		filter.To,
+		filter.MinScore,
+		filter.MaxScore,
+		pq.Array(entryIDs),
+	}
+}
+
+// Deletes or rescores the matching entries in a single transaction, together with their snapshot,
+// the rebuilt score distribution and the audit log entry
+func (cr *ChangeSetRepoPG) Apply(
+	ctx context.Context,
+	request *models.BulkEntryRequest,
+	actor *models.AuditActor,
+) (*models.EntryChangeSet, error) {
+
+	requestJSON, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bulk entry request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second) // Bulk operations can touch many entries
+	defer cancel()
+
+	changeSet := models.EntryChangeSet{
+		LeaderboardID: request.LeaderboardID,
+		Operation:     request.Operation,
+		Request:       *request,
+		CreatedBy:     actor.UserID,
+	}
+	err = withTx(ctx, cr.db, func(tx *sql.Tx) error {
+		// Serializes bulk operations on the leaderboard, and reports a missing leaderboard as not found
+		if _, err := lockLeaderboard(ctx, tx, request.LeaderboardID); err != nil {
+			return err
+		}
+
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO entry_change_sets (leaderboard_id, operation, request, created_by)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, created_at`,
+			request.LeaderboardID,
+			request.Operation,
+			requestJSON,
+			actor.UserID,
+		).Scan(&changeSet.ID, &changeSet.CreatedAt); err != nil {
+			log.Printf("Failed to insert entry change set: %v", err)
+			return fmt.Errorf("failed to create entry change set: %w", translateError(err))
+		}
+
+		query, action := bulkDeleteEntries, models.AuditActionEntriesDelete
+		args := append(bulkFilterArgs(request), changeSet.ID)
+		if request.Operation == models.ChangeSetOperationRescore {
+			query, action = bulkRescoreEntries, models.AuditActionEntriesRescore
+			args = append(args, request.Set, request.Multiply, request.Add)
+		}
+
+		result, err := tx.ExecContext(ctx, query, args...)
+		if err != nil {
+			log.Printf("Failed to %s leaderboard entries: %v", request.Operation, err)
+			return fmt.Errorf("failed to %s leaderboard entries: %w", request.Operation, translateError(err))
+		}
+		affected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to count changed leaderboard entries: %w", err)
+		}
+		if affected == 0 {
+			return fmt.Errorf("no leaderboard entries match the filter: %w", ErrNotFound)
+		}
+		changeSet.Entries = int(affected)
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE entry_change_sets SET entries = $2 WHERE id = $1`,
+			changeSet.ID,
+			changeSet.Entries,
+		); err != nil {
+			log.Printf("Failed to update entry change set: %v", err)
+			return fmt.Errorf("failed to update entry change set: %w", translateError(err))
+		}
+
+		if err := rebuildScoreStats(ctx, tx, request.LeaderboardID); err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, actor, action, models.AuditTargetChangeSet, changeSet.ID, nil, changeSet)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &changeSet, nil
+}
+
+// Puts the entries back as they were before the change set, a change set can only be reverted once
+// Deleted entries are restored with their original ids, unless their user was deleted since
+// Reports on deleted entries are not restored
+func (cr *ChangeSetRepoPG) Revert(
+	ctx context.Context,
+	changeSetID string,
+	actor *models.AuditActor,
+) (*models.EntryChangeSet, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var previous, reverted models.EntryChangeSet
+	err := withTx(ctx, cr.db, func(tx *sql.Tx) error {
+		row := tx.QueryRowContext(ctx, `
+			SELECT `+changeSetColumns+`
+			FROM entry_change_sets
+			WHERE id = $1
+			FOR UPDATE`,
+			changeSetID,
+		)
+		if err := scanChangeSet(row, &previous); err != nil {
+			log.Printf("Failed to lock entry change set: %v", err)
+			return fmt.Errorf("failed to revert entry change set: %w", translateError(err))
+		}
+		if previous.RevertedAt != nil {
+			return fmt.Errorf("failed to revert entry change set reverted at %s: %w", previous.RevertedAt, ErrStateConflict)
+		}
+
+		if _, err := lockLeaderboard(ctx, tx, previous.LeaderboardID); err != nil {
+			return err
+		}
+
+		query := `
+			UPDATE leaderboard_entries e
+			SET
+				score = (i.before->>'score')::INT,
+				updated_at = CURRENT_TIMESTAMP
+			FROM entry_change_set_items i
+			WHERE i.change_set_id = $1
+				AND e.id = i.entry_id`
+		if previous.Operation == models.ChangeSetOperationDelete {
+			query = `
+				INSERT INTO leaderboard_entries
+				SELECT (jsonb_populate_record(NULL::leaderboard_entries, i.before)).*
+				FROM entry_change_set_items i
+				WHERE i.change_set_id = $1
+					AND EXISTS (
+						SELECT 1 FROM users u WHERE u.id = (i.before->>'user_id')::BIGINT AND u.deleted_at IS NULL
+					)
+				ON CONFLICT (id) DO NOTHING`
+		}
+		if _, err := tx.ExecContext(ctx, query, changeSetID); err != nil {
+			log.Printf("Failed to revert leaderboard entries: %v", err)
+			return fmt.Errorf("failed to revert leaderboard entries: %w", translateError(err))
+		}
+
+		reverted = previous
+		reverted.RevertedBy = actor.UserID
+		if err := tx.QueryRowContext(ctx, `
+			UPDATE entry_change_sets
+			SET reverted_by = $2, reverted_at = CURRENT_TIMESTAMP
+			WHERE id = $1
+			RETURNING reverted_at`,
+			changeSetID,
+			actor.UserID,
+		).Scan(&reverted.RevertedAt); err != nil {
+			log.Printf("Failed to update entry change set: %v", err)
+			return fmt.Errorf("failed to update entry change set: %w", translateError(err))
+		}
+
+		if err := rebuildScoreStats(ctx, tx, previous.LeaderboardID); err != nil {
+			return err
+		}
+
+		return writeAudit(
+			ctx, tx, actor,
+			models.AuditActionEntriesRevert, models.AuditTargetChangeSet, changeSetID,
+			previous, reverted,
+		)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &reverted, nil
+}
+
+// Lists the change sets newest first
+func (cr *ChangeSetRepoPG) GetChangeSets(ctx context.Context, filter *models.ChangeSetFilter) ([]models.EntryChangeSet, error) {
+	stmt, err := cr.db.PrepareContext(ctx, `
+		SELECT `+changeSetColumns+`
+		FROM entry_change_sets
+		WHERE ($1 = '' OR leaderboard_id::TEXT = $1)
+			AND `+inTenant("leaderboard_id", "$4")+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare entry change sets statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := stmt.QueryContext(ctx, filter.LeaderboardID, filter.Limit, filter.Offset, models.TenantFromContext(ctx))
+	if err != nil {
+		log.Printf("Failed to query entry change sets: %v", err)
+		return nil, fmt.Errorf("failed to get entry change sets: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	changeSets := make([]models.EntryChangeSet, 0)
+	for rows.Next() {
+		var changeSet models.EntryChangeSet
+		if err := scanChangeSet(rows, &changeSet); err != nil {
+			log.Printf("Failed to scan entry change set: %v", err)
+			return nil, fmt.Errorf("failed to scan entry change set: %w", err)
+		}
+		changeSets = append(changeSets, changeSet)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan entry change sets: %v", err)
+		return nil, fmt.Errorf("failed to scan entry change sets: %w", err)
+	}
+
+	return changeSets, nil
+}
+
+const changeSetColumns = `
+	id, leaderboard_id, operation, request, entries, COALESCE(created_by::TEXT, ''), created_at,
+	COALESCE(reverted_by::TEXT, ''), reverted_at`
+
+func scanChangeSet(row rowScanner, changeSet *models.EntryChangeSet) error {
+	var request []byte
+	if err := row.Scan(
+		&changeSet.ID,
+		&changeSet.LeaderboardID,
+		&changeSet.Operation,
+		&request,
+		&changeSet.Entries,
+		&changeSet.CreatedBy,
+		&changeSet.CreatedAt,
+		&changeSet.RevertedBy,
+		&changeSet.RevertedAt,
+	); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(request, &changeSet.Request); err != nil {
+		return fmt.Errorf("failed to decode bulk entry request: %w", err)
+	}
+	changeSet.Request.LeaderboardID = changeSet.LeaderboardID
+
+	return nil
+}
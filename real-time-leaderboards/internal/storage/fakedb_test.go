@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Statement run against a fakeDB, with the arguments it was given
+type fakeStatement struct {
+	query string
+	args  []driver.Value
+}
+
+// Answers the statements whose query contains the text with the rows, statements without a stub return no rows
+type fakeStub struct {
+	contains string
+	columns  []string
+	rows     [][]any
+	affected int64
+}
+
+// Database recording the statements run by the repositories, queries are not evaluated
+type fakeDB struct {
+	mu       sync.Mutex
+	stubs    []fakeStub
+	executed []fakeStatement
+}
+
+func newFakeDB(t *testing.T, stubs ...fakeStub) (*sql.DB, *fakeDB) {
+	fake := &fakeDB{stubs: stubs}
+	db := sql.OpenDB(fake)
+	t.Cleanup(func() { db.Close() })
+	return db, fake
+}
+
+// Statements whose query contains the text, in the order they were run
+func (f *fakeDB) statements(contains string) []fakeStatement {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	statements := make([]fakeStatement, 0)
+	for _, statement := range f.executed {
+		if strings.Contains(statement.query, contains) {
+			statements = append(statements, statement)
+		}
+	}
+	return statements
+}
+
+func (f *fakeDB) run(query string, args []driver.Value) fakeStub {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.executed = append(f.executed, fakeStatement{query: query, args: args})
+	for _, stub := range f.stubs {
+		if strings.Contains(query, stub.contains) {
+			return stub
+		}
+	}
+	return fakeStub{}
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return fakeDriver{f} }
+
+type fakeDriver struct{ db *fakeDB }
+
+func (d fakeDriver) Open(string) (driver.Conn, error) { return fakeConn{d.db}, nil }
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.db, query}, nil }
+func (c fakeConn) Close() error                              { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{}, nil }
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (s fakeStmt) Close() error  { return nil }
+func (s fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(s.db.run(s.query, args).affected), nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	stub := s.db.run(s.query, args)
+	return &fakeRows{columns: stub.columns, rows: stub.rows}, nil
+}
+
+type fakeRows struct {
+	columns []string
+	rows    [][]any
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	for i, value := range r.rows[0] {
+		dest[i] = value
+	}
+	r.rows = r.rows[1:]
+	return nil
+}
@@ -0,0 +1,287 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+)
+
+// Mutual friendships between players, used to rank leaderboards among friends
+type FriendRepo interface {
+	Request(context.Context, string, string) (*models.Friendship, error)
+	Accept(context.Context, string, string) (*models.Friendship, error)
+	Remove(context.Context, string, string) error
+	GetFriends(context.Context, string, *models.Pagination) ([]models.Friendship, error)
+	GetRequests(context.Context, string, *models.Pagination) ([]models.Friendship, error)
+	GetCircle(context.Context, string) ([]models.User, error)
+}
+
+type FriendRepoPG struct {
+	db *sql.DB
+}
+
+func NewFriendRepoPG(db *sql.DB) *FriendRepoPG {
+	return &FriendRepoPG{
+		db: db,
+	}
+}
+
+// Sends a friend request, or accepts the pending request the other user already sent
+// Returns ErrConflict if the users are already friends or a request is pending
+func (fr *FriendRepoPG) Request(ctx context.Context, requesterID, addresseeID string) (*models.Friendship, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	friendship := models.Friendship{User: models.User{ID: addresseeID}}
+	err := withTx(ctx, fr.db, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(
+			ctx,
+			`SELECT username FROM users WHERE id = $1 AND deleted_at IS NULL`,
+			addresseeID,
+		).Scan(&friendship.User.Username); err != nil {
+			log.Printf("Failed to get user '%s': %v", addresseeID, err)
+			return fmt.Errorf("failed to get user '%s': %w", addresseeID, translateError(err))
+		}
+
+		var acceptedAt sql.NullTime
+		err := tx.QueryRowContext(ctx, `
+			UPDATE friendships
+			SET status = 'accepted', accepted_at = CURRENT_TIMESTAMP
+			WHERE requester_id = $1 AND addressee_id = $2 AND status = 'pending'
+			RETURNING status, created_at, accepted_at`,
+			addresseeID,
+			requesterID,
+		).Scan(&friendship.Status, &friendship.CreatedAt, &acceptedAt)
+		if err == nil {
+			friendship.AcceptedAt = &acceptedAt.Time
+			return nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			log.Printf("Failed to accept pending friend request: %v", err)
+			return fmt.Errorf("failed to accept pending friend request: %w", translateError(err))
+		}
+
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO friendships (requester_id, addressee_id)
+			VALUES ($1, $2)
+			RETURNING status, created_at`,
+			requesterID,
+			addresseeID,
+		).Scan(&friendship.Status, &friendship.CreatedAt); err != nil {
+			log.Printf("Failed to insert friend request: %v", err)
+			return fmt.Errorf("failed to create friend request: %w", translateError(err))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &friendship, nil
+}
+
+// Accepts the pending request the requester sent to the user
+func (fr *FriendRepoPG) Accept(ctx context.Context, userID, requesterID string) (*models.Friendship, error) {
+	stmt, err := fr.db.PrepareContext(ctx, `
+		UPDATE friendships f
+		SET status = 'accepted', accepted_at = CURRENT_TIMESTAMP
+		FROM users u
+		WHERE f.requester_id = u.id
+			AND f.requester_id = $1
+			AND f.addressee_id = $2
+			AND f.status = 'pending'
+			AND u.deleted_at IS NULL
+		RETURNING u.id, u.username, f.status, f.created_at, f.accepted_at`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare accept friend request statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var friendship models.Friendship
+	if err := stmt.QueryRowContext(ctx, requesterID, userID).Scan(
+		&friendship.User.ID,
+		&friendship.User.Username,
+		&friendship.Status,
+		&friendship.CreatedAt,
+		&friendship.AcceptedAt,
+	); err != nil {
+		log.Printf("Failed to accept friend request of user '%s': %v", requesterID, err)
+		return nil, fmt.Errorf("failed to accept friend request of user '%s': %w", requesterID, translateError(err))
+	}
+
+	return &friendship, nil
+}
+
+// Ends a friendship, declines a request received from the other user or cancels one sent to them
+func (fr *FriendRepoPG) Remove(ctx context.Context, userID, otherID string) error {
+	stmt, err := fr.db.PrepareContext(ctx, `
+		DELETE FROM friendships
+		WHERE (requester_id = $1 AND addressee_id = $2) OR (requester_id = $2 AND addressee_id = $1)`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare remove friend statement: %v", err)
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := stmt.ExecContext(ctx, userID, otherID)
+	if err != nil {
+		log.Printf("Failed to remove friendship with user '%s': %v", otherID, err)
+		return fmt.Errorf("failed to remove friendship with user '%s': %w", otherID, translateError(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("Failed to remove friendship with user '%s': %v", otherID, err)
+		return fmt.Errorf("failed to remove friendship with user '%s': %w", otherID, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("failed to remove friendship with user '%s': %w", otherID, ErrNotFound)
+	}
+
+	return nil
+}
+
+// Lists the friends of the user, the most recent first
+func (fr *FriendRepoPG) GetFriends(ctx context.Context, userID string, page *models.Pagination) ([]models.Friendship, error) {
+	return fr.queryFriendships(ctx, `
+		SELECT u.id, u.username, f.status, f.created_at, f.accepted_at
+		FROM friendships f
+		JOIN users u
+			ON u.id = CASE WHEN f.requester_id = $1 THEN f.addressee_id ELSE f.requester_id END
+		WHERE (f.requester_id = $1 OR f.addressee_id = $1)
+			AND f.status = 'accepted'
+			AND u.deleted_at IS NULL
+		ORDER BY f.accepted_at DESC, u.id DESC
+		LIMIT $2 OFFSET $3`,
+		userID,
+		page,
+	)
+}
+
+// Lists the pending requests received by the user, the oldest first
+func (fr *FriendRepoPG) GetRequests(ctx context.Context, userID string, page *models.Pagination) ([]models.Friendship, error) {
+	return fr.queryFriendships(ctx, `
+		SELECT u.id, u.username, f.status, f.created_at, f.accepted_at
+		FROM friendships f
+		JOIN users u
+			ON f.requester_id = u.id
+		WHERE f.addressee_id = $1
+			AND f.status = 'pending'
+			AND u.deleted_at IS NULL
+		ORDER BY f.created_at ASC, u.id ASC
+		LIMIT $2 OFFSET $3`,
+		userID,
+		page,
+	)
+}
+
+func (fr *FriendRepoPG) queryFriendships(
+	ctx context.Context,
+	query, userID string,
+	page *models.Pagination,
+) ([]models.Friendship, error) {
+	stmt, err := fr.db.PrepareContext(ctx, query)
+	if err != nil {
+		log.Printf("Failed to prepare friendships statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := stmt.QueryContext(ctx, userID, page.Limit, page.Offset)
+	if err != nil {
+		log.Printf("Failed to query friendships: %v", err)
+		return nil, fmt.Errorf("failed to get friendships: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	friendships := make([]models.Friendship, 0)
+	for rows.Next() {
+		var friendship models.Friendship
+		if err := rows.Scan(
+			&friendship.User.ID,
+			&friendship.User.Username,
+			&friendship.Status,
+			&friendship.CreatedAt,
+			&friendship.AcceptedAt,
+		); err != nil {
+			log.Printf("Failed to scan friendship: %v", err)
+			return nil, fmt.Errorf("failed to scan friendship: %w", err)
+		}
+		friendships = append(friendships, friendship)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan friendships: %v", err)
+		return nil, fmt.Errorf("failed to scan friendships: %w", err)
+	}
+
+	return friendships, nil
+}
+
+// Returns the user together with all of their friends, the members of their circle
+func (fr *FriendRepoPG) GetCircle(ctx context.Context, userID string) ([]models.User, error) {
+	stmt, err := fr.db.PrepareContext(ctx, `
+		SELECT u.id, u.username
+		FROM users u
+		WHERE u.deleted_at IS NULL
+			AND (
+				u.id = $1
+				OR u.id IN (
+					SELECT CASE WHEN requester_id = $1 THEN addressee_id ELSE requester_id END
+					FROM friendships
+					WHERE (requester_id = $1 OR addressee_id = $1) AND status = 'accepted'
+				)
+			)`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare circle statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := stmt.QueryContext(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to query circle of user '%s': %v", userID, err)
+		return nil, fmt.Errorf("failed to get circle of user '%s': %w", userID, translateError(err))
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0)
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Username); err != nil {
+			log.Printf("Failed to scan circle member: %v", err)
+			return nil, fmt.Errorf("failed to scan circle member: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan circle members: %v", err)
+		return nil, fmt.Errorf("failed to scan circle members: %w", err)
+	}
+
+	return users, nil
+}
@@ -0,0 +1,299 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+)
+
+// Games, their categories and the variables splitting a category into variants
+// Categories are ranked by the leaderboard created with their category_id
+type GameRepo interface {
+	Create(context.Context, *models.GameRequest) (*models.Game, error)
+	Get(context.Context, string) (*models.Game, error)
+	CreateCategory(context.Context, *models.CategoryRequest) (*models.Category, error)
+	CreateVariable(context.Context, *models.VariableRequest) (*models.Variable, error)
+}
+
+type GameRepoPG struct {
+	db *sql.DB
+}
+
+func NewGameRepoPG(db *sql.DB) *GameRepoPG {
+	return &GameRepoPG{
+		db: db,
+	}
+}
+
+// Creates a game, returns ErrConflict if a game with the same name exists
+func (gr *GameRepoPG) Create(ctx context.Context, request *models.GameRequest) (*models.Game, error) {
+	stmt, err := gr.db.PrepareContext(ctx, `
+		INSERT INTO games (name)
+		VALUES ($1)
+		RETURNING id, name, created_at, updated_at`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare insert game statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	game := models.Game{Categories: make([]models.Category, 0)}
+	if err := stmt.QueryRowContext(ctx, request.Name).Scan(
+		&game.ID,
+		&game.Name,
+		&game.CreatedAt,
+		&game.UpdatedAt,
+	); err != nil {
+		log.Printf("Failed to insert game: %v", err)
+		return nil, fmt.Errorf("failed to create game: %w", translateError(err))
+	}
+
+	return &game, nil
+}
+
+// Returns the game with its categories, their variables and the leaderboards ranking them
+func (gr *GameRepoPG) Get(ctx context.Context, gameID string) (*models.Game, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var game models.Game
+	if err := gr.db.QueryRowContext(
+		ctx,
+		`SELECT id, name, created_at, updated_at FROM games WHERE id = $1`,
+		gameID,
+	).Scan(
+		&game.ID,
+		&game.Name,
+		&game.CreatedAt,
+		&game.UpdatedAt,
+	); err != nil {
+		log.Printf("Failed to get game '%s': %v", gameID, err)
+		return nil, fmt.Errorf("failed to get game '%s': %w", gameID, translateError(err))
+	}
+
+	categories, err := getCategories(ctx, gr.db, gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	variables, err := getGameVariables(ctx, gr.db, gameID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range categories {
+		categories[i].Variables = make([]models.Variable, 0)
+		for _, variable := range variables {
+			if variable.CategoryID == categories[i].ID {
+				categories[i].Variables = append(categories[i].Variables, variable)
+			}
+		}
+	}
+	game.Categories = categories
+
+	return &game, nil
+}
+
+// Adds a category to the game, returns ErrNotFound if the game does not exist
+// and ErrConflict if the game already has a category with the same name
+func (gr *GameRepoPG) CreateCategory(ctx context.Context, request *models.CategoryRequest) (*models.Category, error) {
+	stmt, err := gr.db.PrepareContext(ctx, `
+		INSERT INTO game_categories (game_id, name)
+		SELECT g.id, $2
+		FROM games g
+		WHERE g.id = $1
+		RETURNING id, game_id, name, created_at`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare insert category statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	category := models.Category{Variables: make([]models.Variable, 0)}
+	if err := stmt.QueryRowContext(ctx, request.GameID, request.Name).Scan(
+		&category.ID,
+		&category.GameID,
+		&category.Name,
+		&category.CreatedAt,
+	); err != nil {
+		log.Printf("Failed to insert category: %v", err)
+		return nil, fmt.Errorf("failed to create category of game '%s': %w", request.GameID, translateError(err))
+	}
+
+	return &category, nil
+}
+
+// Adds a variable to the category, returns ErrNotFound if the category does not exist
+// and ErrConflict if the category already has a variable with the same name
+// Entries submitted before the variable was added are left without a value for it
+func (gr *GameRepoPG) CreateVariable(ctx context.Context, request *models.VariableRequest) (*models.Variable, error) {
+	stmt, err := gr.db.PrepareContext(ctx, `
+		INSERT INTO category_variables (category_id, name, allowed_values, required)
+		SELECT c.id, $2, $3, $4
+		FROM game_categories c
+		WHERE c.id = $1
+		RETURNING `+variableColumns,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare insert variable statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var variable models.Variable
+	if err := scanVariable(
+		stmt.QueryRowContext(ctx, request.CategoryID, request.Name, pq.StringArray(request.Values), request.Required),
+		&variable,
+	); err != nil {
+		log.Printf("Failed to insert variable: %v", err)
+		return nil, fmt.Errorf("failed to create variable of category '%s': %w", request.CategoryID, translateError(err))
+	}
+
+	return &variable, nil
+}
+
+// Columns read into a models.Variable by scanVariable, in order
+const variableColumns = `id, category_id, name, allowed_values, required, created_at`
+
+func scanVariable(row rowScanner, variable *models.Variable) error {
+	return row.Scan(
+		&variable.ID,
+		&variable.CategoryID,
+		&variable.Name,
+		(*pq.StringArray)(&variable.Values),
+		&variable.Required,
+		&variable.CreatedAt,
+	)
+}
+
+func getCategories(ctx context.Context, q queryer, gameID string) ([]models.Category, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT c.id, c.game_id, c.name, COALESCE(l.id::TEXT, ''), c.created_at
+		FROM game_categories c
+		LEFT JOIN leaderboards l
+			ON l.category_id = c.id AND l.deleted_at IS NULL
+		WHERE c.game_id = $1
+		ORDER BY c.id ASC`,
+		gameID,
+	)
+	if err != nil {
+		log.Printf("Failed to query categories of game '%s': %v", gameID, err)
+		return nil, fmt.Errorf("failed to get categories of game '%s': %w", gameID, translateError(err))
+	}
+	defer rows.Close()
+
+	categories := make([]models.Category, 0)
+	for rows.Next() {
+		var category models.Category
+		if err := rows.Scan(
+			&category.ID,
+			&category.GameID,
+			&category.Name,
+			&category.LeaderboardID,
+			&category.CreatedAt,
+		); err != nil {
+			log.Printf("Failed to scan game category: %v", err)
+			return nil, fmt.Errorf("failed to scan game category: %w", err)
+		}
+		categories = append(categories, category)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan game categories: %v", err)
+		return nil, fmt.Errorf("failed to scan game categories: %w", err)
+	}
+
+	return categories, nil
+}
+
+func getGameVariables(ctx context.Context, q queryer, gameID string) ([]models.Variable, error) {
+	return queryVariables(ctx, q, `
+		SELECT v.id, v.category_id, v.name, v.allowed_values, v.required, v.created_at
+		FROM category_variables v
+		JOIN game_categories c
+			ON v.category_id = c.id
+		WHERE c.game_id = $1
+		ORDER BY v.id ASC`,
+		gameID,
+	)
+}
+
+// Variables of the category ranked by the leaderboard, none if it does not rank a category
+func getLeaderboardVariables(ctx context.Context, q queryer, leaderboardID string) ([]models.Variable, error) {
+	return queryVariables(ctx, q, `
+		SELECT v.id, v.category_id, v.name, v.allowed_values, v.required, v.created_at
+		FROM category_variables v
+		JOIN leaderboards l
+			ON v.category_id = l.category_id
+		WHERE l.id = $1
+		ORDER BY v.id ASC`,
+		leaderboardID,
+	)
+}
+
+func queryVariables(ctx context.Context, q queryer, query string, args ...any) ([]models.Variable, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Printf("Failed to query category variables: %v", err)
+		return nil, fmt.Errorf("failed to get category variables: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	variables := make([]models.Variable, 0)
+	for rows.Next() {
+		var variable models.Variable
+		if err := scanVariable(rows, &variable); err != nil {
+			log.Printf("Failed to scan category variable: %v", err)
+			return nil, fmt.Errorf("failed to scan category variable: %w", err)
+		}
+		variables = append(variables, variable)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan category variables: %v", err)
+		return nil, fmt.Errorf("failed to scan category variables: %w", err)
+	}
+
+	return variables, nil
+}
+
+// Variable values are stored as a JSON object, empty when the run has none
+func variablesArg(values map[string]string) ([]byte, error) {
+	if values == nil {
+		values = map[string]string{}
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode entry variables: %w", err)
+	}
+	return data, nil
+}
+
+// Decodes the variable values of a run, left nil when the run has none
+func decodeVariables(data []byte) (map[string]string, error) {
+	var values map[string]string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to decode entry variables: %w", err)
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+	return values, nil
+}
@@ -3,20 +3,36 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/mochivi/go-real-time-leaderboards/internal/models"
 )
 
 type LeaderboardRepo interface {
 	Get(context.Context, string) (*models.Leaderboard, error)
-	GetEntries(context.Context, string) ([]models.LeaderboardEntry, error)
+	List(context.Context, *models.LeaderboardListFilter) ([]models.LeaderboardListing, error)
+	GetEntries(context.Context, string, string) ([]models.LeaderboardEntry, error)
+	GetRankedEntries(context.Context, *models.VariantFilter) ([]models.RankedEntry, error)
 	Create(context.Context, *models.LeaderboardRequest) (*models.Leaderboard, error)
 	CreateEntry(context.Context, *models.LeaderboardEntryRequest) (*models.LeaderboardEntry, error)
-	Update(context.Context, *models.UpdateLeaderboardRequest) (*models.Leaderboard, error)
-	Delete(context.Context, string) error
+	GetUserSubmissions(context.Context, string, string) (*models.UserSubmissions, error)
+	GetRankedScores(context.Context, string) ([]models.RankedScore, error)
+	GetLeaderboardScores(context.Context, string) ([]models.RankedScore, error)
+	GetScoreStats(context.Context, string) (*models.ScoreStats, error)
+	GetScoreDistribution(context.Context, string, float64) (*models.ScoreDistribution, error)
+	GetModerationQueue(context.Context, *models.ModerationQueueFilter) ([]models.LeaderboardEntry, error)
+	ReviewEntry(context.Context, *models.EntryReview, *models.AuditActor) (*models.LeaderboardEntry, error)
+	UpdateEntry(context.Context, *models.UpdateEntryRequest, *models.AuditActor) (*models.LeaderboardEntry, error)
+	Update(context.Context, *models.UpdateLeaderboardRequest, *models.AuditActor) (*models.Leaderboard, error)
+	Delete(context.Context, string, *models.AuditActor) error
+	Restore(context.Context, string, *models.AuditActor) (*models.Leaderboard, error)
+	PurgeDeleted(context.Context, time.Time) (int64, error)
 }
 
 // Postgres implementation
@@ -38,15 +54,8 @@ func (lr *LeaderboardRepoPG) Get(ctx context.Context, leaderboardID string) (*mo
 	// Get leaderboard
 	stmt, err := lr.db.PrepareContext(
 		ctx,
-		`SELECT
-			id
-			,name
-			,description 
-			,live
-			,created_at
-			,updated_at
-		FROM leaderboards
-		WHERE id = $1`)
+		`SELECT `+leaderboardColumns+` FROM leaderboards WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL`,
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare get statement: %w", err)
 	}
@@ -55,22 +64,132 @@ func (lr *LeaderboardRepoPG) Get(ctx context.Context, leaderboardID string) (*mo
 	defer cancel()
 
 	var leaderboard models.Leaderboard
-	if err := stmt.QueryRowContext(ctx, leaderboardID).Scan(
-		&leaderboard.ID,
-		&leaderboard.Name,
-		&leaderboard.Description,
-		&leaderboard.Live,
-		&leaderboard.CreatedAt,
-		&leaderboard.UpdatedAt,
-	); err != nil {
+	if err := scanLeaderboard(stmt.QueryRowContext(ctx, leaderboardID, models.TenantFromContext(ctx)), &leaderboard); err != nil {
 		log.Printf("failed to get leaderboard: %v", err)
-		return nil, fmt.Errorf("failed to get leaderboard: %w", err)
+		return nil, fmt.Errorf("failed to get leaderboard: %w", translateError(err))
 	}
 
 	return &leaderboard, nil
 }
 
-func (lr *LeaderboardRepoPG) GetEntries(ctx context.Context, leaderboardID string) ([]models.LeaderboardEntry, error) {
+// Order of the listing for each sort, ties are broken by the newest leaderboard first
+var leaderboardListOrders = map[string]string{
+	models.LeaderboardSortNewest:      `l.created_at DESC, l.id DESC`,
+	models.LeaderboardSortMostActive:  `recent_entries DESC, l.created_at DESC, l.id DESC`,
+	models.LeaderboardSortMostPlayers: `players DESC, l.created_at DESC, l.id DESC`,
+	models.LeaderboardSortRelevance:   `ts_rank(l.search_vector, websearch_to_tsquery('english', $5)) DESC, l.created_at DESC, l.id DESC`,
+}
+
+// Lists the public leaderboards of the tenant and the private ones the viewer is a member of
+// Players are counted from ranked entries of users in good standing, recent entries from every submission
+func (lr *LeaderboardRepoPG) List(ctx context.Context, filter *models.LeaderboardListFilter) ([]models.LeaderboardListing, error) {
+	order, ok := leaderboardListOrders[filter.Sort]
+	if !ok {
+		order = leaderboardListOrders[models.LeaderboardSortNewest]
+	}
+
+	stmt, err := lr.db.PrepareContext(ctx, `
+		SELECT
+			l.id
+			,l.tenant_id
+			,l.name
+			,l.description
+			,l.type
+			,COALESCE(l.category_id::TEXT, '')
+			,l.live
+			,l.visibility
+			,l.tags
+			,COALESCE(p.players, 0) AS players
+			,COALESCE(a.recent_entries, 0) AS recent_entries
+			,l.created_at
+		FROM leaderboards l
+		LEFT JOIN LATERAL (
+			SELECT COUNT(DISTINCT e.user_id) AS players
+			FROM leaderboard_entries e
+			JOIN users u
+				ON e.user_id = u.id
+			WHERE e.leaderboard_id = l.id
+				AND e.status IN ('accepted', 'verified')
+				AND e.deleted_at IS NULL
+				AND `+userInGoodStanding+`
+		) p ON TRUE
+		LEFT JOIN LATERAL (
+			SELECT COUNT(*) AS recent_entries
+			FROM leaderboard_entries e
+			WHERE e.leaderboard_id = l.id
+				AND e.deleted_at IS NULL
+				AND e.created_at >= CURRENT_TIMESTAMP - make_interval(days => $9)
+		) a ON TRUE
+		WHERE l.tenant_id = $1
+			AND l.deleted_at IS NULL
+			AND (l.visibility = 'public' OR (l.visibility = 'private' AND `+memberOfLeaderboard("l", "NULLIF($2, '')::BIGINT")+`))
+			AND ($3::BOOLEAN IS NULL OR l.live = $3)
+			AND ($4 = '' OR l.category_id::TEXT = $4)
+			AND ($5 = '' OR l.search_vector @@ websearch_to_tsquery('english', $5))
+			AND ($6 = '' OR l.tags @> ARRAY[$6]::TEXT[])
+		ORDER BY `+order+`
+		LIMIT $7 OFFSET $8`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare list leaderboards statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := stmt.QueryContext(
+		ctx,
+		models.TenantFromContext(ctx),
+		filter.ViewerID,
+		filter.Live,
+		filter.CategoryID,
+		filter.Query,
+		filter.Tag,
+		filter.Limit,
+		filter.Offset,
+		models.LeaderboardActiveDays,
+	)
+	if err != nil {
+		log.Printf("Failed to query leaderboards: %v", err)
+		return nil, fmt.Errorf("failed to list leaderboards: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	leaderboards := make([]models.LeaderboardListing, 0)
+	for rows.Next() {
+		var leaderboard models.LeaderboardListing
+		if err := rows.Scan(
+			&leaderboard.ID,
+			&leaderboard.TenantID,
+			&leaderboard.Name,
+			&leaderboard.Description,
+			&leaderboard.Type,
+			&leaderboard.CategoryID,
+			&leaderboard.Live,
+			&leaderboard.Visibility,
+			(*pq.StringArray)(&leaderboard.Tags),
+			&leaderboard.Players,
+			&leaderboard.RecentEntries,
+			&leaderboard.CreatedAt,
+		); err != nil {
+			log.Printf("Failed to scan leaderboard: %v", err)
+			return nil, fmt.Errorf("failed to scan leaderboard: %w", err)
+		}
+		leaderboards = append(leaderboards, leaderboard)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan leaderboards: %v", err)
+		return nil, fmt.Errorf("failed to scan leaderboards: %w", err)
+	}
+
+	return leaderboards, nil
+}
+
+// Entries of banned and shadowbanned users are only returned when the viewer is that user
+func (lr *LeaderboardRepoPG) GetEntries(ctx context.Context, leaderboardID, viewerID string) ([]models.LeaderboardEntry, error) {
 	log.Printf("Getting leaderboard %s from DB", leaderboardID)
 
 	// Get leaderboard 	
@@ -79,6 +198,11 @@ func (lr *LeaderboardRepoPG) GetEntries(ctx context.Context, leaderboardID strin
 		`SELECT
 			e.id
 			,e.score
+			,e.secondary_score
+			,e.attempt
+			,e.status
+			,e.metadata
+			,e.version
 			,e.created_at
 			,e.updated_at
 			,u.id
@@ -86,7 +210,12 @@ func (lr *LeaderboardRepoPG) GetEntries(ctx context.Context, leaderboardID strin
 		FROM leaderboard_entries e
 		LEFT JOIN users u 
 			ON e.user_id = u.id 
-		WHERE e.leaderboard_id = $1`)
+		WHERE e.leaderboard_id = $1
+			AND `+inTenant("e.leaderboard_id", "$3")+`
+			AND e.status IN ('accepted', 'verified')
+			AND e.deleted_at IS NULL
+			AND (`+userInGoodStanding+` OR u.id::TEXT = $2)
+		ORDER BY e.sort_key DESC, u.id::TEXT COLLATE "C" DESC, e.created_at ASC, e.id ASC`)
 	if err != nil {
 		log.Printf("Failed to prepare get statement: %v", err)
 		return nil, fmt.Errorf("failed to prepare get statement: %w", err)
@@ -95,18 +224,24 @@ func (lr *LeaderboardRepoPG) GetEntries(ctx context.Context, leaderboardID strin
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	rows, err := stmt.QueryContext(ctx, leaderboardID)
+	rows, err := stmt.QueryContext(ctx, leaderboardID, viewerID, models.TenantFromContext(ctx))
 	if err != nil {
 		log.Printf("failed to get leaderboard entries: %v", err)
-		return nil, fmt.Errorf("failed to get leaderboard entries: %w", err)
+		return nil, fmt.Errorf("failed to get leaderboard entries: %w", translateError(err))
 	}
 
 	entries := make([]models.LeaderboardEntry, 0)
 	for rows.Next() {
 		var entry models.LeaderboardEntry
+		var metadata []byte
 		if err = rows.Scan(
 			&entry.ID,
 			&entry.Score,
+			&entry.SecondaryScore,
+			&entry.Attempt,
+			&entry.Status,
+			&metadata,
+			&entry.Version,
 			&entry.CreatedAt,
 			&entry.UpdatedAt,
 			&entry.User.ID,
@@ -115,6 +250,9 @@ func (lr *LeaderboardRepoPG) GetEntries(ctx context.Context, leaderboardID strin
 			log.Printf("failed to scan leaderboard entry: %v", err)
 			return nil, fmt.Errorf("failed to scan leaderboard entry: %w", err)
 		}
+		if entry.Metadata, err = decodeMetadata(metadata); err != nil {
+			return nil, err
+		}
 		entries = append(entries, entry)
 	}
 
@@ -126,13 +264,130 @@ func (lr *LeaderboardRepoPG) GetEntries(ctx context.Context, leaderboardID strin
 	return entries, nil
 }
 
+// Ranks the best run of every player among the runs matching the variable values of the filter
+// Variables left out of the filter are aggregated, so an empty filter ranks all variants together
+// Metadata values are compared as text, so numbers and booleans match their JSON representation
+// Ranks are numbered in the rank mode of the filter, ordinal ties follow the Redis ranking order
+func (lr *LeaderboardRepoPG) GetRankedEntries(ctx context.Context, filter *models.VariantFilter) ([]models.RankedEntry, error) {
+	stmt, err := lr.db.PrepareContext(ctx, `
+		WITH best AS (
+			SELECT DISTINCT ON (e.user_id) e.user_id, u.username, e.score, e.sort_key, e.variables, e.metadata
+			FROM leaderboard_entries e
+			JOIN users u
+				ON e.user_id = u.id
+			WHERE e.leaderboard_id = $1
+				AND `+inTenant("e.leaderboard_id", "$6")+`
+				AND e.status IN ('accepted', 'verified')
+				AND e.deleted_at IS NULL
+				AND e.variables @> $2::JSONB
+				AND NOT EXISTS (
+					SELECT 1 FROM jsonb_each_text($7::JSONB) f WHERE e.metadata->>f.key IS DISTINCT FROM f.value
+				)
+				AND `+userInGoodStanding+`
+			ORDER BY e.user_id, e.sort_key DESC, e.created_at ASC, e.id ASC
+		), ranked AS (
+			SELECT
+				user_id
+				,username
+				,score
+				,sort_key
+				,variables
+				,metadata
+				,CASE $3
+					WHEN 'dense' THEN DENSE_RANK() OVER by_key
+					WHEN 'ordinal' THEN ROW_NUMBER() OVER (ORDER BY sort_key DESC, user_id::TEXT COLLATE "C" DESC)
+					ELSE RANK() OVER by_key
+				END AS rank
+			FROM best
+			WINDOW by_key AS (ORDER BY sort_key DESC)
+		)
+		SELECT rank, user_id, username, score, variables, metadata
+		FROM ranked
+		ORDER BY sort_key DESC, user_id::TEXT COLLATE "C" DESC
+		LIMIT $4 OFFSET $5`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare ranked entries statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	values, err := variablesArg(filter.Values)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := variablesArg(filter.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := stmt.QueryContext(
+		ctx,
+		filter.LeaderboardID,
+		values,
+		filter.RankMode,
+		filter.Page.Limit,
+		filter.Page.Offset,
+		models.TenantFromContext(ctx),
+		metadata,
+	)
+	if err != nil {
+		log.Printf("Failed to query ranked entries: %v", err)
+		return nil, fmt.Errorf("failed to get ranked entries: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	entries := make([]models.RankedEntry, 0)
+	for rows.Next() {
+		var entry models.RankedEntry
+		var variables, metadata []byte
+		if err := rows.Scan(&entry.Rank, &entry.User.ID, &entry.User.Username, &entry.Score, &variables, &metadata); err != nil {
+			log.Printf("Failed to scan ranked entry: %v", err)
+			return nil, fmt.Errorf("failed to scan ranked entry: %w", err)
+		}
+		if entry.Variables, err = decodeVariables(variables); err != nil {
+			return nil, err
+		}
+		if entry.Metadata, err = decodeMetadata(metadata); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan ranked entries: %v", err)
+		return nil, fmt.Errorf("failed to scan ranked entries: %w", err)
+	}
+
+	return entries, nil
+}
+
 func (lr *LeaderboardRepoPG) Create(ctx context.Context, newLeaderboard *models.LeaderboardRequest) (*models.Leaderboard, error) {
 
+	// The creator of a private leaderboard is its first member, so it can read the leaderboard
 	stmt, err := lr.db.PrepareContext(
 		ctx, 
-		`INSERT INTO public.leaderboards (name, description, live, updated_At)
-			VALUES ($1, $2, $3, $4)
-			RETURNING id, name, description, live, created_at, updated_at`,
+		`WITH created AS (
+			INSERT INTO public.leaderboards (
+				name, description, type, rating_system, live, requires_verification, min_score, max_score, max_improvement,
+				min_submission_interval, score_step, tie_breakers, rank_mode, tiers, team_aggregate, team_top_k, updated_At,
+				category_id, tenant_id, metadata, metadata_schema, visibility, tags
+			)
+			VALUES (
+				$1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17,
+				NULLIF($18, '')::BIGINT, $19, $20, $21, $22, $24
+			)
+			RETURNING *
+		), joined AS (
+			INSERT INTO leaderboard_members (leaderboard_id, user_id)
+			SELECT id, $23::BIGINT
+			FROM created
+			WHERE visibility = 'private' AND $23 <> ''
+		)
+		SELECT `+leaderboardColumns+` FROM created`,
 	)
 	if err != nil {
 		log.Printf("Failed to prepare insert statement: %v", err)
@@ -143,23 +398,46 @@ func (lr *LeaderboardRepoPG) Create(ctx context.Context, newLeaderboard *models.
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
+	teamAggregate, teamTopK := teamRankingArgs(newLeaderboard.TeamRanking)
+	tiers, err := tiersArg(newLeaderboard.Tiers)
+	if err != nil {
+		return nil, err
+	}
+	metadata, metadataSchema, err := leaderboardMetadataArgs(newLeaderboard.Metadata, newLeaderboard.MetadataSchema)
+	if err != nil {
+		return nil, err
+	}
+
 	var returnLeaderboard models.Leaderboard
-	if err := stmt.QueryRowContext(
+	if err := scanLeaderboard(stmt.QueryRowContext(
 		ctx,
 		newLeaderboard.Name,
 		newLeaderboard.Description,
+		newLeaderboard.Type,
+		newLeaderboard.RatingSystem,
 		newLeaderboard.Live,
+		newLeaderboard.RequiresVerification,
+		newLeaderboard.ScoreRules.MinScore,
+		newLeaderboard.ScoreRules.MaxScore,
+		newLeaderboard.ScoreRules.MaxImprovement,
+		newLeaderboard.ScoreRules.MinSubmissionInterval,
+		newLeaderboard.ScoreRules.ScoreStep,
+		tieBreakersArg(newLeaderboard.TieBreakers),
+		newLeaderboard.RankMode,
+		tiers,
+		teamAggregate,
+		teamTopK,
 		newLeaderboard.UpdatedAt,
-	).Scan(
-		&returnLeaderboard.ID,
-		&returnLeaderboard.Name,
-		&returnLeaderboard.Description,
-		&returnLeaderboard.Live,
-		&returnLeaderboard.CreatedAt,
-		&returnLeaderboard.UpdatedAt,
-	); err != nil {
+		newLeaderboard.CategoryID,
+		models.TenantFromContext(ctx),
+		metadata,
+		metadataSchema,
+		newLeaderboard.Visibility,
+		newLeaderboard.CreatedBy,
+		tagsArg(newLeaderboard.Tags),
+	), &returnLeaderboard); err != nil {
 		log.Printf("Failed to execute leaderboard creation query: %v", err)
-		return nil, fmt.Errorf("failed to create leaderboard: %w", err)
+		return nil, fmt.Errorf("failed to create leaderboard: %w", translateError(err))
 	}
 
 	return &returnLeaderboard, nil
@@ -167,139 +445,1245 @@ func (lr *LeaderboardRepoPG) Create(ctx context.Context, newLeaderboard *models.
 
 func (lr *LeaderboardRepoPG) CreateEntry(ctx context.Context, entry *models.LeaderboardEntryRequest) (*models.LeaderboardEntry, error) {
 
-	stmt, err := lr.db.PrepareContext(ctx,`
-		INSERT INTO leaderboard_entries (leaderboard_id, user_id, score, updated_at)
-		VALUES ($1, $2, $3, $4)
-		RETURNING id, leaderboard_id, user_id, score, created_at, updated_at`,
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var returnEntry models.LeaderboardEntry
+	err := withTx(ctx, lr.db, func(tx *sql.Tx) error {
+		// Holds off the deletion of the leaderboard and the user until the entry is stored
+		var tenantID string
+		if err := tx.QueryRowContext(ctx, `
+			SELECT l.tenant_id
+			FROM leaderboards l, users u
+			WHERE l.id = $1 AND u.id = $2 AND l.tenant_id = $3 AND l.deleted_at IS NULL AND u.deleted_at IS NULL
+				AND `+memberOfLeaderboard("l", "u.id")+`
+			FOR SHARE`,
+			entry.LeaderboardID,
+			entry.UserID,
+			models.TenantFromContext(ctx),
+		).Scan(&tenantID); err != nil {
+			log.Printf("Failed to lock leaderboard and user of the entry: %v", err)
+			return fmt.Errorf("failed to create leaderboard entry: %w", translateError(err))
+		}
+
+		// Playing in a tenant makes the user one of its members
+		if err := joinTenant(ctx, tx, tenantID, entry.UserID); err != nil {
+			return err
+		}
+
+		// Runs on a game category must name their variant, leaderboards without a category take no variables
+		categoryVariables, err := getLeaderboardVariables(ctx, tx, entry.LeaderboardID)
+		if err != nil {
+			return err
+		}
+		if err := models.ValidateVariableValues(categoryVariables, entry.Variables); err != nil {
+			return err
+		}
+		variables, err := variablesArg(entry.Variables)
+		if err != nil {
+			return err
+		}
+		metadata, err := metadataArg(entry.Metadata)
+		if err != nil {
+			return err
+		}
+
+		var returnVariables, returnMetadata []byte
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO leaderboard_entries (
+				leaderboard_id, user_id, score, secondary_score, status, flag_reason, proof_url, notes, variables,
+				metadata, updated_at
+			)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), $9, $10, $11)
+			RETURNING
+				id, leaderboard_id, user_id, score, secondary_score, attempt, sort_key, status, COALESCE(flag_reason, ''),
+				COALESCE(proof_url, ''), COALESCE(notes, ''), variables, metadata, version, created_at, updated_at`,
+			entry.LeaderboardID,
+			entry.UserID,
+			entry.Score,
+			entry.SecondaryScore,
+			entry.Status,
+			entry.FlagReason,
+			entry.ProofURL,
+			entry.Notes,
+			variables,
+			metadata,
+			entry.UpdatedAt,
+		).Scan(
+			&returnEntry.ID,
+			&returnEntry.LeaderboardID,
+			&returnEntry.User.ID,
+			&returnEntry.Score,
+			&returnEntry.SecondaryScore,
+			&returnEntry.Attempt,
+			&returnEntry.SortKey,
+			&returnEntry.Status,
+			&returnEntry.FlagReason,
+			&returnEntry.ProofURL,
+			&returnEntry.Notes,
+			&returnVariables,
+			&returnMetadata,
+			&returnEntry.Version,
+			&returnEntry.CreatedAt,
+			&returnEntry.UpdatedAt,
+		); err != nil {
+			log.Printf("Failed to insert leaderboard entry: %v", err)
+			return fmt.Errorf("failed to create leaderboard entry: %w", translateError(err))
+		}
+		if returnEntry.Variables, err = decodeVariables(returnVariables); err != nil {
+			return err
+		}
+		if returnEntry.Metadata, err = decodeMetadata(returnMetadata); err != nil {
+			return err
+		}
+
+		// Only ranked scores are part of the distribution used to detect anomalies
+		if !models.IsRankedStatus(returnEntry.Status) || entry.Hidden {
+			return nil
+		}
+		return addScoreToStats(ctx, tx, returnEntry.LeaderboardID, returnEntry.Score)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &returnEntry, nil
+}
+
+// Folds a new accepted score into the leaderboard distribution using Welford's algorithm
+func addScoreToStats(ctx context.Context, tx *sql.Tx, leaderboardID string, score int) error {
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO leaderboard_score_stats AS s (leaderboard_id, count, mean, m2, min_score, max_score, updated_at)
+		VALUES ($1, 1, $2, 0, $3, $3, CURRENT_TIMESTAMP)
+		ON CONFLICT (leaderboard_id) DO UPDATE SET
+			count = s.count + 1,
+			mean = s.mean + ($2 - s.mean) / (s.count + 1),
+			m2 = s.m2 + ($2 - s.mean) * ($2 - (s.mean + ($2 - s.mean) / (s.count + 1))),
+			min_score = LEAST(s.min_score, $3),
+			max_score = GREATEST(s.max_score, $3),
+			updated_at = CURRENT_TIMESTAMP`,
+		leaderboardID,
+		float64(score),
+		score,
+	); err != nil {
+		log.Printf("Failed to update leaderboard score stats: %v", err)
+		return fmt.Errorf("failed to update leaderboard score stats: %w", translateError(err))
+	}
+
+	return nil
+}
+
+// Recomputes the distribution from the ranked scores, after changes that cannot be applied incrementally
+// such as removing or rescoring entries
+func rebuildScoreStats(ctx context.Context, tx *sql.Tx, leaderboardID string) error {
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO leaderboard_score_stats AS s (leaderboard_id, count, mean, m2, min_score, max_score, updated_at)
+		SELECT
+			$1
+			,COUNT(*)
+			,COALESCE(AVG(e.score), 0)
+			,COALESCE(VAR_POP(e.score) * COUNT(*), 0)
+			,MIN(e.score)
+			,MAX(e.score)
+			,CURRENT_TIMESTAMP
+		FROM leaderboard_entries e
+		JOIN users u
+			ON e.user_id = u.id
+		WHERE e.leaderboard_id = $1
+			AND e.status IN ('accepted', 'verified')
+			AND e.deleted_at IS NULL
+			AND `+userInGoodStanding+`
+		ON CONFLICT (leaderboard_id) DO UPDATE SET
+			count = EXCLUDED.count,
+			mean = EXCLUDED.mean,
+			m2 = EXCLUDED.m2,
+			min_score = EXCLUDED.min_score,
+			max_score = EXCLUDED.max_score,
+			updated_at = EXCLUDED.updated_at`,
+		leaderboardID,
+	); err != nil {
+		log.Printf("Failed to rebuild leaderboard score stats: %v", err)
+		return fmt.Errorf("failed to rebuild leaderboard score stats: %w", translateError(err))
+	}
+
+	return nil
+}
+
+// Returns the distribution of accepted scores, empty if the leaderboard has none yet
+func (lr *LeaderboardRepoPG) GetScoreStats(ctx context.Context, leaderboardID string) (*models.ScoreStats, error) {
+	stmt, err := lr.db.PrepareContext(ctx, `
+		SELECT count, mean, m2, COALESCE(min_score, 0), COALESCE(max_score, 0)
+		FROM leaderboard_score_stats
+		WHERE leaderboard_id = $1 AND `+inTenant("leaderboard_id", "$2"),
 	)
 	if err != nil {
-		log.Printf("Failed to prepare entry creation statement: %v", err)
-		return nil, fmt.Errorf("failed to prepare insert statement: %w", err)
+		log.Printf("Failed to prepare score stats statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	var returnEntry models.LeaderboardEntry
-	if err := stmt.QueryRowContext(
+	var stats models.ScoreStats
+	err = stmt.QueryRowContext(ctx, leaderboardID, models.TenantFromContext(ctx)).Scan(
+		&stats.Count,
+		&stats.Mean,
+		&stats.M2,
+		&stats.Min,
+		&stats.Max,
+	)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		log.Printf("Failed to query score stats: %v", err)
+		return nil, fmt.Errorf("failed to get score stats: %w", translateError(err))
+	}
+
+	return &stats, nil
+}
+
+// Aggregates the best ranked score of every player into summary statistics and a histogram
+// A bucket width of 0 splits the range of scores into the default number of buckets
+func (lr *LeaderboardRepoPG) GetScoreDistribution(
+	ctx context.Context,
+	leaderboardID string,
+	bucketWidth float64,
+) (*models.ScoreDistribution, error) {
+	stmt, err := lr.db.PrepareContext(ctx, `
+		WITH scores AS (
+			SELECT MAX(e.score)::DOUBLE PRECISION AS score
+			FROM leaderboard_entries e
+			JOIN users u
+				ON e.user_id = u.id
+			WHERE e.leaderboard_id = $1
+				AND `+inTenant("e.leaderboard_id", "$6")+`
+				AND e.status IN ('accepted', 'verified')
+				AND e.deleted_at IS NULL
+				AND `+userInGoodStanding+`
+			GROUP BY e.user_id
+			UNION ALL
+			SELECT `+conservativeRating+`
+			FROM player_ratings r
+			JOIN users u
+				ON r.user_id = u.id
+			WHERE r.leaderboard_id = $1
+				AND `+inTenant("r.leaderboard_id", "$6")+`
+				AND u.deleted_at IS NULL
+				AND `+userInGoodStanding+`
+		), summary AS (
+			SELECT
+				COUNT(*) AS count
+				,COALESCE(MIN(score), 0) AS min
+				,COALESCE(MAX(score), 0) AS max
+				,COALESCE(AVG(score), 0) AS mean
+				,COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY score), 0) AS median
+				,COALESCE(stddev_samp(score), 0) AS stddev
+			FROM scores
+		), width AS (
+			SELECT CASE
+				WHEN $3::DOUBLE PRECISION > 0 THEN GREATEST($3::DOUBLE PRECISION, (max - min) / $4)
+				ELSE GREATEST(CEIL((max - min) / $5), 1)
+			END AS width
+			FROM summary
+		), origin AS (
+			SELECT w.width, FLOOR(s.min::NUMERIC / w.width::NUMERIC) AS bucket
+			FROM summary s
+			CROSS JOIN width w
+		)
+		SELECT
+			s.count, s.min, s.max, s.mean, s.median, s.stddev, o.width
+			,(o.bucket * o.width::NUMERIC)::DOUBLE PRECISION
+			,b.bucket
+			,COALESCE(b.count, 0)
+		FROM summary s
+		CROSS JOIN origin o
+		LEFT JOIN (
+			SELECT (FLOOR(sc.score::NUMERIC / o.width::NUMERIC) - o.bucket)::BIGINT AS bucket, COUNT(*) AS count
+			FROM scores sc
+			CROSS JOIN origin o
+			GROUP BY 1
+		) b ON TRUE
+		ORDER BY b.bucket`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare score distribution statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := stmt.QueryContext(
 		ctx,
-		entry.LeaderboardID,
-		entry.UserID,
-		entry.Score,
-		entry.UpdatedAt,
-	).Scan(
-		&returnEntry.ID,
-		&returnEntry.LeaderboardID,
-		&returnEntry.User.ID,
-		&returnEntry.Score,
-		&returnEntry.CreatedAt,
-		&returnEntry.UpdatedAt,
-	); err != nil {
-		log.Printf("Failed to insert leaderboard entry: %v", err)
-		return nil, fmt.Errorf("failed to create leaderboard entry: %w", err)
+		leaderboardID,
+		models.ConservativeRatingDeviations,
+		bucketWidth,
+		models.HistogramMaxBuckets,
+		models.HistogramDefaultBuckets,
+		models.TenantFromContext(ctx),
+	)
+	if err != nil {
+		log.Printf("Failed to query score distribution: %v", err)
+		return nil, fmt.Errorf("failed to get score distribution: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	// Every row repeats the summary next to one bucket, a leaderboard without scores has a single row without bucket
+	// Buckets are numbered from the lowest one in NUMERIC, extreme scores over a narrow width would overflow a BIGINT index
+	var distribution models.ScoreDistribution
+	var from float64
+	counts := make(map[int64]int64)
+	for rows.Next() {
+		var bucket sql.NullInt64
+		var count int64
+		if err := rows.Scan(
+			&distribution.Count,
+			&distribution.Min,
+			&distribution.Max,
+			&distribution.Mean,
+			&distribution.Median,
+			&distribution.StdDev,
+			&distribution.BucketWidth,
+			&from,
+			&bucket,
+			&count,
+		); err != nil {
+			log.Printf("Failed to scan score distribution: %v", err)
+			return nil, fmt.Errorf("failed to scan score distribution: %w", err)
+		}
+		if bucket.Valid {
+			counts[bucket.Int64] = count
+		}
 	}
 
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan score distribution: %v", err)
+		return nil, fmt.Errorf("failed to scan score distribution: %w", err)
+	}
 
-	return &returnEntry, nil
+	distribution.Histogram = models.NewHistogram(from, distribution.BucketWidth, counts)
+	return &distribution, nil
 }
 
-// Could be used for leaderboard updates when done by an admin, for example massive removal of invalid entries
-func (lr *LeaderboardRepoPG) Update(ctx context.Context, leaderboard *models.UpdateLeaderboardRequest) (*models.Leaderboard, error) {
-	
+// Summarises the previous entries and the standing of a user, used to enforce the leaderboard score rules
+func (lr *LeaderboardRepoPG) GetUserSubmissions(ctx context.Context, leaderboardID, userID string) (*models.UserSubmissions, error) {
 	stmt, err := lr.db.PrepareContext(ctx, `
-		UPDATE leaderboards
-		SET
-			name = $1,
-			description = $2,
-			live = $3,
-			updated_at = $4
-		WHERE id = $5
-		RETURNING id, name, description, live, created_at, updated_at`,
+		SELECT s.count, s.best_score, s.last_submitted_at, `+userBanColumns+`
+		FROM (
+			SELECT COUNT(*) AS count, COALESCE(MAX(score), 0) AS best_score, MAX(created_at) AS last_submitted_at
+			FROM leaderboard_entries
+			WHERE leaderboard_id = $1
+				AND `+inTenant("leaderboard_id", "$3")+`
+				AND user_id = $2
+				AND status <> 'rejected'
+				AND deleted_at IS NULL
+		) s
+		LEFT JOIN users u
+			ON u.id = $2`,
 	)
 	if err != nil {
-		log.Printf("Failed to prepare update leaderboard statement: %v", err)
+		log.Printf("Failed to prepare user submissions statement: %v", err)
 		return nil, fmt.Errorf("failed to prepare statement: %w", err)
 	}
+	defer stmt.Close()
 
-	ctx, cancel := context.WithTimeout(ctx, 5 * time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
-	var updatedLeaderboard models.Leaderboard
 
-	if err := stmt.QueryRowContext(
-		ctx,
-		leaderboard.Name,
-		leaderboard.Description,
-		leaderboard.Live,
-		leaderboard.UpdatedAt,
-		leaderboard.ID,
-	).Scan(
-		&updatedLeaderboard.ID,
-		&updatedLeaderboard.Name,
-		&updatedLeaderboard.Description,
-		&updatedLeaderboard.Live,
-		&updatedLeaderboard.CreatedAt,
-		&updatedLeaderboard.UpdatedAt,
-	); err != nil {
-		log.Printf("Failed to update leaderboard: %v", err)
-		return nil, fmt.Errorf("failed to update leaderboard: %w", err)
+	var submissions models.UserSubmissions
+	var lastSubmittedAt sql.NullTime
+	var ban banColumns
+	if err := stmt.QueryRowContext(ctx, leaderboardID, userID, models.TenantFromContext(ctx)).Scan(append([]any{
+		&submissions.Count,
+		&submissions.BestScore,
+		&lastSubmittedAt,
+	}, ban.dest()...)...); err != nil {
+		log.Printf("Failed to query user submissions: %v", err)
+		return nil, fmt.Errorf("failed to get user submissions: %w", translateError(err))
 	}
+	submissions.LastSubmittedAt = lastSubmittedAt.Time
+	submissions.Ban = ban.toBan(userID)
 
-	return &updatedLeaderboard, nil
+	return &submissions, nil
 }
 
-func (lr *LeaderboardRepoPG) UpdateEntry(ctx context.Context, leaderboardEntry *models.LeaderboardEntry) (*models.LeaderboardEntry, error) {
+// Returns the best ranked score of the user on every leaderboard they submitted to, used to rebuild their Redis rankings
+// Leaderboards where none of their entries is ranked anymore are returned as hidden, so they get removed
+// Rating leaderboards return the conservative rating of the user instead
+// Accounts are shared by every tenant, so the scores of every tenant are returned with the tenant of their leaderboard
+func (lr *LeaderboardRepoPG) GetRankedScores(ctx context.Context, userID string) ([]models.RankedScore, error) {
 	stmt, err := lr.db.PrepareContext(ctx, `
-		UPDATE leaderboards_entries (score, updated_at)
-		VALUES (?,?)
-		WHERE id = ? AND leaderboard_id = ?
-		RETURNING id, leaderboard_id, user_id, score, created_at, updated_at`,
+		SELECT
+			e.leaderboard_id
+			,l.tenant_id
+			,COALESCE(MAX(e.sort_key) FILTER (WHERE e.status IN ('accepted', 'verified') AND e.deleted_at IS NULL), 0)
+			,`+userBanned+`
+				OR COUNT(*) FILTER (WHERE e.status IN ('accepted', 'verified') AND e.deleted_at IS NULL) = 0
+		FROM leaderboard_entries e
+		JOIN users u
+			ON e.user_id = u.id
+		JOIN leaderboards l
+			ON e.leaderboard_id = l.id
+		WHERE e.user_id = $1
+		GROUP BY e.leaderboard_id, l.tenant_id, u.ban_type, u.banned_until
+		UNION ALL
+		SELECT
+			r.leaderboard_id
+			,l.tenant_id
+			,`+conservativeRating+`
+			,`+userBanned+` OR u.deleted_at IS NOT NULL OR l.deleted_at IS NOT NULL
+		FROM player_ratings r
+		JOIN users u
+			ON r.user_id = u.id
+		JOIN leaderboards l
+			ON r.leaderboard_id = l.id
+		WHERE r.user_id = $1`,
 	)
 	if err != nil {
-		log.Printf("Failed to prepare entry update statement: %v", err)
+		log.Printf("Failed to prepare ranked scores statement: %v", err)
 		return nil, fmt.Errorf("failed to prepare statement: %w", err)
 	}
+	defer stmt.Close()
 
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
-	var updatedEntry models.LeaderboardEntry
 
-	if err := stmt.QueryRowContext(
+	rows, err := stmt.QueryContext(ctx, userID, models.ConservativeRatingDeviations)
+	if err != nil {
+		log.Printf("Failed to query ranked scores: %v", err)
+		return nil, fmt.Errorf("failed to get ranked scores: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	scores := make([]models.RankedScore, 0)
+	for rows.Next() {
+		var score models.RankedScore
+		if err := rows.Scan(&score.LeaderboardID, &score.TenantID, &score.Score, &score.Hidden); err != nil {
+			log.Printf("Failed to scan ranked score: %v", err)
+			return nil, fmt.Errorf("failed to scan ranked score: %w", err)
+		}
+		scores = append(scores, score)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan ranked scores: %v", err)
+		return nil, fmt.Errorf("failed to scan ranked scores: %w", err)
+	}
+
+	return scores, nil
+}
+
+// Returns the best ranked score of every user on the leaderboard, used to rebuild its whole Redis ranking
+// Banned and shadowbanned users are left out, rating leaderboards return the conservative ratings of their players
+func (lr *LeaderboardRepoPG) GetLeaderboardScores(ctx context.Context, leaderboardID string) ([]models.RankedScore, error) {
+	stmt, err := lr.db.PrepareContext(ctx, `
+		SELECT e.user_id, MAX(e.sort_key)
+		FROM leaderboard_entries e
+		JOIN users u
+			ON e.user_id = u.id
+		WHERE e.leaderboard_id = $1
+			AND `+inTenant("e.leaderboard_id", "$3")+`
+			AND e.status IN ('accepted', 'verified')
+			AND e.deleted_at IS NULL
+			AND `+userInGoodStanding+`
+		GROUP BY e.user_id
+		UNION ALL
+		SELECT r.user_id, `+conservativeRating+`
+		FROM player_ratings r
+		JOIN users u
+			ON r.user_id = u.id
+		JOIN leaderboards l
+			ON r.leaderboard_id = l.id
+		WHERE r.leaderboard_id = $1
+			AND l.tenant_id = $3
+			AND l.deleted_at IS NULL
+			AND u.deleted_at IS NULL
+			AND `+userInGoodStanding,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare leaderboard scores statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tenantID := models.TenantFromContext(ctx)
+	rows, err := stmt.QueryContext(ctx, leaderboardID, models.ConservativeRatingDeviations, tenantID)
+	if err != nil {
+		log.Printf("Failed to query leaderboard scores: %v", err)
+		return nil, fmt.Errorf("failed to get leaderboard scores: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	scores := make([]models.RankedScore, 0)
+	for rows.Next() {
+		score := models.RankedScore{TenantID: tenantID, LeaderboardID: leaderboardID}
+		if err := rows.Scan(&score.UserID, &score.Score); err != nil {
+			log.Printf("Failed to scan leaderboard score: %v", err)
+			return nil, fmt.Errorf("failed to scan leaderboard score: %w", err)
+		}
+		scores = append(scores, score)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan leaderboard scores: %v", err)
+		return nil, fmt.Errorf("failed to scan leaderboard scores: %w", err)
+	}
+
+	return scores, nil
+}
+
+// Lists entries waiting for a moderator, oldest first so submissions are reviewed in order
+func (lr *LeaderboardRepoPG) GetModerationQueue(ctx context.Context, filter *models.ModerationQueueFilter) ([]models.LeaderboardEntry, error) {
+	stmt, err := lr.db.PrepareContext(ctx, `
+		SELECT
+			e.id
+			,e.leaderboard_id
+			,e.score
+			,e.status
+			,COALESCE(e.flag_reason, '')
+			,COALESCE(e.proof_url, '')
+			,COALESCE(e.notes, '')
+			,e.metadata
+			,e.created_at
+			,e.updated_at
+			,u.id
+			,u.username
+		FROM leaderboard_entries e
+		LEFT JOIN users u
+			ON e.user_id = u.id
+		WHERE e.status IN ('pending', 'flagged')
+			AND e.deleted_at IS NULL
+			AND ($1 = '' OR e.leaderboard_id::TEXT = $1)
+			AND `+inTenant("e.leaderboard_id", "$4")+`
+		ORDER BY e.created_at ASC, e.id ASC
+		LIMIT $2 OFFSET $3`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare moderation queue statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := stmt.QueryContext(ctx, filter.LeaderboardID, filter.Limit, filter.Offset, models.TenantFromContext(ctx))
+	if err != nil {
+		log.Printf("Failed to query moderation queue: %v", err)
+		return nil, fmt.Errorf("failed to get moderation queue: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	entries := make([]models.LeaderboardEntry, 0)
+	for rows.Next() {
+		var entry models.LeaderboardEntry
+		var metadata []byte
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.LeaderboardID,
+			&entry.Score,
+			&entry.Status,
+			&entry.FlagReason,
+			&entry.ProofURL,
+			&entry.Notes,
+			&metadata,
+			&entry.CreatedAt,
+			&entry.UpdatedAt,
+			&entry.User.ID,
+			&entry.User.Username,
+		); err != nil {
+			log.Printf("Failed to scan moderation queue entry: %v", err)
+			return nil, fmt.Errorf("failed to scan moderation queue entry: %w", err)
+		}
+		if entry.Metadata, err = decodeMetadata(metadata); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan moderation queue: %v", err)
+		return nil, fmt.Errorf("failed to scan moderation queue: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Records a moderator decision on a pending or flagged entry
+// Verified scores join the distribution in the same transaction so the anomaly checks stay consistent
+func (lr *LeaderboardRepoPG) ReviewEntry(
+	ctx context.Context,
+	review *models.EntryReview,
+	actor *models.AuditActor,
+) (*models.LeaderboardEntry, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var reviewedEntry models.LeaderboardEntry
+	err := withTx(ctx, lr.db, func(tx *sql.Tx) error {
+		var previousStatus string
+		var reviewedAt time.Time
+		var escalated bool
+		err := tx.QueryRowContext(ctx, `
+			WITH previous AS (
+				SELECT id, status
+				FROM leaderboard_entries
+				WHERE id = $4 AND `+inTenant("leaderboard_id", "$5")+` AND deleted_at IS NULL
+				FOR UPDATE
+			)
+			UPDATE leaderboard_entries e
+			SET
+				status = $1,
+				review_reason = NULLIF($2, ''),
+				reviewed_by = $3,
+				reviewed_at = CURRENT_TIMESTAMP,
+				updated_at = CURRENT_TIMESTAMP
+			FROM previous
+			WHERE e.id = previous.id AND previous.status IN ('pending', 'flagged')
+			RETURNING
+				e.id, e.leaderboard_id, e.user_id, e.score, e.status, COALESCE(e.flag_reason, ''),
+				COALESCE(e.proof_url, ''), COALESCE(e.notes, ''), COALESCE(e.review_reason, ''), e.reviewed_by,
+				e.reviewed_at, e.version, e.created_at, e.updated_at, previous.status, e.escalated_at IS NOT NULL`,
+			review.Status,
+			review.Reason,
+			actor.UserID,
+			review.EntryID,
+			models.TenantFromContext(ctx),
+		).Scan(
+			&reviewedEntry.ID,
+			&reviewedEntry.LeaderboardID,
+			&reviewedEntry.User.ID,
+			&reviewedEntry.Score,
+			&reviewedEntry.Status,
+			&reviewedEntry.FlagReason,
+			&reviewedEntry.ProofURL,
+			&reviewedEntry.Notes,
+			&reviewedEntry.ReviewReason,
+			&reviewedEntry.ReviewedBy,
+			&reviewedAt,
+			&reviewedEntry.Version,
+			&reviewedEntry.CreatedAt,
+			&reviewedEntry.UpdatedAt,
+			&previousStatus,
+			&escalated,
+		)
+		if errors.Is(err, sql.ErrNoRows) {
+			return entryReviewConflict(ctx, tx, review.EntryID)
+		}
+		if err != nil {
+			log.Printf("Failed to review leaderboard entry: %v", err)
+			return fmt.Errorf("failed to review leaderboard entry: %w", translateError(err))
+		}
+		reviewedEntry.ReviewedAt = &reviewedAt
+
+		if err := writeAudit(
+			ctx, tx, actor,
+			review.AuditAction(), models.AuditTargetEntry, reviewedEntry.ID,
+			map[string]string{"status": previousStatus}, reviewedEntry,
+		); err != nil {
+			return err
+		}
+
+		// Entries escalated by player reports were accepted before, their score is already in the distribution
+		if !models.IsRankedStatus(reviewedEntry.Status) || escalated {
+			return nil
+		}
+		return addScoreToStats(ctx, tx, reviewedEntry.LeaderboardID, reviewedEntry.Score)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &reviewedEntry, nil
+}
+
+// Tells apart a missing entry from one that was already reviewed
+func entryReviewConflict(ctx context.Context, tx *sql.Tx, entryID string) error {
+	var status string
+	if err := tx.QueryRowContext(
 		ctx,
-		leaderboardEntry.Score,
-		leaderboardEntry.UpdatedAt,
-		leaderboardEntry.ID,
-		leaderboardEntry.LeaderboardID,
-	).Scan(
-		&updatedEntry.ID,
-		&updatedEntry.LeaderboardID,
-		&updatedEntry.User.ID,
-		&updatedEntry.Score,
-		&updatedEntry.CreatedAt,
-		&updatedEntry.UpdatedAt,
-	); err != nil {
-		log.Printf("Failed to execute update entry query: %v", err)
-		return nil, fmt.Errorf("failed to update leaderboard entry: %w", err)
+		`SELECT status FROM leaderboard_entries WHERE id = $1 AND `+inTenant("leaderboard_id", "$2")+` AND deleted_at IS NULL`,
+		entryID,
+		models.TenantFromContext(ctx),
+	).Scan(&status); err != nil {
+		log.Printf("Failed to get leaderboard entry status: %v", err)
+		return fmt.Errorf("failed to review leaderboard entry: %w", translateError(err))
 	}
 
-	return &updatedEntry, nil
+	return fmt.Errorf("failed to review leaderboard entry with status '%s': %w", status, ErrStateConflict)
 }
 
-func (lr *LeaderboardRepoPG) Delete(ctx context.Context, leaderboardID string) error {
-	stmt, err := lr.db.PrepareContext(ctx, `DELETE FROM leaderboards WHERE id = $1`)
+// Leaderboard updates are done by admins, bulk changes to its entries go through change sets instead
+// The previous state is locked and recorded in the audit log with the change
+func (lr *LeaderboardRepoPG) Update(
+	ctx context.Context,
+	leaderboard *models.UpdateLeaderboardRequest,
+	actor *models.AuditActor,
+) (*models.Leaderboard, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var updatedLeaderboard models.Leaderboard
+	err := withTx(ctx, lr.db, func(tx *sql.Tx) error {
+		previous, err := lockLeaderboard(ctx, tx, leaderboard.ID)
+		if err != nil {
+			return err
+		}
+		if previous.Type == models.LeaderboardTypeRating && leaderboard.TeamRanking != nil {
+			return fmt.Errorf("failed to enable team ranking on rating leaderboard '%s': %w", leaderboard.ID, ErrStateConflict)
+		}
+		if previous.Type == models.LeaderboardTypeRating && len(leaderboard.TieBreakers) > 0 {
+			return fmt.Errorf("failed to set tie-breakers on rating leaderboard '%s': %w", leaderboard.ID, ErrStateConflict)
+		}
+
+		teamAggregate, teamTopK := teamRankingArgs(leaderboard.TeamRanking)
+		tiers, err := tiersArg(leaderboard.Tiers)
+		if err != nil {
+			return err
+		}
+		metadata, metadataSchema, err := leaderboardMetadataArgs(leaderboard.Metadata, leaderboard.MetadataSchema)
+		if err != nil {
+			return err
+		}
+		if err := scanLeaderboard(tx.QueryRowContext(ctx, `
+			UPDATE leaderboards
+			SET
+				name = $1,
+				description = $2,
+				live = $3,
+				requires_verification = $4,
+				min_score = $5,
+				max_score = $6,
+				max_improvement = $7,
+				min_submission_interval = $8,
+				score_step = $9,
+				tie_breakers = $10,
+				rank_mode = $11,
+				tiers = $12,
+				team_aggregate = $13,
+				team_top_k = $14,
+				metadata = $17,
+				metadata_schema = $18,
+				visibility = $19,
+				tags = $20,
+				updated_at = $15
+			WHERE id = $16
+			RETURNING `+leaderboardColumns,
+			leaderboard.Name,
+			leaderboard.Description,
+			leaderboard.Live,
+			leaderboard.RequiresVerification,
+			leaderboard.ScoreRules.MinScore,
+			leaderboard.ScoreRules.MaxScore,
+			leaderboard.ScoreRules.MaxImprovement,
+			leaderboard.ScoreRules.MinSubmissionInterval,
+			leaderboard.ScoreRules.ScoreStep,
+			tieBreakersArg(leaderboard.TieBreakers),
+			leaderboard.RankMode,
+			tiers,
+			teamAggregate,
+			teamTopK,
+			leaderboard.UpdatedAt,
+			leaderboard.ID,
+			metadata,
+			metadataSchema,
+			leaderboard.Visibility,
+			tagsArg(leaderboard.Tags),
+		), &updatedLeaderboard); err != nil {
+			log.Printf("Failed to update leaderboard: %v", err)
+			return fmt.Errorf("failed to update leaderboard: %w", translateError(err))
+		}
+
+		// Sort keys are written with the entries, new tie-breakers only apply once they are encoded again
+		// Only the sort keys change, so the versions of the entries are kept by the version trigger
+		if !slices.Equal(previous.TieBreakers, updatedLeaderboard.TieBreakers) {
+			if err := ensureSecondaryEncodable(ctx, tx, updatedLeaderboard.ID, updatedLeaderboard.TieBreakers); err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, `
+				UPDATE leaderboard_entries
+				SET sort_key = entry_sort_key(score, secondary_score, attempt, tie_sequence, $2)
+				WHERE leaderboard_id = $1`,
+				updatedLeaderboard.ID,
+				tieBreakersArg(updatedLeaderboard.TieBreakers),
+			); err != nil {
+				log.Printf("Failed to encode sort keys of leaderboard entries: %v", err)
+				return fmt.Errorf("failed to encode sort keys of leaderboard entries: %w", translateError(err))
+			}
+		}
+
+		return writeAudit(
+			ctx, tx, actor,
+			models.AuditActionLeaderboardUpdate, models.AuditTargetLeaderboard, updatedLeaderboard.ID,
+			previous, updatedLeaderboard,
+		)
+	})
 	if err != nil {
-		log.Printf("Failed to prepare leaderboard delete statement: %v", err)
-		return fmt.Errorf("failed to prepare statement: %w", err)
+		return nil, err
 	}
 
+	return &updatedLeaderboard, nil
+}
+
+// Updates the entry if it is still at the version the client read, reporting ErrVersionConflict otherwise
+// Entries of other users are reported as not found when the update is restricted to an owner
+func (lr *LeaderboardRepoPG) UpdateEntry(
+	ctx context.Context,
+	update *models.UpdateEntryRequest,
+	actor *models.AuditActor,
+) (*models.LeaderboardEntry, error) {
+
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	if _, err := stmt.ExecContext(ctx, leaderboardID); err != nil {
-		log.Printf("Failed to execute leaderboard delete query: %v", err)
-		return fmt.Errorf("failed to delete leaderboard: %w", err)
+	var previousEntry, updatedEntry models.LeaderboardEntry
+	err := withTx(ctx, lr.db, func(tx *sql.Tx) error {
+		row := tx.QueryRowContext(ctx, `
+			SELECT `+entryColumns+`
+			FROM leaderboard_entries
+			WHERE id = $1 AND `+inTenant("leaderboard_id", "$2")+` AND deleted_at IS NULL
+			FOR UPDATE`,
+			update.ID,
+			models.TenantFromContext(ctx),
+		)
+		if err := scanEntry(row, &previousEntry); err != nil {
+			log.Printf("Failed to lock leaderboard entry: %v", err)
+			return fmt.Errorf("failed to update leaderboard entry: %w", translateError(err))
+		}
+		if update.OwnerID != "" && previousEntry.User.ID != update.OwnerID {
+			return fmt.Errorf("failed to update leaderboard entry of another user: %w", ErrNotFound)
+		}
+		if update.OwnerID != "" {
+			if err := ensureMember(ctx, tx, previousEntry.LeaderboardID, update.OwnerID); err != nil {
+				return err
+			}
+		}
+		if previousEntry.Version != update.Version {
+			return fmt.Errorf(
+				"failed to update leaderboard entry at version %d, expected %d: %w",
+				previousEntry.Version, update.Version, ErrVersionConflict,
+			)
+		}
+
+		row = tx.QueryRowContext(ctx, `
+			UPDATE leaderboard_entries
+			SET
+				score = COALESCE($2, score),
+				proof_url = NULLIF($3, ''),
+				notes = NULLIF($4, ''),
+				updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1
+			RETURNING `+entryColumns,
+			update.ID,
+			update.Score,
+			update.ProofURL,
+			update.Notes,
+		)
+		if err := scanEntry(row, &updatedEntry); err != nil {
+			log.Printf("Failed to execute update entry query: %v", err)
+			return fmt.Errorf("failed to update leaderboard entry: %w", translateError(err))
+		}
+
+		if err := writeAudit(
+			ctx, tx, actor,
+			models.AuditActionEntryUpdate, models.AuditTargetEntry, updatedEntry.ID,
+			previousEntry, updatedEntry,
+		); err != nil {
+			return err
+		}
+
+		// A new score of a ranked entry cannot be folded into the distribution incrementally
+		if updatedEntry.Score == previousEntry.Score || !models.IsRankedStatus(updatedEntry.Status) {
+			return nil
+		}
+		return rebuildScoreStats(ctx, tx, updatedEntry.LeaderboardID)
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	return &updatedEntry, nil
+}
+
+// Soft deletes the leaderboard together with its entries, which keep the deletion time of the leaderboard
+// so a restore brings back only the entries deleted with it
+func (lr *LeaderboardRepoPG) Delete(ctx context.Context, leaderboardID string, actor *models.AuditActor) error {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return withTx(ctx, lr.db, func(tx *sql.Tx) error {
+		previous, err := lockLeaderboard(ctx, tx, leaderboardID)
+		if err != nil {
+			return err
+		}
+
+		var deletedAt time.Time
+		if err := tx.QueryRowContext(
+			ctx,
+			`UPDATE leaderboards SET deleted_at = CURRENT_TIMESTAMP WHERE id = $1 RETURNING deleted_at`,
+			leaderboardID,
+		).Scan(&deletedAt); err != nil {
+			log.Printf("Failed to execute leaderboard delete query: %v", err)
+			return fmt.Errorf("failed to delete leaderboard: %w", translateError(err))
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE leaderboard_entries
+			SET deleted_at = $2
+			WHERE leaderboard_id = $1 AND deleted_at IS NULL`,
+			leaderboardID,
+			deletedAt,
+		); err != nil {
+			log.Printf("Failed to delete entries of leaderboard '%s': %v", leaderboardID, err)
+			return fmt.Errorf("failed to delete leaderboard entries: %w", translateError(err))
+		}
+
+		return writeAudit(
+			ctx, tx, actor,
+			models.AuditActionLeaderboardDelete, models.AuditTargetLeaderboard, leaderboardID,
+			previous, nil,
+		)
+	})
+}
+
+// Restores a soft deleted leaderboard and the entries deleted with it
+// Returns ErrStateConflict if the leaderboard is not deleted
+func (lr *LeaderboardRepoPG) Restore(ctx context.Context, leaderboardID string, actor *models.AuditActor) (*models.Leaderboard, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var restored models.Leaderboard
+	err := withTx(ctx, lr.db, func(tx *sql.Tx) error {
+		var deletedAt sql.NullTime
+		if err := tx.QueryRowContext(
+			ctx,
+			`SELECT deleted_at FROM leaderboards WHERE id = $1 AND tenant_id = $2 FOR UPDATE`,
+			leaderboardID,
+			models.TenantFromContext(ctx),
+		).Scan(&deletedAt); err != nil {
+			log.Printf("Failed to lock leaderboard '%s': %v", leaderboardID, err)
+			return fmt.Errorf("failed to lock leaderboard '%s': %w", leaderboardID, translateError(err))
+		}
+		if !deletedAt.Valid {
+			return fmt.Errorf("failed to restore leaderboard '%s': %w", leaderboardID, ErrStateConflict)
+		}
+
+		// Entries of users deleted since take the deletion time of their user, so they come back with them
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE leaderboard_entries e
+			SET deleted_at = u.deleted_at
+			FROM users u
+			WHERE e.user_id = u.id
+				AND e.leaderboard_id = $1
+				AND e.deleted_at = $2`,
+			leaderboardID,
+			deletedAt.Time,
+		); err != nil {
+			log.Printf("Failed to restore entries of leaderboard '%s': %v", leaderboardID, err)
+			return fmt.Errorf("failed to restore leaderboard entries: %w", translateError(err))
+		}
+
+		if err := scanLeaderboard(
+			tx.QueryRowContext(
+				ctx,
+				`UPDATE leaderboards SET deleted_at = NULL WHERE id = $1 RETURNING `+leaderboardColumns,
+				leaderboardID,
+			),
+			&restored,
+		); err != nil {
+			log.Printf("Failed to execute leaderboard restore query: %v", err)
+			return fmt.Errorf("failed to restore leaderboard: %w", translateError(err))
+		}
+
+		// Entries are skipped by the stats while deleted
+		if err := rebuildScoreStats(ctx, tx, leaderboardID); err != nil {
+			return err
+		}
+
+		return writeAudit(
+			ctx, tx, actor,
+			models.AuditActionLeaderboardRestore, models.AuditTargetLeaderboard, leaderboardID,
+			nil, restored,
+		)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &restored, nil
+}
+
+// Permanently removes the leaderboards and entries soft deleted before the given time, in every tenant
+// Returns the number of removed rows
+func (lr *LeaderboardRepoPG) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	var purged int64
+	err := withTx(ctx, lr.db, func(tx *sql.Tx) error {
+		for _, query := range []string{
+			`DELETE FROM leaderboard_entries WHERE deleted_at < $1`,
+			`DELETE FROM leaderboards WHERE deleted_at < $1`,
+		} {
+			result, err := tx.ExecContext(ctx, query, before)
+			if err != nil {
+				log.Printf("Failed to purge deleted leaderboards: %v", err)
+				return fmt.Errorf("failed to purge deleted leaderboards: %w", translateError(err))
+			}
+
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				log.Printf("Failed to purge deleted leaderboards: %v", err)
+				return fmt.Errorf("failed to purge deleted leaderboards: %w", err)
+			}
+			purged += rowsAffected
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// Columns read into a models.Leaderboard by scanLeaderboard, in order
+const leaderboardColumns = `
+	id, tenant_id, name, description, type, COALESCE(rating_system, ''), COALESCE(category_id::TEXT, ''), live,
+	visibility, tags, requires_verification, min_score, max_score, max_improvement, min_submission_interval, score_step, tie_breakers,
+	rank_mode, tiers, team_aggregate, team_top_k, metadata, metadata_schema, created_at, updated_at`
+
+// Implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanLeaderboard(row rowScanner, leaderboard *models.Leaderboard) error {
+	var tiers, metadata, metadataSchema []byte
+	var teamAggregate sql.NullString
+	var teamTopK *int
+	if err := row.Scan(
+		&leaderboard.ID,
+		&leaderboard.TenantID,
+		&leaderboard.Name,
+		&leaderboard.Description,
+		&leaderboard.Type,
+		&leaderboard.RatingSystem,
+		&leaderboard.CategoryID,
+		&leaderboard.Live,
+		&leaderboard.Visibility,
+		(*pq.StringArray)(&leaderboard.Tags),
+		&leaderboard.RequiresVerification,
+		&leaderboard.ScoreRules.MinScore,
+		&leaderboard.ScoreRules.MaxScore,
+		&leaderboard.ScoreRules.MaxImprovement,
+		&leaderboard.ScoreRules.MinSubmissionInterval,
+		&leaderboard.ScoreRules.ScoreStep,
+		(*pq.StringArray)(&leaderboard.TieBreakers),
+		&leaderboard.RankMode,
+		&tiers,
+		&teamAggregate,
+		&teamTopK,
+		&metadata,
+		&metadataSchema,
+		&leaderboard.CreatedAt,
+		&leaderboard.UpdatedAt,
+	); err != nil {
+		return err
+	}
+
+	leaderboard.Tiers = nil
+	if err := json.Unmarshal(tiers, &leaderboard.Tiers); err != nil {
+		return fmt.Errorf("failed to decode leaderboard tiers: %w", err)
+	}
+	if len(leaderboard.Tiers) == 0 {
+		leaderboard.Tiers = nil
+	}
+
+	leaderboard.TeamRanking = nil
+	if teamAggregate.Valid {
+		leaderboard.TeamRanking = &models.TeamRanking{Aggregate: teamAggregate.String, TopK: teamTopK}
+	}
+
+	var err error
+	if leaderboard.Metadata, err = decodeMetadata(metadata); err != nil {
+		return err
+	}
+	leaderboard.MetadataSchema = nil
+	if metadataSchema != nil {
+		if err := json.Unmarshal(metadataSchema, &leaderboard.MetadataSchema); err != nil {
+			return fmt.Errorf("failed to decode leaderboard metadata schema: %w", err)
+		}
+	}
 	return nil
 }
 
+// Returns ErrStateConflict if entries of the leaderboard have secondary scores the tie-breakers cannot tell apart
+func ensureSecondaryEncodable(ctx context.Context, tx *sql.Tx, leaderboardID string, tieBreakers models.TieBreakers) error {
+	for i, breaker := range tieBreakers {
+		if breaker != models.TieBreakerHighestSecondary && breaker != models.TieBreakerLowestSecondary {
+			continue
+		}
+
+		maxValue := tieBreakers.MaxValue(i)
+		var overflows bool
+		if err := tx.QueryRowContext(
+			ctx,
+			`SELECT EXISTS (
+				SELECT 1 FROM leaderboard_entries
+				WHERE leaderboard_id = $1 AND (secondary_score < 0 OR secondary_score > $2)
+			)`,
+			leaderboardID,
+			maxValue,
+		).Scan(&overflows); err != nil {
+			log.Printf("Failed to check secondary scores of leaderboard '%s': %v", leaderboardID, err)
+			return fmt.Errorf("failed to check secondary scores of leaderboard '%s': %w", leaderboardID, translateError(err))
+		}
+		if overflows {
+			return fmt.Errorf(
+				"failed to set tie-breakers on leaderboard '%s', secondary scores must be between 0 and %d: %w",
+				leaderboardID, maxValue, ErrStateConflict,
+			)
+		}
+	}
+	return nil
+}
+
+// Leaderboards without tie-breakers store an empty array rather than NULL
+func tieBreakersArg(tieBreakers models.TieBreakers) pq.StringArray {
+	if tieBreakers == nil {
+		return pq.StringArray{}
+	}
+	return pq.StringArray(tieBreakers)
+}
+
+// Leaderboards without tags store an empty array rather than NULL
+func tagsArg(tags models.Tags) pq.StringArray {
+	if tags == nil {
+		return pq.StringArray{}
+	}
+	return pq.StringArray(tags)
+}
+
+// Tiers are stored as a JSON array, empty when the leaderboard has no tiers
+func tiersArg(tiers models.Tiers) ([]byte, error) {
+	if tiers == nil {
+		tiers = models.Tiers{}
+	}
+	data, err := json.Marshal(tiers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode leaderboard tiers: %w", err)
+	}
+	return data, nil
+}
+
+// Metadata is stored as a JSON object, empty when there is none
+func metadataArg(metadata models.Metadata) ([]byte, error) {
+	if metadata == nil {
+		metadata = models.Metadata{}
+	}
+	data, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode metadata: %w", err)
+	}
+	return data, nil
+}
+
+// Decodes stored metadata, left nil when there is none
+func decodeMetadata(data []byte) (models.Metadata, error) {
+	var metadata models.Metadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata: %w", err)
+	}
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+	return metadata, nil
+}
+
+// Encodes the metadata and metadata_schema columns of a leaderboard, the schema is NULL when entries are not checked
+func leaderboardMetadataArgs(metadata models.Metadata, schema *models.MetadataSchema) ([]byte, []byte, error) {
+	metadataData, err := metadataArg(metadata)
+	if err != nil {
+		return nil, nil, err
+	}
+	if schema == nil {
+		return metadataData, nil, nil
+	}
+	schemaData, err := json.Marshal(schema)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode metadata schema: %w", err)
+	}
+	return metadataData, schemaData, nil
+}
+
+// Splits the team ranking into the team_aggregate and team_top_k columns, both NULL when disabled
+func teamRankingArgs(ranking *models.TeamRanking) (sql.NullString, *int) {
+	if ranking == nil {
+		return sql.NullString{}, nil
+	}
+	return sql.NullString{String: ranking.Aggregate, Valid: true}, ranking.TopK
+}
+
+// Reads the leaderboard and holds a row lock on it until the transaction ends
+func lockLeaderboard(ctx context.Context, tx *sql.Tx, leaderboardID string) (*models.Leaderboard, error) {
+	var leaderboard models.Leaderboard
+	if err := scanLeaderboard(
+		tx.QueryRowContext(
+			ctx,
+			`SELECT `+leaderboardColumns+` FROM leaderboards WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL FOR UPDATE`,
+			leaderboardID,
+			models.TenantFromContext(ctx),
+		),
+		&leaderboard,
+	); err != nil {
+		log.Printf("Failed to lock leaderboard: %v", err)
+		return nil, fmt.Errorf("failed to lock leaderboard: %w", translateError(err))
+	}
+
+	return &leaderboard, nil
+}
+
+const entryColumns = `
+	id, leaderboard_id, user_id, score, secondary_score, attempt, sort_key, status, COALESCE(flag_reason, ''),
+	COALESCE(proof_url, ''), COALESCE(notes, ''), COALESCE(review_reason, ''), COALESCE(reviewed_by::TEXT, ''),
+	reviewed_at, metadata, version, created_at, updated_at`
+
+func scanEntry(row rowScanner, entry *models.LeaderboardEntry) error {
+	var metadata []byte
+	if err := row.Scan(
+		&entry.ID,
+		&entry.LeaderboardID,
+		&entry.User.ID,
+		&entry.Score,
+		&entry.SecondaryScore,
+		&entry.Attempt,
+		&entry.SortKey,
+		&entry.Status,
+		&entry.FlagReason,
+		&entry.ProofURL,
+		&entry.Notes,
+		&entry.ReviewReason,
+		&entry.ReviewedBy,
+		&entry.ReviewedAt,
+		&metadata,
+		&entry.Version,
+		&entry.CreatedAt,
+		&entry.UpdatedAt,
+	); err != nil {
+		return err
+	}
+
+	var err error
+	entry.Metadata, err = decodeMetadata(metadata)
+	return err
+}
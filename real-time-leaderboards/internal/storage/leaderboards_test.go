@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeaderboardsGetRankedScoresHidesPermanentBans(t *testing.T) {
+	db, fake := newFakeDB(t, fakeStub{
+		contains: "FROM leaderboard_entries e",
+		columns:  []string{"leaderboard_id", "tenant_id", "score", "hidden"},
+		rows:     [][]any{{"1", "default", float64(900), true}},
+	})
+	repo := NewLeaderboardRepoPG(db)
+
+	scores, err := repo.GetRankedScores(context.Background(), "2")
+	assert.NoError(t, err)
+	assert.Len(t, scores, 1)
+	assert.True(t, scores[0].Hidden)
+
+	// Permanent bans have no expiry, the hidden column must still be true for them rather than NULL
+	statements := fake.statements("FROM leaderboard_entries e")
+	assert.Len(t, statements, 1)
+	assert.Contains(t, statements[0].query, ","+userBanned+"\n")
+	assert.Contains(t, statements[0].query, ","+userBanned+" OR u.deleted_at IS NOT NULL")
+	assert.NotContains(t, statements[0].query, "NOT "+userInGoodStanding)
+}
+
+func TestLeaderboardsGetScoreDistributionExtremeScores(t *testing.T) {
+	// Scores at both ends of a BIGINT, the buckets are numbered from the lowest one rather than from 0
+	db, fake := newFakeDB(t, fakeStub{
+		contains: "WITH scores AS",
+		columns:  []string{"count", "min", "max", "mean", "median", "stddev", "width", "from", "bucket", "count"},
+		rows: [][]any{
+			{int64(2), float64(math.MinInt64), float64(math.MaxInt64), float64(0), float64(0), float64(1.3e19), 1.8446744073709552e16, float64(math.MinInt64), int64(0), int64(1)},
+			{int64(2), float64(math.MinInt64), float64(math.MaxInt64), float64(0), float64(0), float64(1.3e19), 1.8446744073709552e16, float64(math.MinInt64), int64(1000), int64(1)},
+		},
+	})
+	repo := NewLeaderboardRepoPG(db)
+
+	distribution, err := repo.GetScoreDistribution(context.Background(), "1", 0)
+	assert.NoError(t, err)
+	assert.Len(t, distribution.Histogram, 1001)
+	assert.Equal(t, float64(math.MinInt64), distribution.Histogram[0].From)
+	assert.Equal(t, int64(1), distribution.Histogram[1000].Count)
+	assert.InDelta(t, float64(math.MaxInt64), distribution.Histogram[1000].From, 1.9e16)
+
+	// Dividing the scores by the width in NUMERIC never overflows, whatever the width
+	statements := fake.statements("WITH scores AS")
+	assert.Len(t, statements, 1)
+	assert.Contains(t, statements[0].query, "FLOOR(sc.score::NUMERIC / o.width::NUMERIC) - o.bucket")
+	assert.NotContains(t, statements[0].query, "FLOOR(sc.score / w.width)::BIGINT")
+}
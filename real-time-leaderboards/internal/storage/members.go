@@ -0,0 +1,272 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+)
+
+// Members of private leaderboards and the invite codes letting users join them
+// Members can be added to leaderboards of any visibility, only private leaderboards require it
+type MemberRepo interface {
+	GetAccess(ctx context.Context, leaderboardID, userID string) (*models.LeaderboardAccess, error)
+	AddMember(ctx context.Context, leaderboardID, userID string) (*models.LeaderboardMember, error)
+	RemoveMember(ctx context.Context, leaderboardID, userID string) error
+	CreateInvite(context.Context, *models.InviteRequest) (*models.LeaderboardInvite, error)
+	Join(ctx context.Context, code, userID string) (*models.LeaderboardMember, error)
+}
+
+type MemberRepoPG struct {
+	db *sql.DB
+}
+
+func NewMemberRepoPG(db *sql.DB) *MemberRepoPG {
+	return &MemberRepoPG{
+		db: db,
+	}
+}
+
+// Visibility of the leaderboard and whether the user is a member, never a member for anonymous users
+// Returns ErrNotFound if the leaderboard does not exist in the tenant of the request
+func (mr *MemberRepoPG) GetAccess(ctx context.Context, leaderboardID, userID string) (*models.LeaderboardAccess, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var access models.LeaderboardAccess
+	if err := mr.db.QueryRowContext(ctx, `
+		SELECT
+			l.visibility
+			,EXISTS (
+				SELECT 1 FROM leaderboard_members m WHERE m.leaderboard_id = l.id AND m.user_id::TEXT = $2
+			)
+		FROM leaderboards l
+		WHERE l.id = $1 AND l.tenant_id = $3 AND l.deleted_at IS NULL`,
+		leaderboardID,
+		userID,
+		models.TenantFromContext(ctx),
+	).Scan(&access.Visibility, &access.Member); err != nil {
+		log.Printf("Failed to get access to leaderboard '%s': %v", leaderboardID, err)
+		return nil, fmt.Errorf("failed to get access to leaderboard '%s': %w", leaderboardID, translateError(err))
+	}
+
+	return &access, nil
+}
+
+// Adds the user to the leaderboard, adding an existing member again is a no-op
+// Returns ErrNotFound if the leaderboard does not exist and ErrInvalidReference if the user does not
+func (mr *MemberRepoPG) AddMember(ctx context.Context, leaderboardID, userID string) (*models.LeaderboardMember, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var member models.LeaderboardMember
+	err := withTx(ctx, mr.db, func(tx *sql.Tx) error {
+		var tenantID string
+		if err := tx.QueryRowContext(
+			ctx,
+			`SELECT tenant_id FROM leaderboards WHERE id = $1 AND tenant_id = $2 AND deleted_at IS NULL FOR SHARE`,
+			leaderboardID,
+			models.TenantFromContext(ctx),
+		).Scan(&tenantID); err != nil {
+			log.Printf("Failed to lock leaderboard '%s': %v", leaderboardID, err)
+			return fmt.Errorf("failed to add member to leaderboard '%s': %w", leaderboardID, translateError(err))
+		}
+
+		return addMember(ctx, tx, tenantID, leaderboardID, userID, &member)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+// Removes the user from the leaderboard, their entries are kept
+// Returns ErrNotFound if the user is not a member
+func (mr *MemberRepoPG) RemoveMember(ctx context.Context, leaderboardID, userID string) error {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := mr.db.ExecContext(ctx, `
+		DELETE FROM leaderboard_members
+		WHERE leaderboard_id = $1 AND `+inTenant("leaderboard_id", "$3")+` AND user_id = $2`,
+		leaderboardID,
+		userID,
+		models.TenantFromContext(ctx),
+	)
+	if err != nil {
+		log.Printf("Failed to remove member '%s' from leaderboard '%s': %v", userID, leaderboardID, err)
+		return fmt.Errorf("failed to remove member from leaderboard '%s': %w", leaderboardID, translateError(err))
+	}
+
+	removed, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("Failed to read removed members: %v", err)
+		return fmt.Errorf("failed to read removed members: %w", err)
+	}
+	if removed == 0 {
+		return fmt.Errorf("failed to remove member '%s' from leaderboard '%s': %w", userID, leaderboardID, ErrNotFound)
+	}
+
+	return nil
+}
+
+// Creates an invite code for the leaderboard, returns ErrNotFound if the leaderboard does not exist
+func (mr *MemberRepoPG) CreateInvite(ctx context.Context, request *models.InviteRequest) (*models.LeaderboardInvite, error) {
+	stmt, err := mr.db.PrepareContext(ctx, `
+		INSERT INTO leaderboard_invites (leaderboard_id, code, created_by, max_uses, expires_at)
+		SELECT l.id, $2, NULLIF($3, '')::BIGINT, $4, $5
+		FROM leaderboards l
+		WHERE l.id = $1 AND l.tenant_id = $6 AND l.deleted_at IS NULL
+		RETURNING `+inviteColumns,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare insert invite statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var invite models.LeaderboardInvite
+	if err := scanInvite(stmt.QueryRowContext(
+		ctx,
+		request.LeaderboardID,
+		request.Code,
+		request.CreatedBy,
+		request.MaxUses,
+		request.ExpiresAt,
+		models.TenantFromContext(ctx),
+	), &invite); err != nil {
+		log.Printf("Failed to insert invite: %v", err)
+		return nil, fmt.Errorf("failed to create invite to leaderboard '%s': %w", request.LeaderboardID, translateError(err))
+	}
+
+	return &invite, nil
+}
+
+// Joins the user to the leaderboard of the invite, members joining again do not use the invite up
+// Expired and used up invites are reported as ErrNotFound, like unknown codes
+func (mr *MemberRepoPG) Join(ctx context.Context, code, userID string) (*models.LeaderboardMember, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var member models.LeaderboardMember
+	err := withTx(ctx, mr.db, func(tx *sql.Tx) error {
+		var inviteID, leaderboardID, tenantID string
+		if err := tx.QueryRowContext(ctx, `
+			SELECT i.id, i.leaderboard_id, l.tenant_id
+			FROM leaderboard_invites i
+			JOIN leaderboards l
+				ON i.leaderboard_id = l.id
+			WHERE i.code = $1
+				AND l.tenant_id = $2
+				AND l.deleted_at IS NULL
+				AND (i.expires_at IS NULL OR i.expires_at > CURRENT_TIMESTAMP)
+				AND (i.max_uses IS NULL OR i.uses < i.max_uses)
+			FOR UPDATE OF i`,
+			code,
+			models.TenantFromContext(ctx),
+		).Scan(&inviteID, &leaderboardID, &tenantID); err != nil {
+			log.Printf("Failed to lock invite: %v", err)
+			return fmt.Errorf("failed to join leaderboard: %w", translateError(err))
+		}
+
+		var alreadyMember bool
+		if err := tx.QueryRowContext(
+			ctx,
+			`SELECT EXISTS (SELECT 1 FROM leaderboard_members WHERE leaderboard_id = $1 AND user_id = $2)`,
+			leaderboardID,
+			userID,
+		).Scan(&alreadyMember); err != nil {
+			log.Printf("Failed to check membership of leaderboard '%s': %v", leaderboardID, err)
+			return fmt.Errorf("failed to join leaderboard: %w", translateError(err))
+		}
+
+		if !alreadyMember {
+			if _, err := tx.ExecContext(ctx, `UPDATE leaderboard_invites SET uses = uses + 1 WHERE id = $1`, inviteID); err != nil {
+				log.Printf("Failed to use invite '%s': %v", inviteID, err)
+				return fmt.Errorf("failed to use invite: %w", translateError(err))
+			}
+		}
+
+		return addMember(ctx, tx, tenantID, leaderboardID, userID, &member)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+// Adds the user to the leaderboard and to the tenant of the leaderboard
+func addMember(ctx context.Context, tx *sql.Tx, tenantID, leaderboardID, userID string, member *models.LeaderboardMember) error {
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO leaderboard_members (leaderboard_id, user_id)
+		VALUES ($1, $2)
+		-- Touches the existing membership so it is returned unchanged
+		ON CONFLICT (leaderboard_id, user_id) DO UPDATE SET user_id = EXCLUDED.user_id
+		RETURNING leaderboard_id, user_id, created_at`,
+		leaderboardID,
+		userID,
+	).Scan(
+		&member.LeaderboardID,
+		&member.UserID,
+		&member.CreatedAt,
+	); err != nil {
+		log.Printf("Failed to add member '%s' to leaderboard '%s': %v", userID, leaderboardID, err)
+		return fmt.Errorf("failed to add member to leaderboard '%s': %w", leaderboardID, translateError(err))
+	}
+
+	return joinTenant(ctx, tx, tenantID, userID)
+}
+
+// Columns read into a models.LeaderboardInvite by scanInvite, in order
+const inviteColumns = `id, leaderboard_id, code, COALESCE(created_by::TEXT, ''), max_uses, uses, expires_at, created_at`
+
+func scanInvite(row rowScanner, invite *models.LeaderboardInvite) error {
+	return row.Scan(
+		&invite.ID,
+		&invite.LeaderboardID,
+		&invite.Code,
+		&invite.CreatedBy,
+		&invite.MaxUses,
+		&invite.Uses,
+		&invite.ExpiresAt,
+		&invite.CreatedAt,
+	)
+}
+
+// Restricts the rows to private leaderboards the user is a member of, and to leaderboards of any other visibility
+// The leaderboard alias and user expression are inlined in the query
+func memberOfLeaderboard(leaderboard, user string) string {
+	return `(` + leaderboard + `.visibility <> 'private' OR EXISTS (
+		SELECT 1 FROM leaderboard_members m WHERE m.leaderboard_id = ` + leaderboard + `.id AND m.user_id = ` + user + `
+	))`
+}
+
+// Returns ErrNotFound if the leaderboard is private and the user is not one of its members
+func ensureMember(ctx context.Context, tx *sql.Tx, leaderboardID, userID string) error {
+	var member bool
+	if err := tx.QueryRowContext(
+		ctx,
+		`SELECT `+memberOfLeaderboard("l", "$2")+` FROM leaderboards l WHERE l.id = $1`,
+		leaderboardID,
+		userID,
+	).Scan(&member); err != nil {
+		log.Printf("Failed to check membership of leaderboard '%s': %v", leaderboardID, err)
+		return fmt.Errorf("failed to check membership of leaderboard '%s': %w", leaderboardID, translateError(err))
+	}
+	if !member {
+		return fmt.Errorf("failed to access private leaderboard '%s': %w", leaderboardID, ErrNotFound)
+	}
+	return nil
+}
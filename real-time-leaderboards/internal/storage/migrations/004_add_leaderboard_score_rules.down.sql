@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS leaderboard_entries_user_idx;
+
+ALTER TABLE leaderboards
+    DROP CONSTRAINT IF EXISTS leaderboards_score_step_check,
+    DROP CONSTRAINT IF EXISTS leaderboards_score_range_check,
+    DROP COLUMN IF EXISTS score_step,
+    DROP COLUMN IF EXISTS min_submission_interval,
+    DROP COLUMN IF EXISTS max_improvement,
+    DROP COLUMN IF EXISTS max_score,
+    DROP COLUMN IF EXISTS min_score;
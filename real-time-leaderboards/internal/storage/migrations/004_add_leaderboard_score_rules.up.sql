@@ -0,0 +1,10 @@
+ALTER TABLE leaderboards
+    ADD COLUMN IF NOT EXISTS min_score INT, -- NULL columns are not enforced
+    ADD COLUMN IF NOT EXISTS max_score INT,
+    ADD COLUMN IF NOT EXISTS max_improvement INT, -- Largest allowed increase over the user's best score
+    ADD COLUMN IF NOT EXISTS min_submission_interval INT, -- Seconds a user must wait between submissions
+    ADD COLUMN IF NOT EXISTS score_step INT, -- Scores must be a multiple of the step, counted from min_score
+    ADD CONSTRAINT leaderboards_score_range_check CHECK (min_score IS NULL OR max_score IS NULL OR min_score <= max_score),
+    ADD CONSTRAINT leaderboards_score_step_check CHECK (score_step IS NULL OR score_step > 0);
+
+CREATE INDEX IF NOT EXISTS leaderboard_entries_user_idx ON leaderboard_entries (leaderboard_id, user_id, created_at DESC);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS leaderboard_score_stats;
+
+DROP INDEX IF EXISTS leaderboard_entries_status_idx;
+
+ALTER TABLE leaderboard_entries
+    DROP COLUMN IF EXISTS flag_reason,
+    DROP COLUMN IF EXISTS status;
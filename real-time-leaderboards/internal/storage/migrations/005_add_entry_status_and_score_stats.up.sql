@@ -0,0 +1,17 @@
+ALTER TABLE leaderboard_entries
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'accepted', -- Only accepted entries are ranked
+    ADD COLUMN IF NOT EXISTS flag_reason TEXT; -- Why the entry was held out of the ranking
+
+CREATE INDEX IF NOT EXISTS leaderboard_entries_status_idx ON leaderboard_entries (leaderboard_id, status);
+
+-- Running distribution of accepted scores, updated incrementally with Welford's algorithm
+CREATE TABLE IF NOT EXISTS leaderboard_score_stats (
+    leaderboard_id BIGINT PRIMARY KEY,
+    count BIGINT NOT NULL DEFAULT 0,
+    mean DOUBLE PRECISION NOT NULL DEFAULT 0,
+    m2 DOUBLE PRECISION NOT NULL DEFAULT 0, -- Sum of squared differences from the mean
+    min_score INT,
+    max_score INT,
+    updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (leaderboard_id) REFERENCES leaderboards(id) ON DELETE CASCADE
+);
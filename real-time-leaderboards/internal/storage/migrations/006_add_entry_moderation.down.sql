@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS leaderboard_entries_queue_idx;
+
+ALTER TABLE leaderboard_entries
+    DROP CONSTRAINT IF EXISTS leaderboard_entries_reviewed_by_fkey,
+    DROP COLUMN IF EXISTS reviewed_at,
+    DROP COLUMN IF EXISTS reviewed_by,
+    DROP COLUMN IF EXISTS review_reason,
+    DROP COLUMN IF EXISTS notes,
+    DROP COLUMN IF EXISTS proof_url;
+
+ALTER TABLE leaderboards
+    DROP COLUMN IF EXISTS requires_verification;
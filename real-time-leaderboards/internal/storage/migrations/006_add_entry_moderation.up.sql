@@ -0,0 +1,14 @@
+ALTER TABLE leaderboards
+    ADD COLUMN IF NOT EXISTS requires_verification BOOLEAN NOT NULL DEFAULT FALSE; -- New entries wait for a moderator
+
+ALTER TABLE leaderboard_entries
+    ADD COLUMN IF NOT EXISTS proof_url TEXT, -- Link to a video or screenshot backing the run
+    ADD COLUMN IF NOT EXISTS notes TEXT,
+    ADD COLUMN IF NOT EXISTS review_reason TEXT,
+    ADD COLUMN IF NOT EXISTS reviewed_by BIGINT,
+    ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMPTZ,
+    ADD CONSTRAINT leaderboard_entries_reviewed_by_fkey FOREIGN KEY (reviewed_by) REFERENCES users (id) ON DELETE SET NULL;
+
+-- Moderation queue is read oldest first across every leaderboard
+CREATE INDEX IF NOT EXISTS leaderboard_entries_queue_idx ON leaderboard_entries (created_at)
+    WHERE status IN ('pending', 'flagged');
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS users_banned_until_idx;
+
+ALTER TABLE users
+    DROP CONSTRAINT IF EXISTS users_banned_by_fkey,
+    DROP CONSTRAINT IF EXISTS users_ban_type_check,
+    DROP COLUMN IF EXISTS banned_at,
+    DROP COLUMN IF EXISTS banned_by,
+    DROP COLUMN IF EXISTS banned_until,
+    DROP COLUMN IF EXISTS ban_reason,
+    DROP COLUMN IF EXISTS ban_type;
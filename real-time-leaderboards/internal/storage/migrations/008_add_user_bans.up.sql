@@ -0,0 +1,11 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS ban_type VARCHAR(20), -- NULL when the user is in good standing
+    ADD COLUMN IF NOT EXISTS ban_reason TEXT,
+    ADD COLUMN IF NOT EXISTS banned_until TIMESTAMPTZ, -- NULL for permanent bans
+    ADD COLUMN IF NOT EXISTS banned_by BIGINT,
+    ADD COLUMN IF NOT EXISTS banned_at TIMESTAMPTZ,
+    ADD CONSTRAINT users_ban_type_check CHECK (ban_type IN ('ban', 'shadowban')),
+    ADD CONSTRAINT users_banned_by_fkey FOREIGN KEY (banned_by) REFERENCES users (id) ON DELETE SET NULL;
+
+-- Used by the expiry job and the admin listing
+CREATE INDEX IF NOT EXISTS users_banned_until_idx ON users (banned_until) WHERE ban_type IS NOT NULL;
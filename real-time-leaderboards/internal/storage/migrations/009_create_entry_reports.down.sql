@@ -0,0 +1,4 @@
+ALTER TABLE leaderboard_entries
+    DROP COLUMN IF EXISTS escalated_at;
+
+DROP TABLE IF EXISTS entry_reports;
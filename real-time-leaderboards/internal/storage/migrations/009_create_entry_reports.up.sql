@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS entry_reports (
+    id BIGSERIAL PRIMARY KEY,
+    entry_id BIGINT NOT NULL REFERENCES leaderboard_entries (id) ON DELETE CASCADE,
+    reporter_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    reason VARCHAR(20) NOT NULL CHECK (reason IN ('cheating', 'impossible_score', 'invalid_proof', 'other')),
+    details TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (entry_id, reporter_id) -- A player can report an entry only once
+);
+
+CREATE INDEX IF NOT EXISTS entry_reports_created_at_idx ON entry_reports (created_at DESC);
+
+-- Set when enough reports moved an accepted entry into the moderation queue
+-- Its score is already part of the distribution, so a later verification must not add it again
+ALTER TABLE leaderboard_entries
+    ADD COLUMN IF NOT EXISTS escalated_at TIMESTAMPTZ;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS entry_change_set_items;
+DROP TABLE IF EXISTS entry_change_sets;
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS entry_change_sets (
+    id BIGSERIAL PRIMARY KEY,
+    leaderboard_id BIGINT NOT NULL REFERENCES leaderboards (id) ON DELETE CASCADE,
+    operation VARCHAR(20) NOT NULL CHECK (operation IN ('delete', 'rescore')),
+    request JSONB NOT NULL, -- Filter and new score of the operation, as submitted
+    entries INT NOT NULL DEFAULT 0,
+    created_by BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    reverted_by BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    reverted_at TIMESTAMPTZ -- NULL until the change set is reverted, it can only be reverted once
+);
+
+CREATE INDEX IF NOT EXISTS entry_change_sets_leaderboard_idx ON entry_change_sets (leaderboard_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS entry_change_set_items (
+    change_set_id BIGINT NOT NULL REFERENCES entry_change_sets (id) ON DELETE CASCADE,
+    entry_id BIGINT NOT NULL, -- No foreign key, deleted entries are restored from the snapshot
+    before JSONB NOT NULL, -- Whole entry row before the change
+    PRIMARY KEY (change_set_id, entry_id)
+);
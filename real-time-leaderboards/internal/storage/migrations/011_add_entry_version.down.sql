@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS leaderboard_entries_bump_version ON leaderboard_entries;
+DROP FUNCTION IF EXISTS leaderboard_entries_bump_version();
+
+ALTER TABLE leaderboard_entries
+    DROP COLUMN IF EXISTS version;
@@ -0,0 +1,15 @@
+-- Optimistic concurrency for entry updates, clients send the version they read in the If-Match header
+ALTER TABLE leaderboard_entries
+    ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+
+-- Every change bumps the version, including moderation, reports and bulk operations
+CREATE OR REPLACE FUNCTION leaderboard_entries_bump_version() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER leaderboard_entries_bump_version
+    BEFORE UPDATE ON leaderboard_entries
+    FOR EACH ROW EXECUTE FUNCTION leaderboard_entries_bump_version();
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS users_deleted_at_idx;
+DROP INDEX IF EXISTS leaderboard_entries_deleted_at_idx;
+DROP INDEX IF EXISTS leaderboards_deleted_at_idx;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE leaderboard_entries
+    DROP COLUMN IF EXISTS deleted_at;
+
+ALTER TABLE leaderboards
+    DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,16 @@
+-- Deleted rows are kept until the retention job purges them, so administrators can restore them
+-- Entries are deleted together with their leaderboard or user and share the same deleted_at,
+-- which tells them apart from entries deleted earlier when restoring
+ALTER TABLE leaderboards
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+ALTER TABLE leaderboard_entries
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- Used by the retention job
+CREATE INDEX IF NOT EXISTS leaderboards_deleted_at_idx ON leaderboards (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS leaderboard_entries_deleted_at_idx ON leaderboard_entries (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS users_deleted_at_idx ON users (deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,3 @@
+-- Erased users keep their row and entries under a placeholder identity, so the rankings stay intact
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS erased_at TIMESTAMPTZ;
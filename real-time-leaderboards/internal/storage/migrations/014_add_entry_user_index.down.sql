@@ -0,0 +1 @@
+DROP INDEX IF EXISTS leaderboard_entries_user_created_idx;
@@ -0,0 +1,3 @@
+-- Profiles read the entries of a single user across every leaderboard
+CREATE INDEX IF NOT EXISTS leaderboard_entries_user_created_idx ON leaderboard_entries (user_id, created_at DESC)
+    WHERE deleted_at IS NULL;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS friendships (
+    requester_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    addressee_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    accepted_at TIMESTAMPTZ,
+    PRIMARY KEY (requester_id, addressee_id),
+    CHECK (requester_id <> addressee_id)
+);
+
+-- A pair of users has a single relationship, whoever asked first
+CREATE UNIQUE INDEX IF NOT EXISTS friendships_pair_idx
+    ON friendships (LEAST(requester_id, addressee_id), GREATEST(requester_id, addressee_id));
+CREATE INDEX IF NOT EXISTS friendships_addressee_idx ON friendships (addressee_id, status);
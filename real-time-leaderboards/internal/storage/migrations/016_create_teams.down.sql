@@ -0,0 +1,7 @@
+ALTER TABLE leaderboards
+    DROP CONSTRAINT IF EXISTS leaderboards_team_top_k_check,
+    DROP COLUMN IF EXISTS team_top_k,
+    DROP COLUMN IF EXISTS team_aggregate;
+
+DROP TABLE IF EXISTS team_members;
+DROP TABLE IF EXISTS teams;
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS teams (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A user belongs to a single team, every team keeps exactly one owner
+CREATE TABLE IF NOT EXISTS team_members (
+    team_id BIGINT NOT NULL REFERENCES teams (id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL UNIQUE REFERENCES users (id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'officer', 'member')),
+    joined_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (team_id, user_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS team_members_owner_idx ON team_members (team_id) WHERE role = 'owner';
+
+-- Leaderboards with a team aggregate also rank teams by the best scores of their members
+ALTER TABLE leaderboards
+    ADD COLUMN IF NOT EXISTS team_aggregate VARCHAR(20) CHECK (team_aggregate IN ('sum', 'average', 'top_k')),
+    ADD COLUMN IF NOT EXISTS team_top_k INTEGER CHECK (team_top_k > 0),
+    ADD CONSTRAINT leaderboards_team_top_k_check CHECK ((team_aggregate IS DISTINCT FROM 'top_k') = (team_top_k IS NULL));
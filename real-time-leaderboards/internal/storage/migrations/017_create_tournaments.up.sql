@@ -0,0 +1,47 @@
+CREATE TABLE IF NOT EXISTS tournaments (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    leaderboard_id BIGINT NOT NULL REFERENCES leaderboards (id) ON DELETE CASCADE,
+    format VARCHAR(30) NOT NULL CHECK (format IN ('single_elimination', 'double_elimination', 'round_robin')),
+    size INTEGER NOT NULL CHECK (size >= 2),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'running', 'completed')),
+    winner_id BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS tournaments_leaderboard_idx ON tournaments (leaderboard_id);
+
+-- Qualifiers are taken from the leaderboard ranking when the tournament starts and never change after
+CREATE TABLE IF NOT EXISTS tournament_participants (
+    tournament_id BIGINT NOT NULL REFERENCES tournaments (id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    seed INTEGER NOT NULL CHECK (seed > 0),
+    qualifying_score INTEGER NOT NULL,
+    PRIMARY KEY (tournament_id, user_id),
+    UNIQUE (tournament_id, seed)
+);
+
+-- Matches are numbered within their tournament, winners and losers advance to the linked match slots
+-- Empty players of a completed match are byes
+CREATE TABLE IF NOT EXISTS tournament_matches (
+    tournament_id BIGINT NOT NULL REFERENCES tournaments (id) ON DELETE CASCADE,
+    number INTEGER NOT NULL CHECK (number >= 0),
+    bracket VARCHAR(20) NOT NULL CHECK (bracket IN ('winners', 'losers', 'grand_final', 'round_robin')),
+    round INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    player1_id BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    player2_id BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    player1_score INTEGER,
+    player2_score INTEGER,
+    winner_id BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'ready', 'completed')),
+    next_match INTEGER,
+    next_slot SMALLINT CHECK (next_slot IN (1, 2)),
+    loser_match INTEGER,
+    loser_slot SMALLINT CHECK (loser_slot IN (1, 2)),
+    completed_at TIMESTAMPTZ,
+    PRIMARY KEY (tournament_id, number)
+);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS rating_history;
+DROP TABLE IF EXISTS rating_matches;
+DROP TABLE IF EXISTS player_ratings;
+
+ALTER TABLE leaderboards
+    DROP CONSTRAINT IF EXISTS leaderboards_rating_team_check,
+    DROP CONSTRAINT IF EXISTS leaderboards_rating_system_check,
+    DROP COLUMN IF EXISTS rating_system,
+    DROP COLUMN IF EXISTS type;
@@ -0,0 +1,49 @@
+-- Rating leaderboards rank players by the results of their matches instead of submitted entries
+ALTER TABLE leaderboards
+    ADD COLUMN IF NOT EXISTS type VARCHAR(20) NOT NULL DEFAULT 'score' CHECK (type IN ('score', 'rating')),
+    ADD COLUMN IF NOT EXISTS rating_system VARCHAR(20) CHECK (rating_system IN ('elo', 'glicko2')),
+    ADD CONSTRAINT leaderboards_rating_system_check CHECK ((type = 'rating') = (rating_system IS NOT NULL)),
+    ADD CONSTRAINT leaderboards_rating_team_check CHECK (type = 'score' OR team_aggregate IS NULL);
+
+-- Current rating of every player who played on a rating leaderboard, Elo ratings keep no deviation
+CREATE TABLE IF NOT EXISTS player_ratings (
+    leaderboard_id BIGINT NOT NULL REFERENCES leaderboards (id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    rating DOUBLE PRECISION NOT NULL,
+    deviation DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (deviation >= 0),
+    volatility DOUBLE PRECISION NOT NULL DEFAULT 0 CHECK (volatility >= 0),
+    matches INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (leaderboard_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS player_ratings_user_idx ON player_ratings (user_id);
+
+CREATE TABLE IF NOT EXISTS rating_matches (
+    id BIGSERIAL PRIMARY KEY,
+    leaderboard_id BIGINT NOT NULL REFERENCES leaderboards (id) ON DELETE CASCADE,
+    player1_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    player2_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    winner_id BIGINT REFERENCES users (id) ON DELETE CASCADE,
+    draw BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (player1_id <> player2_id),
+    CHECK (draw = (winner_id IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS rating_matches_leaderboard_idx ON rating_matches (leaderboard_id, created_at);
+
+-- Rating of each player after every match they played
+CREATE TABLE IF NOT EXISTS rating_history (
+    match_id BIGINT NOT NULL REFERENCES rating_matches (id) ON DELETE CASCADE,
+    leaderboard_id BIGINT NOT NULL REFERENCES leaderboards (id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    rating_before DOUBLE PRECISION NOT NULL,
+    rating DOUBLE PRECISION NOT NULL,
+    deviation DOUBLE PRECISION NOT NULL,
+    volatility DOUBLE PRECISION NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (match_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS rating_history_player_idx ON rating_history (leaderboard_id, user_id, created_at);
@@ -0,0 +1,15 @@
+DROP TRIGGER IF EXISTS leaderboard_entries_sort_key ON leaderboard_entries;
+DROP FUNCTION IF EXISTS set_entry_sort_key();
+DROP FUNCTION IF EXISTS entry_sort_key(INTEGER, INTEGER, INTEGER, INTEGER, TEXT[]);
+DROP INDEX IF EXISTS leaderboard_entries_sort_key_idx;
+
+ALTER TABLE leaderboard_entries
+    DROP COLUMN IF EXISTS sort_key,
+    DROP COLUMN IF EXISTS tie_sequence,
+    DROP COLUMN IF EXISTS attempt,
+    DROP COLUMN IF EXISTS secondary_score;
+
+ALTER TABLE leaderboards
+    DROP CONSTRAINT IF EXISTS leaderboards_rating_tie_breakers_check,
+    DROP CONSTRAINT IF EXISTS leaderboards_tie_breakers_check,
+    DROP COLUMN IF EXISTS tie_breakers;
@@ -0,0 +1,107 @@
+-- Ordered tie-breakers deciding between equal scores, at most one of the secondary metric directions
+ALTER TABLE leaderboards
+    ADD COLUMN IF NOT EXISTS tie_breakers TEXT[] NOT NULL DEFAULT '{}',
+    ADD CONSTRAINT leaderboards_tie_breakers_check CHECK (
+        tie_breakers <@ ARRAY['earliest_submission', 'fewest_attempts', 'highest_secondary', 'lowest_secondary']
+        AND cardinality(tie_breakers) <= 3
+    );
+
+-- attempt counts the submissions of the user on the leaderboard, tie_sequence the earlier entries with the same score
+ALTER TABLE leaderboard_entries
+    ADD COLUMN IF NOT EXISTS secondary_score INTEGER,
+    ADD COLUMN IF NOT EXISTS attempt INTEGER,
+    ADD COLUMN IF NOT EXISTS tie_sequence INTEGER,
+    ADD COLUMN IF NOT EXISTS sort_key DOUBLE PRECISION;
+
+UPDATE leaderboard_entries e
+SET attempt = n.attempt, tie_sequence = n.tie_sequence, sort_key = e.score
+FROM (
+    SELECT
+        id
+        ,ROW_NUMBER() OVER (PARTITION BY leaderboard_id, user_id ORDER BY created_at, id) AS attempt
+        ,ROW_NUMBER() OVER (PARTITION BY leaderboard_id, score ORDER BY created_at, id) - 1 AS tie_sequence
+    FROM leaderboard_entries
+) n
+WHERE e.id = n.id;
+
+ALTER TABLE leaderboard_entries
+    ALTER COLUMN attempt SET NOT NULL,
+    ALTER COLUMN tie_sequence SET NOT NULL,
+    ALTER COLUMN sort_key SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS leaderboard_entries_sort_key_idx ON leaderboard_entries (leaderboard_id, sort_key DESC);
+
+-- Encodes the score and its tie-breakers into a single value used as the Redis ranking score and to order
+-- entries in Postgres, so both stores rank the same way
+-- The score is the integer part and the tie-breakers fill the 21 fractional bits a double keeps exactly for any
+-- INTEGER score, split evenly between them in order. Values that do not fit their bits saturate and tie
+CREATE OR REPLACE FUNCTION entry_sort_key(
+    score INTEGER,
+    secondary_score INTEGER,
+    attempt INTEGER,
+    tie_sequence INTEGER,
+    tie_breakers TEXT[]
+) RETURNS DOUBLE PRECISION
+LANGUAGE plpgsql IMMUTABLE AS $$
+DECLARE
+    total_bits CONSTANT INTEGER := 21;
+    breakers INTEGER := cardinality(tie_breakers);
+    tie BIGINT := 0;
+    width INTEGER;
+    max_value BIGINT;
+    component BIGINT;
+BEGIN
+    IF breakers = 0 THEN
+        RETURN score;
+    END IF;
+
+    FOR i IN 1..breakers LOOP
+        width := total_bits / breakers + CASE WHEN i <= total_bits % breakers THEN 1 ELSE 0 END;
+        max_value := (1::BIGINT << width) - 1;
+        component := CASE tie_breakers[i]
+            WHEN 'earliest_submission' THEN max_value - LEAST(tie_sequence, max_value)
+            WHEN 'fewest_attempts' THEN max_value - LEAST(attempt - 1, max_value)
+            WHEN 'highest_secondary' THEN LEAST(GREATEST(COALESCE(secondary_score, 0), 0), max_value)
+            WHEN 'lowest_secondary' THEN
+                CASE WHEN secondary_score IS NULL THEN 0 ELSE max_value - LEAST(GREATEST(secondary_score, 0), max_value) END
+        END;
+        tie := (tie << width) | component;
+    END LOOP;
+
+    RETURN score + tie::DOUBLE PRECISION / (1::BIGINT << total_bits);
+END;
+$$;
+
+-- Entries restored from a change set snapshot keep their attempt and tie sequence
+CREATE OR REPLACE FUNCTION set_entry_sort_key() RETURNS TRIGGER
+LANGUAGE plpgsql AS $$
+BEGIN
+    IF NEW.attempt IS NULL THEN
+        SELECT COUNT(*) + 1 INTO NEW.attempt
+        FROM leaderboard_entries
+        WHERE leaderboard_id = NEW.leaderboard_id AND user_id = NEW.user_id AND id <> NEW.id;
+    END IF;
+
+    IF NEW.tie_sequence IS NULL OR (TG_OP = 'UPDATE' AND NEW.score IS DISTINCT FROM OLD.score) THEN
+        SELECT COUNT(*) INTO NEW.tie_sequence
+        FROM leaderboard_entries
+        WHERE leaderboard_id = NEW.leaderboard_id
+            AND score = NEW.score
+            AND (created_at, id) < (NEW.created_at, NEW.id);
+    END IF;
+
+    SELECT entry_sort_key(NEW.score, NEW.secondary_score, NEW.attempt, NEW.tie_sequence, l.tie_breakers)
+    INTO NEW.sort_key
+    FROM leaderboards l
+    WHERE l.id = NEW.leaderboard_id;
+
+    RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER leaderboard_entries_sort_key
+    BEFORE INSERT OR UPDATE OF score, secondary_score ON leaderboard_entries
+    FOR EACH ROW EXECUTE FUNCTION set_entry_sort_key();
+
+ALTER TABLE leaderboards
+    ADD CONSTRAINT leaderboards_rating_tie_breakers_check CHECK (type = 'score' OR cardinality(tie_breakers) = 0);
@@ -0,0 +1,3 @@
+ALTER TABLE leaderboards
+    DROP CONSTRAINT IF EXISTS leaderboards_rank_mode_check,
+    DROP COLUMN IF EXISTS rank_mode;
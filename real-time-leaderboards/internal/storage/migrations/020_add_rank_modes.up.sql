@@ -0,0 +1,4 @@
+-- How ranks are numbered between tied members, competition (1, 2, 2, 4) unless configured otherwise
+ALTER TABLE leaderboards
+    ADD COLUMN IF NOT EXISTS rank_mode TEXT NOT NULL DEFAULT 'competition',
+    ADD CONSTRAINT leaderboards_rank_mode_check CHECK (rank_mode IN ('competition', 'dense', 'ordinal'));
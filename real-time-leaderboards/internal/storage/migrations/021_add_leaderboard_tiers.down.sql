@@ -0,0 +1,3 @@
+ALTER TABLE leaderboards
+    DROP CONSTRAINT IF EXISTS leaderboards_tiers_check,
+    DROP COLUMN IF EXISTS tiers;
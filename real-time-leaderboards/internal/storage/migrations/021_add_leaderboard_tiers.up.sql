@@ -0,0 +1,4 @@
+-- Tiers reached by the players within the top percent of the ranking, declared from the best one
+ALTER TABLE leaderboards
+    ADD COLUMN IF NOT EXISTS tiers JSONB NOT NULL DEFAULT '[]',
+    ADD CONSTRAINT leaderboards_tiers_check CHECK (jsonb_typeof(tiers) = 'array' AND jsonb_array_length(tiers) <= 10);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS leaderboard_entries_variables_idx;
+ALTER TABLE leaderboard_entries DROP COLUMN IF EXISTS variables;
+
+DROP INDEX IF EXISTS leaderboards_category_idx;
+ALTER TABLE leaderboards DROP COLUMN IF EXISTS category_id;
+
+DROP TABLE IF EXISTS category_variables;
+DROP TABLE IF EXISTS game_categories;
+DROP TABLE IF EXISTS games;
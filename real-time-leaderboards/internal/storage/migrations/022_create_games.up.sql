@@ -0,0 +1,41 @@
+-- Games group the categories their runs are ranked in, every category is ranked by its own leaderboard
+CREATE TABLE IF NOT EXISTS games (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS games_name_idx ON games (lower(name));
+
+CREATE TABLE IF NOT EXISTS game_categories (
+    id BIGSERIAL PRIMARY KEY,
+    game_id BIGINT NOT NULL REFERENCES games (id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (game_id, name)
+);
+
+-- Variables split the ranking of a category into variants, such as platform, version or region
+CREATE TABLE IF NOT EXISTS category_variables (
+    id BIGSERIAL PRIMARY KEY,
+    category_id BIGINT NOT NULL REFERENCES game_categories (id) ON DELETE CASCADE,
+    name VARCHAR(30) NOT NULL,
+    allowed_values TEXT[] NOT NULL CHECK (cardinality(allowed_values) BETWEEN 1 AND 50),
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (category_id, name)
+);
+
+-- A category is ranked by a single leaderboard, deleted leaderboards free the category for a new one
+ALTER TABLE leaderboards
+    ADD COLUMN IF NOT EXISTS category_id BIGINT REFERENCES game_categories (id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS leaderboards_category_idx ON leaderboards (category_id)
+    WHERE category_id IS NOT NULL AND deleted_at IS NULL;
+
+-- Variable values of the run, checked against the variables of the leaderboard category when submitted
+ALTER TABLE leaderboard_entries
+    ADD COLUMN IF NOT EXISTS variables JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS leaderboard_entries_variables_idx ON leaderboard_entries USING GIN (variables);
@@ -0,0 +1,7 @@
+DROP TABLE IF EXISTS tenant_api_keys;
+DROP TABLE IF EXISTS tenant_members;
+
+DROP INDEX IF EXISTS leaderboards_tenant_idx;
+ALTER TABLE leaderboards DROP COLUMN IF EXISTS tenant_id;
+
+DROP TABLE IF EXISTS tenants;
@@ -0,0 +1,44 @@
+-- Tenants are the games or projects hosted by the deployment, each owning its leaderboards
+-- Everything created before tenants were introduced belongs to the default tenant
+CREATE TABLE IF NOT EXISTS tenants (
+    id BIGSERIAL PRIMARY KEY,
+    slug VARCHAR(50) NOT NULL UNIQUE CHECK (slug ~ '^[a-z0-9][a-z0-9-]*$'),
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO tenants (id, slug, name) VALUES (1, 'default', 'Default') ON CONFLICT (id) DO NOTHING;
+SELECT setval(pg_get_serial_sequence('tenants', 'id'), GREATEST((SELECT MAX(id) FROM tenants), 1));
+
+ALTER TABLE leaderboards
+    ADD COLUMN IF NOT EXISTS tenant_id BIGINT NOT NULL DEFAULT 1 REFERENCES tenants (id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS leaderboards_tenant_idx ON leaderboards (tenant_id);
+
+-- Accounts are shared by every tenant, members play in the tenant and admins administer its leaderboards
+CREATE TABLE IF NOT EXISTS tenant_members (
+    tenant_id BIGINT NOT NULL REFERENCES tenants (id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL DEFAULT 'player' CHECK (role IN ('player', 'admin')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tenant_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS tenant_members_user_idx ON tenant_members (user_id);
+
+INSERT INTO tenant_members (tenant_id, user_id)
+SELECT 1, id FROM users
+ON CONFLICT DO NOTHING;
+
+-- Requests name their tenant with an API key, only a hash of the key is stored
+CREATE TABLE IF NOT EXISTS tenant_api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL REFERENCES tenants (id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    prefix VARCHAR(12) NOT NULL,
+    key_hash CHAR(64) NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS tenant_api_keys_tenant_idx ON tenant_api_keys (tenant_id);
@@ -0,0 +1,5 @@
+ALTER TABLE leaderboard_entries DROP COLUMN IF EXISTS metadata;
+
+ALTER TABLE leaderboards
+    DROP COLUMN IF EXISTS metadata_schema,
+    DROP COLUMN IF EXISTS metadata;
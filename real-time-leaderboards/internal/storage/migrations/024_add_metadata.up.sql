@@ -0,0 +1,8 @@
+-- Free-form configuration of the leaderboard, and the optional JSON Schema the metadata of its entries must match
+ALTER TABLE leaderboards
+    ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS metadata_schema JSONB;
+
+-- Details of the run such as the character, loadout, replay id or build version
+ALTER TABLE leaderboard_entries
+    ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS leaderboard_invites;
+DROP TABLE IF EXISTS leaderboard_members;
+
+ALTER TABLE leaderboards DROP COLUMN IF EXISTS visibility;
@@ -0,0 +1,28 @@
+-- Public leaderboards are listed and readable by anyone, unlisted ones are only readable by whoever knows their id
+-- Private leaderboards are readable and writable by their members only
+ALTER TABLE leaderboards
+    ADD COLUMN IF NOT EXISTS visibility VARCHAR(10) NOT NULL DEFAULT 'public'
+        CHECK (visibility IN ('public', 'unlisted', 'private'));
+
+CREATE TABLE IF NOT EXISTS leaderboard_members (
+    leaderboard_id BIGINT NOT NULL REFERENCES leaderboards (id) ON DELETE CASCADE,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (leaderboard_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS leaderboard_members_user_idx ON leaderboard_members (user_id);
+
+-- Invite codes let users join a leaderboard on their own, up to max_uses times and until expires_at when set
+CREATE TABLE IF NOT EXISTS leaderboard_invites (
+    id BIGSERIAL PRIMARY KEY,
+    leaderboard_id BIGINT NOT NULL REFERENCES leaderboards (id) ON DELETE CASCADE,
+    code VARCHAR(32) NOT NULL UNIQUE,
+    created_by BIGINT REFERENCES users (id) ON DELETE SET NULL,
+    max_uses INTEGER CHECK (max_uses > 0),
+    uses INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS leaderboard_invites_leaderboard_idx ON leaderboard_invites (leaderboard_id);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS leaderboards_listing_idx;
+DROP INDEX IF EXISTS leaderboards_search_idx;
+DROP INDEX IF EXISTS leaderboards_tags_idx;
+
+ALTER TABLE leaderboards
+    DROP COLUMN IF EXISTS search_vector,
+    DROP COLUMN IF EXISTS tags;
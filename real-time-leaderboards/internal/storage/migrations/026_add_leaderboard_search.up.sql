@@ -0,0 +1,14 @@
+-- Tags group leaderboards for discovery, such as the platform or the season they belong to
+ALTER TABLE leaderboards
+    ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+-- Full-text search on the name and description, names weigh more in the ranking of the results
+ALTER TABLE leaderboards
+    ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', COALESCE(name, '')), 'A') ||
+        setweight(to_tsvector('english', COALESCE(description, '')), 'B')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS leaderboards_tags_idx ON leaderboards USING GIN (tags);
+CREATE INDEX IF NOT EXISTS leaderboards_search_idx ON leaderboards USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS leaderboards_listing_idx ON leaderboards (tenant_id, created_at DESC) WHERE deleted_at IS NULL;
@@ -0,0 +1,7 @@
+DROP FUNCTION IF EXISTS audit_redact_user(JSONB, TEXT);
+
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
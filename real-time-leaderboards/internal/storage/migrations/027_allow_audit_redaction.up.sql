@@ -0,0 +1,26 @@
+-- The audit log stays append-only, except for erasures redacting the personal data held in its snapshots
+-- Redactions are enabled for the current transaction with SET LOCAL audit_log.redacting = 'on',
+-- and can only change the before and after snapshots of a record
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE'
+        AND current_setting('audit_log.redacting', TRUE) = 'on'
+        AND (NEW.id, NEW.actor_id, NEW.actor_role, NEW.action, NEW.target_type, NEW.target_id, NEW.request_id, NEW.created_at)
+            IS NOT DISTINCT FROM
+            (OLD.id, OLD.actor_id, OLD.actor_role, OLD.action, OLD.target_type, OLD.target_id, OLD.request_id, OLD.created_at)
+    THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+-- Replaces the username and email of a user snapshot, keys missing from the snapshot are not added
+CREATE OR REPLACE FUNCTION audit_redact_user(snapshot JSONB, placeholder TEXT) RETURNS JSONB AS $$
+    SELECT CASE
+        WHEN jsonb_typeof(snapshot) IS DISTINCT FROM 'object' THEN snapshot
+        ELSE snapshot
+            || CASE WHEN snapshot ? 'username' THEN jsonb_build_object('username', placeholder) ELSE '{}'::JSONB END
+            || CASE WHEN snapshot ? 'email' THEN jsonb_build_object('email', '') ELSE '{}'::JSONB END
+    END
+$$ LANGUAGE SQL IMMUTABLE;
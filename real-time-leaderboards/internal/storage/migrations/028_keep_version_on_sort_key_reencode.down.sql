@@ -0,0 +1,6 @@
+CREATE OR REPLACE FUNCTION leaderboard_entries_bump_version() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
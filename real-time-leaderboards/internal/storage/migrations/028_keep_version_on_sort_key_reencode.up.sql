@@ -0,0 +1,12 @@
+-- Re-encoding the sort keys when the tie-breakers of a leaderboard change is not a change clients can see,
+-- so updates touching nothing but the sort key keep the version and the If-Match of clients stays valid
+CREATE OR REPLACE FUNCTION leaderboard_entries_bump_version() RETURNS TRIGGER AS $$
+BEGIN
+    IF to_jsonb(NEW) - 'sort_key' = to_jsonb(OLD) - 'sort_key' THEN
+        RETURN NEW;
+    END IF;
+
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,333 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+)
+
+// Player ratings of rating leaderboards, updated from the results of head-to-head matches
+type RatingRepo interface {
+	RecordMatch(context.Context, *models.RatingMatchRequest) (*models.RatingMatch, error)
+	GetRatings(context.Context, string, []string) ([]models.PlayerRating, error)
+	GetHistory(context.Context, string, string, *models.Pagination) ([]models.RatingChange, error)
+}
+
+type RatingRepoPG struct {
+	db *sql.DB
+}
+
+func NewRatingRepoPG(db *sql.DB) *RatingRepoPG {
+	return &RatingRepoPG{
+		db: db,
+	}
+}
+
+// Rating players are ranked by, uses the alias r for player_ratings and takes the number of deviations as $2
+const conservativeRating = `r.rating - $2 * r.deviation`
+
+const playerRatingColumns = `r.user_id, u.username, r.rating, r.deviation, r.volatility, r.matches, r.updated_at`
+
+// Rates both players with the system of the leaderboard and records the match in their rating history
+// Players without a rating yet start from the initial rating of the system
+// Returns ErrStateConflict if the leaderboard ranks scores, and ErrInvalidReference if a player does not exist
+func (rr *RatingRepoPG) RecordMatch(ctx context.Context, request *models.RatingMatchRequest) (*models.RatingMatch, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var match models.RatingMatch
+	err := withTx(ctx, rr.db, func(tx *sql.Tx) error {
+		// Holds off the deletion of the leaderboard until the match is recorded
+		var leaderboardType, system string
+		if err := tx.QueryRowContext(ctx, `
+			SELECT type, COALESCE(rating_system, '')
+			FROM leaderboards
+			WHERE id = $1 AND deleted_at IS NULL
+			FOR SHARE`,
+			request.LeaderboardID,
+		).Scan(&leaderboardType, &system); err != nil {
+			log.Printf("Failed to lock leaderboard of the match: %v", err)
+			return fmt.Errorf("failed to record match: %w", translateError(err))
+		}
+		if leaderboardType != models.LeaderboardTypeRating {
+			return fmt.Errorf("failed to record match on score leaderboard '%s': %w", request.LeaderboardID, ErrStateConflict)
+		}
+
+		initial := models.NewPlayerRating(system, "")
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO player_ratings (leaderboard_id, user_id, rating, deviation, volatility)
+			SELECT $1, u.id, $4, $5, $6
+			FROM users u
+			WHERE u.id IN ($2, $3) AND u.deleted_at IS NULL
+			ON CONFLICT (leaderboard_id, user_id) DO NOTHING`,
+			request.LeaderboardID,
+			request.Player1ID,
+			request.Player2ID,
+			initial.Rating,
+			initial.Deviation,
+			initial.Volatility,
+		); err != nil {
+			log.Printf("Failed to insert initial player ratings: %v", err)
+			return fmt.Errorf("failed to insert initial player ratings: %w", translateError(err))
+		}
+
+		// Rows are locked in the same order by every match, so concurrent matches cannot deadlock
+		ratings, err := lockRatings(ctx, tx, request)
+		if err != nil {
+			return err
+		}
+		player1, ok1 := ratings[request.Player1ID]
+		player2, ok2 := ratings[request.Player2ID]
+		if !ok1 || !ok2 {
+			return fmt.Errorf("failed to record match between '%s' and '%s': %w", request.Player1ID, request.Player2ID, ErrInvalidReference)
+		}
+
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO rating_matches (leaderboard_id, player1_id, player2_id, winner_id, draw)
+			VALUES ($1, $2, $3, NULLIF($4, '')::BIGINT, $5)
+			RETURNING id, leaderboard_id, player1_id, player2_id, COALESCE(winner_id::TEXT, ''), draw, created_at`,
+			request.LeaderboardID,
+			request.Player1ID,
+			request.Player2ID,
+			request.WinnerID,
+			request.Draw,
+		).Scan(
+			&match.ID,
+			&match.LeaderboardID,
+			&match.Player1ID,
+			&match.Player2ID,
+			&match.WinnerID,
+			&match.Draw,
+			&match.CreatedAt,
+		); err != nil {
+			log.Printf("Failed to insert rating match: %v", err)
+			return fmt.Errorf("failed to record match: %w", translateError(err))
+		}
+
+		// Both players are rated against the rating of their opponent from before the match
+		for _, pair := range [][2]models.PlayerRating{{player1, player2}, {player2, player1}} {
+			player, opponent := pair[0], pair[1]
+			rated := models.RateMatch(system, player, opponent, request.ScoreOf(player.User.ID))
+
+			change := models.RatingChange{
+				MatchID:      match.ID,
+				UserID:       player.User.ID,
+				RatingBefore: player.Rating,
+				Rating:       rated.Rating,
+				Deviation:    rated.Deviation,
+				Volatility:   rated.Volatility,
+				CreatedAt:    match.CreatedAt,
+			}
+			if err := updateRating(ctx, tx, match.LeaderboardID, rated, change); err != nil {
+				return err
+			}
+			match.Changes = append(match.Changes, change)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &match, nil
+}
+
+func lockRatings(ctx context.Context, tx *sql.Tx, request *models.RatingMatchRequest) (map[string]models.PlayerRating, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT `+playerRatingColumns+`
+		FROM player_ratings r
+		JOIN users u
+			ON r.user_id = u.id
+		WHERE r.leaderboard_id = $1
+			AND r.user_id IN ($2, $3)
+			AND u.deleted_at IS NULL
+		ORDER BY r.user_id
+		FOR UPDATE OF r`,
+		request.LeaderboardID,
+		request.Player1ID,
+		request.Player2ID,
+	)
+	if err != nil {
+		log.Printf("Failed to lock player ratings: %v", err)
+		return nil, fmt.Errorf("failed to lock player ratings: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	ratings := make(map[string]models.PlayerRating, 2)
+	for rows.Next() {
+		var rating models.PlayerRating
+		if err := scanPlayerRating(rows, &rating); err != nil {
+			log.Printf("Failed to scan player rating: %v", err)
+			return nil, fmt.Errorf("failed to scan player rating: %w", err)
+		}
+		ratings[rating.User.ID] = rating
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan player ratings: %v", err)
+		return nil, fmt.Errorf("failed to scan player ratings: %w", err)
+	}
+
+	return ratings, nil
+}
+
+func updateRating(ctx context.Context, tx *sql.Tx, leaderboardID string, rated models.PlayerRating, change models.RatingChange) error {
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE player_ratings
+		SET rating = $3, deviation = $4, volatility = $5, matches = $6, updated_at = $7
+		WHERE leaderboard_id = $1 AND user_id = $2`,
+		leaderboardID,
+		rated.User.ID,
+		rated.Rating,
+		rated.Deviation,
+		rated.Volatility,
+		rated.Matches,
+		change.CreatedAt,
+	); err != nil {
+		log.Printf("Failed to update player rating: %v", err)
+		return fmt.Errorf("failed to update rating of player '%s': %w", rated.User.ID, translateError(err))
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO rating_history (
+			match_id, leaderboard_id, user_id, rating_before, rating, deviation, volatility, created_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		change.MatchID,
+		leaderboardID,
+		change.UserID,
+		change.RatingBefore,
+		change.Rating,
+		change.Deviation,
+		change.Volatility,
+		change.CreatedAt,
+	); err != nil {
+		log.Printf("Failed to insert rating history: %v", err)
+		return fmt.Errorf("failed to insert rating history of player '%s': %w", change.UserID, translateError(err))
+	}
+
+	return nil
+}
+
+// Returns the ratings of the given players on the leaderboard, players without a rating are left out
+func (rr *RatingRepoPG) GetRatings(ctx context.Context, leaderboardID string, userIDs []string) ([]models.PlayerRating, error) {
+	stmt, err := rr.db.PrepareContext(ctx, `
+		SELECT `+playerRatingColumns+`
+		FROM player_ratings r
+		JOIN users u
+			ON r.user_id = u.id
+		WHERE r.leaderboard_id = $1
+			AND r.user_id::TEXT = ANY($2)`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare get ratings statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := stmt.QueryContext(ctx, leaderboardID, pq.Array(userIDs))
+	if err != nil {
+		log.Printf("Failed to query player ratings: %v", err)
+		return nil, fmt.Errorf("failed to get player ratings: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	ratings := make([]models.PlayerRating, 0, len(userIDs))
+	for rows.Next() {
+		var rating models.PlayerRating
+		if err := scanPlayerRating(rows, &rating); err != nil {
+			log.Printf("Failed to scan player rating: %v", err)
+			return nil, fmt.Errorf("failed to scan player rating: %w", err)
+		}
+		ratings = append(ratings, rating)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan player ratings: %v", err)
+		return nil, fmt.Errorf("failed to scan player ratings: %w", err)
+	}
+
+	return ratings, nil
+}
+
+// Returns the rating of the player after each of their matches on the leaderboard, most recent first
+func (rr *RatingRepoPG) GetHistory(
+	ctx context.Context,
+	leaderboardID, userID string,
+	page *models.Pagination,
+) ([]models.RatingChange, error) {
+	stmt, err := rr.db.PrepareContext(ctx, `
+		SELECT match_id, user_id, rating_before, rating, deviation, volatility, created_at
+		FROM rating_history
+		WHERE leaderboard_id = $1 AND user_id = $2
+		ORDER BY created_at DESC, match_id DESC
+		LIMIT $3 OFFSET $4`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare rating history statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := stmt.QueryContext(ctx, leaderboardID, userID, page.Limit, page.Offset)
+	if err != nil {
+		log.Printf("Failed to query rating history: %v", err)
+		return nil, fmt.Errorf("failed to get rating history: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	history := make([]models.RatingChange, 0)
+	for rows.Next() {
+		var change models.RatingChange
+		if err := rows.Scan(
+			&change.MatchID,
+			&change.UserID,
+			&change.RatingBefore,
+			&change.Rating,
+			&change.Deviation,
+			&change.Volatility,
+			&change.CreatedAt,
+		); err != nil {
+			log.Printf("Failed to scan rating change: %v", err)
+			return nil, fmt.Errorf("failed to scan rating change: %w", err)
+		}
+		history = append(history, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan rating history: %v", err)
+		return nil, fmt.Errorf("failed to scan rating history: %w", err)
+	}
+
+	return history, nil
+}
+
+func scanPlayerRating(row rowScanner, rating *models.PlayerRating) error {
+	if err := row.Scan(
+		&rating.User.ID,
+		&rating.User.Username,
+		&rating.Rating,
+		&rating.Deviation,
+		&rating.Volatility,
+		&rating.Matches,
+		&rating.UpdatedAt,
+	); err != nil {
+		return err
+	}
+
+	rating.ConservativeRating = rating.Conservative()
+	return nil
+}
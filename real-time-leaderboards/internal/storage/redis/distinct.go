@@ -0,0 +1,75 @@
+package cache
+
+import (
+	redis "github.com/go-redis/redis/v8"
+)
+
+// Every sorted set written by the service keeps its distinct scores next to it: a sorted set holding each
+// score once, and a hash counting the members on each score so a score is dropped with its last member
+// Scores are tracked with the string Redis replies for them, so they never depend on how Go formats floats
+func distinctKeys(key string) []string {
+	return []string{key, key + ":distinct", key + ":distinct:counts"}
+}
+
+const distinctHelpers = `
+local function track(score)
+	redis.call('HINCRBY', KEYS[3], score, 1)
+	redis.call('ZADD', KEYS[2], score, score)
+end
+
+local function untrack(score)
+	if redis.call('HINCRBY', KEYS[3], score, -1) <= 0 then
+		redis.call('HDEL', KEYS[3], score)
+		redis.call('ZREM', KEYS[2], score)
+	end
+end
+`
+
+// ARGV holds the member, its score and GT to only raise the score of an existing member
+var zAddScript = redis.NewScript(distinctHelpers + `
+local previous = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if ARGV[3] == 'GT' then
+	redis.call('ZADD', KEYS[1], 'GT', ARGV[2], ARGV[1])
+else
+	redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+end
+local current = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if previous ~= current then
+	if previous then
+		untrack(previous)
+	end
+	track(current)
+end
+return 0
+`)
+
+// ARGV holds the member
+var zRemScript = redis.NewScript(distinctHelpers + `
+local previous = redis.call('ZSCORE', KEYS[1], ARGV[1])
+if previous then
+	redis.call('ZREM', KEYS[1], ARGV[1])
+	untrack(previous)
+end
+return 0
+`)
+
+// ARGV holds the members and their scores in pairs
+var zReplaceScript = redis.NewScript(distinctHelpers + `
+redis.call('DEL', KEYS[1], KEYS[2], KEYS[3])
+for i = 1, #ARGV, 2 do
+	redis.call('ZADD', KEYS[1], ARGV[i + 1], ARGV[i])
+	track(redis.call('ZSCORE', KEYS[1], ARGV[i]))
+end
+return 0
+`)
+
+// ARGV holds the min and max of the range, sets written before their scores were tracked are tracked on first use
+var zCountDistinctScript = redis.NewScript(distinctHelpers + `
+if redis.call('EXISTS', KEYS[2]) == 0 then
+	local members = redis.call('ZRANGE', KEYS[1], 0, -1, 'WITHSCORES')
+	for i = 2, #members, 2 do
+		track(members[i])
+	end
+end
+return redis.call('ZCOUNT', KEYS[2], ARGV[1], ARGV[2])
+`)
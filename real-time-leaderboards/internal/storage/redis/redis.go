@@ -19,6 +19,29 @@ type RedisService interface {
 	Get(context.Context, string, any) error
 	JSONSet(context.Context, string, string, any, time.Duration) error
 	JSONGet(context.Context, string, string, any) error
+
+	// Sorted sets back the leaderboard rankings
+	ZAdd(context.Context, string, string, float64) error
+	ZAddGT(context.Context, string, string, float64) error
+	ZRem(context.Context, string, string) error
+	ZReplace(context.Context, string, map[string]float64) error
+	ZScore(context.Context, string, string) (float64, error)
+	ZCount(context.Context, string, string, string) (int64, error)
+	ZCard(context.Context, string) (int64, error)
+	ZRevRank(context.Context, string, string) (int64, error)
+	ZCountDistinct(context.Context, string, string, string) (int64, error)
+	ZRevRangeWithScores(context.Context, string, int64, int64) ([]ScoredMember, error)
+
+	// Sets hold the circle of friends of every user, intersected with the rankings
+	SReplace(context.Context, string, []string) error
+	Exists(context.Context, string) (bool, error)
+	ZInterWithSet(context.Context, string, string) ([]ScoredMember, error)
+}
+
+// Member of a sorted set with its score
+type ScoredMember struct {
+	Member string
+	Score  float64
 }
 
 // redisService is the concrete redis implementation
@@ -124,6 +147,188 @@ func (r *redisService) JSONGet(ctx context.Context, key, path string, target any
 	return nil
 }
 
+// Adds the member to the sorted set, replacing its score if it is already a member
+func (r *redisService) ZAdd(ctx context.Context, key, member string, score float64) error {
+	if key == "" {
+		return errors.New("key cannot be empty")
+	}
+
+	if err := zAddScript.Run(ctx, r.client, distinctKeys(key), member, score, "").Err(); err != nil {
+		return fmt.Errorf("failed redis ZADD for key %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// Adds the member to the sorted set, or raises its score if the new one is greater
+func (r *redisService) ZAddGT(ctx context.Context, key, member string, score float64) error {
+	if key == "" {
+		return errors.New("key cannot be empty")
+	}
+
+	if err := zAddScript.Run(ctx, r.client, distinctKeys(key), member, score, "GT").Err(); err != nil {
+		return fmt.Errorf("failed redis ZADD GT for key %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func (r *redisService) ZRem(ctx context.Context, key, member string) error {
+	if err := zRemScript.Run(ctx, r.client, distinctKeys(key), member).Err(); err != nil {
+		return fmt.Errorf("failed redis ZREM for key %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// Replaces the whole sorted set with the members in a single script, readers never see it half built
+func (r *redisService) ZReplace(ctx context.Context, key string, members map[string]float64) error {
+	if key == "" {
+		return errors.New("key cannot be empty")
+	}
+
+	args := make([]any, 0, 2*len(members))
+	for member, score := range members {
+		args = append(args, member, score)
+	}
+	if err := zReplaceScript.Run(ctx, r.client, distinctKeys(key), args...).Err(); err != nil {
+		return fmt.Errorf("failed redis ZADD replacing key %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// Counts the distinct scores between min and max, using the redis range syntax such as "-inf" or "(10"
+// Members sharing a score count once, which numbers dense ranks without reading the members above them
+func (r *redisService) ZCountDistinct(ctx context.Context, key, min, max string) (int64, error) {
+	count, err := zCountDistinctScript.Run(ctx, r.client, distinctKeys(key), min, max).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed redis ZCOUNT of distinct scores for key %s: %w", key, err)
+	}
+
+	return count, nil
+}
+
+// Returns the score of the member, ErrNotFound if it is not in the set
+func (r *redisService) ZScore(ctx context.Context, key, member string) (float64, error) {
+	score, err := r.client.ZScore(ctx, key, member).Result()
+	if err == redis.Nil {
+		return 0, ErrNotFound
+	} else if err != nil {
+		return 0, fmt.Errorf("failed redis ZSCORE for key %s: %w", key, err)
+	}
+
+	return score, nil
+}
+
+// Zero based position of the member from the highest score, ErrNotFound if it is not in the set
+func (r *redisService) ZRevRank(ctx context.Context, key, member string) (int64, error) {
+	rank, err := r.client.ZRevRank(ctx, key, member).Result()
+	if err == redis.Nil {
+		return 0, ErrNotFound
+	} else if err != nil {
+		return 0, fmt.Errorf("failed redis ZREVRANK for key %s: %w", key, err)
+	}
+
+	return rank, nil
+}
+
+// Counts the members with scores between min and max, using the redis range syntax such as "-inf" or "(10"
+func (r *redisService) ZCount(ctx context.Context, key, min, max string) (int64, error) {
+	count, err := r.client.ZCount(ctx, key, min, max).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed redis ZCOUNT for key %s: %w", key, err)
+	}
+
+	return count, nil
+}
+
+func (r *redisService) ZCard(ctx context.Context, key string) (int64, error) {
+	count, err := r.client.ZCard(ctx, key).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed redis ZCARD for key %s: %w", key, err)
+	}
+
+	return count, nil
+}
+
+// Returns the members ranked from start to stop, both inclusive and counted from the highest score
+func (r *redisService) ZRevRangeWithScores(ctx context.Context, key string, start, stop int64) ([]ScoredMember, error) {
+	members, err := r.client.ZRevRangeWithScores(ctx, key, start, stop).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed redis ZREVRANGE for key %s: %w", key, err)
+	}
+
+	scored := make([]ScoredMember, 0, len(members))
+	for _, z := range members {
+		member, ok := z.Member.(string)
+		if !ok {
+			continue
+		}
+		scored = append(scored, ScoredMember{Member: member, Score: z.Score})
+	}
+
+	return scored, nil
+}
+
+// Replaces the whole set with the members in a single transaction
+func (r *redisService) SReplace(ctx context.Context, key string, members []string) error {
+	if key == "" {
+		return errors.New("key cannot be empty")
+	}
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		if len(members) == 0 {
+			return nil
+		}
+
+		setMembers := make([]any, 0, len(members))
+		for _, member := range members {
+			setMembers = append(setMembers, member)
+		}
+		pipe.SAdd(ctx, key, setMembers...)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed redis SADD replacing key %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func (r *redisService) Exists(ctx context.Context, key string) (bool, error) {
+	count, err := r.client.Exists(ctx, key).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed redis EXISTS for key %s: %w", key, err)
+	}
+
+	return count > 0, nil
+}
+
+// Returns the members of the sorted set that are also in the set, keeping their sorted set scores
+// Members are ordered from the highest score down
+func (r *redisService) ZInterWithSet(ctx context.Context, zsetKey, setKey string) ([]ScoredMember, error) {
+	members, err := r.client.ZInterWithScores(ctx, &redis.ZStore{
+		Keys:    []string{zsetKey, setKey},
+		Weights: []float64{1, 0}, // Plain set members score 1, which must not be added to the ranking score
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed redis ZINTER for keys %s and %s: %w", zsetKey, setKey, err)
+	}
+
+	scored := make([]ScoredMember, 0, len(members))
+	for i := len(members) - 1; i >= 0; i-- {
+		member, ok := members[i].Member.(string)
+		if !ok {
+			continue
+		}
+		scored = append(scored, ScoredMember{Member: member, Score: members[i].Score})
+	}
+
+	return scored, nil
+}
+
 func serializeValue(value any) (string, error) {
 	switch v := value.(type) {
 	case string:
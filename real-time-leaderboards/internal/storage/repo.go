@@ -4,17 +4,51 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"log"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 // Define standard storage layer errors
 var (
-	ErrNotFound = errors.New("resource not found")
-	ErrConflict = errors.New("resource already exists")
+	ErrNotFound         = errors.New("resource not found")
+	ErrConflict         = errors.New("resource already exists")
+	ErrInvalidReference = errors.New("referenced resource does not exist")
+	ErrStateConflict    = errors.New("resource is not in a state that allows the operation")
+	ErrVersionConflict  = errors.New("resource was modified since it was read")
 )
 
+// Postgres error codes mapped to storage layer errors
+const (
+	pgUniqueViolation           = "23505"
+	pgForeignKeyViolation       = "23503"
+	pgInvalidTextRepresentation = "22P02" // Malformed ids such as /leaderboards/abc, which cannot match any row
+)
+
+// Maps driver errors to the storage layer errors, so callers can rely on errors.Is
+// The original error should be logged before translating it, as it is discarded
+func translateError(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case pgUniqueViolation:
+			return ErrConflict
+		case pgForeignKeyViolation:
+			return ErrInvalidReference
+		case pgInvalidTextRepresentation:
+			return ErrNotFound
+		}
+	}
+
+	return err
+}
+
 func NewPostgres(addr string, maxOpenConns, maxIdleConns int, maxIdleTime string) (*sql.DB, error) {
 	db, err := sql.Open("postgres", addr)
 	if err != nil {
@@ -38,4 +72,27 @@ func NewPostgres(addr string, maxOpenConns, maxIdleConns int, maxIdleTime string
 	}
 
 	return db, nil
-}
\ No newline at end of file
+}
+
+// Runs fn inside a transaction, committing if it succeeds and rolling back otherwise
+func withTx(ctx context.Context, db *sql.DB, fn func(*sql.Tx) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		log.Printf("Failed to begin transaction: %v", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			log.Printf("Failed to rollback transaction: %v", rollbackErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Failed to commit transaction: %v", err)
+		return fmt.Errorf("failed to commit transaction: %w", translateError(err))
+	}
+
+	return nil
+}
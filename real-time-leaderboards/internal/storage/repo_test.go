@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslateError(t *testing.T) {
+	connectionErr := errors.New("connection refused")
+
+	testCases := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{"no rows", sql.ErrNoRows, ErrNotFound},
+		{"wrapped no rows", fmt.Errorf("failed to scan: %w", sql.ErrNoRows), ErrNotFound},
+		{"unique violation", &pq.Error{Code: pgUniqueViolation}, ErrConflict},
+		{"foreign key violation", &pq.Error{Code: pgForeignKeyViolation}, ErrInvalidReference},
+		{"malformed id", &pq.Error{Code: pgInvalidTextRepresentation}, ErrNotFound},
+		{"unknown error", connectionErr, connectionErr},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.ErrorIs(t, translateError(testCase.err), testCase.expected)
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+)
+
+// Player reports on leaderboard entries, enough of them send an accepted entry back to the moderation queue
+type ReportRepo interface {
+	Create(context.Context, *models.EntryReportRequest, int) (*models.EntryReportResult, error)
+	GetReportedEntries(context.Context, *models.ReportFilter) ([]models.ReportedEntry, error)
+	GetEntryReports(context.Context, string, *models.Pagination) ([]models.EntryReport, error)
+}
+
+type ReportRepoPG struct {
+	db *sql.DB
+}
+
+func NewReportRepoPG(db *sql.DB) *ReportRepoPG {
+	return &ReportRepoPG{
+		db: db,
+	}
+}
+
+// Records the report and flags the entry once it reaches the escalation threshold
+// Only accepted entries are escalated, the others are either in the queue already or were reviewed by a moderator
+// Players can only report entries of the leaderboards they can read
+func (rr *ReportRepoPG) Create(
+	ctx context.Context,
+	report *models.EntryReportRequest,
+	threshold int,
+) (*models.EntryReportResult, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result := models.EntryReportResult{}
+	err := withTx(ctx, rr.db, func(tx *sql.Tx) error {
+		// Lock the entry so concurrent reports cannot escalate it twice
+		var leaderboardID, status string
+		var escalated bool
+		if err := tx.QueryRowContext(ctx, `
+			SELECT leaderboard_id, user_id, status, escalated_at IS NOT NULL
+			FROM leaderboard_entries
+			WHERE id = $1 AND `+inTenant("leaderboard_id", "$2")+` AND deleted_at IS NULL
+			FOR UPDATE`,
+			report.EntryID,
+			models.TenantFromContext(ctx),
+		).Scan(&leaderboardID, &result.OwnerID, &status, &escalated); err != nil {
+			log.Printf("Failed to lock reported leaderboard entry: %v", err)
+			return fmt.Errorf("failed to report leaderboard entry: %w", translateError(err))
+		}
+		// Entries of private leaderboards are reported as missing to players who cannot read them
+		if err := ensureMember(ctx, tx, leaderboardID, report.ReporterID); err != nil {
+			return err
+		}
+		if result.OwnerID == report.ReporterID {
+			return fmt.Errorf("failed to report own leaderboard entry: %w", ErrStateConflict)
+		}
+
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO entry_reports (entry_id, reporter_id, reason, details)
+			VALUES ($1, $2, $3, NULLIF($4, ''))
+			RETURNING id, entry_id, reporter_id, reason, COALESCE(details, ''), created_at`,
+			report.EntryID,
+			report.ReporterID,
+			report.Reason,
+			report.Details,
+		).Scan(
+			&result.Report.ID,
+			&result.Report.EntryID,
+			&result.Report.Reporter.ID,
+			&result.Report.Reason,
+			&result.Report.Details,
+			&result.Report.CreatedAt,
+		); err != nil {
+			log.Printf("Failed to insert entry report: %v", err)
+			return fmt.Errorf("failed to create entry report: %w", translateError(err))
+		}
+
+		if err := tx.QueryRowContext(ctx, `
+			SELECT COUNT(*) FROM entry_reports WHERE entry_id = $1`,
+			report.EntryID,
+		).Scan(&result.Reports); err != nil {
+			log.Printf("Failed to count entry reports: %v", err)
+			return fmt.Errorf("failed to count entry reports: %w", translateError(err))
+		}
+
+		if result.Reports < threshold || status != models.EntryStatusAccepted || escalated {
+			return nil
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE leaderboard_entries
+			SET
+				status = 'flagged',
+				flag_reason = $2,
+				escalated_at = CURRENT_TIMESTAMP,
+				updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1`,
+			report.EntryID,
+			fmt.Sprintf("reported by %d players", result.Reports),
+		); err != nil {
+			log.Printf("Failed to escalate reported leaderboard entry: %v", err)
+			return fmt.Errorf("failed to escalate leaderboard entry: %w", translateError(err))
+		}
+		result.Escalated = true
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// Lists reported entries with their report counts, the most reported first
+func (rr *ReportRepoPG) GetReportedEntries(ctx context.Context, filter *models.ReportFilter) ([]models.ReportedEntry, error) {
+	stmt, err := rr.db.PrepareContext(ctx, `
+		SELECT
+			e.id
+			,e.leaderboard_id
+			,e.score
+			,e.status
+			,COALESCE(e.flag_reason, '')
+			,COALESCE(e.proof_url, '')
+			,COALESCE(e.notes, '')
+			,e.created_at
+			,e.updated_at
+			,u.id
+			,u.username
+			,r.reports
+			,r.reasons
+			,r.last_reported_at
+			,e.escalated_at
+		FROM (
+			SELECT
+				entry_id
+				,SUM(reports) AS reports
+				,jsonb_object_agg(reason, reports) AS reasons
+				,MAX(last_reported_at) AS last_reported_at
+			FROM (
+				SELECT entry_id, reason, COUNT(*) AS reports, MAX(created_at) AS last_reported_at
+				FROM entry_reports
+				GROUP BY entry_id, reason
+			) by_reason
+			GROUP BY entry_id
+		) r
+		JOIN leaderboard_entries e
+			ON r.entry_id = e.id
+		LEFT JOIN users u
+			ON e.user_id = u.id
+		WHERE e.deleted_at IS NULL
+			AND ($1 = '' OR e.leaderboard_id::TEXT = $1)
+			AND `+inTenant("e.leaderboard_id", "$5")+`
+			AND r.reports >= $2
+		ORDER BY r.reports DESC, r.last_reported_at DESC
+		LIMIT $3 OFFSET $4`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare reported entries statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := stmt.QueryContext(
+		ctx,
+		filter.LeaderboardID,
+		filter.MinReports,
+		filter.Limit,
+		filter.Offset,
+		models.TenantFromContext(ctx),
+	)
+	if err != nil {
+		log.Printf("Failed to query reported entries: %v", err)
+		return nil, fmt.Errorf("failed to get reported entries: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	reported := make([]models.ReportedEntry, 0)
+	for rows.Next() {
+		var entry models.ReportedEntry
+		var reasons []byte
+		if err := rows.Scan(
+			&entry.Entry.ID,
+			&entry.Entry.LeaderboardID,
+			&entry.Entry.Score,
+			&entry.Entry.Status,
+			&entry.Entry.FlagReason,
+			&entry.Entry.ProofURL,
+			&entry.Entry.Notes,
+			&entry.Entry.CreatedAt,
+			&entry.Entry.UpdatedAt,
+			&entry.Entry.User.ID,
+			&entry.Entry.User.Username,
+			&entry.Reports,
+			&reasons,
+			&entry.LastReportedAt,
+			&entry.EscalatedAt,
+		); err != nil {
+			log.Printf("Failed to scan reported entry: %v", err)
+			return nil, fmt.Errorf("failed to scan reported entry: %w", err)
+		}
+		if err := json.Unmarshal(reasons, &entry.Reasons); err != nil {
+			log.Printf("Failed to decode report reasons: %v", err)
+			return nil, fmt.Errorf("failed to decode report reasons: %w", err)
+		}
+		reported = append(reported, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan reported entries: %v", err)
+		return nil, fmt.Errorf("failed to scan reported entries: %w", err)
+	}
+
+	return reported, nil
+}
+
+// Lists the individual reports on an entry, newest first
+func (rr *ReportRepoPG) GetEntryReports(ctx context.Context, entryID string, page *models.Pagination) ([]models.EntryReport, error) {
+	stmt, err := rr.db.PrepareContext(ctx, `
+		SELECT
+			r.id
+			,r.entry_id
+			,r.reason
+			,COALESCE(r.details, '')
+			,r.created_at
+			,u.id
+			,u.username
+		FROM entry_reports r
+		JOIN leaderboard_entries e
+			ON r.entry_id = e.id
+		JOIN users u
+			ON r.reporter_id = u.id
+		WHERE r.entry_id = $1
+			AND `+inTenant("e.leaderboard_id", "$4")+`
+			AND e.deleted_at IS NULL
+			AND u.deleted_at IS NULL
+		ORDER BY r.created_at DESC, r.id DESC
+		LIMIT $2 OFFSET $3`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare entry reports statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := stmt.QueryContext(ctx, entryID, page.Limit, page.Offset, models.TenantFromContext(ctx))
+	if err != nil {
+		log.Printf("Failed to query entry reports: %v", err)
+		return nil, fmt.Errorf("failed to get entry reports: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	reports := make([]models.EntryReport, 0)
+	for rows.Next() {
+		var report models.EntryReport
+		if err := rows.Scan(
+			&report.ID,
+			&report.EntryID,
+			&report.Reason,
+			&report.Details,
+			&report.CreatedAt,
+			&report.Reporter.ID,
+			&report.Reporter.Username,
+		); err != nil {
+			log.Printf("Failed to scan entry report: %v", err)
+			return nil, fmt.Errorf("failed to scan entry report: %w", err)
+		}
+		reports = append(reports, report)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan entry reports: %v", err)
+		return nil, fmt.Errorf("failed to scan entry reports: %w", err)
+	}
+
+	return reports, nil
+}
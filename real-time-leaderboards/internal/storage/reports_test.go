@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReportsScopedToTenant(t *testing.T) {
+	// Entry 5 is on a leaderboard of another tenant, so the fake database has no rows for tenant 4
+	db, fake := newFakeDB(t)
+	repo := NewReportRepoPG(db)
+	ctx := models.ContextWithTenant(context.Background(), "4")
+
+	_, err := repo.Create(ctx, &models.EntryReportRequest{EntryID: "5", ReporterID: "2", Reason: "cheating"}, 3)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	reported, err := repo.GetReportedEntries(ctx, &models.ReportFilter{Pagination: models.Pagination{Limit: 10}})
+	assert.NoError(t, err)
+	assert.Empty(t, reported)
+
+	reports, err := repo.GetEntryReports(ctx, "5", &models.Pagination{Limit: 10})
+	assert.NoError(t, err)
+	assert.Empty(t, reports)
+
+	statements := fake.statements("leaderboard_id IN (SELECT id FROM leaderboards WHERE tenant_id = ")
+	assert.Len(t, statements, 3)
+	assert.Equal(t, "4", statements[0].args[1])
+	assert.Equal(t, "4", statements[1].args[4])
+	assert.Equal(t, "4", statements[2].args[3])
+}
+
+func TestReportsCreateHidesPrivateLeaderboards(t *testing.T) {
+	// Entry 5 is on private leaderboard 1, which player 2 is not a member of
+	db, fake := newFakeDB(t,
+		fakeStub{
+			contains: "FOR UPDATE",
+			columns:  []string{"leaderboard_id", "user_id", "status", "escalated"},
+			rows:     [][]any{{"1", "3", models.EntryStatusAccepted, false}},
+		},
+		fakeStub{
+			contains: "leaderboard_members m",
+			columns:  []string{"member"},
+			rows:     [][]any{{false}},
+		},
+	)
+	repo := NewReportRepoPG(db)
+
+	_, err := repo.Create(context.Background(), &models.EntryReportRequest{EntryID: "5", ReporterID: "2", Reason: "cheating"}, 3)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	statements := fake.statements("leaderboard_members m")
+	assert.Len(t, statements, 1)
+	assert.Equal(t, []driver.Value{"1", "2"}, statements[0].args)
+	assert.Empty(t, fake.statements("INSERT INTO entry_reports"))
+}
+
+func TestChangeSetsScopedToTenant(t *testing.T) {
+	db, fake := newFakeDB(t)
+	repo := NewChangeSetRepoPG(db)
+	ctx := models.ContextWithTenant(context.Background(), "4")
+
+	changeSets, err := repo.GetChangeSets(ctx, &models.ChangeSetFilter{Pagination: models.Pagination{Limit: 10}})
+	assert.NoError(t, err)
+	assert.Empty(t, changeSets)
+
+	statements := fake.statements("FROM entry_change_sets")
+	assert.Len(t, statements, 1)
+	assert.Contains(t, statements[0].query, "leaderboard_id IN (SELECT id FROM leaderboards WHERE tenant_id = $4)")
+	assert.Equal(t, "4", statements[0].args[3])
+}
@@ -0,0 +1,562 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+)
+
+// Teams of players, ranked on the leaderboards configured with a team aggregate
+type TeamRepo interface {
+	Create(context.Context, *models.TeamRequest, string) (*models.Team, error)
+	Get(context.Context, string) (*models.Team, error)
+	GetByIDs(context.Context, []string) ([]models.Team, error)
+	GetUserTeam(context.Context, string) (*models.Team, error)
+	GetMember(context.Context, string, string) (*models.TeamMember, error)
+	Join(context.Context, string, string) (*models.TeamMember, error)
+	Leave(context.Context, string, string) error
+	SetRole(context.Context, string, string, string) (*models.TeamMember, error)
+	RemoveMember(context.Context, string, string) error
+	GetTeamScores(context.Context, string, string) ([]models.TeamScore, error)
+	GetLeaderboardTeamScores(context.Context, string) ([]models.TeamScore, error)
+}
+
+type TeamRepoPG struct {
+	db *sql.DB
+}
+
+func NewTeamRepoPG(db *sql.DB) *TeamRepoPG {
+	return &TeamRepoPG{
+		db: db,
+	}
+}
+
+// Creates the team with the user as its owner
+// Returns ErrConflict if the name is taken or the user is already in a team
+func (tr *TeamRepoPG) Create(ctx context.Context, request *models.TeamRequest, ownerID string) (*models.Team, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var team models.Team
+	err := withTx(ctx, tr.db, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(
+			ctx,
+			`INSERT INTO teams (name) VALUES ($1) RETURNING id, name, created_at, updated_at`,
+			request.Name,
+		).Scan(&team.ID, &team.Name, &team.CreatedAt, &team.UpdatedAt); err != nil {
+			log.Printf("Failed to insert team: %v", err)
+			return fmt.Errorf("failed to create team: %w", translateError(err))
+		}
+
+		owner := models.TeamMember{Role: models.TeamRoleOwner}
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO team_members (team_id, user_id, role)
+			SELECT $1, u.id, $3
+			FROM users u
+			WHERE u.id = $2 AND u.deleted_at IS NULL
+			RETURNING user_id, joined_at`,
+			team.ID,
+			ownerID,
+			owner.Role,
+		).Scan(&owner.User.ID, &owner.JoinedAt); err != nil {
+			log.Printf("Failed to add owner to team: %v", err)
+			return fmt.Errorf("failed to add owner to team: %w", translateError(err))
+		}
+
+		if err := tx.QueryRowContext(
+			ctx,
+			`SELECT username FROM users WHERE id = $1`,
+			ownerID,
+		).Scan(&owner.User.Username); err != nil {
+			log.Printf("Failed to get team owner: %v", err)
+			return fmt.Errorf("failed to get team owner: %w", translateError(err))
+		}
+		team.Members = []models.TeamMember{owner}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &team, nil
+}
+
+// Returns the team with its members, the owner first and then by seniority
+func (tr *TeamRepoPG) Get(ctx context.Context, teamID string) (*models.Team, error) {
+	stmt, err := tr.db.PrepareContext(ctx, `SELECT id, name, created_at, updated_at FROM teams WHERE id = $1`)
+	if err != nil {
+		log.Printf("Failed to prepare get team statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var team models.Team
+	if err := stmt.QueryRowContext(ctx, teamID).Scan(&team.ID, &team.Name, &team.CreatedAt, &team.UpdatedAt); err != nil {
+		log.Printf("Failed to get team '%s': %v", teamID, err)
+		return nil, fmt.Errorf("failed to get team '%s': %w", teamID, translateError(err))
+	}
+
+	rows, err := tr.db.QueryContext(ctx, `
+		SELECT u.id, u.username, m.role, m.joined_at
+		FROM team_members m
+		JOIN users u
+			ON m.user_id = u.id
+		WHERE m.team_id = $1 AND u.deleted_at IS NULL
+		ORDER BY m.role = 'owner' DESC, m.joined_at ASC, u.id ASC`,
+		teamID,
+	)
+	if err != nil {
+		log.Printf("Failed to query members of team '%s': %v", teamID, err)
+		return nil, fmt.Errorf("failed to get members of team '%s': %w", teamID, translateError(err))
+	}
+	defer rows.Close()
+
+	team.Members = make([]models.TeamMember, 0)
+	for rows.Next() {
+		var member models.TeamMember
+		if err := rows.Scan(&member.User.ID, &member.User.Username, &member.Role, &member.JoinedAt); err != nil {
+			log.Printf("Failed to scan team member: %v", err)
+			return nil, fmt.Errorf("failed to scan team member: %w", err)
+		}
+		team.Members = append(team.Members, member)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan team members: %v", err)
+		return nil, fmt.Errorf("failed to scan team members: %w", err)
+	}
+
+	return &team, nil
+}
+
+// Returns the teams without their members, teams that no longer exist are left out
+func (tr *TeamRepoPG) GetByIDs(ctx context.Context, teamIDs []string) ([]models.Team, error) {
+	stmt, err := tr.db.PrepareContext(ctx, `
+		SELECT id, name, created_at, updated_at
+		FROM teams
+		WHERE id::TEXT = ANY($1)`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare get teams statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := stmt.QueryContext(ctx, pq.Array(teamIDs))
+	if err != nil {
+		log.Printf("Failed to query teams: %v", err)
+		return nil, fmt.Errorf("failed to get teams: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	teams := make([]models.Team, 0, len(teamIDs))
+	for rows.Next() {
+		var team models.Team
+		if err := rows.Scan(&team.ID, &team.Name, &team.CreatedAt, &team.UpdatedAt); err != nil {
+			log.Printf("Failed to scan team: %v", err)
+			return nil, fmt.Errorf("failed to scan team: %w", err)
+		}
+		teams = append(teams, team)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan teams: %v", err)
+		return nil, fmt.Errorf("failed to scan teams: %w", err)
+	}
+
+	return teams, nil
+}
+
+// Returns the team of the user without its members, ErrNotFound if the user is not in a team
+func (tr *TeamRepoPG) GetUserTeam(ctx context.Context, userID string) (*models.Team, error) {
+	stmt, err := tr.db.PrepareContext(ctx, `
+		SELECT t.id, t.name, t.created_at, t.updated_at
+		FROM team_members m
+		JOIN teams t
+			ON m.team_id = t.id
+		WHERE m.user_id = $1`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare get user team statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var team models.Team
+	if err := stmt.QueryRowContext(ctx, userID).Scan(&team.ID, &team.Name, &team.CreatedAt, &team.UpdatedAt); err != nil {
+		log.Printf("Failed to get team of user '%s': %v", userID, err)
+		return nil, fmt.Errorf("failed to get team of user '%s': %w", userID, translateError(err))
+	}
+
+	return &team, nil
+}
+
+// Returns the membership of the user in the team, ErrNotFound if they are not a member
+func (tr *TeamRepoPG) GetMember(ctx context.Context, teamID, userID string) (*models.TeamMember, error) {
+	stmt, err := tr.db.PrepareContext(ctx, `
+		SELECT u.id, u.username, m.role, m.joined_at
+		FROM team_members m
+		JOIN users u
+			ON m.user_id = u.id
+		WHERE m.team_id = $1 AND m.user_id = $2 AND u.deleted_at IS NULL`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare get team member statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var member models.TeamMember
+	if err := stmt.QueryRowContext(ctx, teamID, userID).Scan(
+		&member.User.ID,
+		&member.User.Username,
+		&member.Role,
+		&member.JoinedAt,
+	); err != nil {
+		log.Printf("Failed to get member '%s' of team '%s': %v", userID, teamID, err)
+		return nil, fmt.Errorf("failed to get member '%s' of team '%s': %w", userID, teamID, translateError(err))
+	}
+
+	return &member, nil
+}
+
+// Adds the user to the team as a plain member
+// Returns ErrNotFound if the team does not exist and ErrConflict if the user is already in a team
+func (tr *TeamRepoPG) Join(ctx context.Context, teamID, userID string) (*models.TeamMember, error) {
+	stmt, err := tr.db.PrepareContext(ctx, `
+		WITH joined AS (
+			INSERT INTO team_members (team_id, user_id)
+			SELECT t.id, u.id
+			FROM teams t, users u
+			WHERE t.id = $1 AND u.id = $2 AND u.deleted_at IS NULL
+			RETURNING user_id, role, joined_at
+		)
+		SELECT u.id, u.username, j.role, j.joined_at
+		FROM joined j
+		JOIN users u
+			ON j.user_id = u.id`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare join team statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var member models.TeamMember
+	if err := stmt.QueryRowContext(ctx, teamID, userID).Scan(
+		&member.User.ID,
+		&member.User.Username,
+		&member.Role,
+		&member.JoinedAt,
+	); err != nil {
+		log.Printf("Failed to join team '%s': %v", teamID, err)
+		return nil, fmt.Errorf("failed to join team '%s': %w", teamID, translateError(err))
+	}
+
+	return &member, nil
+}
+
+// Removes the user from the team, a team whose owner leaves last is disbanded
+// Returns ErrStateConflict if the owner leaves while other members remain, ownership must be handed over first
+func (tr *TeamRepoPG) Leave(ctx context.Context, teamID, userID string) error {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return withTx(ctx, tr.db, func(tx *sql.Tx) error {
+		// Locking the team serialises membership changes, so the owner never leaves members behind
+		if _, err := tx.ExecContext(ctx, `SELECT 1 FROM teams WHERE id = $1 FOR UPDATE`, teamID); err != nil {
+			log.Printf("Failed to lock team '%s': %v", teamID, err)
+			return fmt.Errorf("failed to lock team '%s': %w", teamID, translateError(err))
+		}
+
+		var role string
+		var others int
+		if err := tx.QueryRowContext(ctx, `
+			SELECT m.role, (SELECT COUNT(*) FROM team_members o WHERE o.team_id = m.team_id AND o.user_id <> m.user_id)
+			FROM team_members m
+			WHERE m.team_id = $1 AND m.user_id = $2`,
+			teamID,
+			userID,
+		).Scan(&role, &others); err != nil {
+			log.Printf("Failed to get member '%s' of team '%s': %v", userID, teamID, err)
+			return fmt.Errorf("failed to get member '%s' of team '%s': %w", userID, teamID, translateError(err))
+		}
+
+		if role != models.TeamRoleOwner {
+			if _, err := tx.ExecContext(
+				ctx,
+				`DELETE FROM team_members WHERE team_id = $1 AND user_id = $2`,
+				teamID,
+				userID,
+			); err != nil {
+				log.Printf("Failed to leave team '%s': %v", teamID, err)
+				return fmt.Errorf("failed to leave team '%s': %w", teamID, translateError(err))
+			}
+			return nil
+		}
+
+		if others > 0 {
+			return fmt.Errorf("owner cannot leave team '%s' with members: %w", teamID, ErrStateConflict)
+		}
+
+		if _, err := tx.ExecContext(ctx, `DELETE FROM teams WHERE id = $1`, teamID); err != nil {
+			log.Printf("Failed to disband team '%s': %v", teamID, err)
+			return fmt.Errorf("failed to disband team '%s': %w", teamID, translateError(err))
+		}
+
+		return nil
+	})
+}
+
+// Changes the role of a member, making someone the owner hands the ownership over and
+// the previous owner becomes an officer
+func (tr *TeamRepoPG) SetRole(ctx context.Context, teamID, userID, role string) (*models.TeamMember, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var member models.TeamMember
+	err := withTx(ctx, tr.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `SELECT 1 FROM teams WHERE id = $1 FOR UPDATE`, teamID); err != nil {
+			log.Printf("Failed to lock team '%s': %v", teamID, err)
+			return fmt.Errorf("failed to lock team '%s': %w", teamID, translateError(err))
+		}
+
+		if role == models.TeamRoleOwner {
+			if _, err := tx.ExecContext(ctx, `
+				UPDATE team_members
+				SET role = $3
+				WHERE team_id = $1 AND user_id <> $2 AND role = $4`,
+				teamID,
+				userID,
+				models.TeamRoleOfficer,
+				models.TeamRoleOwner,
+			); err != nil {
+				log.Printf("Failed to demote owner of team '%s': %v", teamID, err)
+				return fmt.Errorf("failed to demote owner of team '%s': %w", teamID, translateError(err))
+			}
+		}
+
+		// The owner keeps their role until someone else is made owner
+		if err := tx.QueryRowContext(ctx, `
+			UPDATE team_members m
+			SET role = $3
+			FROM users u
+			WHERE m.user_id = u.id
+				AND m.team_id = $1
+				AND m.user_id = $2
+				AND (m.role <> 'owner' OR $3 = 'owner')
+			RETURNING u.id, u.username, m.role, m.joined_at`,
+			teamID,
+			userID,
+			role,
+		).Scan(&member.User.ID, &member.User.Username, &member.Role, &member.JoinedAt); err != nil {
+			log.Printf("Failed to set role of member '%s' of team '%s': %v", userID, teamID, err)
+			return fmt.Errorf("failed to set role of member '%s' of team '%s': %w", userID, teamID, translateError(err))
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+// Removes a member other than the owner from the team
+func (tr *TeamRepoPG) RemoveMember(ctx context.Context, teamID, userID string) error {
+	stmt, err := tr.db.PrepareContext(ctx, `
+		DELETE FROM team_members
+		WHERE team_id = $1 AND user_id = $2 AND role <> 'owner'`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare remove team member statement: %v", err)
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := stmt.ExecContext(ctx, teamID, userID)
+	if err != nil {
+		log.Printf("Failed to remove member '%s' of team '%s': %v", userID, teamID, err)
+		return fmt.Errorf("failed to remove member '%s' of team '%s': %w", userID, teamID, translateError(err))
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("Failed to remove member '%s' of team '%s': %v", userID, teamID, err)
+		return fmt.Errorf("failed to remove member '%s' of team '%s': %w", userID, teamID, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("failed to remove member '%s' of team '%s': %w", userID, teamID, ErrNotFound)
+	}
+
+	return nil
+}
+
+// Combines the best scores of the members, o must be the alias of the ranked member scores with their
+// position in the team and l the alias of the leaderboards table
+const teamAggregateScore = `CASE l.team_aggregate
+	WHEN 'sum' THEN COALESCE(SUM(o.score), 0)
+	WHEN 'average' THEN COALESCE(AVG(o.score), 0)
+	ELSE COALESCE(SUM(o.score) FILTER (WHERE o.position <= l.team_top_k), 0)
+END`
+
+// Aggregates the best ranked score of every member of the team on the leaderboards with a team ranking,
+// or only on the given leaderboard when it is not empty
+// A score is returned for every such leaderboard, marked hidden when no member is ranked on it, so
+// teams that lost their last ranked member or were disbanded are removed from the rankings
+// Teams span every tenant, each score carries the tenant of its leaderboard
+func (tr *TeamRepoPG) GetTeamScores(ctx context.Context, teamID, leaderboardID string) ([]models.TeamScore, error) {
+	stmt, err := tr.db.PrepareContext(ctx, `
+		WITH best AS (
+			SELECT e.leaderboard_id, MAX(e.score) AS score
+			FROM team_members m
+			JOIN users u
+				ON m.user_id = u.id
+			JOIN leaderboard_entries e
+				ON e.user_id = u.id
+			WHERE m.team_id = $1
+				AND e.status IN ('accepted', 'verified')
+				AND e.deleted_at IS NULL
+				AND u.deleted_at IS NULL
+				AND `+userInGoodStanding+`
+			GROUP BY e.leaderboard_id, e.user_id
+		), ordered AS (
+			SELECT leaderboard_id, score, ROW_NUMBER() OVER (PARTITION BY leaderboard_id ORDER BY score DESC) AS position
+			FROM best
+		)
+		SELECT
+			l.id
+			,l.tenant_id
+			,`+teamAggregateScore+`
+			,COUNT(o.score) = 0
+		FROM leaderboards l
+		LEFT JOIN ordered o
+			ON o.leaderboard_id = l.id
+		WHERE l.team_aggregate IS NOT NULL
+			AND l.deleted_at IS NULL
+			AND ($2 = '' OR l.id::TEXT = $2)
+		GROUP BY l.id, l.tenant_id, l.team_aggregate, l.team_top_k`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare team scores statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := stmt.QueryContext(ctx, teamID, leaderboardID)
+	if err != nil {
+		log.Printf("Failed to query scores of team '%s': %v", teamID, err)
+		return nil, fmt.Errorf("failed to get scores of team '%s': %w", teamID, translateError(err))
+	}
+	defer rows.Close()
+
+	scores := make([]models.TeamScore, 0)
+	for rows.Next() {
+		score := models.TeamScore{TeamID: teamID}
+		if err := rows.Scan(&score.LeaderboardID, &score.TenantID, &score.Score, &score.Hidden); err != nil {
+			log.Printf("Failed to scan team score: %v", err)
+			return nil, fmt.Errorf("failed to scan team score: %w", err)
+		}
+		scores = append(scores, score)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan team scores: %v", err)
+		return nil, fmt.Errorf("failed to scan team scores: %w", err)
+	}
+
+	return scores, nil
+}
+
+// Aggregates the scores of every team with a ranked member on the leaderboard, used to rebuild its
+// team ranking, nothing is returned when the leaderboard has no team ranking
+func (tr *TeamRepoPG) GetLeaderboardTeamScores(ctx context.Context, leaderboardID string) ([]models.TeamScore, error) {
+	stmt, err := tr.db.PrepareContext(ctx, `
+		WITH best AS (
+			SELECT m.team_id, MAX(e.score) AS score
+			FROM team_members m
+			JOIN users u
+				ON m.user_id = u.id
+			JOIN leaderboard_entries e
+				ON e.user_id = u.id
+			WHERE e.leaderboard_id = $1
+				AND e.status IN ('accepted', 'verified')
+				AND e.deleted_at IS NULL
+				AND u.deleted_at IS NULL
+				AND `+userInGoodStanding+`
+			GROUP BY m.team_id, e.user_id
+		), ordered AS (
+			SELECT team_id, score, ROW_NUMBER() OVER (PARTITION BY team_id ORDER BY score DESC) AS position
+			FROM best
+		)
+		SELECT o.team_id, `+teamAggregateScore+`
+		FROM ordered o
+		JOIN leaderboards l
+			ON l.id = $1
+		WHERE l.team_aggregate IS NOT NULL AND l.deleted_at IS NULL AND l.tenant_id = $2
+		GROUP BY o.team_id, l.team_aggregate, l.team_top_k`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare leaderboard team scores statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := stmt.QueryContext(ctx, leaderboardID, models.TenantFromContext(ctx))
+	if err != nil {
+		log.Printf("Failed to query team scores of leaderboard '%s': %v", leaderboardID, err)
+		return nil, fmt.Errorf("failed to get team scores of leaderboard '%s': %w", leaderboardID, translateError(err))
+	}
+	defer rows.Close()
+
+	scores := make([]models.TeamScore, 0)
+	for rows.Next() {
+		score := models.TeamScore{TenantID: models.TenantFromContext(ctx), LeaderboardID: leaderboardID}
+		if err := rows.Scan(&score.TeamID, &score.Score); err != nil {
+			log.Printf("Failed to scan team score: %v", err)
+			return nil, fmt.Errorf("failed to scan team score: %w", err)
+		}
+		scores = append(scores, score)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan team scores: %v", err)
+		return nil, fmt.Errorf("failed to scan team scores: %w", err)
+	}
+
+	return scores, nil
+}
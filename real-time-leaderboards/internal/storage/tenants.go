@@ -0,0 +1,253 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+)
+
+// Tenants own their leaderboards, the memberships of their players and the API keys naming them
+// Repositories scope their queries by the tenant carried in the context, see models.TenantFromContext
+type TenantRepo interface {
+	Create(context.Context, *models.TenantRequest) (*models.Tenant, error)
+	Get(context.Context, string) (*models.Tenant, error)
+	SetMember(context.Context, *models.TenantMemberRequest) (*models.TenantMember, error)
+	GetMemberRole(ctx context.Context, tenantID, userID string) (string, error)
+	CreateAPIKey(ctx context.Context, request *models.APIKeyRequest, keyHash string) (*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, tenantID, keyID string) error
+	GetByAPIKey(ctx context.Context, keyHash string) (*models.APIKey, error)
+}
+
+type TenantRepoPG struct {
+	db *sql.DB
+}
+
+func NewTenantRepoPG(db *sql.DB) *TenantRepoPG {
+	return &TenantRepoPG{
+		db: db,
+	}
+}
+
+// Creates a tenant, returns ErrConflict if the slug is taken
+func (tr *TenantRepoPG) Create(ctx context.Context, request *models.TenantRequest) (*models.Tenant, error) {
+	stmt, err := tr.db.PrepareContext(ctx, `
+		INSERT INTO tenants (slug, name)
+		VALUES ($1, $2)
+		RETURNING id, slug, name, created_at`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare insert tenant statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var tenant models.Tenant
+	if err := stmt.QueryRowContext(ctx, request.Slug, request.Name).Scan(
+		&tenant.ID,
+		&tenant.Slug,
+		&tenant.Name,
+		&tenant.CreatedAt,
+	); err != nil {
+		log.Printf("Failed to insert tenant: %v", err)
+		return nil, fmt.Errorf("failed to create tenant: %w", translateError(err))
+	}
+
+	return &tenant, nil
+}
+
+func (tr *TenantRepoPG) Get(ctx context.Context, tenantID string) (*models.Tenant, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var tenant models.Tenant
+	if err := tr.db.QueryRowContext(
+		ctx,
+		`SELECT id, slug, name, created_at FROM tenants WHERE id = $1`,
+		tenantID,
+	).Scan(
+		&tenant.ID,
+		&tenant.Slug,
+		&tenant.Name,
+		&tenant.CreatedAt,
+	); err != nil {
+		log.Printf("Failed to get tenant '%s': %v", tenantID, err)
+		return nil, fmt.Errorf("failed to get tenant '%s': %w", tenantID, translateError(err))
+	}
+
+	return &tenant, nil
+}
+
+// Adds the user to the tenant or changes its role, returns ErrInvalidReference if the tenant or user does not exist
+func (tr *TenantRepoPG) SetMember(ctx context.Context, request *models.TenantMemberRequest) (*models.TenantMember, error) {
+	stmt, err := tr.db.PrepareContext(ctx, `
+		INSERT INTO tenant_members (tenant_id, user_id, role)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (tenant_id, user_id) DO UPDATE SET role = EXCLUDED.role
+		RETURNING tenant_id, user_id, role, created_at`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare upsert tenant member statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var member models.TenantMember
+	if err := stmt.QueryRowContext(ctx, request.TenantID, request.UserID, request.Role).Scan(
+		&member.TenantID,
+		&member.UserID,
+		&member.Role,
+		&member.CreatedAt,
+	); err != nil {
+		log.Printf("Failed to upsert tenant member: %v", err)
+		return nil, fmt.Errorf("failed to set member of tenant '%s': %w", request.TenantID, translateError(err))
+	}
+
+	return &member, nil
+}
+
+// Role of the user in the tenant, returns ErrNotFound if the user is not a member
+func (tr *TenantRepoPG) GetMemberRole(ctx context.Context, tenantID, userID string) (string, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var role string
+	if err := tr.db.QueryRowContext(
+		ctx,
+		`SELECT role FROM tenant_members WHERE tenant_id = $1 AND user_id = $2`,
+		tenantID,
+		userID,
+	).Scan(&role); err != nil {
+		log.Printf("Failed to get role of user '%s' in tenant '%s': %v", userID, tenantID, err)
+		return "", fmt.Errorf("failed to get role of user '%s': %w", userID, translateError(err))
+	}
+
+	return role, nil
+}
+
+// Stores the hash of a new API key of the tenant, returns ErrInvalidReference if the tenant does not exist
+func (tr *TenantRepoPG) CreateAPIKey(ctx context.Context, request *models.APIKeyRequest, keyHash string) (*models.APIKey, error) {
+	stmt, err := tr.db.PrepareContext(ctx, `
+		INSERT INTO tenant_api_keys (tenant_id, name, prefix, key_hash)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+apiKeyColumns,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare insert API key statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var apiKey models.APIKey
+	if err := scanAPIKey(
+		stmt.QueryRowContext(ctx, request.TenantID, request.Name, request.Prefix, keyHash),
+		&apiKey,
+	); err != nil {
+		log.Printf("Failed to insert API key: %v", err)
+		return nil, fmt.Errorf("failed to create API key of tenant '%s': %w", request.TenantID, translateError(err))
+	}
+
+	return &apiKey, nil
+}
+
+// Revokes the API key, returns ErrNotFound if the tenant has no such key left active
+func (tr *TenantRepoPG) RevokeAPIKey(ctx context.Context, tenantID, keyID string) error {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := tr.db.ExecContext(
+		ctx,
+		`UPDATE tenant_api_keys SET revoked_at = $3 WHERE id = $1 AND tenant_id = $2 AND revoked_at IS NULL`,
+		keyID,
+		tenantID,
+		time.Now(),
+	)
+	if err != nil {
+		log.Printf("Failed to revoke API key '%s': %v", keyID, err)
+		return fmt.Errorf("failed to revoke API key '%s': %w", keyID, translateError(err))
+	}
+
+	revoked, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("Failed to read revoked API keys: %v", err)
+		return fmt.Errorf("failed to read revoked API keys: %w", err)
+	}
+	if revoked == 0 {
+		return fmt.Errorf("failed to revoke API key '%s': %w", keyID, ErrNotFound)
+	}
+
+	return nil
+}
+
+// Active API key with the given hash, returns ErrNotFound if the key is unknown or revoked
+func (tr *TenantRepoPG) GetByAPIKey(ctx context.Context, keyHash string) (*models.APIKey, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var apiKey models.APIKey
+	if err := scanAPIKey(tr.db.QueryRowContext(
+		ctx,
+		`SELECT `+apiKeyColumns+` FROM tenant_api_keys WHERE key_hash = $1 AND revoked_at IS NULL`,
+		keyHash,
+	), &apiKey); err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", translateError(err))
+	}
+
+	return &apiKey, nil
+}
+
+// Columns read into a models.APIKey by scanAPIKey, in order
+const apiKeyColumns = `id, tenant_id, name, prefix, created_at, revoked_at`
+
+func scanAPIKey(row rowScanner, apiKey *models.APIKey) error {
+	var revokedAt sql.NullTime
+	if err := row.Scan(
+		&apiKey.ID,
+		&apiKey.TenantID,
+		&apiKey.Name,
+		&apiKey.Prefix,
+		&apiKey.CreatedAt,
+		&revokedAt,
+	); err != nil {
+		return err
+	}
+	if revokedAt.Valid {
+		apiKey.RevokedAt = &revokedAt.Time
+	}
+	return nil
+}
+
+// Restricts a leaderboard id column to the leaderboards of the tenant bound to the given parameter
+func inTenant(column, param string) string {
+	return column + " IN (SELECT id FROM leaderboards WHERE tenant_id = " + param + ")"
+}
+
+// Players join the tenant of the leaderboards they submit entries to
+func joinTenant(ctx context.Context, tx *sql.Tx, tenantID, userID string) error {
+	if _, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO tenant_members (tenant_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		tenantID,
+		userID,
+	); err != nil {
+		log.Printf("Failed to add user '%s' to tenant '%s': %v", userID, tenantID, err)
+		return fmt.Errorf("failed to add user to tenant: %w", translateError(err))
+	}
+	return nil
+}
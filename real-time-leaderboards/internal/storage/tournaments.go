@@ -0,0 +1,561 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+)
+
+// Tournaments seeded from the ranking of a qualification leaderboard
+type TournamentRepo interface {
+	Create(context.Context, *models.TournamentRequest) (*models.Tournament, error)
+	Get(context.Context, string) (*models.Tournament, error)
+	Start(context.Context, string, *models.AuditActor) (*models.Tournament, error)
+	RecordResult(context.Context, string, int, *models.MatchResult, *models.AuditActor) (*models.Tournament, error)
+}
+
+type TournamentRepoPG struct {
+	db *sql.DB
+}
+
+func NewTournamentRepoPG(db *sql.DB) *TournamentRepoPG {
+	return &TournamentRepoPG{
+		db: db,
+	}
+}
+
+// Implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...any) *sql.Row
+}
+
+// Creates a pending tournament, returns ErrInvalidReference if the leaderboard does not exist in the tenant of the request
+func (tr *TournamentRepoPG) Create(ctx context.Context, request *models.TournamentRequest) (*models.Tournament, error) {
+	stmt, err := tr.db.PrepareContext(ctx, `
+		INSERT INTO tournaments (name, leaderboard_id, format, size)
+		SELECT $1, l.id, $3, $4
+		FROM leaderboards l
+		WHERE l.id = $2 AND l.tenant_id = $5 AND l.deleted_at IS NULL
+		RETURNING `+tournamentColumns,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare insert tournament statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var tournament models.Tournament
+	err = scanTournament(
+		stmt.QueryRowContext(
+			ctx,
+			request.Name,
+			request.LeaderboardID,
+			request.Format,
+			request.Size,
+			models.TenantFromContext(ctx),
+		),
+		&tournament,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to create tournament on leaderboard '%s': %w", request.LeaderboardID, ErrInvalidReference)
+	}
+	if err != nil {
+		log.Printf("Failed to insert tournament: %v", err)
+		return nil, fmt.Errorf("failed to create tournament: %w", translateError(err))
+	}
+
+	return &tournament, nil
+}
+
+// Returns the tournament with its participants and the state of its bracket
+func (tr *TournamentRepoPG) Get(ctx context.Context, tournamentID string) (*models.Tournament, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return getTournament(ctx, tr.db, tournamentID)
+}
+
+// Seeds the top players of the qualification leaderboard and builds the bracket
+// Seeds follow the leaderboard ranking, ties are decided by its tie-breakers as in Redis
+// Returns ErrStateConflict if the tournament already started or fewer than two players qualified
+func (tr *TournamentRepoPG) Start(
+	ctx context.Context,
+	tournamentID string,
+	actor *models.AuditActor,
+) (*models.Tournament, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var started *models.Tournament
+	err := withTx(ctx, tr.db, func(tx *sql.Tx) error {
+		previous, err := lockTournament(ctx, tx, tournamentID)
+		if err != nil {
+			return err
+		}
+		if previous.Status != models.TournamentStatusPending {
+			return fmt.Errorf("failed to start tournament '%s': %w", tournamentID, ErrStateConflict)
+		}
+
+		participants, err := qualifyParticipants(ctx, tx, previous)
+		if err != nil {
+			return err
+		}
+
+		seeded := make([]string, 0, len(participants))
+		for _, participant := range participants {
+			seeded = append(seeded, participant.User.ID)
+		}
+		matches, err := models.BuildBracket(previous.Format, seeded)
+		if errors.Is(err, models.ErrNotEnoughParticipants) {
+			return fmt.Errorf("failed to start tournament '%s' with %d qualifiers: %w", tournamentID, len(seeded), ErrStateConflict)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to build bracket of tournament '%s': %w", tournamentID, err)
+		}
+
+		for _, participant := range participants {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO tournament_participants (tournament_id, user_id, seed, qualifying_score)
+				VALUES ($1, $2, $3, $4)`,
+				tournamentID,
+				participant.User.ID,
+				participant.Seed,
+				participant.QualifyingScore,
+			); err != nil {
+				log.Printf("Failed to insert tournament participant: %v", err)
+				return fmt.Errorf("failed to insert tournament participant: %w", translateError(err))
+			}
+		}
+
+		for _, match := range matches {
+			if err := insertMatch(ctx, tx, tournamentID, match); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE tournaments
+			SET status = $2, started_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1`,
+			tournamentID,
+			models.TournamentStatusRunning,
+		); err != nil {
+			log.Printf("Failed to start tournament: %v", err)
+			return fmt.Errorf("failed to start tournament '%s': %w", tournamentID, translateError(err))
+		}
+
+		started, err = getTournament(ctx, tx, tournamentID)
+		if err != nil {
+			return err
+		}
+
+		// The bracket follows from the participants, recording it would only bloat the audit log
+		after := *started
+		after.Matches, after.Standings = nil, nil
+		return writeAudit(
+			ctx, tx, actor,
+			models.AuditActionTournamentStart, models.AuditTargetTournament, tournamentID,
+			previous, after,
+		)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return started, nil
+}
+
+// Records the result of a ready match and advances the players through the bracket, the tournament
+// completes once its winner is known
+// Returns ErrStateConflict if the tournament is not running or the match cannot be played yet, and
+// ErrInvalidReference if the winner is not one of the players
+func (tr *TournamentRepoPG) RecordResult(
+	ctx context.Context,
+	tournamentID string,
+	number int,
+	result *models.MatchResult,
+	actor *models.AuditActor,
+) (*models.Tournament, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	var updated *models.Tournament
+	err := withTx(ctx, tr.db, func(tx *sql.Tx) error {
+		locked, err := lockTournament(ctx, tx, tournamentID)
+		if err != nil {
+			return err
+		}
+		if locked.Status != models.TournamentStatusRunning {
+			return fmt.Errorf("failed to record result in tournament '%s': %w", tournamentID, ErrStateConflict)
+		}
+
+		tournament, err := getTournament(ctx, tx, tournamentID)
+		if err != nil {
+			return err
+		}
+		if number < 0 || number >= len(tournament.Matches) {
+			return fmt.Errorf("failed to get match %d of tournament '%s': %w", number, tournamentID, ErrNotFound)
+		}
+		previous := tournament.Matches[number]
+
+		changed, err := models.RecordResult(tournament.Matches, number, *result, time.Now())
+		switch {
+		case errors.Is(err, models.ErrMatchNotFound):
+			return fmt.Errorf("failed to get match %d of tournament '%s': %w", number, tournamentID, ErrNotFound)
+		case errors.Is(err, models.ErrMatchNotReady):
+			return fmt.Errorf("failed to record result of match %d: %w", number, ErrStateConflict)
+		case errors.Is(err, models.ErrNotInMatch):
+			return fmt.Errorf("failed to record result of match %d: %w", number, ErrInvalidReference)
+		case err != nil:
+			return fmt.Errorf("failed to record result of match %d: %w", number, err)
+		}
+
+		for _, n := range changed {
+			if err := updateMatch(ctx, tx, tournamentID, tournament.Matches[n]); err != nil {
+				return err
+			}
+		}
+
+		winnerID := models.TournamentWinner(tournament.Format, tournament.Matches, tournament.Participants)
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE tournaments
+			SET
+				status = CASE WHEN $2 = '' THEN status ELSE $3 END,
+				winner_id = NULLIF($2, '')::BIGINT,
+				completed_at = CASE WHEN $2 = '' THEN NULL ELSE CURRENT_TIMESTAMP END,
+				updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1`,
+			tournamentID,
+			winnerID,
+			models.TournamentStatusCompleted,
+		); err != nil {
+			log.Printf("Failed to update tournament: %v", err)
+			return fmt.Errorf("failed to update tournament '%s': %w", tournamentID, translateError(err))
+		}
+
+		if err := writeAudit(
+			ctx, tx, actor,
+			models.AuditActionTournamentResult, models.AuditTargetTournament, tournamentID,
+			previous, tournament.Matches[number],
+		); err != nil {
+			return err
+		}
+
+		updated, err = getTournament(ctx, tx, tournamentID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// Best ranked score of the top players of the leaderboard, in seed order
+func qualifyParticipants(
+	ctx context.Context,
+	tx *sql.Tx,
+	tournament *models.Tournament,
+) ([]models.TournamentParticipant, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT user_id, username, score
+		FROM (
+			SELECT DISTINCT ON (e.user_id) e.user_id, u.username, e.score, e.sort_key
+			FROM leaderboard_entries e
+			JOIN users u
+				ON e.user_id = u.id
+			JOIN leaderboards l
+				ON e.leaderboard_id = l.id
+			WHERE e.leaderboard_id = $1
+				AND e.status IN ('accepted', 'verified')
+				AND e.deleted_at IS NULL
+				AND l.deleted_at IS NULL
+				AND u.deleted_at IS NULL
+				AND `+userInGoodStanding+`
+			ORDER BY e.user_id, e.sort_key DESC
+		) best
+		ORDER BY sort_key DESC, user_id::TEXT COLLATE "C" DESC
+		LIMIT $2`,
+		tournament.LeaderboardID,
+		tournament.Size,
+	)
+	if err != nil {
+		log.Printf("Failed to query qualifiers of tournament '%s': %v", tournament.ID, err)
+		return nil, fmt.Errorf("failed to get qualifiers of tournament '%s': %w", tournament.ID, translateError(err))
+	}
+	defer rows.Close()
+
+	participants := make([]models.TournamentParticipant, 0, tournament.Size)
+	for rows.Next() {
+		participant := models.TournamentParticipant{Seed: len(participants) + 1}
+		if err := rows.Scan(&participant.User.ID, &participant.User.Username, &participant.QualifyingScore); err != nil {
+			log.Printf("Failed to scan qualifier: %v", err)
+			return nil, fmt.Errorf("failed to scan qualifier: %w", err)
+		}
+		participants = append(participants, participant)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan qualifiers: %v", err)
+		return nil, fmt.Errorf("failed to scan qualifiers: %w", err)
+	}
+
+	return participants, nil
+}
+
+// Columns read into a models.Tournament by scanTournament, in order
+const tournamentColumns = `
+	id, name, leaderboard_id, format, size, status, COALESCE(winner_id::TEXT, ''),
+	created_at, updated_at, started_at, completed_at`
+
+func scanTournament(row rowScanner, tournament *models.Tournament) error {
+	return row.Scan(
+		&tournament.ID,
+		&tournament.Name,
+		&tournament.LeaderboardID,
+		&tournament.Format,
+		&tournament.Size,
+		&tournament.Status,
+		&tournament.WinnerID,
+		&tournament.CreatedAt,
+		&tournament.UpdatedAt,
+		&tournament.StartedAt,
+		&tournament.CompletedAt,
+	)
+}
+
+// Reads the tournament and holds a row lock on it until the transaction ends, serialising results
+// Tournaments on leaderboards of other tenants are reported as not found
+func lockTournament(ctx context.Context, tx *sql.Tx, tournamentID string) (*models.Tournament, error) {
+	var tournament models.Tournament
+	if err := scanTournament(
+		tx.QueryRowContext(
+			ctx,
+			`SELECT `+tournamentColumns+` FROM tournaments WHERE id = $1 AND `+inTenant("leaderboard_id", "$2")+` FOR UPDATE`,
+			tournamentID,
+			models.TenantFromContext(ctx),
+		),
+		&tournament,
+	); err != nil {
+		log.Printf("Failed to lock tournament: %v", err)
+		return nil, fmt.Errorf("failed to lock tournament: %w", translateError(err))
+	}
+
+	return &tournament, nil
+}
+
+// Tournaments on leaderboards of other tenants are reported as not found
+func getTournament(ctx context.Context, q queryer, tournamentID string) (*models.Tournament, error) {
+	var tournament models.Tournament
+	if err := scanTournament(
+		q.QueryRowContext(
+			ctx,
+			`SELECT `+tournamentColumns+` FROM tournaments WHERE id = $1 AND `+inTenant("leaderboard_id", "$2"),
+			tournamentID,
+			models.TenantFromContext(ctx),
+		),
+		&tournament,
+	); err != nil {
+		log.Printf("Failed to get tournament '%s': %v", tournamentID, err)
+		return nil, fmt.Errorf("failed to get tournament '%s': %w", tournamentID, translateError(err))
+	}
+
+	participants, err := getParticipants(ctx, q, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	tournament.Participants = participants
+
+	matches, err := getMatches(ctx, q, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	tournament.Matches = matches
+
+	if tournament.Format == models.TournamentFormatRoundRobin && len(participants) > 0 {
+		tournament.Standings = models.RoundRobinStandings(participants, matches)
+	}
+
+	return &tournament, nil
+}
+
+func getParticipants(ctx context.Context, q queryer, tournamentID string) ([]models.TournamentParticipant, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT p.seed, u.id, u.username, p.qualifying_score
+		FROM tournament_participants p
+		JOIN users u
+			ON p.user_id = u.id
+		WHERE p.tournament_id = $1
+		ORDER BY p.seed ASC`,
+		tournamentID,
+	)
+	if err != nil {
+		log.Printf("Failed to query participants of tournament '%s': %v", tournamentID, err)
+		return nil, fmt.Errorf("failed to get participants of tournament '%s': %w", tournamentID, translateError(err))
+	}
+	defer rows.Close()
+
+	participants := make([]models.TournamentParticipant, 0)
+	for rows.Next() {
+		var participant models.TournamentParticipant
+		if err := rows.Scan(
+			&participant.Seed,
+			&participant.User.ID,
+			&participant.User.Username,
+			&participant.QualifyingScore,
+		); err != nil {
+			log.Printf("Failed to scan tournament participant: %v", err)
+			return nil, fmt.Errorf("failed to scan tournament participant: %w", err)
+		}
+		participants = append(participants, participant)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan tournament participants: %v", err)
+		return nil, fmt.Errorf("failed to scan tournament participants: %w", err)
+	}
+
+	return participants, nil
+}
+
+func getMatches(ctx context.Context, q queryer, tournamentID string) ([]models.TournamentMatch, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT
+			number, bracket, round, position,
+			COALESCE(player1_id::TEXT, ''), COALESCE(player2_id::TEXT, ''), player1_score, player2_score,
+			COALESCE(winner_id::TEXT, ''), status, next_match, next_slot, loser_match, loser_slot, completed_at
+		FROM tournament_matches
+		WHERE tournament_id = $1
+		ORDER BY number ASC`,
+		tournamentID,
+	)
+	if err != nil {
+		log.Printf("Failed to query matches of tournament '%s': %v", tournamentID, err)
+		return nil, fmt.Errorf("failed to get matches of tournament '%s': %w", tournamentID, translateError(err))
+	}
+	defer rows.Close()
+
+	matches := make([]models.TournamentMatch, 0)
+	for rows.Next() {
+		var match models.TournamentMatch
+		var nextMatch, nextSlot, loserMatch, loserSlot sql.NullInt32
+		if err := rows.Scan(
+			&match.Number,
+			&match.Bracket,
+			&match.Round,
+			&match.Position,
+			&match.Player1ID,
+			&match.Player2ID,
+			&match.Player1Score,
+			&match.Player2Score,
+			&match.WinnerID,
+			&match.Status,
+			&nextMatch,
+			&nextSlot,
+			&loserMatch,
+			&loserSlot,
+			&match.CompletedAt,
+		); err != nil {
+			log.Printf("Failed to scan tournament match: %v", err)
+			return nil, fmt.Errorf("failed to scan tournament match: %w", err)
+		}
+		if nextMatch.Valid {
+			match.Next = &models.MatchSlot{Match: int(nextMatch.Int32), Slot: int(nextSlot.Int32)}
+		}
+		if loserMatch.Valid {
+			match.LoserNext = &models.MatchSlot{Match: int(loserMatch.Int32), Slot: int(loserSlot.Int32)}
+		}
+		matches = append(matches, match)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan tournament matches: %v", err)
+		return nil, fmt.Errorf("failed to scan tournament matches: %w", err)
+	}
+
+	return matches, nil
+}
+
+// Splits a match slot into its nullable match and slot columns
+func matchSlotArgs(slot *models.MatchSlot) (sql.NullInt32, sql.NullInt32) {
+	if slot == nil {
+		return sql.NullInt32{}, sql.NullInt32{}
+	}
+	return sql.NullInt32{Int32: int32(slot.Match), Valid: true}, sql.NullInt32{Int32: int32(slot.Slot), Valid: true}
+}
+
+func insertMatch(ctx context.Context, tx *sql.Tx, tournamentID string, match models.TournamentMatch) error {
+	nextMatch, nextSlot := matchSlotArgs(match.Next)
+	loserMatch, loserSlot := matchSlotArgs(match.LoserNext)
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO tournament_matches (
+			tournament_id, number, bracket, round, position, player1_id, player2_id, winner_id, status,
+			next_match, next_slot, loser_match, loser_slot
+		)
+		VALUES (
+			$1, $2, $3, $4, $5, NULLIF($6, '')::BIGINT, NULLIF($7, '')::BIGINT, NULLIF($8, '')::BIGINT, $9,
+			$10, $11, $12, $13
+		)`,
+		tournamentID,
+		match.Number,
+		match.Bracket,
+		match.Round,
+		match.Position,
+		match.Player1ID,
+		match.Player2ID,
+		match.WinnerID,
+		match.Status,
+		nextMatch,
+		nextSlot,
+		loserMatch,
+		loserSlot,
+	); err != nil {
+		log.Printf("Failed to insert tournament match: %v", err)
+		return fmt.Errorf("failed to insert match %d of tournament '%s': %w", match.Number, tournamentID, translateError(err))
+	}
+
+	return nil
+}
+
+// Writes the players, result and status of the match, its place in the bracket never changes
+func updateMatch(ctx context.Context, tx *sql.Tx, tournamentID string, match models.TournamentMatch) error {
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE tournament_matches
+		SET
+			player1_id = NULLIF($3, '')::BIGINT,
+			player2_id = NULLIF($4, '')::BIGINT,
+			player1_score = $5,
+			player2_score = $6,
+			winner_id = NULLIF($7, '')::BIGINT,
+			status = $8,
+			completed_at = $9
+		WHERE tournament_id = $1 AND number = $2`,
+		tournamentID,
+		match.Number,
+		match.Player1ID,
+		match.Player2ID,
+		match.Player1Score,
+		match.Player2Score,
+		match.WinnerID,
+		match.Status,
+		match.CompletedAt,
+	); err != nil {
+		log.Printf("Failed to update tournament match: %v", err)
+		return fmt.Errorf("failed to update match %d of tournament '%s': %w", match.Number, tournamentID, translateError(err))
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTournamentsScopedToTenant(t *testing.T) {
+	// Leaderboard 1 belongs to another tenant, so the fake database has no rows for tenant 4
+	db, fake := newFakeDB(t)
+	repo := NewTournamentRepoPG(db)
+	ctx := models.ContextWithTenant(context.Background(), "4")
+
+	_, err := repo.Create(ctx, &models.TournamentRequest{
+		Name:          "Spring Cup",
+		LeaderboardID: "1",
+		Format:        models.TournamentFormatSingleElimination,
+		Size:          8,
+	})
+	assert.ErrorIs(t, err, ErrInvalidReference)
+
+	_, err = repo.Get(ctx, "5")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	statements := fake.statements("tournaments")
+	assert.Len(t, statements, 2)
+	assert.Contains(t, statements[0].query, "l.tenant_id = $5")
+	assert.Equal(t, "4", statements[0].args[4])
+	assert.Contains(t, statements[1].query, "leaderboard_id IN (SELECT id FROM leaderboards WHERE tenant_id = $2)")
+	assert.Equal(t, "4", statements[1].args[1])
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/lib/pq"
 	"github.com/mochivi/go-real-time-leaderboards/internal/models"
 )
 
@@ -14,8 +15,61 @@ type UserRepo interface {
 	Create(context.Context, *models.RegisterUser, string) (*models.User, error)
 	GetByUsername(context.Context, string) (*models.User, error)
 	GetByID(context.Context, string) (*models.User, error)
-	Update(context.Context, *models.UpdateUser) (*models.User, error)
-	Delete(context.Context, string) error
+	GetByIDs(context.Context, []string) ([]models.User, error)
+	Update(context.Context, *models.UpdateUser, *models.AuditActor) (*models.User, error)
+	Delete(context.Context, string, *models.AuditActor) error
+	Restore(context.Context, string, *models.AuditActor) (*models.User, error)
+	PurgeDeleted(context.Context, time.Time) (int64, error)
+	Export(context.Context, string) (*models.UserExport, error)
+	GetProfile(context.Context, string, string) (*models.PlayerProfile, error)
+	Erase(context.Context, string, *models.AuditActor) error
+	Ban(context.Context, string, *models.BanRequest, *models.AuditActor) (*models.Ban, error)
+	Unban(context.Context, string, *models.AuditActor) error
+	GetBans(context.Context, *models.BanFilter) ([]models.Ban, error)
+	ExpireBans(context.Context) ([]string, error)
+}
+
+// Matches users with an active ban or shadowban, u must be the alias of the users table
+// Permanent bans have no banned_until, the predicates must never be NULL so they can be negated and scanned
+const userBanned = `(u.ban_type IS NOT NULL AND (u.banned_until IS NULL OR u.banned_until > CURRENT_TIMESTAMP))`
+
+// Matches users without an active ban or shadowban, u must be the alias of the users table
+const userInGoodStanding = `(NOT ` + userBanned + `)`
+
+// Ban columns of the users table, in the order read by banColumns
+const userBanColumns = `ban_type, COALESCE(ban_reason, ''), banned_until, banned_by, banned_at`
+
+// Nullable ban columns of a user row
+type banColumns struct {
+	banType   sql.NullString
+	reason    string
+	expiresAt sql.NullTime
+	bannedBy  sql.NullString
+	bannedAt  sql.NullTime
+}
+
+func (b *banColumns) dest() []any {
+	return []any{&b.banType, &b.reason, &b.expiresAt, &b.bannedBy, &b.bannedAt}
+}
+
+// Returns nil if the user has no ban, expired bans are returned until the expiry job clears them
+func (b banColumns) toBan(userID string) *models.Ban {
+	if !b.banType.Valid {
+		return nil
+	}
+
+	ban := models.Ban{
+		UserID:    userID,
+		Type:      b.banType.String,
+		Reason:    b.reason,
+		BannedBy:  b.bannedBy.String,
+		CreatedAt: b.bannedAt.Time,
+	}
+	if b.expiresAt.Valid {
+		ban.ExpiresAt = &b.expiresAt.Time
+	}
+
+	return &ban
 }
 
 // Users will be added to postgres users table
@@ -29,12 +83,19 @@ func NewUserRepoPG(db *sql.DB) *UserRepoPG {
 	}
 }
 
+// Accounts are shared by every tenant, the user joins the tenant it registers in
 func (ur *UserRepoPG) Create(ctx context.Context, registerUser *models.RegisterUser, passwordHash string) (*models.User, error) {
 
 	stmt, err := ur.db.PrepareContext(ctx, `
-		INSERT INTO users (username, password_hash, email)
-		VALUES ($1, $2, $3)
-		RETURNING id, username, email, role, created_at, updated_at
+		WITH created AS (
+			INSERT INTO users (username, password_hash, email)
+			VALUES ($1, $2, $3)
+			RETURNING id, username, email, role, created_at, updated_at
+		), joined AS (
+			INSERT INTO tenant_members (tenant_id, user_id)
+			SELECT $4, id FROM created
+		)
+		SELECT id, username, email, role, created_at, updated_at FROM created
 	`)
 	if err != nil {
 		log.Printf("failed to prepare user creation statement: %v", err)
@@ -50,6 +111,7 @@ func (ur *UserRepoPG) Create(ctx context.Context, registerUser *models.RegisterU
 		registerUser.Username,
 		passwordHash,
 		registerUser.Email,
+		models.TenantFromContext(ctx),
 	).Scan(
 		&createdUser.ID,
 		&createdUser.Username,
@@ -59,7 +121,7 @@ func (ur *UserRepoPG) Create(ctx context.Context, registerUser *models.RegisterU
 		&createdUser.UpdatedAt,
 	); err != nil {
 		log.Printf("failed to execute user creation query: %v", err)
-		return nil, fmt.Errorf("failed to execute user creation query: %w", err)
+		return nil, fmt.Errorf("failed to execute user creation query: %w", translateError(err))
 	}
 	
 	return &createdUser, nil
@@ -69,9 +131,9 @@ func (ur *UserRepoPG) Create(ctx context.Context, registerUser *models.RegisterU
 func (ur *UserRepoPG) GetByUsername(ctx context.Context, username string) (*models.User, error) {
 
 	stmt, err := ur.db.PrepareContext(ctx, `
-		SELECT id, username, password_hash, email, role, created_at, updated_at
+		SELECT id, username, password_hash, email, role, created_at, updated_at, `+userBanColumns+`
 		FROM users
-		WHERE username = $1`,
+		WHERE username = $1 AND deleted_at IS NULL`,
 	)
 	if err != nil {
 		log.Printf("failed to prepare query user by username: %v", err)
@@ -82,7 +144,8 @@ func (ur *UserRepoPG) GetByUsername(ctx context.Context, username string) (*mode
 	defer cancel()
 
 	var user models.User
-	if err = stmt.QueryRowContext(ctx, username).Scan(
+	var ban banColumns
+	if err = stmt.QueryRowContext(ctx, username).Scan(append([]any{
 		&user.ID,
 		&user.Username,
 		&user.PasswordHash,
@@ -90,10 +153,11 @@ func (ur *UserRepoPG) GetByUsername(ctx context.Context, username string) (*mode
 		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
-	); err != nil {
+	}, ban.dest()...)...); err != nil {
 		log.Printf("failed to query user by username: %v", err)
-		return nil, fmt.Errorf("failed to query user by username: %w", err)
+		return nil, fmt.Errorf("failed to query user by username: %w", translateError(err))
 	}
+	user.Ban = ban.toBan(user.ID)
 	
 	return &user, nil
 }
@@ -101,9 +165,9 @@ func (ur *UserRepoPG) GetByUsername(ctx context.Context, username string) (*mode
 func (ur *UserRepoPG) GetByID(ctx context.Context, userID string) (*models.User, error) {
 
 	stmt, err := ur.db.PrepareContext(ctx, `
-		SELECT id, username, password_hash, email, role, created_at, updated_at
+		SELECT id, username, password_hash, email, role, created_at, updated_at, `+userBanColumns+`
 		FROM users
-		WHERE id = $1`,
+		WHERE id = $1 AND deleted_at IS NULL`,
 	)
 	if err != nil {
 		log.Printf("failed to prepare query user by username: %v", err)
@@ -114,7 +178,8 @@ func (ur *UserRepoPG) GetByID(ctx context.Context, userID string) (*models.User,
 	defer cancel()
 
 	var user models.User
-	if err = stmt.QueryRowContext(ctx, userID).Scan(
+	var ban banColumns
+	if err = stmt.QueryRowContext(ctx, userID).Scan(append([]any{
 		&user.ID,
 		&user.Username,
 		&user.PasswordHash,
@@ -122,76 +187,851 @@ func (ur *UserRepoPG) GetByID(ctx context.Context, userID string) (*models.User,
 		&user.Role,
 		&user.CreatedAt,
 		&user.UpdatedAt,
-	); err != nil {
+	}, ban.dest()...)...); err != nil {
 		log.Printf("failed to query user by username: %v", err)
-		return nil, fmt.Errorf("failed to query user by username: %w", err)
+		return nil, fmt.Errorf("failed to query user by username: %w", translateError(err))
 	}
+	user.Ban = ban.toBan(user.ID)
 	
 	return &user, nil
 }
 
-func (ur *UserRepoPG) Update(ctx context.Context, updateUser *models.UpdateUser) (*models.User, error) {
-
+// Returns the public fields of the users, users that were deleted are left out
+func (ur *UserRepoPG) GetByIDs(ctx context.Context, userIDs []string) ([]models.User, error) {
 	stmt, err := ur.db.PrepareContext(ctx, `
-		UPDATE users 
-		SET 
-			username = $1,
-			email = $2,
-			role= $3
-		WHERE id = $4
-		RETURNING id, username, email, role, created_at, updated_at
-	`)
+		SELECT id, username, created_at, updated_at
+		FROM users
+		WHERE id::TEXT = ANY($1) AND deleted_at IS NULL`,
+	)
 	if err != nil {
-		log.Printf("failed to prepare user creation statement: %v", err)
-		return nil, fmt.Errorf("failed to prepare user creation statement: %w", err)
+		log.Printf("Failed to prepare get users statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
 	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := stmt.QueryContext(ctx, pq.Array(userIDs))
+	if err != nil {
+		log.Printf("Failed to query users: %v", err)
+		return nil, fmt.Errorf("failed to get users: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0, len(userIDs))
+	for rows.Next() {
+		var user models.User
+		if err := rows.Scan(&user.ID, &user.Username, &user.CreatedAt, &user.UpdatedAt); err != nil {
+			log.Printf("Failed to scan user: %v", err)
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan users: %v", err)
+		return nil, fmt.Errorf("failed to scan users: %w", err)
+	}
+
+	return users, nil
+}
+
+// The previous state of the user is recorded in the audit log with the change
+func (ur *UserRepoPG) Update(ctx context.Context, updateUser *models.UpdateUser, actor *models.AuditActor) (*models.User, error) {
 
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
 	var updatedUser models.User
-	if err = stmt.QueryRowContext(
-		ctx,
-		updateUser.Username,
-		updateUser.Email,
-		updateUser.Role,
-		updateUser.ID,
-	).Scan(
-		&updatedUser.ID,
-		&updatedUser.Username,
-		&updatedUser.Email,
-		&updatedUser.Role,
-		&updatedUser.CreatedAt,
-		&updatedUser.UpdatedAt,
-	); err != nil {
-		log.Printf("failed to execute user creation query: %v", err)
-		return nil, fmt.Errorf("failed to execute user creation query: %w", err)
+	err := withTx(ctx, ur.db, func(tx *sql.Tx) error {
+		var previousUser models.User
+		if err := tx.QueryRowContext(ctx, `
+			SELECT id, username, email, role, created_at, updated_at
+			FROM users
+			WHERE id = $1 AND deleted_at IS NULL AND erased_at IS NULL
+			FOR UPDATE`,
+			updateUser.ID,
+		).Scan(
+			&previousUser.ID,
+			&previousUser.Username,
+			&previousUser.Email,
+			&previousUser.Role,
+			&previousUser.CreatedAt,
+			&previousUser.UpdatedAt,
+		); err != nil {
+			log.Printf("failed to lock user: %v", err)
+			return fmt.Errorf("failed to lock user: %w", translateError(err))
+		}
+
+		if err := tx.QueryRowContext(ctx, `
+			UPDATE users 
+			SET 
+				username = $1,
+				email = $2,
+				role= $3
+			WHERE id = $4
+			RETURNING id, username, email, role, created_at, updated_at`,
+			updateUser.Username,
+			updateUser.Email,
+			updateUser.Role,
+			updateUser.ID,
+		).Scan(
+			&updatedUser.ID,
+			&updatedUser.Username,
+			&updatedUser.Email,
+			&updatedUser.Role,
+			&updatedUser.CreatedAt,
+			&updatedUser.UpdatedAt,
+		); err != nil {
+			log.Printf("failed to execute user update query: %v", err)
+			return fmt.Errorf("failed to execute user update query: %w", translateError(err))
+		}
+
+		return writeAudit(
+			ctx, tx, actor,
+			models.AuditActionUserUpdate, models.AuditTargetUser, updatedUser.ID,
+			previousUser, updatedUser,
+		)
+	})
+	if err != nil {
+		return nil, err
 	}
 	
 	return &updatedUser, nil
 }
 
-func (ur *UserRepoPG) Delete(ctx context.Context, userID string) error {
+// Soft deletes the user together with their entries, which keep the deletion time of the user
+// so a restore brings back only the entries deleted with them
+func (ur *UserRepoPG) Delete(ctx context.Context, userID string, actor *models.AuditActor) error {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return withTx(ctx, ur.db, func(tx *sql.Tx) error {
+		var previousUser models.User
+		var deletedAt time.Time
+		if err := tx.QueryRowContext(ctx, `
+			UPDATE users
+			SET deleted_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND deleted_at IS NULL
+			RETURNING id, username, email, role, created_at, updated_at, deleted_at`,
+			userID,
+		).Scan(
+			&previousUser.ID,
+			&previousUser.Username,
+			&previousUser.Email,
+			&previousUser.Role,
+			&previousUser.CreatedAt,
+			&previousUser.UpdatedAt,
+			&deletedAt,
+		); err != nil {
+			log.Printf("Failed to delete user with of id '%s': %v", userID, err)
+			return fmt.Errorf("failed to delete user with of id '%s': %w", userID, translateError(err))
+		}
+
+		if err := setUserEntriesDeletedAt(ctx, tx, userID, `
+			UPDATE leaderboard_entries
+			SET deleted_at = $2
+			WHERE user_id = $1 AND deleted_at IS NULL
+			RETURNING leaderboard_id`,
+			deletedAt,
+		); err != nil {
+			return err
+		}
+
+		return writeAudit(
+			ctx, tx, actor,
+			models.AuditActionUserDelete, models.AuditTargetUser, userID,
+			previousUser, nil,
+		)
+	})
+}
+
+// Restores a soft deleted user and the entries deleted with them
+// Returns ErrStateConflict if the user is not deleted
+func (ur *UserRepoPG) Restore(ctx context.Context, userID string, actor *models.AuditActor) (*models.User, error) {
 
-	stmt, err := ur.db.PrepareContext(ctx, `DELETE FROM users WHERE id = $1`)
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var restoredUser models.User
+	err := withTx(ctx, ur.db, func(tx *sql.Tx) error {
+		var deletedAt sql.NullTime
+		if err := tx.QueryRowContext(
+			ctx,
+			`SELECT deleted_at FROM users WHERE id = $1 FOR UPDATE`,
+			userID,
+		).Scan(&deletedAt); err != nil {
+			log.Printf("Failed to lock user '%s': %v", userID, err)
+			return fmt.Errorf("failed to lock user '%s': %w", userID, translateError(err))
+		}
+		if !deletedAt.Valid {
+			return fmt.Errorf("failed to restore user '%s': %w", userID, ErrStateConflict)
+		}
+
+		// Entries on leaderboards deleted since take the deletion time of their leaderboard,
+		// so they come back with it
+		if err := setUserEntriesDeletedAt(ctx, tx, userID, `
+			UPDATE leaderboard_entries e
+			SET deleted_at = l.deleted_at
+			FROM leaderboards l
+			WHERE e.leaderboard_id = l.id
+				AND e.user_id = $1
+				AND e.deleted_at = $2
+			RETURNING e.leaderboard_id`,
+			deletedAt.Time,
+		); err != nil {
+			return err
+		}
+
+		if err := tx.QueryRowContext(ctx, `
+			UPDATE users
+			SET deleted_at = NULL
+			WHERE id = $1
+			RETURNING id, username, email, role, created_at, updated_at`,
+			userID,
+		).Scan(
+			&restoredUser.ID,
+			&restoredUser.Username,
+			&restoredUser.Email,
+			&restoredUser.Role,
+			&restoredUser.CreatedAt,
+			&restoredUser.UpdatedAt,
+		); err != nil {
+			log.Printf("Failed to restore user with of id '%s': %v", userID, err)
+			return fmt.Errorf("failed to restore user with of id '%s': %w", userID, translateError(err))
+		}
+
+		return writeAudit(
+			ctx, tx, actor,
+			models.AuditActionUserRestore, models.AuditTargetUser, userID,
+			nil, restoredUser,
+		)
+	})
 	if err != nil {
-		log.Printf("failed to prepare user creation statement: %v", err)
-		return fmt.Errorf("failed to prepare user creation statement: %w", err)
+		return nil, err
+	}
+
+	return &restoredUser, nil
+}
+
+// Runs a query changing deleted_at of the entries of a user, which must return the leaderboard of every changed entry,
+// then rebuilds the score stats of those leaderboards
+func setUserEntriesDeletedAt(ctx context.Context, tx *sql.Tx, userID, query string, deletedAt time.Time) error {
+	rows, err := tx.QueryContext(ctx, query, userID, deletedAt)
+	if err != nil {
+		log.Printf("Failed to update entries of user '%s': %v", userID, err)
+		return fmt.Errorf("failed to update entries of user '%s': %w", userID, translateError(err))
+	}
+	defer rows.Close()
+
+	leaderboardIDs := make(map[string]struct{})
+	for rows.Next() {
+		var leaderboardID string
+		if err := rows.Scan(&leaderboardID); err != nil {
+			log.Printf("Failed to scan entry leaderboard: %v", err)
+			return fmt.Errorf("failed to scan entry leaderboard: %w", err)
+		}
+		leaderboardIDs[leaderboardID] = struct{}{}
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan entry leaderboards: %v", err)
+		return fmt.Errorf("failed to scan entry leaderboards: %w", err)
+	}
+	rows.Close()
+
+	// Stats of deleted leaderboards are rebuilt as well, they are only read once it is restored
+	for leaderboardID := range leaderboardIDs {
+		if err := rebuildScoreStats(ctx, tx, leaderboardID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Permanently removes the users soft deleted before the given time, their entries and reports go with them
+// Returns the number of removed users
+func (ur *UserRepoPG) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	result, err := ur.db.ExecContext(ctx, `DELETE FROM users WHERE deleted_at < $1`, before)
+	if err != nil {
+		log.Printf("Failed to purge deleted users: %v", err)
+		return 0, fmt.Errorf("failed to purge deleted users: %w", translateError(err))
 	}
 
+	purged, err := result.RowsAffected()
+	if err != nil {
+		log.Printf("Failed to purge deleted users: %v", err)
+		return 0, fmt.Errorf("failed to purge deleted users: %w", err)
+	}
+
+	return purged, nil
+}
+
+// Matches the entries shown on profiles, the same ones listed on the leaderboards
+// Entries of banned and shadowbanned users are only shown to themselves, $2 must be the viewer id
+// Only the leaderboards of the tenant of the request are listed, $3 must be the tenant id
+// Private leaderboards are only listed to their members
+const profileEntryVisible = `
+	e.status IN ('accepted', 'verified')
+	AND e.deleted_at IS NULL
+	AND l.deleted_at IS NULL
+	AND l.tenant_id = $3
+	AND (l.visibility <> 'private' OR EXISTS (
+		SELECT 1 FROM leaderboard_members m WHERE m.leaderboard_id = l.id AND m.user_id::TEXT = $2
+	))
+	AND (` + userInGoodStanding + ` OR u.id::TEXT = $2)`
+
+// Builds the public profile of the user as seen by the viewer, empty for anonymous viewers
+func (ur *UserRepoPG) GetProfile(ctx context.Context, userID, viewerID string) (*models.PlayerProfile, error) {
+
+	tenantID := models.TenantFromContext(ctx)
 	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	result, err := stmt.ExecContext(ctx, userID)
+	profile := models.PlayerProfile{
+		BestRanks:      make([]models.BoardRank, 0),
+		RecentActivity: make([]models.ProfileActivity, 0),
+	}
+
+	var lastSubmissionAt sql.NullTime
+	if err := ur.db.QueryRowContext(ctx, `
+		SELECT
+			u.id
+			,u.username
+			,u.created_at
+			,COUNT(DISTINCT e.leaderboard_id)
+			,COUNT(e.id)
+			,MAX(e.created_at)
+		FROM users u
+		LEFT JOIN (
+			leaderboard_entries e
+			JOIN leaderboards l
+				ON e.leaderboard_id = l.id
+		)
+			ON e.user_id = u.id AND `+profileEntryVisible+`
+		WHERE u.id = $1 AND u.deleted_at IS NULL
+		GROUP BY u.id`,
+		userID,
+		viewerID,
+		tenantID,
+	).Scan(
+		&profile.ID,
+		&profile.Username,
+		&profile.CreatedAt,
+		&profile.Stats.LeaderboardsPlayed,
+		&profile.Stats.TotalSubmissions,
+		&lastSubmissionAt,
+	); err != nil {
+		log.Printf("Failed to get profile of user '%s': %v", userID, err)
+		return nil, fmt.Errorf("failed to get profile of user '%s': %w", userID, translateError(err))
+	}
+	if lastSubmissionAt.Valid {
+		profile.Stats.LastSubmissionAt = &lastSubmissionAt.Time
+	}
+	if profile.Stats.TotalSubmissions == 0 {
+		return &profile, nil
+	}
+
+	// Ranks are computed only on the leaderboards the user played
+	rankRows, err := ur.db.QueryContext(ctx, `
+		WITH best AS (
+			SELECT e.leaderboard_id, l.rank_mode, e.user_id, MAX(e.score) AS score, MAX(e.sort_key) AS sort_key
+			FROM leaderboard_entries e
+			JOIN leaderboards l
+				ON e.leaderboard_id = l.id
+			JOIN users u
+				ON e.user_id = u.id
+			WHERE e.leaderboard_id IN (
+					SELECT leaderboard_id FROM leaderboard_entries WHERE user_id = $1 AND deleted_at IS NULL
+				)
+				AND `+profileEntryVisible+`
+			GROUP BY e.leaderboard_id, l.rank_mode, e.user_id
+		), ranked AS (
+			-- Numbered in the rank mode of each leaderboard, ordinal ties follow the Redis ranking order
+			SELECT
+				leaderboard_id
+				,user_id
+				,score
+				,CASE rank_mode
+					WHEN 'dense' THEN DENSE_RANK() OVER by_key
+					WHEN 'ordinal' THEN ROW_NUMBER() OVER (
+						PARTITION BY leaderboard_id ORDER BY sort_key DESC, user_id::TEXT COLLATE "C" DESC
+					)
+					ELSE RANK() OVER by_key
+				END AS rank
+			FROM best
+			WINDOW by_key AS (PARTITION BY leaderboard_id ORDER BY sort_key DESC)
+		)
+		SELECT r.leaderboard_id, l.name, r.score, r.rank
+		FROM ranked r
+		JOIN leaderboards l
+			ON r.leaderboard_id = l.id
+		WHERE r.user_id = $1
+		ORDER BY r.rank, l.name`,
+		userID,
+		viewerID,
+		tenantID,
+	)
+	if err != nil {
+		log.Printf("Failed to query ranks of user '%s': %v", userID, err)
+		return nil, fmt.Errorf("failed to get ranks of user '%s': %w", userID, translateError(err))
+	}
+	defer rankRows.Close()
+
+	for rankRows.Next() {
+		var rank models.BoardRank
+		if err := rankRows.Scan(&rank.LeaderboardID, &rank.LeaderboardName, &rank.BestScore, &rank.Rank); err != nil {
+			log.Printf("Failed to scan rank: %v", err)
+			return nil, fmt.Errorf("failed to scan rank: %w", err)
+		}
+		if rank.Rank == 1 {
+			profile.Stats.WorldRecords++
+		}
+		profile.BestRanks = append(profile.BestRanks, rank)
+	}
+	if err := rankRows.Err(); err != nil {
+		log.Printf("Failed to scan ranks: %v", err)
+		return nil, fmt.Errorf("failed to scan ranks: %w", err)
+	}
+
+	activityRows, err := ur.db.QueryContext(ctx, `
+		SELECT e.id, e.leaderboard_id, l.name, e.score, e.created_at
+		FROM leaderboard_entries e
+		JOIN leaderboards l
+			ON e.leaderboard_id = l.id
+		JOIN users u
+			ON e.user_id = u.id
+		WHERE e.user_id = $1 AND `+profileEntryVisible+`
+		ORDER BY e.created_at DESC, e.id DESC
+		LIMIT $4`,
+		userID,
+		viewerID,
+		tenantID,
+		models.ProfileRecentActivityLimit,
+	)
 	if err != nil {
-		log.Printf("Failed to delete user with of id '%s': %v", userID, err)
-		return fmt.Errorf("failed to delete user with of id '%s': %w", userID, err)
+		log.Printf("Failed to query recent activity of user '%s': %v", userID, err)
+		return nil, fmt.Errorf("failed to get recent activity of user '%s': %w", userID, translateError(err))
 	}
+	defer activityRows.Close()
 
-	if rowsAffected, err := result.RowsAffected(); err != nil || rowsAffected == 0 {
-		log.Printf("Failed to delete user with of id '%s', no rows affected: %v", userID, err)
-		return fmt.Errorf("failed to delete user with of id '%s', no rows affected: %w", userID, err)
+	for activityRows.Next() {
+		var activity models.ProfileActivity
+		if err := activityRows.Scan(
+			&activity.EntryID,
+			&activity.LeaderboardID,
+			&activity.LeaderboardName,
+			&activity.Score,
+			&activity.CreatedAt,
+		); err != nil {
+			log.Printf("Failed to scan activity: %v", err)
+			return nil, fmt.Errorf("failed to scan activity: %w", err)
+		}
+		profile.RecentActivity = append(profile.RecentActivity, activity)
+	}
+	if err := activityRows.Err(); err != nil {
+		log.Printf("Failed to scan activities: %v", err)
+		return nil, fmt.Errorf("failed to scan activities: %w", err)
+	}
+
+	return &profile, nil
+}
+
+// Collects everything stored about the user, including entries that are soft deleted
+func (ur *UserRepoPG) Export(ctx context.Context, userID string) (*models.UserExport, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	export := models.UserExport{
+		ExportedAt:        time.Now(),
+		Entries:           make([]models.LeaderboardEntry, 0),
+		SubmissionHistory: make([]models.EntryEvent, 0),
+		Reports:           make([]models.EntryReport, 0),
+	}
+
+	if err := ur.db.QueryRowContext(ctx, `
+		SELECT id, username, email, role, created_at, updated_at
+		FROM users
+		WHERE id = $1 AND deleted_at IS NULL`,
+		userID,
+	).Scan(
+		&export.Profile.ID,
+		&export.Profile.Username,
+		&export.Profile.Email,
+		&export.Profile.Role,
+		&export.Profile.CreatedAt,
+		&export.Profile.UpdatedAt,
+	); err != nil {
+		log.Printf("Failed to get user '%s' for export: %v", userID, err)
+		return nil, fmt.Errorf("failed to get user '%s': %w", userID, translateError(err))
+	}
+
+	entryRows, err := ur.db.QueryContext(ctx, `
+		SELECT `+entryColumns+`
+		FROM leaderboard_entries
+		WHERE user_id = $1
+		ORDER BY created_at, id`,
+		userID,
+	)
+	if err != nil {
+		log.Printf("Failed to query entries of user '%s': %v", userID, err)
+		return nil, fmt.Errorf("failed to get entries of user '%s': %w", userID, translateError(err))
+	}
+	defer entryRows.Close()
+
+	for entryRows.Next() {
+		var entry models.LeaderboardEntry
+		if err := scanEntry(entryRows, &entry); err != nil {
+			log.Printf("Failed to scan entry: %v", err)
+			return nil, fmt.Errorf("failed to scan entry: %w", err)
+		}
+		entry.User = export.Profile
+		export.Entries = append(export.Entries, entry)
+	}
+	if err := entryRows.Err(); err != nil {
+		log.Printf("Failed to scan entries: %v", err)
+		return nil, fmt.Errorf("failed to scan entries: %w", err)
+	}
+
+	historyRows, err := ur.db.QueryContext(ctx, `
+		SELECT a.target_id, a.action, a.before, a.after, a.created_at
+		FROM audit_log a
+		JOIN leaderboard_entries e
+			ON a.target_id = e.id::TEXT
+		WHERE a.target_type = $2 AND e.user_id = $1
+		ORDER BY a.created_at, a.id`,
+		userID,
+		models.AuditTargetEntry,
+	)
+	if err != nil {
+		log.Printf("Failed to query submission history of user '%s': %v", userID, err)
+		return nil, fmt.Errorf("failed to get submission history of user '%s': %w", userID, translateError(err))
+	}
+	defer historyRows.Close()
+
+	for historyRows.Next() {
+		var event models.EntryEvent
+		var before, after []byte
+		if err := historyRows.Scan(&event.EntryID, &event.Action, &before, &after, &event.CreatedAt); err != nil {
+			log.Printf("Failed to scan entry event: %v", err)
+			return nil, fmt.Errorf("failed to scan entry event: %w", err)
+		}
+		event.Before, event.After = before, after
+		export.SubmissionHistory = append(export.SubmissionHistory, event)
+	}
+	if err := historyRows.Err(); err != nil {
+		log.Printf("Failed to scan entry events: %v", err)
+		return nil, fmt.Errorf("failed to scan entry events: %w", err)
+	}
+
+	reportRows, err := ur.db.QueryContext(ctx, `
+		SELECT id, entry_id, reason, COALESCE(details, ''), created_at
+		FROM entry_reports
+		WHERE reporter_id = $1
+		ORDER BY created_at, id`,
+		userID,
+	)
+	if err != nil {
+		log.Printf("Failed to query reports of user '%s': %v", userID, err)
+		return nil, fmt.Errorf("failed to get reports of user '%s': %w", userID, translateError(err))
+	}
+	defer reportRows.Close()
+
+	for reportRows.Next() {
+		report := models.EntryReport{Reporter: export.Profile}
+		if err := reportRows.Scan(&report.ID, &report.EntryID, &report.Reason, &report.Details, &report.CreatedAt); err != nil {
+			log.Printf("Failed to scan report: %v", err)
+			return nil, fmt.Errorf("failed to scan report: %w", err)
+		}
+		export.Reports = append(export.Reports, report)
+	}
+	if err := reportRows.Err(); err != nil {
+		log.Printf("Failed to scan reports: %v", err)
+		return nil, fmt.Errorf("failed to scan reports: %w", err)
+	}
+
+	return &export, nil
+}
+
+// Anonymises the user, who keeps their id and entries under a placeholder username so the rankings stay intact
+// Proofs, notes and report details are cleared, bans are kept without their reason
+// The audit log keeps its records, the snapshots of the user and their entries are redacted the same way
+// and the erasure is recorded without personal data
+// Returns ErrStateConflict if the user was already erased
+func (ur *UserRepoPG) Erase(ctx context.Context, userID string, actor *models.AuditActor) error {
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	return withTx(ctx, ur.db, func(tx *sql.Tx) error {
+		var erasedAt sql.NullTime
+		if err := tx.QueryRowContext(
+			ctx,
+			`SELECT erased_at FROM users WHERE id = $1 FOR UPDATE`,
+			userID,
+		).Scan(&erasedAt); err != nil {
+			log.Printf("Failed to lock user '%s': %v", userID, err)
+			return fmt.Errorf("failed to lock user '%s': %w", userID, translateError(err))
+		}
+		if erasedAt.Valid {
+			return fmt.Errorf("failed to erase user '%s': %w", userID, ErrStateConflict)
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE users
+			SET
+				username = $2,
+				email = '',
+				password_hash = '',
+				role = 'visitor',
+				ban_reason = NULL,
+				erased_at = CURRENT_TIMESTAMP
+			WHERE id = $1`,
+			userID,
+			models.ErasedUsername(userID),
+		); err != nil {
+			log.Printf("Failed to erase user '%s': %v", userID, err)
+			return fmt.Errorf("failed to erase user '%s': %w", userID, translateError(err))
+		}
+
+		// Snapshots of bulk changes would bring the proofs back on revert
+		for _, query := range []string{
+			`UPDATE leaderboard_entries SET proof_url = NULL, notes = NULL WHERE user_id = $1`,
+			`UPDATE entry_reports SET details = NULL WHERE reporter_id = $1`,
+			`UPDATE entry_change_set_items
+			SET before = before || '{"proof_url": null, "notes": null}'::JSONB
+			WHERE before->>'user_id' = $1`,
+		} {
+			if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+				log.Printf("Failed to erase data of user '%s': %v", userID, err)
+				return fmt.Errorf("failed to erase data of user '%s': %w", userID, translateError(err))
+			}
+		}
+
+		if err := redactAuditLog(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		return writeAudit(ctx, tx, actor, models.AuditActionUserErase, models.AuditTargetUser, userID, nil, nil)
+	})
+}
+
+// Redacts the username and email from the audit snapshots of the user, and from the snapshots of their entries
+// along with the proofs and notes, the audit log only allows it while audit_log.redacting is set
+func redactAuditLog(ctx context.Context, tx *sql.Tx, userID string) error {
+	placeholder := models.ErasedUsername(userID)
+	for _, statement := range []struct {
+		query string
+		args  []any
+	}{
+		{query: `SET LOCAL audit_log.redacting = 'on'`},
+		{
+			query: `
+			UPDATE audit_log
+			SET before = audit_redact_user(before, $2), after = audit_redact_user(after, $2)
+			WHERE target_type = $3 AND target_id = $1`,
+			args: []any{userID, placeholder, models.AuditTargetUser},
+		},
+		{
+			query: `
+			UPDATE audit_log
+			SET
+				before = CASE WHEN before ? 'user'
+					THEN jsonb_set(before, '{user}', audit_redact_user(before->'user', $2)) - 'proof_url' - 'notes'
+					ELSE before END,
+				after = CASE WHEN after ? 'user'
+					THEN jsonb_set(after, '{user}', audit_redact_user(after->'user', $2)) - 'proof_url' - 'notes'
+					ELSE after END
+			WHERE target_type = $3 AND target_id IN (SELECT id::TEXT FROM leaderboard_entries WHERE user_id = $1)`,
+			args: []any{userID, placeholder, models.AuditTargetEntry},
+		},
+	} {
+		if _, err := tx.ExecContext(ctx, statement.query, statement.args...); err != nil {
+			log.Printf("Failed to redact audit log of user '%s': %v", userID, err)
+			return fmt.Errorf("failed to redact audit log of user '%s': %w", userID, translateError(err))
+		}
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// Bans or shadowbans the user, replacing any previous ban
+func (ur *UserRepoPG) Ban(ctx context.Context, userID string, request *models.BanRequest, actor *models.AuditActor) (*models.Ban, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var ban *models.Ban
+	err := withTx(ctx, ur.db, func(tx *sql.Tx) error {
+		previous, err := lockUserBan(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+
+		var columns banColumns
+		if err := tx.QueryRowContext(ctx, `
+			UPDATE users
+			SET
+				ban_type = $1,
+				ban_reason = $2,
+				banned_until = $3,
+				banned_by = $4,
+				banned_at = CURRENT_TIMESTAMP
+			WHERE id = $5
+			RETURNING `+userBanColumns,
+			request.Type,
+			request.Reason,
+			request.ExpiresAt,
+			actor.UserID,
+			userID,
+		).Scan(columns.dest()...); err != nil {
+			log.Printf("Failed to ban user '%s': %v", userID, err)
+			return fmt.Errorf("failed to ban user '%s': %w", userID, translateError(err))
+		}
+		ban = columns.toBan(userID)
+
+		return writeAudit(ctx, tx, actor, models.AuditActionUserBan, models.AuditTargetUser, userID, previous, ban)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ban, nil
+}
+
+// Lifts an active ban, returns ErrStateConflict if the user is not banned
+func (ur *UserRepoPG) Unban(ctx context.Context, userID string, actor *models.AuditActor) error {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	return withTx(ctx, ur.db, func(tx *sql.Tx) error {
+		previous, err := lockUserBan(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		if !previous.Active(time.Now()) {
+			return fmt.Errorf("failed to unban user '%s': %w", userID, ErrStateConflict)
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE users
+			SET ban_type = NULL, ban_reason = NULL, banned_until = NULL, banned_by = NULL, banned_at = NULL
+			WHERE id = $1`,
+			userID,
+		); err != nil {
+			log.Printf("Failed to unban user '%s': %v", userID, err)
+			return fmt.Errorf("failed to unban user '%s': %w", userID, translateError(err))
+		}
+
+		return writeAudit(ctx, tx, actor, models.AuditActionUserUnban, models.AuditTargetUser, userID, previous, nil)
+	})
+}
+
+// Reads the current ban of the user and holds a row lock on it until the transaction ends
+func lockUserBan(ctx context.Context, tx *sql.Tx, userID string) (*models.Ban, error) {
+	var columns banColumns
+	if err := tx.QueryRowContext(
+		ctx,
+		`SELECT `+userBanColumns+` FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE`,
+		userID,
+	).Scan(columns.dest()...); err != nil {
+		log.Printf("Failed to lock user '%s': %v", userID, err)
+		return nil, fmt.Errorf("failed to lock user '%s': %w", userID, translateError(err))
+	}
+
+	return columns.toBan(userID), nil
+}
+
+// Lists the active bans of the members of the tenant, most recent first
+func (ur *UserRepoPG) GetBans(ctx context.Context, filter *models.BanFilter) ([]models.Ban, error) {
+	stmt, err := ur.db.PrepareContext(ctx, `
+		SELECT u.id, u.username, `+userBanColumns+`
+		FROM users u
+		WHERE `+userBanned+`
+			AND u.deleted_at IS NULL
+			AND ($1 = '' OR u.ban_type = $1)
+			AND u.id IN (SELECT user_id FROM tenant_members WHERE tenant_id = $4)
+		ORDER BY u.banned_at DESC, u.id DESC
+		LIMIT $2 OFFSET $3`,
+	)
+	if err != nil {
+		log.Printf("Failed to prepare bans statement: %v", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := stmt.QueryContext(ctx, filter.Type, filter.Limit, filter.Offset, models.TenantFromContext(ctx))
+	if err != nil {
+		log.Printf("Failed to query bans: %v", err)
+		return nil, fmt.Errorf("failed to get bans: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	bans := make([]models.Ban, 0)
+	for rows.Next() {
+		var userID, username string
+		var columns banColumns
+		if err := rows.Scan(append([]any{&userID, &username}, columns.dest()...)...); err != nil {
+			log.Printf("Failed to scan ban: %v", err)
+			return nil, fmt.Errorf("failed to scan ban: %w", err)
+		}
+		ban := columns.toBan(userID)
+		ban.Username = username
+		bans = append(bans, *ban)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan bans: %v", err)
+		return nil, fmt.Errorf("failed to scan bans: %w", err)
+	}
+
+	return bans, nil
+}
+
+// Clears the bans that reached their expiry time and returns the affected user ids
+// Queries already ignore expired bans, this only lets the rankings be restored
+func (ur *UserRepoPG) ExpireBans(ctx context.Context) ([]string, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	rows, err := ur.db.QueryContext(ctx, `
+		UPDATE users
+		SET ban_type = NULL, ban_reason = NULL, banned_until = NULL, banned_by = NULL, banned_at = NULL
+		WHERE ban_type IS NOT NULL AND banned_until <= CURRENT_TIMESTAMP
+		RETURNING id`,
+	)
+	if err != nil {
+		log.Printf("Failed to expire bans: %v", err)
+		return nil, fmt.Errorf("failed to expire bans: %w", translateError(err))
+	}
+	defer rows.Close()
+
+	userIDs := make([]string, 0)
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			log.Printf("Failed to scan expired ban: %v", err)
+			return nil, fmt.Errorf("failed to scan expired ban: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("Failed to scan expired bans: %v", err)
+		return nil, fmt.Errorf("failed to scan expired bans: %w", err)
+	}
+
+	return userIDs, nil
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+	"time"
+
+	"github.com/mochivi/go-real-time-leaderboards/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsersGetProfileHidesPrivateLeaderboards(t *testing.T) {
+	db, fake := newFakeDB(t, fakeStub{
+		contains: "COUNT(DISTINCT e.leaderboard_id)",
+		columns:  []string{"id", "username", "created_at", "leaderboards", "submissions", "last_submission"},
+		rows:     [][]any{{"2", "runner", time.Now(), int64(1), int64(3), time.Now()}},
+	})
+	repo := NewUserRepoPG(db)
+
+	// The viewer is not a member of any leaderboard, the private ones must be filtered out for them
+	profile, err := repo.GetProfile(context.Background(), "2", "7")
+	assert.NoError(t, err)
+	assert.Equal(t, "runner", profile.Username)
+
+	statements := fake.statements("leaderboard_entries")
+	assert.Len(t, statements, 3) // Stats, best ranks and recent activity
+	for _, statement := range statements {
+		assert.Contains(t, statement.query, "l.visibility <> 'private' OR EXISTS")
+		assert.Contains(t, statement.query, "m.user_id::TEXT = $2")
+		assert.Equal(t, "7", statement.args[1])
+	}
+}
+
+func TestUsersEraseRedactsAuditLog(t *testing.T) {
+	db, fake := newFakeDB(t, fakeStub{
+		contains: "SELECT erased_at",
+		columns:  []string{"erased_at"},
+		rows:     [][]any{{nil}},
+	})
+	repo := NewUserRepoPG(db)
+
+	err := repo.Erase(context.Background(), "2", &models.AuditActor{UserID: "1", Role: "administrator"})
+	assert.NoError(t, err)
+
+	statements := fake.statements("audit_log")
+	assert.Len(t, statements, 4) // Enables the redaction, redacts the user and entry snapshots, records the erasure
+	assert.Contains(t, statements[0].query, "SET LOCAL audit_log.redacting = 'on'")
+
+	assert.Contains(t, statements[1].query, "audit_redact_user(before, $2)")
+	assert.Equal(t, []driver.Value{"2", "erased-user-2", models.AuditTargetUser}, statements[1].args)
+
+	assert.Contains(t, statements[2].query, "- 'proof_url' - 'notes'")
+	assert.Equal(t, []driver.Value{"2", "erased-user-2", models.AuditTargetEntry}, statements[2].args)
+
+	assert.Contains(t, statements[3].query, "INSERT INTO audit_log")
+}
+
+func TestUsersGetBansListsPermanentBans(t *testing.T) {
+	db, fake := newFakeDB(t, fakeStub{
+		contains: "FROM users u",
+		columns:  []string{"id", "username", "ban_type", "ban_reason", "banned_until", "banned_by", "banned_at"},
+		rows:     [][]any{{"2", "cheater", models.BanTypeShadowban, "botting", nil, "1", time.Now()}},
+	})
+	repo := NewUserRepoPG(db)
+
+	bans, err := repo.GetBans(context.Background(), &models.BanFilter{Pagination: models.Pagination{Limit: 10}})
+	assert.NoError(t, err)
+	assert.Len(t, bans, 1)
+	assert.Nil(t, bans[0].ExpiresAt)
+
+	// Negating the good standing predicate would be NULL for bans without an expiry and leave them out
+	statements := fake.statements("FROM users u")
+	assert.Len(t, statements, 1)
+	assert.Contains(t, statements[0].query, "WHERE "+userBanned)
+}